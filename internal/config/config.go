@@ -1,11 +1,11 @@
 package config
 
 import (
-	"crypto/rand"
-	"encoding/base64"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -26,12 +26,173 @@ type Config struct {
 	Host          string
 	BaseURL       string
 
+	// Where the DB/Redis settings came from (see CONNECTION_PRECEDENCE)
+	ConnectionPrecedence string
+	DBSource             string
+	RedisSource          string
+
+	// Asymmetric access-token signing (HS256 with JWTSecret when unset).
+	// JWTSigningKey is parsed from the PEM by LoadConfig.
+	JWTAlgorithm      string
+	JWTPrivateKey     string
+	JWTPrivateKeyFile string
+	JWTKeyID          string
+	JWTSigningKey     *SigningKey
+
+	// Token lifetimes: access tokens, refresh tokens, and refresh tokens for
+	// logins with "remember_me"
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	RememberMeTTL   time.Duration
+
+	// Concurrent sessions per user; the oldest are signed out past it (0 = no cap)
+	MaxSessions int
+
 	// SMTP Email Configuration
 	SMTPHost     string
 	SMTPPort     string
 	SMTPUsername string
 	SMTPPassword string
 	SMTPFrom     string
+
+	// Shared secret for the inbound bounce/complaint webhook
+	EmailWebhookSecret string
+
+	// Anonymous link abuse scoring: scores at or above the CAPTCHA threshold
+	// need a CAPTCHA token, scores at or above the review threshold are held
+	// for admin review
+	AbuseCaptchaThreshold int
+	AbuseReviewThreshold  int
+	CaptchaSecret         string
+	CaptchaVerifyURL      string
+
+	// Require a CAPTCHA (X-Captcha-Token header) on register, login and
+	// forgot-password; needs CaptchaSecret
+	AuthCaptcha bool
+
+	// Google Safe Browsing lookups of new links and periodic rescans of live
+	// ones (disabled without an API key); matches are quarantined
+	SafeBrowsingAPIKey       string
+	SafeBrowsingScanInterval time.Duration
+
+	// Redis memory budget: usage ratio of maxmemory that counts as pressure,
+	// and whether the app may switch maxmemory-policy to volatile-ttl itself
+	RedisPressureRatio float64
+	RedisManagePolicy  bool
+
+	// Optional event bus for click/URL events: "nats" or "kafka" (REST proxy);
+	// empty disables publishing
+	EventBus           string
+	EventBusURL        string
+	EventBusTopic      string
+	EventBusBufferSize int
+
+	// Feature flags for lifecycle emails
+	WelcomeEmailEnabled     bool
+	OnboardingEmailsEnabled bool
+
+	// Deprecated routes: "METHOD /path|YYYY-MM-DD|successor,..." and whether
+	// calls after the sunset date are rejected with 410
+	DeprecatedEndpoints string
+	EnforceSunset       bool
+
+	// Link previews: screenshot service URL template ("{url}" is replaced by
+	// the destination) and the S3-compatible bucket thumbnails are stored in.
+	// Disabled unless both the service and a bucket are configured.
+	ScreenshotServiceURL string
+	ObjectStoreEndpoint  string
+	ObjectStoreBucket    string
+	ObjectStoreRegion    string
+	ObjectStoreAccessKey string
+	ObjectStoreSecretKey string
+	ObjectStorePublicURL string
+
+	// Argon2id cost for new password hashes (see tools/hashbench to pick
+	// values for the target hardware); below-minimum values fail startup
+	Argon2Time     int
+	Argon2MemoryKB int
+	Argon2Threads  int
+
+	// Soft launch: registration requires an admin-issued invite code
+	InviteOnly bool
+
+	// Links created or re-pointed by non-admin members stay offline until
+	// an admin approves them
+	LinkApprovalRequired bool
+
+	// Reject new passwords found in the HaveIBeenPwned corpus (fails open on API errors)
+	PasswordBreachCheck bool
+
+	// Disposable email domains rejected on registration and password reset:
+	// the bundled list (unless disabled) plus extra domains, minus allowed ones
+	DisposableEmailBlocking bool
+	DisposableEmailDomains  []string
+	DisposableEmailAllow    []string
+
+	// Custom short codes reserved on top of the bundled list
+	ReservedShortCodes []string
+
+	// Branded domains also serving short links, refused as destinations
+	// like the URL prefix's domain
+	ShortLinkDomains []string
+
+	// Short code case policy: "insensitive" stores codes lowercase and
+	// redirects any spelling, "sensitive" keeps case and matches exactly
+	ShortCodeCase string
+
+	// Allow custom short codes with emoji and non-Latin letters
+	UnicodeShortCodes bool
+
+	// Anonymous links show the destination on a preview page before redirecting
+	AnonymousPreviewPage bool
+
+	// HTTP status of redirects for links without their own redirect type (301, 302, 307 or 308)
+	DefaultRedirectStatus int
+
+	// How often active links' destinations are re-checked for 4xx/5xx and
+	// DNS failures; 0 disables the checker
+	LinkHealthCheckInterval time.Duration
+
+	// How long rendered QR images stay cached in Redis; 0 disables the cache
+	QRCacheTTL time.Duration
+
+	// Comma-separated list of emails promoted to admin on startup
+	AdminEmails []string
+
+	// Google sign-in (disabled when the client ID is empty). The secret and
+	// redirect URL are only needed for the authorization code flow.
+	GoogleClientID     string
+	GoogleClientSecret string
+	GoogleRedirectURL  string
+
+	// Generic OIDC single sign-on (disabled when the issuer is empty). The
+	// discovery URL defaults to the issuer's /.well-known/openid-configuration.
+	// Claims are mapped to the user's email and name; new users are created
+	// on first login unless auto-provisioning is off. OIDCTrustEmail accepts
+	// emails from issuers that don't send email_verified.
+	OIDCIssuer         string
+	OIDCDiscoveryURL   string
+	OIDCClientID       string
+	OIDCClientSecret   string
+	OIDCRedirectURL    string
+	OIDCScopes         []string
+	OIDCEmailClaim     string
+	OIDCFirstNameClaim string
+	OIDCLastNameClaim  string
+	OIDCAutoProvision  bool
+	OIDCTrustEmail     bool
+
+	// Click event retention in days (0 keeps events forever), optionally
+	// overridden per plan ("free=90,pro=365"). Aged-out events are rolled up
+	// into daily totals unless the mode is "delete".
+	AnalyticsRetentionDays  int
+	AnalyticsRetentionPlans map[string]int
+	AnalyticsRetentionMode  string
+
+	// Detailed click events are sampled at ClickSamplingRate once an instance
+	// sees more than ClickSamplingQPS redirects per second (0 disables)
+	ClickSamplingQPS  int
+	ClickSamplingRate float64
 }
 
 func LoadConfig() (*Config, error) {
@@ -54,130 +215,216 @@ func LoadConfig() (*Config, error) {
 		Host:          getEnv("HOST", "localhost"),                 // ← TAMBAHKAN INI
 		BaseURL:       getEnv("BASE_URL", "http://localhost:8080"), // ← TAMBAHKAN INI
 
+		ConnectionPrecedence: strings.ToLower(getEnv("CONNECTION_PRECEDENCE", PrecedenceAuto)),
+
+		JWTAlgorithm:      getEnv("JWT_ALGORITHM", "HS256"),
+		JWTPrivateKey:     getEnv("JWT_PRIVATE_KEY", ""),
+		JWTPrivateKeyFile: getEnv("JWT_PRIVATE_KEY_FILE", ""),
+		JWTKeyID:          getEnv("JWT_KEY_ID", ""),
+
+		AccessTokenTTL:  getEnvDuration("ACCESS_TOKEN_TTL", 24*time.Hour),
+		RefreshTokenTTL: getEnvDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		RememberMeTTL:   getEnvDuration("REMEMBER_ME_TTL", 30*24*time.Hour),
+		MaxSessions:     getEnvInt("MAX_SESSIONS", 10),
+
 		// SMTP Email Configuration
 		SMTPHost:     getEnv("SMTP_HOST", "smtp.gmail.com"),
 		SMTPPort:     getEnv("SMTP_PORT", "587"),
 		SMTPUsername: getEnv("SMTP_USERNAME", ""),
 		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
 		SMTPFrom:     getEnv("SMTP_FROM_EMAIL", ""),
-	}
 
-	// ✅ Parse DATABASE_URL if exists (Render format)
-	if databaseURL := os.Getenv("DATABASE_URL"); databaseURL != "" {
-		parseDatabaseURL(databaseURL, cfg)
-	}
+		EmailWebhookSecret: getEnv("EMAIL_WEBHOOK_SECRET", ""),
 
-	// ✅ Parse REDIS_URL if exists
-	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
-		parseRedisURL(redisURL, cfg)
-	}
+		AbuseCaptchaThreshold: getEnvInt("ABUSE_CAPTCHA_THRESHOLD", 40),
+		AbuseReviewThreshold:  getEnvInt("ABUSE_REVIEW_THRESHOLD", 70),
+		CaptchaSecret:         getEnv("CAPTCHA_SECRET", ""),
+		CaptchaVerifyURL:      getEnv("CAPTCHA_VERIFY_URL", "https://hcaptcha.com/siteverify"),
+		AuthCaptcha:           getEnvBool("AUTH_CAPTCHA", false),
 
-	// Validate required fields
-	// ...existing validation...
+		SafeBrowsingAPIKey:       getEnv("SAFE_BROWSING_API_KEY", ""),
+		SafeBrowsingScanInterval: getEnvDuration("SAFE_BROWSING_SCAN_INTERVAL", 24*time.Hour),
 
-	return cfg, nil
-}
+		RedisPressureRatio: getEnvFloat("REDIS_MEMORY_PRESSURE_RATIO", 0.85),
+		RedisManagePolicy:  getEnvBool("REDIS_MANAGE_EVICTION_POLICY", false),
 
-// ✅ Parse DATABASE_URL helper
-func parseDatabaseURL(dbURL string, cfg *Config) {
-	dbURL = strings.TrimPrefix(dbURL, "postgresql://")
-	dbURL = strings.TrimPrefix(dbURL, "postgres://")
+		EventBus:           getEnv("EVENT_BUS", ""),
+		EventBusURL:        getEnv("EVENT_BUS_URL", ""),
+		EventBusTopic:      getEnv("EVENT_BUS_TOPIC", "lynx.events"),
+		EventBusBufferSize: getEnvInt("EVENT_BUS_BUFFER_SIZE", 10000),
 
-	if strings.Contains(dbURL, "@") {
-		parts := strings.SplitN(dbURL, "@", 2)
-		userPass := strings.SplitN(parts[0], ":", 2)
-		hostAndDb := strings.SplitN(parts[1], "/", 2)
+		WelcomeEmailEnabled:     getEnvBool("FEATURE_WELCOME_EMAIL", true),
+		OnboardingEmailsEnabled: getEnvBool("FEATURE_ONBOARDING_EMAILS", false),
 
-		cfg.DBUser = userPass[0]
-		if len(userPass) > 1 {
-			cfg.DBPassword = userPass[1]
-		}
+		DeprecatedEndpoints: getEnv("DEPRECATED_ENDPOINTS", ""),
+		EnforceSunset:       getEnvBool("DEPRECATION_ENFORCE_SUNSET", false),
 
-		hostPort := strings.SplitN(hostAndDb[0], ":", 2)
-		cfg.DBHost = hostPort[0]
-		cfg.DBPort = "5432"
-		if len(hostPort) > 1 {
-			cfg.DBPort = hostPort[1]
-		}
+		ScreenshotServiceURL: getEnv("SCREENSHOT_SERVICE_URL", ""),
+		ObjectStoreEndpoint:  getEnv("OBJECT_STORAGE_ENDPOINT", ""),
+		ObjectStoreBucket:    getEnv("OBJECT_STORAGE_BUCKET", ""),
+		ObjectStoreRegion:    getEnv("OBJECT_STORAGE_REGION", "us-east-1"),
+		ObjectStoreAccessKey: getEnv("OBJECT_STORAGE_ACCESS_KEY", ""),
+		ObjectStoreSecretKey: getEnv("OBJECT_STORAGE_SECRET_KEY", ""),
+		ObjectStorePublicURL: getEnv("OBJECT_STORAGE_PUBLIC_URL", ""),
 
-		if len(hostAndDb) > 1 {
-			cfg.DBName = strings.SplitN(hostAndDb[1], "?", 2)[0]
-		}
-	}
-}
+		Argon2Time:     getEnvInt("ARGON2_TIME", 1),
+		Argon2MemoryKB: getEnvInt("ARGON2_MEMORY_KB", 64*1024),
+		Argon2Threads:  getEnvInt("ARGON2_THREADS", 4),
 
-// ✅ Parse REDIS_URL helper
-func parseRedisURL(redisURL string, cfg *Config) {
-	redisURL = strings.TrimPrefix(redisURL, "redis://")
+		InviteOnly:  getEnvBool("REGISTRATION_INVITE_ONLY", false),
+		AdminEmails: getEnvList("ADMIN_EMAILS"),
 
-	if strings.Contains(redisURL, "@") {
-		parts := strings.SplitN(redisURL, "@", 2)
+		ReservedShortCodes:   getEnvList("RESERVED_SHORT_CODES"),
+		ShortLinkDomains:     getEnvList("SHORT_LINK_DOMAINS"),
+		ShortCodeCase:        getEnv("SHORT_CODE_CASE", "insensitive"),
+		UnicodeShortCodes:    getEnvBool("UNICODE_SHORT_CODES", false),
+		AnonymousPreviewPage: getEnvBool("ANONYMOUS_PREVIEW_PAGE", false),
 
-		if strings.Contains(parts[0], ":") {
-			userPass := strings.SplitN(parts[0], ":", 2)
-			if len(userPass) > 1 {
-				cfg.RedisPassword = userPass[1]
-			}
-		}
+		DefaultRedirectStatus: getEnvInt("DEFAULT_REDIRECT_STATUS", 302),
 
-		hostPort := strings.SplitN(parts[1], ":", 2)
-		cfg.RedisHost = hostPort[0]
-		cfg.RedisPort = "6379"
-		if len(hostPort) > 1 {
-			cfg.RedisPort = hostPort[1]
-		}
+		LinkHealthCheckInterval: getEnvDuration("LINK_HEALTH_CHECK_INTERVAL", 24*time.Hour),
+
+		QRCacheTTL: getEnvDuration("QR_CACHE_TTL", 24*time.Hour),
+
+		LinkApprovalRequired: getEnvBool("LINK_APPROVAL_REQUIRED", false),
+
+		PasswordBreachCheck: getEnvBool("PASSWORD_BREACH_CHECK", true),
+
+		DisposableEmailBlocking: getEnvBool("DISPOSABLE_EMAIL_BLOCKING", true),
+		DisposableEmailDomains:  getEnvList("DISPOSABLE_EMAIL_DOMAINS"),
+		DisposableEmailAllow:    getEnvList("DISPOSABLE_EMAIL_ALLOW"),
+
+		GoogleClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+		GoogleClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+		GoogleRedirectURL:  getEnv("GOOGLE_REDIRECT_URL", ""),
+
+		OIDCIssuer:         strings.TrimSuffix(getEnv("OIDC_ISSUER", ""), "/"),
+		OIDCDiscoveryURL:   getEnv("OIDC_DISCOVERY_URL", ""),
+		OIDCClientID:       getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:   getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:    getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCScopes:         getEnvList("OIDC_SCOPES"),
+		OIDCEmailClaim:     getEnv("OIDC_EMAIL_CLAIM", "email"),
+		OIDCFirstNameClaim: getEnv("OIDC_FIRST_NAME_CLAIM", "given_name"),
+		OIDCLastNameClaim:  getEnv("OIDC_LAST_NAME_CLAIM", "family_name"),
+		OIDCAutoProvision:  getEnvBool("OIDC_AUTO_PROVISION", true),
+		OIDCTrustEmail:     getEnvBool("OIDC_TRUST_EMAIL", false),
+
+		AnalyticsRetentionDays:  getEnvInt("ANALYTICS_RETENTION_DAYS", 0),
+		AnalyticsRetentionPlans: getEnvIntMap("ANALYTICS_RETENTION_PLANS"),
+		AnalyticsRetentionMode:  getEnv("ANALYTICS_RETENTION_MODE", "aggregate"),
+
+		ClickSamplingQPS:  getEnvInt("CLICK_SAMPLING_QPS_THRESHOLD", 0),
+		ClickSamplingRate: getEnvFloat("CLICK_SAMPLING_RATE", 0.1),
 	}
-}
 
-// ✅ ENHANCED: Secret validation with auto-generation
-func (c *Config) validateAndNormalizeSecrets() error {
-	// 1. Validate JWT Secret
-	if c.JWTSecret == "" {
-		if c.AppEnv == "production" {
-			return fmt.Errorf("JWT_SECRET is required in production")
+	// ✅ DATABASE_URL/REDIS_URL (Render format) or the discrete vars above, never a mix
+	switch cfg.ConnectionPrecedence {
+	case PrecedenceAuto, PrecedenceURL, PrecedenceDiscrete:
+	default:
+		return nil, fmt.Errorf("CONNECTION_PRECEDENCE must be auto, url or discrete (got %q)", cfg.ConnectionPrecedence)
+	}
+	if err := cfg.resolveDatabase(); err != nil {
+		return nil, err
+	}
+	if err := cfg.resolveRedis(); err != nil {
+		return nil, err
+	}
+
+	// ✅ Access tokens can't outlive the refresh tokens that renew them
+	if cfg.AccessTokenTTL <= 0 || cfg.RefreshTokenTTL < cfg.AccessTokenTTL || cfg.RememberMeTTL < cfg.RefreshTokenTTL {
+		return nil, fmt.Errorf("token lifetimes must satisfy 0 < ACCESS_TOKEN_TTL <= REFRESH_TOKEN_TTL <= REMEMBER_ME_TTL")
+	}
+
+	// ✅ OIDC sign-in needs a client to verify ID tokens for
+	if cfg.OIDCIssuer != "" {
+		if cfg.OIDCClientID == "" {
+			return nil, fmt.Errorf("OIDC_CLIENT_ID is required when OIDC_ISSUER is set")
+		}
+		if cfg.OIDCDiscoveryURL == "" {
+			cfg.OIDCDiscoveryURL = cfg.OIDCIssuer + "/.well-known/openid-configuration"
 		}
-		// Auto-generate for development
-		secret, err := generateSecureSecret(64)
-		if err != nil {
-			return fmt.Errorf("failed to generate JWT secret: %w", err)
+		if len(cfg.OIDCScopes) == 0 {
+			cfg.OIDCScopes = []string{"openid", "email", "profile"}
 		}
-		c.JWTSecret = secret
-		fmt.Printf("⚠️  [DEV] Auto-generated JWT_SECRET (save to .env for persistence)\n")
 	}
 
-	// 2. Validate JWT Secret strength
-	if len(c.JWTSecret) < 32 {
-		return fmt.Errorf("JWT_SECRET must be at least 32 characters (current: %d)", len(c.JWTSecret))
+	// ✅ Load the asymmetric JWT signing key, if one is configured
+	privateKey, err := cfg.readPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.JWTSigningKey, err = LoadSigningKey(cfg.JWTAlgorithm, privateKey, cfg.JWTKeyID); err != nil {
+		return nil, err
 	}
 
-	// 3. Validate Database Password (allow empty for postgres superuser)
-	// Comment out this check temporarily
-	// if c.DBPassword == "" {
-	//     return fmt.Errorf("DB_PASSWORD is required")
-	// }
+	// Validate required fields
+	// ...existing validation...
+
+	return cfg, nil
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
 
-	// 4. Validate SMTP credentials for production
-	if c.AppEnv == "production" {
-		if c.SMTPUsername == "" || c.SMTPPassword == "" {
-			return fmt.Errorf("SMTP credentials are required in production")
+// getEnvList splits a comma-separated variable, dropping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
 		}
 	}
+	return values
+}
 
-	return nil
+// getEnvInt reads an integer variable, falling back on missing or invalid values
+func getEnvInt(key string, defaultValue int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return n
+	}
+	return defaultValue
 }
 
-// ✅ NEW: Generate cryptographically secure secret
-func generateSecureSecret(length int) (string, error) {
-	bytes := make([]byte, length)
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+// getEnvFloat reads a float variable, falling back on missing or invalid values
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if f, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return f
 	}
-	return base64.URLEncoding.EncodeToString(bytes), nil
+	return defaultValue
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// getEnvDuration reads a Go duration ("24h", "30m"), falling back on missing or invalid values
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return d
+	}
+	return defaultValue
+}
+
+// getEnvBool reads a boolean variable, falling back on missing or invalid values
+func getEnvBool(key string, defaultValue bool) bool {
+	if b, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return b
 	}
 	return defaultValue
 }
+
+// getEnvIntMap parses a "key=value,key=value" variable with integer values
+func getEnvIntMap(key string) map[string]int {
+	values := make(map[string]int)
+	for _, entry := range getEnvList(key) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(parts[1])); err == nil {
+			values[strings.TrimSpace(parts[0])] = n
+		}
+	}
+	return values
+}
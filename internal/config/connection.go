@@ -0,0 +1,165 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Precedence between DATABASE_URL/REDIS_URL and the discrete DB_*/REDIS_*
+// variables (CONNECTION_PRECEDENCE). One source is always used as a whole;
+// values are never mixed between the two.
+const (
+	// PrecedenceAuto uses whichever source is set; when both are, they must agree
+	PrecedenceAuto = "auto"
+	// PrecedenceURL uses the URL and ignores the discrete variables
+	PrecedenceURL = "url"
+	// PrecedenceDiscrete uses the discrete variables and ignores the URL
+	PrecedenceDiscrete = "discrete"
+)
+
+// Connection setting sources recorded on Config for the startup log
+const (
+	SourceURL      = "url"
+	SourceDiscrete = "discrete"
+	SourceDefaults = "defaults"
+)
+
+// connField is one connection setting that can come from either source
+type connField struct {
+	env      string  // discrete variable, e.g. DB_HOST
+	target   *string // Config field it fills
+	fallback string  // used when the chosen source leaves it unset
+	url      string  // value parsed from the URL ("" when absent)
+}
+
+// resolveConnection fills the fields from the chosen source and returns it.
+// In auto mode a discrete variable that disagrees with the URL is an error.
+func resolveConnection(urlEnv, precedence string, fields []connField) (string, error) {
+	rawURL := os.Getenv(urlEnv)
+
+	// Empty variables (as in .env.example) count as unset
+	var discrete []string
+	for _, f := range fields {
+		if os.Getenv(f.env) != "" {
+			discrete = append(discrete, f.env)
+		}
+	}
+
+	source := SourceDiscrete
+	switch {
+	case rawURL == "" && len(discrete) == 0:
+		source = SourceDefaults
+	case rawURL == "":
+		source = SourceDiscrete
+	case len(discrete) == 0 || precedence == PrecedenceURL:
+		source = SourceURL
+	case precedence == PrecedenceDiscrete:
+		source = SourceDiscrete
+	default:
+		var conflicts []string
+		for _, f := range fields {
+			if value := os.Getenv(f.env); value != "" && value != f.url {
+				conflicts = append(conflicts, f.env)
+			}
+		}
+		if len(conflicts) > 0 {
+			return "", fmt.Errorf("%s conflicts with %s; unset one of them or set CONNECTION_PRECEDENCE=url|discrete",
+				urlEnv, strings.Join(conflicts, ", "))
+		}
+		source = SourceURL
+	}
+
+	for _, f := range fields {
+		value := getEnv(f.env, f.fallback)
+		if source == SourceURL {
+			value = f.url
+			if value == "" {
+				value = f.fallback
+			}
+		}
+		*f.target = value
+	}
+	return source, nil
+}
+
+// resolveDatabase picks DATABASE_URL or the DB_* variables
+func (c *Config) resolveDatabase() error {
+	parsed, err := parseConnectionURL(os.Getenv("DATABASE_URL"), "5432")
+	if err != nil {
+		return fmt.Errorf("invalid DATABASE_URL: %w", err)
+	}
+
+	c.DBSource, err = resolveConnection("DATABASE_URL", c.ConnectionPrecedence, []connField{
+		{env: "DB_HOST", target: &c.DBHost, fallback: "127.0.0.1", url: parsed.host},
+		{env: "DB_PORT", target: &c.DBPort, fallback: "5432", url: parsed.port},
+		{env: "DB_USER", target: &c.DBUser, fallback: "lynx_user", url: parsed.user},
+		{env: "DB_PASSWORD", target: &c.DBPassword, fallback: "lynx_password_123", url: parsed.password},
+		{env: "DB_NAME", target: &c.DBName, fallback: "lynx_db", url: parsed.path},
+	})
+	return err
+}
+
+// resolveRedis picks REDIS_URL or the REDIS_* variables
+func (c *Config) resolveRedis() error {
+	parsed, err := parseConnectionURL(os.Getenv("REDIS_URL"), "6379")
+	if err != nil {
+		return fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+
+	c.RedisSource, err = resolveConnection("REDIS_URL", c.ConnectionPrecedence, []connField{
+		{env: "REDIS_HOST", target: &c.RedisHost, fallback: "127.0.0.1", url: parsed.host},
+		{env: "REDIS_PORT", target: &c.RedisPort, fallback: "6379", url: parsed.port},
+		{env: "REDIS_PASSWORD", target: &c.RedisPassword, fallback: "", url: parsed.password},
+	})
+	return err
+}
+
+type connectionURL struct {
+	host, port, user, password, path string
+}
+
+func parseConnectionURL(raw, defaultPort string) (connectionURL, error) {
+	if raw == "" {
+		return connectionURL{}, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return connectionURL{}, err
+	}
+	if u.Hostname() == "" {
+		return connectionURL{}, fmt.Errorf("missing host")
+	}
+
+	parsed := connectionURL{
+		host: u.Hostname(),
+		port: u.Port(),
+		path: strings.TrimPrefix(u.Path, "/"),
+	}
+	if parsed.port == "" {
+		parsed.port = defaultPort
+	}
+	if u.User != nil {
+		parsed.user = u.User.Username()
+		parsed.password, _ = u.User.Password()
+	}
+	return parsed, nil
+}
+
+// DatabaseSummary describes the database connection for logs, password redacted
+func (c *Config) DatabaseSummary() string {
+	return fmt.Sprintf("%s:%s@%s:%s/%s", c.DBUser, redact(c.DBPassword), c.DBHost, c.DBPort, c.DBName)
+}
+
+// RedisSummary describes the Redis connection for logs, password redacted
+func (c *Config) RedisSummary() string {
+	return fmt.Sprintf(":%s@%s:%s", redact(c.RedisPassword), c.RedisHost, c.RedisPort)
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "***"
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// SigningKey is an asymmetric key used to sign access tokens, so other
+// services can verify them from the published JWKS without the HMAC secret
+type SigningKey struct {
+	Method     jwt.SigningMethod
+	KeyID      string
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+}
+
+// LoadSigningKey parses a PEM private key for the given algorithm (RS256 or
+// EdDSA). HS256 returns nil: tokens are then signed with the shared secret.
+func LoadSigningKey(algorithm, privateKeyPEM, keyID string) (*SigningKey, error) {
+	key := &SigningKey{KeyID: keyID}
+
+	switch strings.ToUpper(algorithm) {
+	case "", "HS256":
+		return nil, nil
+	case "RS256":
+		private, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid RS256 private key: %w", err)
+		}
+		if private.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RS256 key must be at least 2048 bits")
+		}
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodRS256, private, &private.PublicKey
+	case "EDDSA":
+		parsed, err := jwt.ParseEdPrivateKeyFromPEM([]byte(privateKeyPEM))
+		if err != nil {
+			return nil, fmt.Errorf("invalid EdDSA private key: %w", err)
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("EdDSA key must be Ed25519")
+		}
+		key.Method, key.PrivateKey, key.PublicKey = jwt.SigningMethodEdDSA, private, private.Public()
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q (use HS256, RS256 or EdDSA)", algorithm)
+	}
+
+	if key.KeyID == "" {
+		der, err := x509.MarshalPKIXPublicKey(key.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive key id: %w", err)
+		}
+		sum := sha256.Sum256(der)
+		key.KeyID = base64.RawURLEncoding.EncodeToString(sum[:])[:16]
+	}
+
+	return key, nil
+}
+
+// readPrivateKey returns the PEM from JWT_PRIVATE_KEY_FILE when set, otherwise
+// JWT_PRIVATE_KEY with escaped newlines restored (single-line env values)
+func (c *Config) readPrivateKey() (string, error) {
+	if c.JWTPrivateKeyFile != "" {
+		data, err := os.ReadFile(c.JWTPrivateKeyFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read JWT_PRIVATE_KEY_FILE: %w", err)
+		}
+		return string(data), nil
+	}
+	return strings.ReplaceAll(c.JWTPrivateKey, `\n`, "\n"), nil
+}
+
+// JWK returns the public half of the key in JSON Web Key form
+func (k *SigningKey) JWK() map[string]string {
+	jwk := map[string]string{
+		"kid": k.KeyID,
+		"alg": k.Method.Alg(),
+		"use": "sig",
+	}
+
+	switch public := k.PublicKey.(type) {
+	case *rsa.PublicKey:
+		jwk["kty"] = "RSA"
+		jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+		jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk["kty"] = "OKP"
+		jwk["crv"] = "Ed25519"
+		jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+	}
+
+	return jwk
+}
@@ -1,52 +1,247 @@
 package config
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"fmt"
+	"log"
+	"sync"
 	"time"
+
+	"github.com/golang-jwt/jwt/v4"
 )
 
-// SecretManager handles JWT secret rotation
+// SecretGracePeriod is how long tokens signed with a rotated-out secret stay valid
+const SecretGracePeriod = 7 * 24 * time.Hour
+
+// secretReloadInterval limits store reads when a token's signature matches
+// none of the known secrets
+const secretReloadInterval = 5 * time.Second
+
+// RetiredSecret is a rotated-out secret, accepted until its grace period ends
+type RetiredSecret struct {
+	Secret    string    `json:"secret"`
+	RetiredAt time.Time `json:"retired_at"`
+}
+
+// SecretState is the rotation state shared by all instances
+type SecretState struct {
+	Current   string
+	Retired   []RetiredSecret
+	RotatedAt time.Time
+}
+
+// SecretStore persists the rotation state, so every instance signs and
+// verifies with the same secrets and rotations survive restarts
+type SecretStore interface {
+	// LoadSecrets returns the stored state, nil when no rotation happened yet
+	LoadSecrets(ctx context.Context) (*SecretState, error)
+	// RotateSecrets atomically retires the stored current secret (initial
+	// when nothing is stored yet) and makes newSecret the current one
+	RotateSecrets(ctx context.Context, initial, newSecret string) (*SecretState, error)
+}
+
+// SecretManager handles JWT secret rotation. Without a store rotated secrets
+// live in memory only: a restart goes back to JWT_SECRET, and each instance
+// rotates on its own.
 type SecretManager struct {
-	CurrentSecret  string
-	PreviousSecret string
-	RotatedAt      time.Time
+	mu            sync.RWMutex
+	CurrentSecret string
+	Retired       []RetiredSecret
+	RotatedAt     time.Time
+
+	store        SecretStore
+	lastReloadAt time.Time
+
+	// Asymmetric key for new tokens; nil keeps signing with CurrentSecret
+	signingKey *SigningKey
 }
 
 // NewSecretManager creates a new secret manager
 func NewSecretManager(currentSecret string) *SecretManager {
 	return &SecretManager{
-		CurrentSecret:  currentSecret,
-		PreviousSecret: "",
-		RotatedAt:      time.Now(),
+		CurrentSecret: currentSecret,
+		RotatedAt:     time.Now(),
 	}
 }
 
-// RotateSecret generates a new secret and keeps the old one for grace period
-func (sm *SecretManager) RotateSecret() error {
+// SetStore shares rotations through store and loads the secrets rotated so
+// far, which replace JWT_SECRET
+func (sm *SecretManager) SetStore(ctx context.Context, store SecretStore) error {
+	sm.mu.Lock()
+	sm.store = store
+	sm.mu.Unlock()
+	_, err := sm.Refresh(ctx)
+	return err
+}
+
+// StartRefresh reloads the secrets from the store every interval, so
+// instances pick up rotations made elsewhere
+func (sm *SecretManager) StartRefresh(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	go func() {
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if _, err := sm.Refresh(ctx); err != nil {
+				log.Printf("⚠️  Failed to reload JWT secrets: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+// Refresh loads the secrets from the store and reports whether the current
+// secret changed
+func (sm *SecretManager) Refresh(ctx context.Context) (bool, error) {
+	sm.mu.Lock()
+	store := sm.store
+	sm.lastReloadAt = time.Now()
+	sm.mu.Unlock()
+	if store == nil {
+		return false, nil
+	}
+
+	state, err := store.LoadSecrets(ctx)
+	if err != nil || state == nil {
+		return false, err
+	}
+	return sm.adopt(state), nil
+}
+
+// Reload refreshes the secrets for a token none of them verified, in case
+// another instance just rotated; calls are throttled, and it reports
+// whether there is a new secret to try
+func (sm *SecretManager) Reload() bool {
+	sm.mu.RLock()
+	due := sm.store != nil && time.Since(sm.lastReloadAt) >= secretReloadInterval
+	sm.mu.RUnlock()
+	if !due {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	changed, err := sm.Refresh(ctx)
+	return err == nil && changed
+}
+
+// RotateSecret generates a new secret and keeps the old ones valid for the
+// grace period. With a store the rotation applies to all instances.
+func (sm *SecretManager) RotateSecret(ctx context.Context) error {
 	newSecret, err := GenerateSecureSecret(64)
 	if err != nil {
 		return fmt.Errorf("failed to rotate secret: %w", err)
 	}
 
-	sm.PreviousSecret = sm.CurrentSecret
-	sm.CurrentSecret = newSecret
-	sm.RotatedAt = time.Now()
+	sm.mu.RLock()
+	store := sm.store
+	sm.mu.RUnlock()
+	if store != nil {
+		state, err := store.RotateSecrets(ctx, sm.Current(), newSecret)
+		if err != nil {
+			return fmt.Errorf("failed to rotate secret: %w", err)
+		}
+		sm.adopt(state)
+		return nil
+	}
 
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	now := time.Now()
+	sm.Retired = append(pruneRetired(sm.Retired, now), RetiredSecret{Secret: sm.CurrentSecret, RetiredAt: now})
+	sm.CurrentSecret = newSecret
+	sm.RotatedAt = now
 	return nil
 }
 
-// GetValidSecrets returns all valid secrets (current + previous for grace period)
+// adopt switches to a stored state and reports whether the current secret changed
+func (sm *SecretManager) adopt(state *SecretState) bool {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	changed := sm.CurrentSecret != state.Current
+	sm.CurrentSecret = state.Current
+	sm.Retired = pruneRetired(state.Retired, time.Now())
+	sm.RotatedAt = state.RotatedAt
+	return changed
+}
+
+// pruneRetired drops the secrets whose grace period is over
+func pruneRetired(retired []RetiredSecret, now time.Time) []RetiredSecret {
+	var valid []RetiredSecret
+	for _, secret := range retired {
+		if now.Sub(secret.RetiredAt) < SecretGracePeriod {
+			valid = append(valid, secret)
+		}
+	}
+	return valid
+}
+
+// GetValidSecrets returns all valid secrets: the current one first, then
+// those rotated out within the grace period
 func (sm *SecretManager) GetValidSecrets() []string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
 	secrets := []string{sm.CurrentSecret}
+	for _, secret := range sm.Retired {
+		if time.Since(secret.RetiredAt) < SecretGracePeriod {
+			secrets = append(secrets, secret.Secret)
+		}
+	}
+	return secrets
+}
+
+// Current returns the secret new tokens are signed with
+func (sm *SecretManager) Current() string {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.CurrentSecret
+}
+
+// LastRotation returns when the secret was last rotated (or the manager created)
+func (sm *SecretManager) LastRotation() time.Time {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.RotatedAt
+}
+
+// SetSigningKey switches new tokens to the asymmetric key. Tokens already
+// signed with the HMAC secrets stay valid until they expire.
+func (sm *SecretManager) SetSigningKey(key *SigningKey) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.signingKey = key
+}
 
-	// Keep previous secret valid for 7 days after rotation
-	if sm.PreviousSecret != "" && time.Since(sm.RotatedAt) < 7*24*time.Hour {
-		secrets = append(secrets, sm.PreviousSecret)
+// SigningKey returns the asymmetric signing key, or nil when using HS256
+func (sm *SecretManager) SigningKey() *SigningKey {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.signingKey
+}
+
+// Sign issues a token with the asymmetric key when configured, otherwise
+// with the current HMAC secret
+func (sm *SecretManager) Sign(claims jwt.MapClaims) (string, error) {
+	if key := sm.SigningKey(); key != nil {
+		token := jwt.NewWithClaims(key.Method, claims)
+		token.Header["kid"] = key.KeyID
+		return token.SignedString(key.PrivateKey)
 	}
 
-	return secrets
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(sm.Current()))
+}
+
+// JWKS returns the public keys for the /.well-known/jwks.json document.
+// The set is empty under HS256, which can't be verified without the secret.
+func (sm *SecretManager) JWKS() map[string]interface{} {
+	keys := []map[string]string{}
+	if key := sm.SigningKey(); key != nil {
+		keys = append(keys, key.JWK())
+	}
+	return map[string]interface{}{"keys": keys}
 }
 
 // GenerateSecureSecret creates a cryptographically secure random string
@@ -0,0 +1,473 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/config"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type AdminHandler struct {
+	adminService     interfaces.AdminService
+	retentionService interfaces.RetentionService
+	memoryBudget     interfaces.MemoryBudget
+	secrets          *config.SecretManager
+}
+
+func NewAdminHandler(adminService interfaces.AdminService, retentionService interfaces.RetentionService, memoryBudget interfaces.MemoryBudget, secrets *config.SecretManager) *AdminHandler {
+	return &AdminHandler{
+		adminService:     adminService,
+		retentionService: retentionService,
+		memoryBudget:     memoryBudget,
+		secrets:          secrets,
+	}
+}
+
+// RotateJWTSecret signs new tokens with a fresh secret on every instance;
+// tokens signed with the previous ones stay valid for the grace period
+func (h *AdminHandler) RotateJWTSecret(c *gin.Context) {
+	if err := h.secrets.RotateSecret(c.Request.Context()); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	rotatedAt := h.secrets.LastRotation()
+	utils.LoggerFromContext(c.Request.Context()).Warn("JWT secret rotated", "rotated_at", rotatedAt)
+
+	utils.SuccessResponse(c, http.StatusOK, "JWT secret rotated successfully", gin.H{
+		"rotated_at":           rotatedAt,
+		"previous_valid_until": rotatedAt.Add(config.SecretGracePeriod),
+	})
+}
+
+// PurgeExpiredURLs permanently deletes expired URLs (supports ?dry_run=true)
+func (h *AdminHandler) PurgeExpiredURLs(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.PurgeExpiredURLs(c.Request.Context(), dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// BanUser suspends a user and disables all of their links (supports ?dry_run=true)
+func (h *AdminHandler) BanUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.BanUser(c.Request.Context(), userID, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// SuspendUser locks a user out and signs out all of their sessions; with
+// "pause_links" their links are disabled until reinstated (supports ?dry_run=true)
+func (h *AdminHandler) SuspendUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SuspendUserRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+			return
+		}
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.SuspendUser(c.Request.Context(), userID, req.PauseLinks, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// ReinstateUser lifts a suspension and re-enables the links it paused (supports ?dry_run=true)
+func (h *AdminHandler) ReinstateUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.ReinstateUser(c.Request.Context(), userID, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// AddBlockedDomains adds domains to the destination blocklist (supports ?dry_run=true)
+func (h *AdminHandler) AddBlockedDomains(c *gin.Context) {
+	var req models.BlocklistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.AddBlockedDomains(c.Request.Context(), adminID, req.Domains, req.Reason, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// PurgeClickEvents applies the analytics retention policy now (supports ?dry_run=true)
+func (h *AdminHandler) PurgeClickEvents(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.retentionService.Purge(c.Request.Context(), dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// ListPendingReviews lists anonymous links held for abuse review
+func (h *AdminHandler) ListPendingReviews(c *gin.Context) {
+	pagination := utils.GetPaginationFromContext(c)
+
+	urls, total, err := h.adminService.ListPendingReviews(c.Request.Context(), pagination.Page, pagination.PerPage)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.PaginationResponse(c, http.StatusOK, "Pending reviews retrieved successfully", urls, utils.Meta{
+		Page:      pagination.Page,
+		PerPage:   pagination.PerPage,
+		Total:     total,
+		TotalPage: (total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage),
+	})
+}
+
+// ApproveURL releases a held link
+func (h *AdminHandler) ApproveURL(c *gin.Context) {
+	h.reviewURL(c, true)
+}
+
+// RejectURL disables a held link
+func (h *AdminHandler) RejectURL(c *gin.Context) {
+	h.reviewURL(c, false)
+}
+
+func (h *AdminHandler) reviewURL(c *gin.Context, approve bool) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	url, err := h.adminService.ReviewURL(c.Request.Context(), urlID, approve)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL reviewed successfully", url)
+}
+
+// ListAwaitingApproval lists members' links waiting for approval
+func (h *AdminHandler) ListAwaitingApproval(c *gin.Context) {
+	pagination := utils.GetPaginationFromContext(c)
+
+	urls, total, err := h.adminService.ListAwaitingApproval(c.Request.Context(), pagination.Page, pagination.PerPage)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.PaginationResponse(c, http.StatusOK, "Links awaiting approval retrieved successfully", urls, utils.Meta{
+		Page:      pagination.Page,
+		PerPage:   pagination.PerPage,
+		Total:     total,
+		TotalPage: (total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage),
+	})
+}
+
+// ApproveLinks publishes links awaiting approval in bulk (supports ?dry_run=true)
+func (h *AdminHandler) ApproveLinks(c *gin.Context) {
+	h.reviewApprovals(c, true)
+}
+
+// RejectLinks disables links awaiting approval in bulk (supports ?dry_run=true)
+func (h *AdminHandler) RejectLinks(c *gin.Context) {
+	h.reviewApprovals(c, false)
+}
+
+func (h *AdminHandler) reviewApprovals(c *gin.Context, approve bool) {
+	var req models.ReviewLinksRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.ReviewApprovals(c.Request.Context(), req.IDs, approve, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// GetDomainStats shows top, new and most flagged destination domains
+func (h *AdminHandler) GetDomainStats(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("limit must be between 1 and 100"))
+		return
+	}
+
+	report, err := h.adminService.GetDomainStats(c.Request.Context(), limit)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Domain stats retrieved successfully", report)
+}
+
+// GetPasswordHashStats reports how many password hashes await an upgrade
+func (h *AdminHandler) GetPasswordHashStats(c *gin.Context) {
+	report, err := h.adminService.GetPasswordHashStats(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password hash stats retrieved successfully", report)
+}
+
+// CreateInviteCodes generates invite codes for invite-only registration
+func (h *AdminHandler) CreateInviteCodes(c *gin.Context) {
+	var req models.CreateInviteCodesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	adminID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	invites, err := h.adminService.CreateInviteCodes(c.Request.Context(), adminID, &req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Invite codes created successfully", invites)
+}
+
+// ListInviteCodes lists all invite codes
+func (h *AdminHandler) ListInviteCodes(c *gin.Context) {
+	invites, err := h.adminService.ListInviteCodes(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invite codes retrieved successfully", invites)
+}
+
+// RevokeInviteCode revokes an invite code
+func (h *AdminHandler) RevokeInviteCode(c *gin.Context) {
+	inviteID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.adminService.RevokeInviteCode(c.Request.Context(), inviteID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Invite code revoked successfully", nil)
+}
+
+// GetRedisMemory reports Redis memory usage per key family (?refresh=true to re-measure)
+func (h *AdminHandler) GetRedisMemory(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var report *types.RedisMemoryReport
+	var err error
+	if c.Query("refresh") == "true" {
+		report, err = h.memoryBudget.Refresh(ctx)
+	} else {
+		report, err = h.memoryBudget.Report(ctx)
+	}
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Redis memory report retrieved successfully", report)
+}
+
+// FlushCacheKey deletes one cache key, e.g. a stale redirect (supports ?dry_run=true)
+func (h *AdminHandler) FlushCacheKey(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	var req models.FlushCacheKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.adminService.FlushCacheKey(c.Request.Context(), req.Key, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// RebuildNegativeCache drops cached "not found" answers and re-warms the
+// redirect cache (supports ?dry_run=true)
+func (h *AdminHandler) RebuildNegativeCache(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.adminService.RebuildNegativeCache(c.Request.Context(), dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	respondAdminAction(c, result)
+}
+
+// ReconcileClicks realigns a link's stored and Redis click counts (supports ?dry_run=true)
+func (h *AdminHandler) ReconcileClicks(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
+
+	report, err := h.adminService.ReconcileClicks(c.Request.Context(), urlID, dryRun)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Clicks reconciled successfully", report)
+}
+
+// ListFailedEmails lists emails that failed permanently
+func (h *AdminHandler) ListFailedEmails(c *gin.Context) {
+	failed, err := h.adminService.ListFailedEmails(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Failed emails retrieved successfully", failed)
+}
+
+// RetryFailedEmail puts a failed email back on the queue
+func (h *AdminHandler) RetryFailedEmail(c *gin.Context) {
+	email, err := h.adminService.RetryFailedEmail(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email queued for retry", email)
+}
+
+// parseDryRun reads the dry_run query parameter, writing a 400 response on invalid input
+func parseDryRun(c *gin.Context) (bool, bool) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("dry_run must be a boolean"))
+		return false, false
+	}
+	return dryRun, true
+}
+
+func respondAdminAction(c *gin.Context, result *types.AdminActionResult) {
+	message := "Admin action executed successfully"
+	if result.DryRun {
+		message = "Dry run completed, no changes were made"
+	}
+	utils.SuccessResponse(c, http.StatusOK, message, result)
+}
@@ -1,30 +1,42 @@
 package handlers
 
 import (
+	"context"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"golang.org/x/net/websocket"
 )
 
+// realtimePushInterval is how often the live view is pushed over the websocket
+const realtimePushInterval = 5 * time.Second
+
 type AnalyticsHandler struct {
 	analyticsService interfaces.AnalyticsService
+	realtime         interfaces.RealtimeAnalytics
 }
 
-func NewAnalyticsHandler(analyticsService interfaces.AnalyticsService) *AnalyticsHandler {
+func NewAnalyticsHandler(analyticsService interfaces.AnalyticsService, realtime interfaces.RealtimeAnalytics) *AnalyticsHandler {
 	return &AnalyticsHandler{
 		analyticsService: analyticsService,
+		realtime:         realtime,
 	}
 }
 
 // GetUserAnalytics retrieves analytics for all user's URLs
 func (h *AnalyticsHandler) GetUserAnalytics(c *gin.Context) {
-	ctx := c.Request.Context()
-	userID := c.GetUint("user_id")
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
 
+	ctx := c.Request.Context()
 	analytics, err := h.analyticsService.GetUserAnalytics(ctx, userID)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err)
@@ -36,27 +48,201 @@ func (h *AnalyticsHandler) GetUserAnalytics(c *gin.Context) {
 
 // GetURLAnalytics retrieves analytics for a specific URL
 func (h *AnalyticsHandler) GetURLAnalytics(c *gin.Context) {
-	urlID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidURLID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	analytics, err := h.analyticsService.GetURLAnalytics(ctx, userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL analytics retrieved successfully", analytics)
+}
+
+// GetCampaignStats aggregates UTM-tagged clicks per campaign across all user's URLs
+func (h *AnalyticsHandler) GetCampaignStats(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	stats, err := h.analyticsService.GetCampaignStats(ctx, userID, nil)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Campaign statistics retrieved successfully", stats)
+}
+
+// GetURLCampaignStats aggregates UTM-tagged clicks per campaign for a specific URL
+func (h *AnalyticsHandler) GetURLCampaignStats(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidURLID)
 		return
 	}
 
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
 	ctx := c.Request.Context()
-	userID := c.GetUint("user_id")
+	stats, err := h.analyticsService.GetCampaignStats(ctx, userID, &urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Campaign statistics retrieved successfully", stats)
+}
+
+// ComparePeriods returns clicks for a date range and the preceding range of equal length.
+// Query: from, to (YYYY-MM-DD, inclusive, or RFC3339) defaulting to the last 7 days,
+// and an optional url_id to restrict to one link.
+func (h *AnalyticsHandler) ComparePeriods(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
 
-	analytics, err := h.analyticsService.GetURLAnalytics(ctx, userID, uint(urlID))
+	now := time.Now().UTC()
+	to, err := parseDateParam(c.Query("to"), true, now)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("invalid 'to' date: "+err.Error()))
+		return
+	}
+	from, err := parseDateParam(c.Query("from"), false, to.AddDate(0, 0, -7))
 	if err != nil {
-		switch err {
-		case types.ErrURLNotFound:
-			utils.ErrorResponse(c, http.StatusNotFound, err)
-		case types.ErrUnauthorized:
-			utils.ErrorResponse(c, http.StatusForbidden, err)
-		default:
-			utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("invalid 'from' date: "+err.Error()))
+		return
+	}
+
+	var urlID *uuid.UUID
+	if raw := c.Query("url_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidURLID)
+			return
 		}
+		urlID = &id
+	}
+
+	ctx := c.Request.Context()
+	comparison, err := h.analyticsService.ComparePeriods(ctx, userID, urlID, from, to)
+	if err != nil {
+		utils.HandleError(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "URL analytics retrieved successfully", analytics)
+	utils.SuccessResponse(c, http.StatusOK, "Period comparison retrieved successfully", comparison)
+}
+
+// GetRealtime returns the live view: clicks per minute for the last half
+// hour, plus the links clicked in the last five minutes
+func (h *AnalyticsHandler) GetRealtime(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	realtime, err := h.realtime.Realtime(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	utils.SuccessResponse(c, http.StatusOK, "Realtime analytics retrieved successfully", realtime)
+}
+
+// StreamRealtime upgrades to a websocket and pushes the live view every
+// realtimePushInterval until the client disconnects. Browsers can't set
+// headers on websockets, so the access token may come as ?access_token=.
+func (h *AnalyticsHandler) StreamRealtime(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	// Authentication is the token, not cookies, so any origin may connect
+	server := websocket.Server{
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			h.pushRealtime(conn, userID)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *AnalyticsHandler) pushRealtime(conn *websocket.Conn, userID uuid.UUID) {
+	ctx, cancel := context.WithCancel(conn.Request().Context())
+	defer cancel()
+
+	// The client only ever closes; reading notices that
+	go func() {
+		defer cancel()
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+	}()
+
+	ticker := time.NewTicker(realtimePushInterval)
+	defer ticker.Stop()
+	for {
+		realtime, err := h.realtime.Realtime(ctx, userID)
+		if err != nil {
+			if ctx.Err() == nil {
+				utils.LoggerFromContext(ctx).Error("Failed to compute realtime analytics", "user_id", userID, "error", err)
+			}
+			return
+		}
+		if err := websocket.JSON.Send(conn, realtime); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// parseDateParam parses YYYY-MM-DD or RFC3339. A date-only upper bound is made
+// inclusive by moving it to the start of the next day.
+func parseDateParam(raw string, upperBound bool, fallback time.Time) (time.Time, error) {
+	if raw == "" {
+		return fallback, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t.UTC(), nil
+	}
+	t, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
 }
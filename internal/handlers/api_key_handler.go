@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type APIKeyHandler struct {
+	apiKeyService interfaces.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService interfaces.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// CreateAPIKey issues a new API key. Keys cannot mint other keys.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	if c.GetString("auth_method") == "api_key" {
+		utils.ErrorResponse(c, http.StatusForbidden, types.ErrInteractiveLoginRequired)
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	if err := models.ValidateScopes(req.Scopes); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	apiKey, err := h.apiKeyService.CreateAPIKey(ctx, userID, req.Name, req.Scopes)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created successfully, store it now as it will not be shown again", apiKey)
+}
+
+// ListAPIKeys lists the user's API keys
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	keys, err := h.apiKeyService.ListAPIKeys(ctx, userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+// RevokeAPIKey revokes one of the user's API keys
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	keyID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.apiKeyService.RevokeAPIKey(ctx, userID, keyID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/config"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/services"
@@ -18,17 +25,31 @@ import (
 
 type AuthHandler struct {
 	authService  interfaces.AuthService
-	jwtSecret    string
+	secrets      *config.SecretManager
 	db           *gorm.DB
-	emailService *services.EmailService
+	emailQueue   *services.EmailQueue
+	verification *services.VerificationService
+	google       *services.GoogleOAuth
+	oidc         *services.OIDCProvider
+	magicLinks   *services.MagicLinkService
+	sessions     interfaces.SessionService
+	logins       interfaces.LoginAuditService
+	lifetimes    types.TokenLifetimes
 }
 
-func NewAuthHandler(authService interfaces.AuthService, jwtSecret string, db *gorm.DB) *AuthHandler {
+func NewAuthHandler(authService interfaces.AuthService, secrets *config.SecretManager, db *gorm.DB, emailQueue *services.EmailQueue, verification *services.VerificationService, google *services.GoogleOAuth, oidc *services.OIDCProvider, magicLinks *services.MagicLinkService, sessions interfaces.SessionService, logins interfaces.LoginAuditService, lifetimes types.TokenLifetimes) *AuthHandler {
 	return &AuthHandler{
 		authService:  authService,
-		jwtSecret:    jwtSecret,
+		secrets:      secrets,
 		db:           db,
-		emailService: services.NewEmailService(),
+		emailQueue:   emailQueue,
+		verification: verification,
+		google:       google,
+		oidc:         oidc,
+		magicLinks:   magicLinks,
+		sessions:     sessions,
+		logins:       logins,
+		lifetimes:    lifetimes,
 	}
 }
 
@@ -48,15 +69,27 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		LastName:  req.LastName,
 	}
 
-	if err := h.authService.Register(ctx, user); err != nil {
+	if err := h.authService.Register(ctx, user, req.InviteCode); err != nil {
 		if err == types.ErrUserExists {
 			utils.ErrorResponse(c, http.StatusConflict, err)
 			return
 		}
+		if err == types.ErrInviteCodeRequired || err == types.ErrInvalidInviteCode || err == types.ErrPasswordBreached || err == types.ErrDisposableEmail {
+			utils.HandleError(c, err)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	// Verification/welcome/onboarding emails are best-effort and must not fail registration
+	if err := h.verification.SendVerification(ctx, user.ID); err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to queue verification email", "user_id", user.ID, "error", err)
+	}
+	if err := h.emailQueue.EnqueueSignup(ctx, user.ID); err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to queue signup emails", "user_id", user.ID, "error", err)
+	}
+
 	utils.SuccessResponse(c, http.StatusCreated, "User registered successfully", types.RegisterResponse{
 		User: user,
 	})
@@ -72,15 +105,103 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	ctx := c.Request.Context()
 	user, err := h.authService.Login(ctx, req.Email, req.Password)
 	if err != nil {
+		h.recordLogin(c, models.LoginMethodPassword, req.Email, nil, err)
+		if err == types.ErrAccountSuspended || err == types.ErrAccountLocked {
+			utils.HandleError(c, err)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidCredentials)
 		return
 	}
 
-	token, refresh, err := h.generateTokenPair(user.ID)
+	token, refresh, err := h.generateTokenPair(c, user, req.RememberMe)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInvalidToken)
+		return
+	}
+	h.recordLogin(c, models.LoginMethodPassword, user.Email, &user.ID, nil)
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", types.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+	})
+}
+
+// GoogleLogin signs in (or signs up) with a Google ID token or authorization code
+func (h *AuthHandler) GoogleLogin(c *gin.Context) {
+	var req models.GoogleOAuthRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, err := h.google.Authenticate(ctx, req.IDToken, req.Code)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	user, err := h.authService.LoginWithGoogle(ctx, identity, req.InviteCode)
+	if err != nil {
+		h.recordLogin(c, models.LoginMethodGoogle, identity.Email, nil, err)
+		utils.HandleError(c, err)
+		return
+	}
+
+	token, refresh, err := h.generateTokenPair(c, user, false)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInvalidToken)
+		return
+	}
+	h.recordLogin(c, models.LoginMethodGoogle, user.Email, &user.ID, nil)
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", types.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+	})
+}
+
+// OIDCConfig returns where the frontend should send users for single sign-on
+func (h *AuthHandler) OIDCConfig(c *gin.Context) {
+	info, err := h.oidc.AuthorizationInfo(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "SSO configuration retrieved successfully", info)
+}
+
+// OIDCLogin signs in (or provisions) a user with an ID token or authorization
+// code from the configured OIDC issuer
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	var req models.OIDCLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	identity, err := h.oidc.Authenticate(ctx, req.IDToken, req.Code, req.State)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	user, err := h.authService.LoginWithOIDC(ctx, identity, h.oidc.AutoProvision())
+	if err != nil {
+		h.recordLogin(c, models.LoginMethodOIDC, identity.Email, nil, err)
+		utils.HandleError(c, err)
+		return
+	}
+
+	token, refresh, err := h.generateTokenPair(c, user, false)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInvalidToken)
 		return
 	}
+	h.recordLogin(c, models.LoginMethodOIDC, user.Email, &user.ID, nil)
 
 	utils.SuccessResponse(c, http.StatusOK, "Login successful", types.LoginResponse{
 		Token:        token,
@@ -88,6 +209,46 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	})
 }
 
+// recordLogin adds a sign-in attempt to the account's security log
+func (h *AuthHandler) recordLogin(c *gin.Context, method, email string, userID *uuid.UUID, loginErr error) {
+	event := &models.LoginEvent{
+		UserID:    userID,
+		Email:     email,
+		Method:    method,
+		Success:   loginErr == nil,
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Country:   utils.ClientCountry(c),
+	}
+	switch loginErr {
+	case nil:
+	case types.ErrInvalidCredentials, types.ErrAccountSuspended, types.ErrAccountLocked, types.ErrInviteCodeRequired, types.ErrInvalidInviteCode, types.ErrSSOAccountNotProvisioned:
+		event.Reason = loginErr.Error()
+	default:
+		event.Reason = "internal error"
+	}
+	h.logins.RecordLogin(c.Request.Context(), event)
+}
+
+// ListLoginEvents shows recent sign-in attempts so users can spot unfamiliar access
+func (h *AuthHandler) ListLoginEvents(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	events, err := h.logins.ListLoginEvents(c.Request.Context(), userID, limit)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Login events retrieved successfully", events)
+}
+
 func (h *AuthHandler) Logout(c *gin.Context) {
 	userID, err := uuid.Parse(c.GetString("user_id"))
 	if err != nil {
@@ -104,6 +265,177 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Logged out successfully", nil)
 }
 
+// ChangePassword updates the password and signs out all other sessions. The
+// caller's own token is revoked too, so a fresh token pair is returned.
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	if c.GetString("auth_method") == "api_key" {
+		utils.ErrorResponse(c, http.StatusForbidden, types.ErrInteractiveLoginRequired)
+		return
+	}
+
+	var req models.UpdatePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.authService.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	user, err := h.authService.GetUserByID(ctx, userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	// Tokens issued at or before the revocation are rejected, so the new pair
+	// is dated just past it when both fall within the same millisecond
+	issuedAt := time.Now()
+	if revokedAt, ok := h.sessions.UserRevokedAt(ctx, userID); ok && issuedAt.UnixMilli() <= revokedAt.UnixMilli() {
+		issuedAt = revokedAt.Add(time.Millisecond)
+	}
+	token, refresh, err := h.generateTokenPairAt(c, user, false, issuedAt)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInvalidToken)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Password changed successfully", types.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+	})
+}
+
+// DeleteAccount permanently deletes the caller's account and all their data
+func (h *AuthHandler) DeleteAccount(c *gin.Context) {
+	if c.GetString("auth_method") == "api_key" {
+		utils.ErrorResponse(c, http.StatusForbidden, types.ErrInteractiveLoginRequired)
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.authService.DeleteAccount(ctx, userID, req.Password); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account deleted successfully", nil)
+}
+
+// ListSessions lists the devices signed in to the account
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	sessions, err := h.sessions.ListSessions(ctx, userID, c.GetString("session_id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sessions retrieved successfully", sessions)
+}
+
+// RevokeSession signs out a single device
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.sessions.RevokeSession(ctx, userID, sessionID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Session revoked successfully", nil)
+}
+
+// RevokeToken revokes a single access token (by default the caller's own)
+// while its session stays signed in, e.g. after a token leaked into logs
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.RevokeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	tokenString := req.Token
+	if tokenString == "" {
+		tokenString = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+
+	token, err := utils.ParseTokenWith(tokenString, h.secrets)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidToken)
+		return
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidClaims)
+		return
+	}
+	// Only the user's own access tokens; tokens issued before jti have to be
+	// revoked with their session
+	tokenID, _ := claims["jti"].(string)
+	owner, _ := claims["user_id"].(string)
+	tokenType, _ := claims["typ"].(string)
+	exp, _ := claims["exp"].(float64)
+	if owner != userID.String() || tokenType != utils.TokenTypeAccess || tokenID == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidToken)
+		return
+	}
+
+	if err := h.sessions.RevokeAccessToken(c.Request.Context(), tokenID, time.Unix(int64(exp), 0)); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token revoked successfully", nil)
+}
+
 func (h *AuthHandler) GetUserDetails(c *gin.Context) {
 	userIDStr := c.GetString("user_id")
 	userID, err := uuid.Parse(userIDStr)
@@ -122,45 +454,171 @@ func (h *AuthHandler) GetUserDetails(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "User details retrieved successfully", user)
 }
 
+// VerifyEmail confirms an email address from the link in the verification email
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("token is required"))
+		return
+	}
+
+	user, err := h.verification.VerifyEmail(c.Request.Context(), token)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email verified successfully", user)
+}
+
+// ResendVerification sends a new verification email to the current user
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.verification.SendVerification(c.Request.Context(), userID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Verification email has been sent", nil)
+}
+
+// UpdateEmailPreferences lets a user opt in or out of onboarding emails
+func (h *AuthHandler) UpdateEmailPreferences(c *gin.Context) {
+	var req models.UpdateEmailPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ?", userID).
+		UpdateColumn("onboarding_emails", *req.OnboardingEmails).Error; err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email preferences updated successfully", gin.H{
+		"onboarding_emails": *req.OnboardingEmails,
+	})
+}
+
 // ForgotPassword handles password reset request
 func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 	var req models.ResetPasswordRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// The rate limiter has already read the body, which gin cached for us
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, err)
 		return
 	}
 
-	ctx := c.Request.Context()
-	token, err := h.authService.RequestPasswordReset(ctx, req.Email)
+	// Every outcome gets the same answer, so it reveals neither whether the
+	// account exists nor whether its address is suppressed
+	h.queueResetEmail(c.Request.Context(), req.Email)
+	utils.SuccessResponse(c, http.StatusOK, "If the email exists, a password reset link has been sent", nil)
+}
+
+// queueResetEmail issues a reset token for the account with this email and
+// queues the email, which the queue skips for suppressed addresses. Failures
+// are only logged, without the address.
+func (h *AuthHandler) queueResetEmail(ctx context.Context, email string) {
+	token, err := h.authService.RequestPasswordReset(ctx, email)
+	if errors.Is(err, types.ErrDisposableEmail) {
+		utils.LoggerFromContext(ctx).Info("Refused password reset for a disposable address")
+		return
+	}
 	if err != nil {
-		// Log the actual error for debugging
-		fmt.Printf("Error generating reset token: %v\n", err)
-		utils.SuccessResponse(c, http.StatusOK, "If the email exists, a password reset link has been sent", nil)
+		utils.LoggerFromContext(ctx).Error("Failed to generate reset token", "error", err)
 		return
 	}
-
-	// If token is empty, email doesn't exist (security: don't reveal)
 	if token == "" {
-		utils.SuccessResponse(c, http.StatusOK, "If the email exists, a password reset link has been sent", nil)
 		return
 	}
 
 	var user models.User
-	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
-		fmt.Printf("Error finding user: %v\n", err)
-		utils.SuccessResponse(c, http.StatusOK, "If the email exists, a password reset link has been sent", nil)
+	if err := h.db.WithContext(ctx).Select("id").Where("email = ?", email).First(&user).Error; err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to find user for reset email", "error", err)
+		return
+	}
+	job := services.EmailJob{Kind: services.EmailJobPasswordReset, UserID: user.ID, Token: token}
+	if err := h.emailQueue.Enqueue(ctx, job, time.Now()); err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to queue reset email", "user_id", user.ID, "error", err)
+	}
+}
+
+// RequestMagicLink emails a single-use login link
+func (h *AuthHandler) RequestMagicLink(c *gin.Context) {
+	var req models.MagicLinkRequest
+	if err := c.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
 		return
 	}
 
-	fullName := user.FirstName + " " + user.LastName
-	if err := h.emailService.SendResetPasswordEmail(user.Email, fullName, token); err != nil {
-		// ✅ Log the actual email error for debugging
-		fmt.Printf("SMTP Error: %v\n", err)
-		utils.ErrorResponse(c, http.StatusInternalServerError, fmt.Errorf("failed to send email: %v", err))
+	ctx := c.Request.Context()
+	if err := h.magicLinks.SendLink(ctx, req.Email); err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to send login link", "error", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInternalError)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Password reset email has been sent successfully", nil)
+	utils.SuccessResponse(c, http.StatusOK, "If the email exists, a login link has been sent", nil)
+}
+
+// MagicLinkLogin exchanges a login link token for a token pair. It is a POST
+// made by the frontend page the email links to, so mail scanners prefetching
+// the link cannot use it up.
+func (h *AuthHandler) MagicLinkLogin(c *gin.Context) {
+	var req models.MagicLinkLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.magicLinks.Redeem(ctx, req.Token)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	token, refresh, err := h.generateTokenPair(c, user, false)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInvalidToken)
+		return
+	}
+	h.recordLogin(c, models.LoginMethodMagicLink, user.Email, &user.ID, nil)
+
+	utils.SuccessResponse(c, http.StatusOK, "Login successful", types.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+	})
+}
+
+// UnlockAccount lifts a login lock from the link in the unlock email
+func (h *AuthHandler) UnlockAccount(c *gin.Context) {
+	var req models.UnlockAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	if err := h.authService.UnlockAccount(c.Request.Context(), req.Token); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account unlocked successfully", nil)
 }
 
 // ResetPasswordConfirm handles the actual password reset with token
@@ -173,6 +631,10 @@ func (h *AuthHandler) ResetPasswordConfirm(c *gin.Context) {
 
 	ctx := c.Request.Context()
 	if err := h.authService.ResetPassword(ctx, req.Token, req.NewPassword); err != nil {
+		if err == types.ErrPasswordBreached {
+			utils.HandleError(c, err)
+			return
+		}
 		utils.ErrorResponse(c, http.StatusBadRequest, fmt.Errorf("invalid or expired reset token"))
 		return
 	}
@@ -180,27 +642,140 @@ func (h *AuthHandler) ResetPasswordConfirm(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Password has been reset successfully", nil)
 }
 
-func (h *AuthHandler) generateTokenPair(userID uuid.UUID) (token, refresh string, err error) {
-	token, err = h.generateToken(userID, 24*time.Hour)
+// generateTokenPair starts a new session for the client and issues its tokens;
+// rememberMe picks the longer refresh lifetime
+func (h *AuthHandler) generateTokenPair(c *gin.Context, user *models.User, rememberMe bool) (token, refresh string, err error) {
+	return h.generateTokenPairAt(c, user, rememberMe, time.Now())
+}
+
+// generateTokenPairAt is generateTokenPair with the session and its tokens
+// dated issuedAt
+func (h *AuthHandler) generateTokenPairAt(c *gin.Context, user *models.User, rememberMe bool, issuedAt time.Time) (token, refresh string, err error) {
+	expiresAt := issuedAt.Add(h.lifetimes.RefreshFor(rememberMe))
+	session, err := h.sessions.CreateSession(c.Request.Context(), user.ID, c.Request.UserAgent(), c.ClientIP(), issuedAt, expiresAt)
+	if err != nil {
+		return "", "", err
+	}
+
+	return h.issueTokens(c, user, session.ID, uuid.New(), issuedAt, expiresAt)
+}
+
+// issueTokens signs an access token and a stored refresh token for an
+// existing session; familyID links the refresh token to the ones it replaces.
+// Refresh tokens expire with their session (refreshExpiresAt), so rotating
+// them never extends a sign-in.
+func (h *AuthHandler) issueTokens(c *gin.Context, user *models.User, sessionID, familyID uuid.UUID, issuedAt, refreshExpiresAt time.Time) (token, refresh string, err error) {
+	now := issuedAt.UTC()
+	accessExpiresAt := now.Add(h.lifetimes.Access)
+	if accessExpiresAt.After(refreshExpiresAt) {
+		accessExpiresAt = refreshExpiresAt
+	}
+
+	// Each access token gets its own ID so it can be revoked on its own
+	token, err = h.generateToken(user, sessionID, utils.TokenTypeAccess, now, accessExpiresAt, jwt.MapClaims{
+		"jti": uuid.New().String(),
+	})
 	if err != nil {
 		return "", "", err
 	}
 
-	refresh, err = h.generateToken(userID, 7*24*time.Hour)
+	record := &models.RefreshToken{
+		ID:        uuid.New(),
+		FamilyID:  familyID,
+		UserID:    user.ID,
+		SessionID: sessionID,
+		CreatedAt: now,
+		ExpiresAt: refreshExpiresAt.UTC(),
+	}
+	refresh, err = h.generateToken(user, sessionID, utils.TokenTypeRefresh, now, refreshExpiresAt, jwt.MapClaims{
+		"jti": record.ID.String(),
+	})
 	if err != nil {
 		return "", "", err
 	}
 
+	if err := h.sessions.StoreRefreshToken(c.Request.Context(), record, refresh); err != nil {
+		return "", "", err
+	}
 	return token, refresh, nil
 }
 
-func (h *AuthHandler) generateToken(userID uuid.UUID, expiration time.Duration) (string, error) {
+// RefreshToken exchanges a refresh token for a new token pair. The presented
+// token is single use; replaying it signs the session out.
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var req models.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	parsed, err := utils.ParseTokenWith(req.RefreshToken, h.secrets)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidToken)
+		return
+	}
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok || claims["typ"] != utils.TokenTypeRefresh {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidToken)
+		return
+	}
+	tokenID, err := uuid.Parse(fmt.Sprint(claims["jti"]))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidToken)
+		return
+	}
+
+	ctx := c.Request.Context()
+	previous, err := h.sessions.RotateRefreshToken(ctx, tokenID, req.RefreshToken)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	user, err := h.authService.GetUserByID(ctx, previous.UserID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	if user.IsSuspended() {
+		utils.HandleError(c, types.ErrAccountSuspended)
+		return
+	}
+
+	token, refresh, err := h.issueTokens(c, user, previous.SessionID, previous.FamilyID, time.Now(), previous.ExpiresAt)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, types.ErrInvalidToken)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token refreshed successfully", types.LoginResponse{
+		Token:        token,
+		RefreshToken: refresh,
+	})
+}
+
+func (h *AuthHandler) generateToken(user *models.User, sessionID uuid.UUID, tokenType string, issuedAt, expiresAt time.Time, extra jwt.MapClaims) (string, error) {
 	claims := jwt.MapClaims{
-		"user_id": userID.String(),
-		"exp":     time.Now().Add(expiration).Unix(),
-		"iat":     time.Now().Unix(),
+		"user_id": user.ID.String(),
+		"sub":     user.ID.String(),
+		"email":   user.Email,
+		"role":    user.Role,
+		"typ":     tokenType,
+		"sid":     sessionID.String(), // lets AuthMiddleware reject a single signed-out session
+		"exp":     expiresAt.Unix(),
+		"iat":     issuedAt.Unix(),
+		"iat_ms":  issuedAt.UnixMilli(), // compared with the logout time by AuthMiddleware
+	}
+	for key, value := range extra {
+		claims[key] = value
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(h.jwtSecret))
+	return h.secrets.Sign(claims)
+}
+
+// JWKS publishes the public signing keys so other services can verify access
+// tokens without the HMAC secret (empty when signing with HS256)
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.secrets.JWKS())
 }
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// bioPageTemplate renders a bio page as a plain list of buttons
+var bioPageTemplate = template.Must(template.New("bio_page").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{if .Title}}{{.Title}}{{else}}{{.Slug}}{{end}}</title>
+<meta property="og:title" content="{{if .Title}}{{.Title}}{{else}}{{.Slug}}{{end}}">
+{{- if .Description}}
+<meta name="description" content="{{.Description}}">
+<meta property="og:description" content="{{.Description}}">
+{{- end}}
+<style>
+body{font-family:Arial,sans-serif;color:#333;text-align:center;padding:40px 20px;max-width:480px;margin:0 auto}
+a.link{display:block;margin:12px 0;padding:14px;border:1px solid #ccc;border-radius:8px;color:#333;text-decoration:none}
+</style>
+</head>
+<body>
+<h1>{{if .Title}}{{.Title}}{{else}}{{.Slug}}{{end}}</h1>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- range .Links}}
+<a class="link" href="{{.Href}}" rel="noopener">{{.Title}}</a>
+{{- end}}
+</body>
+</html>
+`))
+
+type BioPageHandler struct {
+	bioPages interfaces.BioPageService
+}
+
+func NewBioPageHandler(bioPages interfaces.BioPageService) *BioPageHandler {
+	return &BioPageHandler{bioPages: bioPages}
+}
+
+// GetBioPage returns the caller's bio page with its view and click counts
+func (h *BioPageHandler) GetBioPage(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	page, err := h.bioPages.GetBioPage(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bio page retrieved successfully", page)
+}
+
+// SetBioPage creates or replaces the caller's bio page
+func (h *BioPageHandler) SetBioPage(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.BioPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	page, err := h.bioPages.SetBioPage(c.Request.Context(), userID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bio page updated successfully", page)
+}
+
+// DeleteBioPage takes the caller's bio page offline
+func (h *BioPageHandler) DeleteBioPage(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.bioPages.DeleteBioPage(c.Request.Context(), userID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bio page deleted successfully", nil)
+}
+
+// GetPublicBioPage returns a bio page as JSON, for embedding
+func (h *BioPageHandler) GetPublicBioPage(c *gin.Context) {
+	page, err := h.bioPages.GetPublicBioPage(c.Request.Context(), c.Param("slug"), false)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	utils.SuccessResponse(c, http.StatusOK, "Bio page retrieved successfully", page)
+}
+
+// RenderBioPage serves a bio page as HTML and counts the view
+func (h *BioPageHandler) RenderBioPage(c *gin.Context) {
+	page, err := h.bioPages.GetPublicBioPage(c.Request.Context(), c.Param("slug"), !utils.IsBot(c.Request.UserAgent()))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := bioPageTemplate.Execute(&body, page); err != nil {
+		utils.LoggerFromContext(c.Request.Context()).Error("Failed to render bio page", "error", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+}
+
+// FollowBioLink counts a click from a bio page and forwards to the link's short URL
+func (h *BioPageHandler) FollowBioLink(c *gin.Context) {
+	linkID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	shortURL, err := h.bioPages.FollowBioLink(c.Request.Context(), c.Param("slug"), linkID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Redirect(http.StatusFound, shortURL)
+}
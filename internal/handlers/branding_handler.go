@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type BrandingHandler struct {
+	branding interfaces.BrandingService
+}
+
+func NewBrandingHandler(branding interfaces.BrandingService) *BrandingHandler {
+	return &BrandingHandler{branding: branding}
+}
+
+// GetBranding returns the account's logo, color and email sender name
+func (h *BrandingHandler) GetBranding(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	branding, err := h.branding.GetBranding(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Branding retrieved successfully", branding)
+}
+
+// UpdateBranding replaces the account's branding
+func (h *BrandingHandler) UpdateBranding(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.BrandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	branding, err := h.branding.UpdateBranding(c.Request.Context(), userID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Branding updated successfully", branding)
+}
+
+// DeleteBranding goes back to the default look
+func (h *BrandingHandler) DeleteBranding(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.branding.DeleteBranding(c.Request.Context(), userID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Branding reset successfully", nil)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/services"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type EmailWebhookHandler struct {
+	emailService *services.EmailService
+	secret       string
+}
+
+func NewEmailWebhookHandler(emailService *services.EmailService, secret string) *EmailWebhookHandler {
+	return &EmailWebhookHandler{
+		emailService: emailService,
+		secret:       secret,
+	}
+}
+
+// HandleEvents receives bounce/complaint notifications from the email provider.
+// The provider must send the shared secret in the X-Webhook-Secret header.
+func (h *EmailWebhookHandler) HandleEvents(c *gin.Context) {
+	provided := c.GetHeader("X-Webhook-Secret")
+	if h.secret == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(h.secret)) != 1 {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidToken)
+		return
+	}
+
+	var req models.EmailEventsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	updated, err := h.emailService.HandleDeliveryEvents(c.Request.Context(), req.Events)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Email events processed", gin.H{
+		"received": len(req.Events),
+		"updated":  updated,
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// interstitialDelayMs is how long the pixels get to fire before redirecting
+const interstitialDelayMs = 800
+
+// interstitialTemplate loads the link's retargeting pixels, then redirects.
+// With consent required, Google tags start in consent mode "denied" (cookieless
+// pings only) and the Meta pixel is revoked, so no ad cookies are set.
+var interstitialTemplate = template.Must(template.New("interstitial").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<noscript><meta http-equiv="refresh" content="0;url={{.URL}}"></noscript>
+<title>Redirecting…</title>
+<style>
+body{font-family:Arial,sans-serif;color:#333;text-align:center;padding:40px 20px}
+a{color:{{.Color}}}
+.logo{max-height:64px;max-width:240px;margin-bottom:24px}
+</style>
+{{- if .Google}}
+<script async src="https://www.googletagmanager.com/gtag/js?id={{index .Google 0}}"></script>
+<script>
+window.dataLayer = window.dataLayer || [];
+function gtag(){dataLayer.push(arguments);}
+{{- if .ConsentRequired}}
+gtag('consent', 'default', {ad_storage: 'denied', ad_user_data: 'denied', ad_personalization: 'denied', analytics_storage: 'denied'});
+{{- end}}
+gtag('js', new Date());
+{{- range .Google}}
+gtag('config', {{.}});
+{{- end}}
+</script>
+{{- end}}
+{{- if .Meta}}
+<script>
+!function(f,b,e,v,n,t,s){if(f.fbq)return;n=f.fbq=function(){n.callMethod?n.callMethod.apply(n,arguments):n.queue.push(arguments)};if(!f._fbq)f._fbq=n;n.push=n;n.loaded=!0;n.version='2.0';n.queue=[];t=b.createElement(e);t.async=!0;t.src=v;s=b.getElementsByTagName(e)[0];s.parentNode.insertBefore(t,s)}(window,document,'script','https://connect.facebook.net/en_US/fbevents.js');
+{{- if .ConsentRequired}}
+fbq('consent', 'revoke');
+{{- end}}
+{{- range .Meta}}
+fbq('init', {{.}});
+{{- end}}
+fbq('track', 'PageView');
+</script>
+{{- end}}
+</head>
+<body>
+{{- if .LogoURL}}
+<img class="logo" src="{{.LogoURL}}" alt="">
+{{- end}}
+<p>Redirecting to <a href="{{.URL}}">{{.URL}}</a>…</p>
+<script>setTimeout(function(){window.location.replace({{.URL}});}, {{.DelayMs}});</script>
+</body>
+</html>
+`))
+
+type interstitialData struct {
+	URL             string
+	Google          []string
+	Meta            []string
+	ConsentRequired bool
+	DelayMs         int
+	// Link owner's branding
+	LogoURL string
+	Color   template.CSS
+}
+
+// optedOutOfTracking reports whether the browser sent Global Privacy Control or Do Not Track
+func optedOutOfTracking(c *gin.Context) bool {
+	return c.GetHeader("Sec-GPC") == "1" || c.GetHeader("DNT") == "1"
+}
+
+// renderInterstitial serves the pixel page that forwards to the destination,
+// styled with the link owner's branding (nil for the defaults)
+func renderInterstitial(c *gin.Context, target *types.RedirectTarget, branding *models.Branding) {
+	data := interstitialData{
+		URL:             target.URL,
+		ConsentRequired: target.PixelConsentRequired,
+		DelayMs:         interstitialDelayMs,
+		// Colors are validated as hex on save, so they're safe to inline as CSS
+		Color: template.CSS(branding.Color()),
+	}
+	if branding != nil {
+		data.LogoURL = branding.LogoURL
+	}
+	for _, pixel := range target.Pixels {
+		switch pixel.Provider {
+		case models.PixelProviderGoogle:
+			data.Google = append(data.Google, pixel.ID)
+		case models.PixelProviderMeta:
+			data.Meta = append(data.Meta, pixel.ID)
+		}
+	}
+
+	var page bytes.Buffer
+	if err := interstitialTemplate.Execute(&page, data); err != nil {
+		// The visitor still gets where they were going, just without pixels
+		utils.LoggerFromContext(c.Request.Context()).Error("Failed to render interstitial", "error", err)
+		c.Redirect(http.StatusFound, target.URL)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type MetaHandler struct {
+	metadata interfaces.LinkMetadataService
+}
+
+func NewMetaHandler(metadata interfaces.LinkMetadataService) *MetaHandler {
+	return &MetaHandler{metadata: metadata}
+}
+
+// GetMetadata returns a short link's unfurl card for chat apps and bots.
+// Responses are cacheable by CDNs and clients for an hour.
+func (h *MetaHandler) GetMetadata(c *gin.Context) {
+	meta, err := h.metadata.GetMetadata(c.Request.Context(), c.Param("shortCode"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600, stale-while-revalidate=86400")
+	utils.SuccessResponse(c, http.StatusOK, "Metadata retrieved successfully", meta)
+}
+
+// GetPreviewPage returns the same card as a minimal HTML document for
+// preview fetchers that only read Open Graph tags. It never redirects and
+// never counts a click.
+func (h *MetaHandler) GetPreviewPage(c *gin.Context) {
+	meta, err := h.metadata.GetMetadata(c.Request.Context(), c.Param("shortCode"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	renderUnfurlPage(c, meta)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type PreviewHandler struct {
+	previewService interfaces.PreviewService
+}
+
+func NewPreviewHandler(previewService interfaces.PreviewService) *PreviewHandler {
+	return &PreviewHandler{
+		previewService: previewService,
+	}
+}
+
+// RefreshPreview queues a new destination thumbnail for a link
+func (h *PreviewHandler) RefreshPreview(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.previewService.RefreshPreview(ctx, userID, urlID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Preview refresh queued", nil)
+}
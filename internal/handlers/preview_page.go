@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// previewPageTemplate shows where a link goes and lets the visitor decide
+// whether to continue; nothing redirects automatically
+var previewPageTemplate = template.Must(template.New("preview_page").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>You are leaving for {{.Domain}}</title>
+<style>
+body{font-family:Arial,sans-serif;color:#333;text-align:center;padding:40px 20px}
+.logo{max-height:64px;max-width:240px;margin-bottom:24px}
+.domain{font-size:1.5em;font-weight:bold;margin:8px 0}
+.url{color:#666;word-break:break-all;margin-bottom:24px}
+.continue{display:inline-block;padding:12px 24px;border-radius:6px;background:{{.Color}};color:#fff;text-decoration:none}
+</style>
+</head>
+<body>
+{{- if .LogoURL}}
+<img class="logo" src="{{.LogoURL}}" alt="">
+{{- end}}
+<p>This link will take you to</p>
+<p class="domain">{{.Domain}}</p>
+<p class="url">{{.URL}}</p>
+<a class="continue" href="{{.URL}}" rel="noopener noreferrer">Continue</a>
+</body>
+</html>
+`))
+
+type previewPageData struct {
+	URL    string
+	Domain string
+	// Link owner's branding
+	LogoURL string
+	Color   template.CSS
+}
+
+// renderPreviewPage serves the page showing a link's destination with a
+// "continue" button, styled with the owner's branding (nil for the defaults)
+func renderPreviewPage(c *gin.Context, target *types.RedirectTarget, branding *models.Branding) {
+	data := previewPageData{
+		URL:    target.URL,
+		Domain: utils.ExtractDomain(target.URL),
+		// Colors are validated as hex on save, so they're safe to inline as CSS
+		Color: template.CSS(branding.Color()),
+	}
+	if branding != nil {
+		data.LogoURL = branding.LogoURL
+	}
+
+	var page bytes.Buffer
+	if err := previewPageTemplate.Execute(&page, data); err != nil {
+		utils.LoggerFromContext(c.Request.Context()).Error("Failed to render preview page", "error", err)
+		c.Redirect(http.StatusFound, target.URL)
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
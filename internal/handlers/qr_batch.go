@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"archive/zip"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// ExportQRBatch streams a ZIP with the QR codes of the listed links, one
+// PNG (default) or SVG file per link named by its short code
+func (h *QRHandler) ExportQRBatch(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.QRBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	if req.Format == "" {
+		req.Format = "png"
+	}
+
+	// Resolve every link before the response starts so that a bad ID is
+	// still reported as an error rather than a truncated archive
+	ctx := c.Request.Context()
+	urls, err := h.urlService.GetURLsByIDs(ctx, userID, req.URLIDs)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("qr-codes-%s.zip", time.Now().UTC().Format("2006-01-02"))
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	for _, url := range urls {
+		var data []byte
+		if req.Format == "svg" {
+			data, err = h.qrService.GenerateQRCodeSVG(ctx, url.ShortCode)
+		} else {
+			data, err = h.qrService.GenerateQRCode(ctx, url.ShortCode)
+		}
+		if err == nil {
+			err = writeZipFile(archive, url.ShortCode+"."+req.Format, data, req.Format == "svg")
+		}
+		if err != nil {
+			// Headers are out already, the client sees a truncated archive
+			utils.LoggerFromContext(ctx).Error("QR batch export failed", "user_id", userID, "short_code", url.ShortCode, "error", err)
+			return
+		}
+		c.Writer.Flush()
+	}
+	if err := archive.Close(); err != nil {
+		utils.LoggerFromContext(ctx).Error("QR batch export failed", "user_id", userID, "error", err)
+	}
+}
+
+// writeZipFile adds one file to the archive; PNGs are already compressed
+// and are stored as they are
+func writeZipFile(archive *zip.Writer, name string, data []byte, compress bool) error {
+	method := zip.Store
+	if compress {
+		method = zip.Deflate
+	}
+	w, err := archive.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: time.Now().UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
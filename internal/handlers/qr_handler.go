@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
 )
@@ -30,15 +36,17 @@ func (h *QRHandler) GetQRCode(c *gin.Context) {
 		return
 	}
 
-	// Verify URL exists
+	if c.Query("logo") == "true" {
+		h.getQRCodeWithLogo(c, shortCode)
+		return
+	}
+
 	ctx := c.Request.Context()
-	_, err := h.urlService.GetLongURL(ctx, shortCode)
-	if err != nil {
-		if err == types.ErrURLNotFound {
-			utils.ErrorResponse(c, http.StatusNotFound, err)
-			return
-		}
-		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+	if !h.linkExists(c, shortCode) {
+		return
+	}
+	etag := h.qrService.ETag(shortCode)
+	if qrNotModified(c, etag) {
 		return
 	}
 
@@ -49,11 +57,137 @@ func (h *QRHandler) GetQRCode(c *gin.Context) {
 		return
 	}
 
-	c.Header("Content-Type", "image/png")
-	c.Header("Cache-Control", "public, max-age=86400") // Cache for 24 hours
+	setQRCacheHeaders(c, etag)
 	c.Data(http.StatusOK, "image/png", qrCode)
 }
 
+// getQRCodeWithLogo serves ?logo=true: the QR code with the owner's uploaded
+// logo, or a built-in one picked with ?preset=, in the center. The owner can
+// replace the logo at any time, so the image is only cached for an hour.
+func (h *QRHandler) getQRCodeWithLogo(c *gin.Context, shortCode string) {
+	ctx := c.Request.Context()
+	// The logo belongs to the owner of the code in its stored spelling
+	shortCode, ok := h.servableShortCode(c, shortCode)
+	if !ok {
+		return
+	}
+
+	qrCode, err := h.qrService.GenerateQRCodeWithLogo(ctx, shortCode, c.Query("preset"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	sum := sha256.Sum256(qrCode)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=3600")
+	if strings.Contains(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "image/png", qrCode)
+}
+
+// GetQRLogo returns the account's uploaded QR logo
+func (h *QRHandler) GetQRLogo(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	logo, err := h.qrService.GetQRLogo(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, "image/png", logo.Data)
+}
+
+// UploadQRLogo replaces the account's QR logo with the multipart "logo" file
+func (h *QRHandler) UploadQRLogo(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	header, err := c.FormFile("logo")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	if header.Size > models.MaxQRLogoBytes {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidQRLogo)
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, models.MaxQRLogoBytes+1))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	logo, err := h.qrService.SetQRLogo(c.Request.Context(), userID, data)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "QR logo updated successfully", logo)
+}
+
+// DeleteQRLogo removes the account's QR logo
+func (h *QRHandler) DeleteQRLogo(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.qrService.DeleteQRLogo(c.Request.Context(), userID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "QR logo deleted successfully", nil)
+}
+
+// GetQRCodeSVG returns the QR code as a scalable SVG image for print
+func (h *QRHandler) GetQRCodeSVG(c *gin.Context) {
+	shortCode := c.Param("shortCode")
+	if shortCode == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidInput)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if !h.linkExists(c, shortCode) {
+		return
+	}
+	etag := h.qrService.SVGETag(shortCode)
+	if qrNotModified(c, etag) {
+		return
+	}
+
+	svg, err := h.qrService.GenerateQRCodeSVG(ctx, shortCode)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	setQRCacheHeaders(c, etag)
+	c.Data(http.StatusOK, "image/svg+xml", svg)
+}
+
 // GetQRCodeBase64 returns the QR code as a base64 encoded string
 func (h *QRHandler) GetQRCodeBase64(c *gin.Context) {
 	shortCode := c.Param("shortCode")
@@ -63,13 +197,70 @@ func (h *QRHandler) GetQRCodeBase64(c *gin.Context) {
 	}
 
 	ctx := c.Request.Context()
+	if !h.linkExists(c, shortCode) {
+		return
+	}
+	etag := h.qrService.ETag(shortCode)
+	if qrNotModified(c, etag) {
+		return
+	}
+
 	base64QR, err := h.qrService.GetQRCodeAsBase64(ctx, shortCode)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err)
 		return
 	}
 
+	setQRCacheHeaders(c, etag)
 	utils.SuccessResponse(c, http.StatusOK, "QR code generated successfully", gin.H{
 		"qr_code": fmt.Sprintf("data:image/png;base64,%s", base64QR),
 	})
 }
+
+// linkExists looks the code up before anything is served, a 304 included, so
+// deleted or trashed links stop answering; it writes the error response
+func (h *QRHandler) linkExists(c *gin.Context, shortCode string) bool {
+	_, ok := h.servableShortCode(c, shortCode)
+	return ok
+}
+
+// servableShortCode returns the stored spelling of a code that currently
+// redirects, without counting a click. Links that no longer redirect are
+// 404 or 410 here, whatever the redirect itself answers; it writes the error
+// response.
+func (h *QRHandler) servableShortCode(c *gin.Context, shortCode string) (string, bool) {
+	code, err := h.urlService.ServableShortCode(c.Request.Context(), shortCode)
+	switch err {
+	case nil:
+		return code, true
+	case types.ErrURLNotFound:
+		utils.ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrURLDisabled, types.ErrURLInactive, types.ErrURLQuarantined, types.ErrURLUnderReview:
+		utils.ErrorResponse(c, http.StatusGone, err)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+	}
+	return "", false
+}
+
+// qrNotModified answers 304 when the client already holds this version
+// (If-None-Match) and reports whether the request has been handled
+func qrNotModified(c *gin.Context, etag string) bool {
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			setQRCacheHeaders(c, etag)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// setQRCacheHeaders caches a QR response for an hour and then revalidates, so
+// a deleted or renamed code stops being served soon after; only successful
+// responses get these so errors are never cached
+func setQRCacheHeaders(c *gin.Context, etag string) {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=3600")
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// quarantinePage replaces the redirect of links whose destination matched a
+// Safe Browsing threat list; it deliberately doesn't link to the destination
+const quarantinePage = `<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex, nofollow">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Warning: unsafe link</title>
+<style>
+body{font-family:Arial,sans-serif;color:#333;text-align:center;padding:40px 20px}
+h1{color:#c62828}
+p{max-width:520px;margin:12px auto;line-height:1.5}
+</style>
+</head>
+<body>
+<h1>This link has been blocked</h1>
+<p>The page this short link points to was reported as unsafe: it may try to steal your personal information or install harmful software.</p>
+<p>The link is waiting for review by our team.</p>
+</body>
+</html>
+`
+
+// renderQuarantinePage serves the warning shown instead of a quarantined link
+func renderQuarantinePage(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Data(http.StatusForbidden, "text/html; charset=utf-8", []byte(quarantinePage))
+}
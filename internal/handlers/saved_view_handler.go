@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type SavedViewHandler struct {
+	savedViews interfaces.SavedViewService
+}
+
+func NewSavedViewHandler(savedViews interfaces.SavedViewService) *SavedViewHandler {
+	return &SavedViewHandler{savedViews: savedViews}
+}
+
+// ListViews returns the user's saved link-list views
+func (h *SavedViewHandler) ListViews(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	views, err := h.savedViews.ListViews(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Saved views retrieved successfully", views)
+}
+
+// CreateView saves a named filter/sort combination
+func (h *SavedViewHandler) CreateView(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	req, ok := bindSavedView(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.savedViews.CreateView(c.Request.Context(), userID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Saved view created successfully", view)
+}
+
+// UpdateView replaces a saved view
+func (h *SavedViewHandler) UpdateView(c *gin.Context) {
+	viewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	req, ok := bindSavedView(c)
+	if !ok {
+		return
+	}
+
+	view, err := h.savedViews.UpdateView(c.Request.Context(), userID, viewID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Saved view updated successfully", view)
+}
+
+// DeleteView removes a saved view
+func (h *SavedViewHandler) DeleteView(c *gin.Context) {
+	viewID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.savedViews.DeleteView(c.Request.Context(), userID, viewID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Saved view deleted successfully", nil)
+}
+
+func bindSavedView(c *gin.Context) (models.SavedViewRequest, bool) {
+	var req models.SavedViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return req, false
+	}
+	if err := req.Validate(); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type ServiceAccountHandler struct {
+	serviceAccounts interfaces.ServiceAccountService
+}
+
+func NewServiceAccountHandler(serviceAccounts interfaces.ServiceAccountService) *ServiceAccountHandler {
+	return &ServiceAccountHandler{serviceAccounts: serviceAccounts}
+}
+
+// CreateServiceAccount creates a non-interactive account for a job or integration
+func (h *ServiceAccountHandler) CreateServiceAccount(c *gin.Context) {
+	var req models.CreateServiceAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	account, err := h.serviceAccounts.CreateServiceAccount(c.Request.Context(), req.Name)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Service account created successfully", account)
+}
+
+// ListServiceAccounts returns all service accounts
+func (h *ServiceAccountHandler) ListServiceAccounts(c *gin.Context) {
+	accounts, err := h.serviceAccounts.ListServiceAccounts(c.Request.Context())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Service accounts retrieved successfully", accounts)
+}
+
+// DeleteServiceAccount revokes all of the account's tokens and removes it
+func (h *ServiceAccountHandler) DeleteServiceAccount(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.serviceAccounts.DeleteServiceAccount(c.Request.Context(), accountID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Service account deleted successfully", nil)
+}
+
+// CreateToken issues a scoped token for a service account (sent as X-API-Key)
+func (h *ServiceAccountHandler) CreateToken(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.CreateServiceTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+	if err := models.ValidateScopes(req.Scopes); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	token, err := h.serviceAccounts.CreateToken(c.Request.Context(), accountID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Token created successfully, store it now as it will not be shown again", token)
+}
+
+// ListTokens returns a service account's tokens
+func (h *ServiceAccountHandler) ListTokens(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	tokens, err := h.serviceAccounts.ListTokens(c.Request.Context(), accountID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tokens retrieved successfully", tokens)
+}
+
+// RevokeToken disables one of a service account's tokens
+func (h *ServiceAccountHandler) RevokeToken(c *gin.Context) {
+	accountID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+	tokenID, err := uuid.Parse(c.Param("tokenID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.serviceAccounts.RevokeToken(c.Request.Context(), accountID, tokenID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Token revoked successfully", nil)
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type TagHandler struct {
+	tagService interfaces.TagService
+}
+
+func NewTagHandler(tagService interfaces.TagService) *TagHandler {
+	return &TagHandler{tagService: tagService}
+}
+
+// ListTags returns the user's tags with link counts
+func (h *TagHandler) ListTags(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	tags, err := h.tagService.ListTags(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tags retrieved successfully", tags)
+}
+
+// RenameTag renames a tag across all of the user's links
+func (h *TagHandler) RenameTag(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.RenameTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	updated, err := h.tagService.RenameTag(c.Request.Context(), userID, c.Param("tag"), req.Name)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tag renamed successfully", gin.H{"updated_links": updated})
+}
+
+// MergeTags folds several tags into one across all of the user's links
+func (h *TagHandler) MergeTags(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	updated, err := h.tagService.MergeTags(c.Request.Context(), userID, req.From, req.To)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tags merged successfully", gin.H{"updated_links": updated})
+}
+
+// DeleteTag removes a tag from all of the user's links
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	updated, err := h.tagService.DeleteTag(c.Request.Context(), userID, c.Param("tag"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tag deleted successfully", gin.H{"updated_links": updated})
+}
+
+// StartBatch queues an operation over every link carrying a tag
+func (h *TagHandler) StartBatch(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.TagBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	job, err := h.tagService.StartBatch(c.Request.Context(), userID, c.Param("tag"), req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusAccepted, "Tag batch job started", job)
+}
+
+// GetBatchJob reports the progress of a tag batch job
+func (h *TagHandler) GetBatchJob(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	job, err := h.tagService.GetBatchJob(c.Request.Context(), userID, c.Param("jobID"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Tag batch job retrieved successfully", job)
+}
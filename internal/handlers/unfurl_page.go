@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// unfurlPageTemplate is just enough HTML for messaging apps to build a
+// preview card: Open Graph and Twitter tags, no scripts and no redirect
+var unfurlPageTemplate = template.Must(template.New("unfurl").Parse(`<!doctype html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<meta property="og:type" content="website">
+<meta property="og:url" content="{{.ShortURL}}">
+<meta property="og:title" content="{{.Title}}">
+{{- if .Description}}
+<meta name="description" content="{{.Description}}">
+<meta property="og:description" content="{{.Description}}">
+{{- end}}
+{{- if .SiteName}}
+<meta property="og:site_name" content="{{.SiteName}}">
+{{- end}}
+{{- if .Image}}
+<meta property="og:image" content="{{.Image}}">
+<meta name="twitter:card" content="summary_large_image">
+<meta name="twitter:image" content="{{.Image}}">
+{{- else}}
+<meta name="twitter:card" content="summary">
+{{- end}}
+<meta name="twitter:title" content="{{.Title}}">
+</head>
+<body></body>
+</html>
+`))
+
+// renderUnfurlPage serves a link's preview card as HTML; like the JSON
+// metadata it is cacheable for an hour
+func renderUnfurlPage(c *gin.Context, meta *types.LinkMetadata) {
+	data := *meta
+	if data.Title == "" {
+		data.Title = data.ShortURL
+	}
+
+	var page bytes.Buffer
+	if err := unfurlPageTemplate.Execute(&page, data); err != nil {
+		utils.LoggerFromContext(c.Request.Context()).Error("Failed to render unfurl page", "error", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=3600, stale-while-revalidate=86400")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
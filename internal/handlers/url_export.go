@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+var urlExportHeader = []string{"id", "short_code", "short_url", "long_url", "clicks", "is_active", "tags", "created_at", "expires_at"}
+
+// ExportURLs streams all of the user's links as CSV (default) or a JSON
+// array, for backups or moving to another service
+func (h *URLHandler) ExportURLs(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	format := strings.ToLower(c.DefaultQuery("format", "csv"))
+	if format != "csv" && format != "json" {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("format must be csv or json"))
+		return
+	}
+
+	filename := fmt.Sprintf("links-%s.%s", time.Now().UTC().Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "no-store")
+
+	ctx := c.Request.Context()
+	if format == "json" {
+		err = h.exportJSON(c, userID)
+	} else {
+		err = h.exportCSV(c, userID)
+	}
+	if err != nil {
+		// Headers may be out already, the client sees a truncated file
+		utils.LoggerFromContext(ctx).Error("Links export failed", "user_id", userID, "format", format, "error", err)
+		if !c.Writer.Written() {
+			utils.HandleError(c, err)
+		}
+	}
+}
+
+func (h *URLHandler) exportCSV(c *gin.Context, userID uuid.UUID) error {
+	var w *csv.Writer
+	start := func() error {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		w = csv.NewWriter(c.Writer)
+		return w.Write(urlExportHeader)
+	}
+	err := h.urlService.ExportURLs(c.Request.Context(), userID, func(batch []types.ExportedURL) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		for _, url := range batch {
+			expiresAt := ""
+			if url.ExpiresAt != nil {
+				expiresAt = url.ExpiresAt.UTC().Format(time.RFC3339)
+			}
+			if err := w.Write([]string{
+				url.ID.String(),
+				url.ShortCode,
+				url.ShortURL,
+				url.LongURL,
+				strconv.FormatInt(url.Clicks, 10),
+				strconv.FormatBool(url.IsActive),
+				strings.Join(url.Tags, ";"),
+				url.CreatedAt.UTC().Format(time.RFC3339),
+				expiresAt,
+			}); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		c.Writer.Flush()
+		return w.Error()
+	})
+	if err != nil {
+		return err
+	}
+	if w == nil {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func (h *URLHandler) exportJSON(c *gin.Context, userID uuid.UUID) error {
+	first := true
+	start := func() {
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		c.Status(http.StatusOK)
+		c.Writer.WriteString("[")
+	}
+	err := h.urlService.ExportURLs(c.Request.Context(), userID, func(batch []types.ExportedURL) error {
+		if first {
+			start()
+		}
+		for _, url := range batch {
+			data, err := json.Marshal(url)
+			if err != nil {
+				return err
+			}
+			if !first {
+				c.Writer.WriteString(",")
+			}
+			first = false
+			if _, err := c.Writer.Write(data); err != nil {
+				return err
+			}
+		}
+		c.Writer.Flush()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if first {
+		start()
+	}
+	_, err = c.Writer.WriteString("]")
+	return err
+}
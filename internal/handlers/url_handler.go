@@ -3,7 +3,10 @@ package handlers
 import (
 	"fmt"
 	"net/http"
+	neturl "net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -14,15 +17,23 @@ import (
 )
 
 type URLHandler struct {
-	urlService interfaces.URLService
-	baseURL    string
+	urlService       interfaces.URLService
+	analyticsService interfaces.AnalyticsService
+	savedViews       interfaces.SavedViewService
+	branding         interfaces.BrandingService
+	metadata         interfaces.LinkMetadataService
+	baseURL          string
 }
 
 // Constructor function for initializing URLHandler
-func NewURLHandler(urlService interfaces.URLService, baseURL string) *URLHandler {
+func NewURLHandler(urlService interfaces.URLService, analyticsService interfaces.AnalyticsService, savedViews interfaces.SavedViewService, branding interfaces.BrandingService, metadata interfaces.LinkMetadataService, baseURL string) *URLHandler {
 	return &URLHandler{
-		urlService: urlService,
-		baseURL:    strings.TrimSuffix(baseURL, "/"), // Removes trailing slash
+		urlService:       urlService,
+		analyticsService: analyticsService,
+		savedViews:       savedViews,
+		branding:         branding,
+		metadata:         metadata,
+		baseURL:          strings.TrimSuffix(baseURL, "/"), // Removes trailing slash
 	}
 }
 
@@ -40,8 +51,26 @@ func (h *URLHandler) CreateShortURL(c *gin.Context) {
 		return
 	}
 
+	expiresAt, err := req.Expiry(time.Now())
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
 	ctx := c.Request.Context()
-	url, err := h.urlService.CreateShortURL(ctx, userID, req.LongURL, req.ShortCode)
+	if req.ReuseExisting && req.ShortCode == "" {
+		existing, err := h.urlService.FindExistingURL(ctx, userID, req.LongURL)
+		if err != nil {
+			utils.HandleError(c, err)
+			return
+		}
+		if existing != nil {
+			utils.SuccessResponse(c, http.StatusOK, "Existing short URL returned", existing)
+			return
+		}
+	}
+
+	url, err := h.urlService.CreateShortURL(ctx, userID, req.LongURL, req.ShortCode, expiresAt, req.Tags, req.Notes)
 	if err != nil {
 		utils.HandleError(c, err)
 		return
@@ -61,15 +90,117 @@ func (h *URLHandler) CreateAnonymousURL(c *gin.Context) {
 	ctx := c.Request.Context()
 
 	// Create anonymous URL with default 7 days expiry (168 hours)
-	url, err := h.urlService.CreateAnonymousURL(ctx, req.LongURL, req.ShortCode, 168)
+	url, err := h.urlService.CreateAnonymousURL(ctx, req.LongURL, req.ShortCode, 168, models.ClientInfo{
+		IP:           c.ClientIP(),
+		CaptchaToken: req.CaptchaToken,
+	})
 	if err != nil {
 		utils.HandleError(c, err)
 		return
 	}
 
+	if url.IsPendingReview() {
+		utils.SuccessResponse(c, http.StatusAccepted, "Short URL created and held for review", url)
+		return
+	}
+
 	utils.SuccessResponse(c, http.StatusCreated, "Short URL created successfully", url)
 }
 
+// GetManagedURL shows an anonymous link and its stats to whoever holds its management token
+func (h *URLHandler) GetManagedURL(c *gin.Context) {
+	managed, err := h.urlService.GetManagedURL(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL retrieved successfully", managed)
+}
+
+// ExtendManagedURL moves an anonymous link's expiry using its management token
+func (h *URLHandler) ExtendManagedURL(c *gin.Context) {
+	var req models.ExtendAnonymousURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	url, err := h.urlService.ExtendManagedURL(c.Request.Context(), c.Param("token"), req.ExpiryHours)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL expiry extended successfully", url)
+}
+
+// DeleteManagedURL deletes an anonymous link using its management token
+func (h *URLHandler) DeleteManagedURL(c *gin.Context) {
+	if err := h.urlService.DeleteManagedURL(c.Request.Context(), c.Param("token")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL deleted successfully", nil)
+}
+
+// SuggestShortCodes proposes available custom short codes for a destination,
+// so a taken code can be answered with alternatives
+func (h *URLHandler) SuggestShortCodes(c *gin.Context) {
+	longURL := strings.TrimSpace(c.Query("long_url"))
+	if longURL == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("long_url is required"))
+		return
+	}
+	title := strings.TrimSpace(c.Query("title"))
+	if len(title) > 200 {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("title must be at most 200 characters"))
+		return
+	}
+	count := 5
+	if value := c.Query("count"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 10 {
+			utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError("count must be between 1 and 10"))
+			return
+		}
+		count = n
+	}
+
+	suggestions, err := h.urlService.SuggestShortCodes(c.Request.Context(), longURL, title, count)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Short code suggestions retrieved successfully", types.ShortCodeSuggestions{Suggestions: suggestions})
+}
+
+// ClaimURLs attaches anonymous links, created before signing up or logging
+// in, to the caller's account using the claim tokens returned at creation
+func (h *URLHandler) ClaimURLs(c *gin.Context) {
+	var req models.ClaimURLsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	urls, err := h.urlService.ClaimURLs(c.Request.Context(), userID, req.ClaimTokens)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, fmt.Sprintf("%d URL(s) claimed successfully", len(urls)), urls)
+}
+
 // GetUserURLs retrieves paginated short URLs created by the user
 func (h *URLHandler) GetUserURLs(c *gin.Context) {
 	var pagination utils.PaginationRequest
@@ -91,8 +222,14 @@ func (h *URLHandler) GetUserURLs(c *gin.Context) {
 		pagination.PerPage = 10
 	}
 
+	filter, err := parseURLFilter(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
 	ctx := c.Request.Context()
-	urls, total, err := h.urlService.GetUserURLsPaginated(ctx, userID, pagination.Page, pagination.PerPage)
+	urls, total, err := h.urlService.GetUserURLsPaginated(ctx, userID, pagination.Page, pagination.PerPage, filter)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, err)
 		return
@@ -114,14 +251,94 @@ func (h *URLHandler) GetUserURLs(c *gin.Context) {
 	// ✅ FIX: Cast int to int64 untuk perhitungan
 	totalPages := (total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage)
 
+	// Saved views are a convenience; the list still loads without them
+	views, err := h.savedViews.ListViews(ctx, userID)
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to load saved views", "error", err)
+		views = []models.SavedView{}
+	}
+
 	utils.PaginationResponse(c, http.StatusOK, "URLs retrieved successfully", urlResponses, utils.Meta{
-		Page:      pagination.Page,
-		PerPage:   pagination.PerPage,
-		Total:     total,      // int64
-		TotalPage: totalPages, // int64
+		Page:       pagination.Page,
+		PerPage:    pagination.PerPage,
+		Total:      total,      // int64
+		TotalPage:  totalPages, // int64
+		SavedViews: views,
 	})
 }
 
+// parseURLFilter reads the link list's filter and sort query parameters
+func parseURLFilter(c *gin.Context) (models.URLFilter, error) {
+	filter := models.URLFilter{
+		Search: strings.TrimSpace(c.Query("search")),
+		Tag:    models.NormalizeTag(c.Query("tag")),
+		Health: strings.ToLower(c.Query("health")),
+		Sort:   c.Query("sort"),
+		Order:  strings.ToLower(c.Query("order")),
+	}
+	if len(filter.Search) > 200 {
+		return filter, types.NewValidationError("search must be at most 200 characters")
+	}
+	if c.Query("tag") != "" && filter.Tag == "" {
+		return filter, types.NewValidationError("invalid tag")
+	}
+	if filter.Sort != "" && !models.SavedViewSorts[filter.Sort] {
+		return filter, types.NewValidationError(fmt.Sprintf("unsupported sort column %q", filter.Sort))
+	}
+	if filter.Order != "" && filter.Order != "asc" && filter.Order != "desc" {
+		return filter, types.NewValidationError("order must be asc or desc")
+	}
+	switch filter.Health {
+	case "", models.HealthOK, models.HealthBroken, "unchecked":
+	default:
+		return filter, types.NewValidationError("health must be ok, broken or unchecked")
+	}
+
+	if workspace := c.Query("workspace"); workspace != "" {
+		workspaceID, err := uuid.Parse(workspace)
+		if err != nil {
+			return filter, types.ErrInvalidUUID
+		}
+		filter.WorkspaceID = &workspaceID
+	}
+
+	if collection := c.Query("collection"); collection != "" {
+		collectionID, err := uuid.Parse(collection)
+		if err != nil {
+			return filter, types.ErrInvalidUUID
+		}
+		filter.CollectionID = &collectionID
+	}
+
+	for name, dest := range map[string]**bool{"expired": &filter.Expired, "active": &filter.Active} {
+		if value := c.Query(name); value != "" {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return filter, types.NewValidationError(name + " must be true or false")
+			}
+			*dest = &b
+		}
+	}
+
+	for name, dest := range map[string]**time.Time{"created_after": &filter.CreatedAfter, "created_before": &filter.CreatedBefore} {
+		if value := c.Query(name); value != "" {
+			t, err := parseListTime(value)
+			if err != nil {
+				return filter, types.NewValidationError(name + " must be a date (2006-01-02) or an RFC 3339 time")
+			}
+			*dest = &t
+		}
+	}
+	return filter, nil
+}
+
+func parseListTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
 // GetURL fetches details of a specific short URL
 func (h *URLHandler) GetURL(c *gin.Context) {
 	urlID, err := uuid.Parse(c.Param("id"))
@@ -156,8 +373,8 @@ func (h *URLHandler) GetURL(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "URL retrieved successfully", response)
 }
 
-// DeleteURL deletes a specific short URL
-func (h *URLHandler) DeleteURL(c *gin.Context) {
+// SetRotation configures the destinations a rotator link cycles through
+func (h *URLHandler) SetRotation(c *gin.Context) {
 	urlID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
@@ -170,53 +387,818 @@ func (h *URLHandler) DeleteURL(c *gin.Context) {
 		return
 	}
 
+	var req models.SetRotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
 	ctx := c.Request.Context()
-	if err := h.urlService.DeleteURL(ctx, userID, urlID); err != nil {
+	url, err := h.urlService.SetRotation(ctx, userID, urlID, req.Destinations, req.Mode)
+	if err != nil {
 		utils.HandleError(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "URL deleted successfully", nil)
+	utils.SuccessResponse(c, http.StatusOK, "URL rotation updated successfully", url)
 }
 
-// RedirectToLongURL redirects a short URL to the original long URL
-func (h *URLHandler) RedirectToLongURL(c *gin.Context) {
-	shortCode := c.Param("shortCode")
+// SetVisitorLimit caps how many unique visitors can open a link
+func (h *URLHandler) SetVisitorLimit(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
 
-	// ✅ ADD: Debug logs
-	fmt.Printf("🌐 [HANDLER] Redirect requested for: %s\n", shortCode)
-	fmt.Printf("🌐 [HANDLER] Full path: %s\n", c.Request.URL.Path)
-	fmt.Printf("🌐 [HANDLER] Method: %s\n", c.Request.Method)
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
 
-	if shortCode == "" {
-		fmt.Printf("❌ [HANDLER] Empty short code!\n")
-		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidShortCode)
+	var req models.SetVisitorLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
 		return
 	}
 
 	ctx := c.Request.Context()
-	longURL, err := h.urlService.GetLongURL(ctx, shortCode)
+	url, err := h.urlService.SetVisitorLimit(ctx, userID, urlID, *req.MaxUniqueVisitors)
 	if err != nil {
-		fmt.Printf("❌ [HANDLER] Error getting long URL: %v\n", err)
-		switch err {
-		case types.ErrURLNotFound:
-			utils.ErrorResponse(c, http.StatusNotFound, err)
-		case types.ErrInvalidShortCode:
-			utils.ErrorResponse(c, http.StatusBadRequest, err)
-		default:
-			utils.ErrorResponse(c, http.StatusInternalServerError, err)
-		}
+		utils.HandleError(c, err)
 		return
 	}
 
-	fmt.Printf("✅ [HANDLER] Redirecting to: %s\n", longURL)
+	utils.SuccessResponse(c, http.StatusOK, "URL visitor limit updated successfully", url)
+}
 
-	utils.Logger.Info("Redirecting to URL",
-		"short_code", shortCode,
-		"long_url", longURL,
-		"ip", c.ClientIP(),
-		"user_agent", c.Request.UserAgent(),
-		"referer", c.Request.Referer())
+// ownerBranding looks up the link owner's branding for the interstitial; a
+// failed lookup falls back to the defaults rather than blocking the redirect
+func (h *URLHandler) ownerBranding(c *gin.Context, target *types.RedirectTarget) *models.Branding {
+	if target.OwnerID == nil {
+		return nil
+	}
+	branding, err := h.branding.GetBranding(c.Request.Context(), *target.OwnerID)
+	if err != nil {
+		utils.LoggerFromContext(c.Request.Context()).Warn("Failed to load branding", "user_id", *target.OwnerID, "error", err)
+		return nil
+	}
+	return branding
+}
+
+// noUnfurlPage is served to social crawlers of links that opted out of previews
+const noUnfurlPage = `<!doctype html><html><head><meta name="robots" content="noindex, nofollow"><title></title></head><body></body></html>`
+
+// SetCrawlerPolicy changes how a link treats social crawlers, search engines and bot clicks
+func (h *URLHandler) SetCrawlerPolicy(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetCrawlerPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetCrawlerPolicy(ctx, userID, urlID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL crawler policy updated successfully", url)
+}
+
+// SetPreviewPage turns the destination preview page on or off for a link
+func (h *URLHandler) SetPreviewPage(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetPreviewPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetPreviewPage(ctx, userID, urlID, *req.Enabled)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL preview page updated successfully", url)
+}
+
+// SetRedirectType sets the HTTP status a link redirects with
+func (h *URLHandler) SetRedirectType(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetRedirectTypeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetRedirectType(ctx, userID, urlID, *req.RedirectType)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL redirect type updated successfully", url)
+}
+
+// SetVariants turns a link into an A/B split test, or ends the test
+func (h *URLHandler) SetVariants(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetVariantsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	url, err := h.urlService.SetVariants(c.Request.Context(), userID, urlID, req.Variants)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
 
-	c.Redirect(http.StatusMovedPermanently, longURL)
+	if url.IsPendingReview() {
+		utils.SuccessResponse(c, http.StatusAccepted, "URL variants updated and held for review", url)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "URL variants updated successfully", url)
+}
+
+// GetVariants returns a split test's variants with their clicks
+func (h *URLHandler) GetVariants(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	variants, err := h.urlService.GetVariants(c.Request.Context(), userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL variants retrieved successfully", variants)
+}
+
+// SetUTM sets the UTM parameters added to a link's destination on redirect
+func (h *URLHandler) SetUTM(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.UTMParams
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	url, err := h.urlService.SetUTM(c.Request.Context(), userID, urlID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL UTM parameters updated successfully", url)
+}
+
+// SetRoutingRules sends visitors to different destinations by local time of day and weekday
+func (h *URLHandler) SetRoutingRules(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetRoutingRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetRoutingRules(ctx, userID, urlID, req.Timezone, req.Rules)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL routing rules updated successfully", url)
+}
+
+// SetLanguageRoutes sends visitors to per-language destinations based on Accept-Language
+func (h *URLHandler) SetLanguageRoutes(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetLanguageRoutesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetLanguageRoutes(ctx, userID, urlID, req.Routes)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL language routes updated successfully", url)
+}
+
+// SetCountryRoutes sends visitors from specific countries to their own destination
+func (h *URLHandler) SetCountryRoutes(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetCountryRoutesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetCountryRoutes(ctx, userID, urlID, req.Routes)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL country routes updated successfully", url)
+}
+
+// RenameShortCode changes a link's short code; the old code keeps forwarding for a grace period
+func (h *URLHandler) RenameShortCode(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.RenameShortCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.RenameShortCode(ctx, userID, urlID, req.ShortCode)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL short code updated successfully", url)
+}
+
+// SetDeviceTargets sends iOS, Android and desktop visitors to their own destination
+func (h *URLHandler) SetDeviceTargets(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.DeviceTargets
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetDeviceTargets(ctx, userID, urlID, req)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL device targets updated successfully", url)
+}
+
+// SetPixels attaches retargeting pixels that fire before the redirect
+func (h *URLHandler) SetPixels(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetPixelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetPixels(ctx, userID, urlID, req.Pixels, req.ConsentRequired)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL pixels updated successfully", url)
+}
+
+// SetPublished adds a link to or removes it from the user's public feed
+func (h *URLHandler) SetPublished(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetPublishedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	ctx := c.Request.Context()
+	url, err := h.urlService.SetPublished(ctx, userID, urlID, *req.Published, strings.TrimSpace(req.Title))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL publication updated successfully", url)
+}
+
+// GetPublishedFeed serves a user's published links as public JSON for embedding
+func (h *URLHandler) GetPublishedFeed(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	feed, err := h.urlService.GetPublishedFeed(ctx, userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	// Embeddable from any site; no credentials are involved
+	if c.Writer.Header().Get("Access-Control-Allow-Origin") == "" {
+		c.Header("Access-Control-Allow-Origin", "*")
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	utils.SuccessResponse(c, http.StatusOK, "Feed retrieved successfully", feed)
+}
+
+// DeleteURL deletes a specific short URL
+// UpdateURL changes a short URL's destination
+func (h *URLHandler) UpdateURL(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.UpdateURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	url, err := h.urlService.UpdateURL(c.Request.Context(), userID, urlID, req.LongURL, req.Tags, req.Notes)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	if url.IsPendingReview() {
+		utils.SuccessResponse(c, http.StatusAccepted, "URL updated and held for review", url)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "URL updated successfully", url)
+}
+
+// GetLinkPreview returns the Open Graph card of a link's destination,
+// fetched server-side so the dashboard avoids CORS
+func (h *URLHandler) GetLinkPreview(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	preview, err := h.metadata.GetLinkPreview(c.Request.Context(), userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Link preview retrieved successfully", preview)
+}
+
+// ToggleURL pauses or reactivates a short URL
+func (h *URLHandler) ToggleURL(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	url, err := h.urlService.ToggleActive(c.Request.Context(), userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	if url.IsActive {
+		utils.SuccessResponse(c, http.StatusOK, "URL activated successfully", url)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "URL paused successfully", url)
+}
+
+// PinURL pins a short URL to the top of the list, or unpins it
+func (h *URLHandler) PinURL(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	url, err := h.urlService.TogglePinned(c.Request.Context(), userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	if url.IsPinned {
+		utils.SuccessResponse(c, http.StatusOK, "URL pinned successfully", url)
+		return
+	}
+	utils.SuccessResponse(c, http.StatusOK, "URL unpinned successfully", url)
+}
+
+func (h *URLHandler) DeleteURL(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	ctx := c.Request.Context()
+	if err := h.urlService.DeleteURL(ctx, userID, urlID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL moved to trash successfully", nil)
+}
+
+// GetDestinationHistory lists who changed a link's destination, from what and to what
+func (h *URLHandler) GetDestinationHistory(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	history, err := h.urlService.GetDestinationHistory(c.Request.Context(), userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL history retrieved successfully", history)
+}
+
+// GetTrashedURLs lists deleted links that can still be restored
+func (h *URLHandler) GetTrashedURLs(c *gin.Context) {
+	var pagination utils.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if pagination.Page == 0 {
+		pagination.Page = 1
+	}
+	if pagination.PerPage == 0 {
+		pagination.PerPage = 10
+	}
+
+	urls, total, err := h.urlService.GetTrashedURLs(c.Request.Context(), userID, pagination.Page, pagination.PerPage)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	totalPages := (total + int64(pagination.PerPage) - 1) / int64(pagination.PerPage)
+	utils.PaginationResponse(c, http.StatusOK, "Trashed URLs retrieved successfully", urls, utils.Meta{
+		Page:      pagination.Page,
+		PerPage:   pagination.PerPage,
+		Total:     total,
+		TotalPage: totalPages,
+	})
+}
+
+// RestoreURL takes a deleted link out of the trash
+func (h *URLHandler) RestoreURL(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	url, err := h.urlService.RestoreURL(c.Request.Context(), userID, urlID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL restored successfully", url)
+}
+
+// RedirectToLongURL redirects a short URL to the original long URL
+func (h *URLHandler) RedirectToLongURL(c *gin.Context) {
+	shortCode := c.Param("shortCode")
+
+	// ✅ ADD: Debug logs
+	fmt.Printf("🌐 [HANDLER] Redirect requested for: %s\n", shortCode)
+	fmt.Printf("🌐 [HANDLER] Full path: %s\n", c.Request.URL.Path)
+	fmt.Printf("🌐 [HANDLER] Method: %s\n", c.Request.Method)
+
+	if shortCode == "" {
+		fmt.Printf("❌ [HANDLER] Empty short code!\n")
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidShortCode)
+		return
+	}
+	// Emoji codes normally arrive decoded; a proxy may have encoded them again
+	if strings.Contains(shortCode, "%") {
+		if decoded, err := neturl.PathUnescape(shortCode); err == nil {
+			shortCode = decoded
+		}
+	}
+
+	ctx := c.Request.Context()
+	userAgent := c.Request.UserAgent()
+
+	// Messaging apps get the preview card straight from the cached metadata:
+	// no click is counted and the redirect is never resolved
+	if utils.IsMessagingPreviewFetcher(userAgent) {
+		meta, err := h.metadata.GetMetadata(ctx, shortCode)
+		if err != nil {
+			utils.HandleError(c, err)
+			return
+		}
+		renderUnfurlPage(c, meta)
+		return
+	}
+
+	target, err := h.urlService.ResolveRedirect(ctx, shortCode, types.Visitor{
+		ID:             utils.VisitorID(c),
+		Bot:            utils.IsBot(userAgent),
+		AcceptLanguage: c.GetHeader("Accept-Language"),
+		Country:        utils.ClientCountry(c),
+		Platform:       utils.ClientPlatform(userAgent),
+		Hops:           redirectHops(c),
+	})
+	if err != nil {
+		fmt.Printf("❌ [HANDLER] Error getting long URL: %v\n", err)
+		switch err {
+		case types.ErrURLNotFound:
+			utils.ErrorResponse(c, http.StatusNotFound, err)
+		case types.ErrURLDisabled, types.ErrVisitorLimitReached, types.ErrURLInactive:
+			utils.ErrorResponse(c, http.StatusGone, err)
+		case types.ErrURLUnderReview:
+			utils.ErrorResponse(c, http.StatusForbidden, err)
+		case types.ErrURLQuarantined:
+			renderQuarantinePage(c)
+		case types.ErrRedirectLoop:
+			utils.ErrorResponse(c, http.StatusLoopDetected, err)
+		case types.ErrInvalidShortCode:
+			utils.ErrorResponse(c, http.StatusBadRequest, err)
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	longURL := target.URL
+	fmt.Printf("✅ [HANDLER] Redirecting to: %s\n", longURL)
+
+	// Record click details, including inbound UTM parameters
+	if target.Counted {
+		// Under the stored spelling of the code, whichever one was requested
+		h.analyticsService.RecordClick(ctx, &models.ClickEvent{
+			ShortCode:   target.ShortCode,
+			Referer:     c.Request.Referer(),
+			UserAgent:   userAgent,
+			UTMSource:   c.Query("utm_source"),
+			UTMMedium:   c.Query("utm_medium"),
+			UTMCampaign: c.Query("utm_campaign"),
+			Variant:     target.Variant,
+		})
+	}
+
+	if !target.Indexable {
+		c.Header("X-Robots-Tag", "noindex, nofollow")
+	}
+
+	// Without unfurls, preview fetchers get an empty page instead of the destination
+	if !target.Unfurl && utils.IsSocialCrawler(userAgent) {
+		c.Header("Cache-Control", "no-store")
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(noUnfurlPage))
+		return
+	}
+
+	// The preview page lets people see the destination first; bots go straight through
+	if target.PreviewPage && !utils.IsBot(userAgent) {
+		renderPreviewPage(c, target, h.ownerBranding(c, target))
+		return
+	}
+
+	// Retargeting pixels fire on an interstitial page; bots and visitors who opted out of tracking skip it
+	if len(target.Pixels) > 0 && !utils.IsBot(userAgent) && !optedOutOfTracking(c) {
+		renderInterstitial(c, target, h.ownerBranding(c, target))
+		return
+	}
+
+	utils.LoggerFromContext(ctx).Info("Redirecting to URL",
+		"short_code", shortCode,
+		"long_url", longURL,
+		"ip", c.ClientIP(),
+		"user_agent", c.Request.UserAgent(),
+		"referer", c.Request.Referer())
+
+	// Rotators must not be cached by browsers, or visitors would stick to one destination
+	if target.Rotating {
+		c.Header("Cache-Control", "no-store")
+	}
+	c.Redirect(target.Status, longURL)
+}
+
+// redirectHops reads how many of our short links the visit has already
+// passed through; anything unreadable counts as none
+func redirectHops(c *gin.Context) int {
+	hops, err := strconv.Atoi(c.Query(types.RedirectHopParam))
+	if err != nil || hops < 0 {
+		return 0
+	}
+	return hops
 }
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type WorkspaceHandler struct {
+	workspace interfaces.WorkspaceService
+}
+
+func NewWorkspaceHandler(workspace interfaces.WorkspaceService) *WorkspaceHandler {
+	return &WorkspaceHandler{workspace: workspace}
+}
+
+// ExportWorkspace downloads the account's configuration as JSON
+func (h *WorkspaceHandler) ExportWorkspace(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	export, err := h.workspace.Export(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	filename := fmt.Sprintf("workspace-%s.json", export.ExportedAt.Format("2006-01-02"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.JSON(http.StatusOK, export)
+}
+
+// ImportWorkspace merges an exported configuration into the account
+func (h *WorkspaceHandler) ImportWorkspace(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var export models.WorkspaceExport
+	if err := c.ShouldBindJSON(&export); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	result, err := h.workspace.Import(c.Request.Context(), userID, &export)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace imported successfully", result)
+}
@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+type WorkspaceMembershipHandler struct {
+	workspaces interfaces.WorkspaceMembershipService
+}
+
+func NewWorkspaceMembershipHandler(workspaces interfaces.WorkspaceMembershipService) *WorkspaceMembershipHandler {
+	return &WorkspaceMembershipHandler{workspaces: workspaces}
+}
+
+// ListWorkspaces returns the shared workspaces the caller belongs to
+func (h *WorkspaceMembershipHandler) ListWorkspaces(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	workspaces, err := h.workspaces.ListWorkspaces(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspaces retrieved successfully", workspaces)
+}
+
+// CreateWorkspace creates a shared workspace owned by the caller
+func (h *WorkspaceMembershipHandler) CreateWorkspace(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.WorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	workspace, err := h.workspaces.CreateWorkspace(c.Request.Context(), userID, req.Name)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Workspace created successfully", workspace)
+}
+
+// DeleteWorkspace dissolves a workspace; its links go back to their creators
+func (h *WorkspaceMembershipHandler) DeleteWorkspace(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.workspaces.DeleteWorkspace(c.Request.Context(), userID, workspaceID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace deleted successfully", nil)
+}
+
+// ListMembers returns the members of a workspace
+func (h *WorkspaceMembershipHandler) ListMembers(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	members, err := h.workspaces.ListMembers(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace members retrieved successfully", members)
+}
+
+// InviteMember invites an email address to a workspace
+func (h *WorkspaceMembershipHandler) InviteMember(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.InviteWorkspaceMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	invite, err := h.workspaces.InviteMember(c.Request.Context(), userID, workspaceID, req.Email)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Workspace invite sent successfully", invite)
+}
+
+// ListInvites returns a workspace's pending invites
+func (h *WorkspaceMembershipHandler) ListInvites(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	invites, err := h.workspaces.ListInvites(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace invites retrieved successfully", invites)
+}
+
+// RevokeInvite withdraws a pending workspace invite
+func (h *WorkspaceMembershipHandler) RevokeInvite(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	inviteID, err := uuid.Parse(c.Param("inviteID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.workspaces.RevokeInvite(c.Request.Context(), userID, workspaceID, inviteID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace invite revoked successfully", nil)
+}
+
+// ListMyInvites returns the workspace invites addressed to the caller
+func (h *WorkspaceMembershipHandler) ListMyInvites(c *gin.Context) {
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	invites, err := h.workspaces.ListMyInvites(c.Request.Context(), userID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace invites retrieved successfully", invites)
+}
+
+// AcceptInvite joins the workspace an invite was for
+func (h *WorkspaceMembershipHandler) AcceptInvite(c *gin.Context) {
+	inviteID, err := uuid.Parse(c.Param("inviteID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	workspace, err := h.workspaces.AcceptInvite(c.Request.Context(), userID, inviteID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace invite accepted successfully", workspace)
+}
+
+// DeclineInvite discards a workspace invite addressed to the caller
+func (h *WorkspaceMembershipHandler) DeclineInvite(c *gin.Context) {
+	inviteID, err := uuid.Parse(c.Param("inviteID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.workspaces.DeclineInvite(c.Request.Context(), userID, inviteID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace invite declined successfully", nil)
+}
+
+// RemoveMember removes a member from a workspace, or lets a member leave
+func (h *WorkspaceMembershipHandler) RemoveMember(c *gin.Context) {
+	workspaceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	memberID, err := uuid.Parse(c.Param("userID"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	if err := h.workspaces.RemoveMember(c.Request.Context(), userID, workspaceID, memberID); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Workspace member removed successfully", nil)
+}
+
+// SetURLWorkspace shares a link into a workspace or makes it private again
+func (h *WorkspaceMembershipHandler) SetURLWorkspace(c *gin.Context) {
+	urlID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.ErrInvalidUUID)
+		return
+	}
+
+	userID, err := uuid.Parse(c.GetString("user_id"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidUUID)
+		return
+	}
+
+	var req models.SetWorkspaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, types.NewValidationError(err.Error()))
+		return
+	}
+
+	url, err := h.workspaces.SetURLWorkspace(c.Request.Context(), userID, urlID, req.WorkspaceID)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "URL workspace updated successfully", url)
+}
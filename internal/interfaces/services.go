@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
@@ -9,35 +10,245 @@ import (
 )
 
 type AuthService interface {
-	Register(ctx context.Context, user *models.User) error
+	Register(ctx context.Context, user *models.User, inviteCode string) error
 	Login(ctx context.Context, email, password string) (*models.User, error)
+	LoginWithGoogle(ctx context.Context, identity *types.OAuthIdentity, inviteCode string) (*models.User, error)
+	LoginWithOIDC(ctx context.Context, identity *types.OAuthIdentity, autoProvision bool) (*models.User, error)
 	GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error)
 	InvalidateUserSessions(ctx context.Context, userID uuid.UUID) error
+	ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error
+	DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error
 	RequestPasswordReset(ctx context.Context, email string) (string, error)
 	ResetPassword(ctx context.Context, token, newPassword string) error
+	UnlockAccount(ctx context.Context, token string) error
 }
 
 type URLService interface {
-	CreateShortURL(ctx context.Context, userID uuid.UUID, longURL string, customShortCode string) (*models.URL, error)
-	CreateAnonymousURL(ctx context.Context, longURL string, customShortCode string, expiryHours int) (*models.URL, error) // ← TAMBAHKAN INI
+	CreateShortURL(ctx context.Context, userID uuid.UUID, longURL string, customShortCode string, expiresAt *time.Time, tags []string, notes string) (*models.URL, error)
+	ServableShortCode(ctx context.Context, code string) (string, error)
+	SuggestShortCodes(ctx context.Context, longURL, title string, count int) ([]string, error)
+	FindExistingURL(ctx context.Context, userID uuid.UUID, longURL string) (*models.URL, error)
+	CreateAnonymousURL(ctx context.Context, longURL string, customShortCode string, expiryHours int, client models.ClientInfo) (*models.URL, error) // ← TAMBAHKAN INI
 	GetLongURL(ctx context.Context, shortCode string) (string, error)
+	ResolveRedirect(ctx context.Context, shortCode string, visitor types.Visitor) (*types.RedirectTarget, error)
+	SetPreviewPage(ctx context.Context, userID, urlID uuid.UUID, enabled bool) (*models.URL, error)
+	SetRedirectType(ctx context.Context, userID, urlID uuid.UUID, status int) (*models.URL, error)
+	RenameShortCode(ctx context.Context, userID, urlID uuid.UUID, newCode string) (*models.URL, error)
+	SetDeviceTargets(ctx context.Context, userID, urlID uuid.UUID, targets models.DeviceTargets) (*models.URL, error)
+	SetCountryRoutes(ctx context.Context, userID, urlID uuid.UUID, routes map[string]string) (*models.URL, error)
+	SetLanguageRoutes(ctx context.Context, userID, urlID uuid.UUID, routes map[string]string) (*models.URL, error)
+	SetVariants(ctx context.Context, userID, urlID uuid.UUID, variants []models.VariantRequest) (*models.URL, error)
+	GetVariants(ctx context.Context, userID, urlID uuid.UUID) ([]models.URLVariant, error)
+	SetRotation(ctx context.Context, userID, urlID uuid.UUID, destinations []string, mode string) (*models.URL, error)
+	SetRoutingRules(ctx context.Context, userID, urlID uuid.UUID, timezone string, rules []models.RoutingRule) (*models.URL, error)
+	SetPixels(ctx context.Context, userID, urlID uuid.UUID, pixels []models.RetargetingPixel, consentRequired bool) (*models.URL, error)
+	SetVisitorLimit(ctx context.Context, userID, urlID uuid.UUID, maxUniqueVisitors int64) (*models.URL, error)
+	SetUTM(ctx context.Context, userID, urlID uuid.UUID, params models.UTMParams) (*models.URL, error)
+	SetCrawlerPolicy(ctx context.Context, userID, urlID uuid.UUID, req models.SetCrawlerPolicyRequest) (*models.URL, error)
+	SetPublished(ctx context.Context, userID, urlID uuid.UUID, published bool, title string) (*models.URL, error)
+	GetPublishedFeed(ctx context.Context, userID uuid.UUID) (*types.PublishedFeed, error)
 	GetURLByID(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error)
-	GetUserURLsPaginated(ctx context.Context, userID uuid.UUID, page, perPage int) ([]models.URL, int64, error) // ← UBAH int menjadi int64
-	UpdateURL(ctx context.Context, userID, urlID uuid.UUID, longURL string) (*models.URL, error)
+	GetURLsByIDs(ctx context.Context, userID uuid.UUID, urlIDs []uuid.UUID) ([]models.URL, error)
+	ClaimURLs(ctx context.Context, userID uuid.UUID, tokens []string) ([]models.URL, error)
+	GetManagedURL(ctx context.Context, token string) (*types.ManagedURL, error)
+	ExtendManagedURL(ctx context.Context, token string, expiryHours int) (*models.URL, error)
+	DeleteManagedURL(ctx context.Context, token string) error
+	ExportURLs(ctx context.Context, userID uuid.UUID, fn func([]types.ExportedURL) error) error
+	GetUserURLsPaginated(ctx context.Context, userID uuid.UUID, page, perPage int, filter models.URLFilter) ([]models.URL, int64, error) // ← UBAH int menjadi int64
+	UpdateURL(ctx context.Context, userID, urlID uuid.UUID, longURL string, tags []string, notes *string) (*models.URL, error)
+	GetDestinationHistory(ctx context.Context, userID, urlID uuid.UUID) ([]types.DestinationChangeEntry, error)
+	GetTrashedURLs(ctx context.Context, userID uuid.UUID, page, perPage int) ([]models.URL, int64, error)
+	RestoreURL(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error)
+	TogglePinned(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error)
+	ToggleActive(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error)
 	DeleteURL(ctx context.Context, userID, urlID uuid.UUID) error
 	GetURLStats(ctx context.Context, urlID uuid.UUID) (*models.URLStats, error)
 }
 
 type AnalyticsService interface {
-	GetUserAnalytics(ctx context.Context, userID uint) (*types.Analytics, error)
-	GetURLAnalytics(ctx context.Context, userID, urlID uint) (*types.URLAnalytics, error)
+	RecordClick(ctx context.Context, event *models.ClickEvent)
+	GetUserAnalytics(ctx context.Context, userID uuid.UUID) (*types.Analytics, error)
+	GetURLAnalytics(ctx context.Context, userID, urlID uuid.UUID) (*types.URLAnalytics, error)
+	GetCampaignStats(ctx context.Context, userID uuid.UUID, urlID *uuid.UUID) ([]types.CampaignStats, error)
+	ComparePeriods(ctx context.Context, userID uuid.UUID, urlID *uuid.UUID, from, to time.Time) (*types.PeriodComparison, error)
+}
+
+// ClickListener receives every recorded click. Implementations must not block.
+type ClickListener interface {
+	NotifyClick(event *models.ClickEvent)
+}
+
+// URLListener is notified after a short URL has been created. Implementations must not block.
+type URLListener interface {
+	NotifyURLCreated(url *models.URL)
+}
+
+// URLChangeListener is a URLListener also notified after a link's
+// destinations were changed. Implementations must not block.
+type URLChangeListener interface {
+	NotifyURLChanged(url *models.URL)
+}
+
+type WebhookService interface {
+	ClickListener
+	CreateWebhook(ctx context.Context, userID uuid.UUID, req *models.CreateWebhookRequest) (*models.Webhook, error)
+	ListWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error)
+	DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error
+	ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error)
+}
+
+type SessionService interface {
+	CreateSession(ctx context.Context, userID uuid.UUID, userAgent, ip string, createdAt, expiresAt time.Time) (*models.Session, error)
+	UserRevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, bool)
+	ListSessions(ctx context.Context, userID uuid.UUID, currentID string) ([]models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error
+	CountSessions(ctx context.Context, userID uuid.UUID) (int64, error)
+	RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	StoreRefreshToken(ctx context.Context, record *models.RefreshToken, token string) error
+	RotateRefreshToken(ctx context.Context, tokenID uuid.UUID, token string) (*models.RefreshToken, error)
+}
+
+type LoginAuditService interface {
+	RecordLogin(ctx context.Context, event *models.LoginEvent)
+	ListLoginEvents(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginEvent, error)
+}
+
+type TagService interface {
+	ListTags(ctx context.Context, userID uuid.UUID) ([]types.TagCount, error)
+	RenameTag(ctx context.Context, userID uuid.UUID, tag, name string) (int64, error)
+	MergeTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int64, error)
+	DeleteTag(ctx context.Context, userID uuid.UUID, tag string) (int64, error)
+	StartBatch(ctx context.Context, userID uuid.UUID, tag string, req models.TagBatchRequest) (*types.TagJob, error)
+	GetBatchJob(ctx context.Context, userID uuid.UUID, jobID string) (*types.TagJob, error)
+}
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, scopes []string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error
+	Authenticate(ctx context.Context, key string) (*models.APIKey, error)
+}
+
+type ServiceAccountService interface {
+	CreateServiceAccount(ctx context.Context, name string) (*models.User, error)
+	ListServiceAccounts(ctx context.Context) ([]models.User, error)
+	DeleteServiceAccount(ctx context.Context, accountID uuid.UUID) error
+	CreateToken(ctx context.Context, accountID uuid.UUID, req models.CreateServiceTokenRequest) (*models.APIKey, error)
+	ListTokens(ctx context.Context, accountID uuid.UUID) ([]models.APIKey, error)
+	RevokeToken(ctx context.Context, accountID, tokenID uuid.UUID) error
+}
+
+type PreviewService interface {
+	URLListener
+	RefreshPreview(ctx context.Context, userID, urlID uuid.UUID) error
 }
 
 type QRService interface {
 	GenerateQRCode(ctx context.Context, shortCode string) ([]byte, error)
+	GenerateQRCodeSVG(ctx context.Context, shortCode string) ([]byte, error)
 	GetQRCodeAsBase64(ctx context.Context, shortCode string) (string, error)
+	ETag(shortCode string) string
+	SVGETag(shortCode string) string
+	GenerateQRCodeWithLogo(ctx context.Context, shortCode, preset string) ([]byte, error)
+	SetQRLogo(ctx context.Context, userID uuid.UUID, data []byte) (*models.QRLogo, error)
+	GetQRLogo(ctx context.Context, userID uuid.UUID) (*models.QRLogo, error)
+	DeleteQRLogo(ctx context.Context, userID uuid.UUID) error
+}
+
+type AdminService interface {
+	PurgeExpiredURLs(ctx context.Context, dryRun bool) (*types.AdminActionResult, error)
+	BanUser(ctx context.Context, userID uuid.UUID, dryRun bool) (*types.AdminActionResult, error)
+	SuspendUser(ctx context.Context, userID uuid.UUID, pauseLinks, dryRun bool) (*types.AdminActionResult, error)
+	ReinstateUser(ctx context.Context, userID uuid.UUID, dryRun bool) (*types.AdminActionResult, error)
+	AddBlockedDomains(ctx context.Context, adminID uuid.UUID, domains []string, reason string, dryRun bool) (*types.AdminActionResult, error)
+	ListPendingReviews(ctx context.Context, page, perPage int) ([]models.URL, int64, error)
+	ReviewURL(ctx context.Context, urlID uuid.UUID, approve bool) (*models.URL, error)
+	ListAwaitingApproval(ctx context.Context, page, perPage int) ([]models.URL, int64, error)
+	ReviewApprovals(ctx context.Context, urlIDs []uuid.UUID, approve, dryRun bool) (*types.AdminActionResult, error)
+	GetDomainStats(ctx context.Context, limit int) (*types.DomainReport, error)
+	CreateInviteCodes(ctx context.Context, adminID uuid.UUID, req *models.CreateInviteCodesRequest) ([]models.InviteCode, error)
+	ListInviteCodes(ctx context.Context) ([]models.InviteCode, error)
+	RevokeInviteCode(ctx context.Context, inviteID uuid.UUID) error
+	GetPasswordHashStats(ctx context.Context) (*types.PasswordHashReport, error)
+	FlushCacheKey(ctx context.Context, key string, dryRun bool) (*types.AdminActionResult, error)
+	RebuildNegativeCache(ctx context.Context, dryRun bool) (*types.AdminActionResult, error)
+	ReconcileClicks(ctx context.Context, urlID uuid.UUID, dryRun bool) (*types.ClickReconciliation, error)
+	ListFailedEmails(ctx context.Context) ([]types.FailedEmail, error)
+	RetryFailedEmail(ctx context.Context, jobID string) (*types.FailedEmail, error)
+}
+
+type RetentionService interface {
+	Purge(ctx context.Context, dryRun bool) (*types.AdminActionResult, error)
+}
+
+type MemoryBudget interface {
+	Report(ctx context.Context) (*types.RedisMemoryReport, error)
+	Refresh(ctx context.Context) (*types.RedisMemoryReport, error)
 }
 
 type EmailService interface {
 	SendResetPasswordEmail(toEmail, toName, resetToken string) error
 }
+
+type WorkspaceMembershipService interface {
+	CreateWorkspace(ctx context.Context, userID uuid.UUID, name string) (*models.Workspace, error)
+	ListWorkspaces(ctx context.Context, userID uuid.UUID) ([]types.WorkspaceSummary, error)
+	DeleteWorkspace(ctx context.Context, userID, workspaceID uuid.UUID) error
+	ListMembers(ctx context.Context, userID, workspaceID uuid.UUID) ([]types.WorkspaceMemberInfo, error)
+	InviteMember(ctx context.Context, userID, workspaceID uuid.UUID, email string) (*models.WorkspaceInvite, error)
+	ListInvites(ctx context.Context, userID, workspaceID uuid.UUID) ([]models.WorkspaceInvite, error)
+	RevokeInvite(ctx context.Context, userID, workspaceID, inviteID uuid.UUID) error
+	ListMyInvites(ctx context.Context, userID uuid.UUID) ([]types.WorkspaceInviteInfo, error)
+	AcceptInvite(ctx context.Context, userID, inviteID uuid.UUID) (*models.Workspace, error)
+	DeclineInvite(ctx context.Context, userID, inviteID uuid.UUID) error
+	RemoveMember(ctx context.Context, userID, workspaceID, memberID uuid.UUID) error
+	SetURLWorkspace(ctx context.Context, userID, urlID uuid.UUID, workspaceID *uuid.UUID) (*models.URL, error)
+}
+
+type BioPageService interface {
+	GetBioPage(ctx context.Context, userID uuid.UUID) (*types.BioPageDetails, error)
+	SetBioPage(ctx context.Context, userID uuid.UUID, req models.BioPageRequest) (*types.BioPageDetails, error)
+	DeleteBioPage(ctx context.Context, userID uuid.UUID) error
+	GetPublicBioPage(ctx context.Context, slug string, countView bool) (*types.PublicBioPage, error)
+	FollowBioLink(ctx context.Context, slug string, linkID uuid.UUID) (string, error)
+}
+
+type CollectionService interface {
+	ListCollections(ctx context.Context, userID uuid.UUID) ([]types.CollectionSummary, error)
+	CreateCollection(ctx context.Context, userID uuid.UUID, name string) (*models.Collection, error)
+	RenameCollection(ctx context.Context, userID, collectionID uuid.UUID, name string) (*models.Collection, error)
+	DeleteCollection(ctx context.Context, userID, collectionID uuid.UUID) error
+	SetURLCollection(ctx context.Context, userID, urlID uuid.UUID, collectionID *uuid.UUID) (*models.URL, error)
+}
+
+type SavedViewService interface {
+	ListViews(ctx context.Context, userID uuid.UUID) ([]models.SavedView, error)
+	CreateView(ctx context.Context, userID uuid.UUID, req models.SavedViewRequest) (*models.SavedView, error)
+	UpdateView(ctx context.Context, userID, viewID uuid.UUID, req models.SavedViewRequest) (*models.SavedView, error)
+	DeleteView(ctx context.Context, userID, viewID uuid.UUID) error
+}
+
+type LinkMetadataService interface {
+	GetMetadata(ctx context.Context, shortCode string) (*types.LinkMetadata, error)
+	GetLinkPreview(ctx context.Context, userID, urlID uuid.UUID) (*types.LinkMetadata, error)
+}
+
+type BrandingService interface {
+	GetBranding(ctx context.Context, userID uuid.UUID) (*models.Branding, error)
+	UpdateBranding(ctx context.Context, userID uuid.UUID, req models.BrandingRequest) (*models.Branding, error)
+	DeleteBranding(ctx context.Context, userID uuid.UUID) error
+}
+
+type CaptchaVerifier interface {
+	CaptchaEnabled() bool
+	VerifyCaptcha(ctx context.Context, token, ip string) bool
+}
+
+type WorkspaceService interface {
+	Export(ctx context.Context, userID uuid.UUID) (*models.WorkspaceExport, error)
+	Import(ctx context.Context, userID uuid.UUID, export *models.WorkspaceExport) (*types.WorkspaceImportResult, error)
+}
+
+type RealtimeAnalytics interface {
+	Realtime(ctx context.Context, userID uuid.UUID) (*types.RealtimeAnalytics, error)
+}
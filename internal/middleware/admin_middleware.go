@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// AdminMiddleware allows the request only for admin accounts. Must run after AuthMiddleware.
+// The role is read from the database so demotions take effect immediately.
+func AdminMiddleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var user models.User
+		if err := db.WithContext(c.Request.Context()).
+			First(&user, "id = ?", c.GetString("user_id")).Error; err != nil {
+			utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrUserNotFound)
+			c.Abort()
+			return
+		}
+
+		if !user.IsAdmin() || user.IsSuspended() {
+			utils.ErrorResponse(c, http.StatusForbidden, types.ErrAdminRequired)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
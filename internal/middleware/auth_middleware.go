@@ -3,17 +3,49 @@ package middleware
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/config"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// AuthMiddleware authenticates a JWT bearer token or, when apiKeys is set,
+// an X-API-Key header. Scoped keys are limited to the routes their scopes
+// cover (see routeScope).
+func AuthMiddleware(secrets *config.SecretManager, redisClient *redis.Client, apiKeys interfaces.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader("X-API-Key"); key != "" && apiKeys != nil {
+			apiKey, err := apiKeys.Authenticate(c.Request.Context(), key)
+			if err != nil {
+				utils.HandleError(c, err)
+				c.Abort()
+				return
+			}
+			if len(apiKey.Scopes) > 0 {
+				scope := routeScope(c.Request.Method, c.FullPath())
+				if scope == "" || !apiKey.HasScope(scope) {
+					utils.ErrorResponse(c, http.StatusForbidden, types.ErrInsufficientScope)
+					c.Abort()
+					return
+				}
+			}
+			c.Set("auth_method", "api_key")
+			utils.SetUserIDInContext(c, apiKey.UserID.String())
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
+		// Browsers can't set headers on websocket handshakes
+		if authHeader == "" && strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+			authHeader = c.Query("access_token")
+		}
 		if authHeader == "" {
 			utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrMissingToken)
 			c.Abort()
@@ -21,12 +53,7 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		}
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, types.ErrInvalidSigningMethod
-			}
-			return []byte(jwtSecret), nil
-		})
+		token, err := utils.ParseTokenWith(tokenString, secrets)
 
 		if err != nil {
 			utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidToken)
@@ -41,6 +68,13 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// Refresh tokens only buy new tokens; they can't call the API
+		if tokenType, _ := claims["typ"].(string); tokenType == utils.TokenTypeRefresh {
+			utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrInvalidToken)
+			c.Abort()
+			return
+		}
+
 		// Get user_id from claims as string
 		userIDStr, ok := claims["user_id"].(string)
 		if !ok {
@@ -57,8 +91,93 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		// Set UUID in context
-		c.Set("user_id", userID.String())
+		// Reject tokens issued at or before the user's last logout
+		if isSessionRevoked(c, redisClient, userID, claims) {
+			utils.ErrorResponse(c, http.StatusUnauthorized, types.ErrSessionRevoked)
+			c.Abort()
+			return
+		}
+
+		// Set UUID in gin and request context (picked up by utils.LoggerFromContext)
+		utils.SetUserIDInContext(c, userID.String())
 		c.Next()
 	}
 }
+
+// isSessionRevoked compares when the token was issued with the logout time
+// stored by AuthService.InvalidateUserSessions (Unix milliseconds), or under
+// the legacy per-user key for logouts recorded before the sorted set, and checks
+// whether the token's own session ("sid") was signed out or the token itself
+// ("jti") was revoked. Live sessions get
+// their last-seen time refreshed. Fails open when Redis is unavailable.
+func isSessionRevoked(c *gin.Context, redisClient *redis.Client, userID uuid.UUID, claims jwt.MapClaims) bool {
+	if utils.RedisDegraded() {
+		return false
+	}
+
+	ctx := c.Request.Context()
+	sessionID, _ := claims["sid"].(string)
+	tokenID, _ := claims["jti"].(string)
+
+	pipe := redisClient.Pipeline()
+	userRevocation := pipe.ZScore(ctx, utils.RevokedSessionsKey, userID.String())
+	legacyRevocation := pipe.Get(ctx, utils.LegacySessionKey(userID.String()))
+	var sessionRevocation *redis.FloatCmd
+	if sessionID != "" {
+		sessionRevocation = pipe.ZScore(ctx, utils.RevokedSessionIDsKey, sessionID)
+	}
+	var tokenRevocation *redis.FloatCmd
+	var legacyTokenRevocation *redis.IntCmd
+	if tokenID != "" {
+		tokenRevocation = pipe.ZScore(ctx, utils.RevokedTokenIDsKey, tokenID)
+		legacyTokenRevocation = pipe.Exists(ctx, utils.LegacyRevokedTokenKey(tokenID))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		utils.LoggerFromContext(ctx).Warn("Session revocation check skipped", "error", err)
+		return false
+	}
+
+	if sessionRevocation != nil {
+		if _, err := sessionRevocation.Result(); err == nil {
+			return true
+		}
+	}
+	if tokenRevocation != nil {
+		if _, err := tokenRevocation.Result(); err == nil {
+			return true
+		}
+	}
+	if legacyTokenRevocation != nil && legacyTokenRevocation.Val() > 0 {
+		return true
+	}
+
+	if score, err := userRevocation.Result(); err == nil && issuedAtOrBefore(claims, int64(score)) {
+		return true
+	}
+	if revokedAt, err := legacyRevocation.Int64(); err == nil && issuedAtOrBefore(claims, revokedAt) {
+		return true
+	}
+
+	if tokenID != "" {
+		c.Set("token_id", tokenID)
+	}
+	if sessionID != "" {
+		c.Set("session_id", sessionID)
+		redisClient.Set(ctx, utils.SessionSeenKey(sessionID), time.Now().UnixMilli(), utils.SessionRevocationTTL)
+	}
+	return false
+}
+
+// issuedAtOrBefore reports whether the token was issued at or before revokedAt (ms)
+func issuedAtOrBefore(claims jwt.MapClaims, revokedAt int64) bool {
+	// iat_ms disambiguates a logout and a new login within the same second;
+	// older tokens only carry iat and are treated as issued at the end of it
+	if issuedAtMs, ok := claims["iat_ms"].(float64); ok {
+		return int64(issuedAtMs) <= revokedAt
+	}
+	if issuedAt, ok := claims["iat"].(float64); ok {
+		return int64(issuedAt)*1000+999 <= revokedAt
+	}
+	// Tokens without iat cannot be proven newer than the logout
+	return true
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// CaptchaHeader carries the hCaptcha/reCAPTCHA response token on protected routes
+const CaptchaHeader = "X-Captcha-Token"
+
+// CaptchaMiddleware requires a solved CAPTCHA on the route, sent in the
+// X-Captcha-Token header. It is a no-op unless enabled and a provider secret
+// is configured, so local setups keep working without one.
+func CaptchaMiddleware(verifier interfaces.CaptchaVerifier, enabled bool) gin.HandlerFunc {
+	if !enabled || !verifier.CaptchaEnabled() {
+		if enabled {
+			utils.Logger.Warn("AUTH_CAPTCHA is enabled but CAPTCHA_SECRET is empty; auth CAPTCHA disabled")
+		}
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		if !verifier.VerifyCaptcha(c.Request.Context(), c.GetHeader(CaptchaHeader), c.ClientIP()) {
+			utils.ErrorResponse(c, http.StatusPreconditionRequired, types.ErrCaptchaRequired)
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -23,11 +23,11 @@ func CORSMiddleware() gin.HandlerFunc {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 			c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 			c.Writer.Header().Set("Access-Control-Allow-Headers",
-				"Content-Type, Content-Length, Accept-Encoding, Authorization, accept, origin, Cache-Control, X-Requested-With")
+				"Content-Type, Content-Length, Accept-Encoding, Authorization, X-API-Key, X-Captcha-Token, accept, origin, Cache-Control, X-Requested-With")
 			c.Writer.Header().Set("Access-Control-Allow-Methods",
 				"POST, OPTIONS, GET, PUT, DELETE, PATCH")
 			c.Writer.Header().Set("Access-Control-Expose-Headers",
-				"Content-Length, Content-Type")
+				"Content-Length, Content-Type, Deprecation, Sunset, Link")
 			c.Writer.Header().Set("Access-Control-Max-Age", "43200")
 		}
 
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// DeprecationRule marks one route as deprecated
+type DeprecationRule struct {
+	Method    string     `json:"method"`
+	Path      string     `json:"path"` // gin route pattern, e.g. /v1/api/urls/:id
+	Sunset    *time.Time `json:"sunset,omitempty"`
+	Successor string     `json:"successor,omitempty"`
+	Hits      int64      `json:"hits"`
+}
+
+// ParseDeprecationRules parses a comma-separated list of
+// "METHOD /path[|YYYY-MM-DD sunset][|successor link]" entries
+func ParseDeprecationRules(spec string) ([]DeprecationRule, error) {
+	var rules []DeprecationRule
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, "|")
+		route := strings.Fields(parts[0])
+		if len(route) != 2 {
+			return nil, fmt.Errorf("invalid deprecation rule %q: expected \"METHOD /path\"", entry)
+		}
+
+		rule := DeprecationRule{Method: strings.ToUpper(route[0]), Path: route[1]}
+		if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+			sunset, err := time.Parse("2006-01-02", strings.TrimSpace(parts[1]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid sunset date in deprecation rule %q: %w", entry, err)
+			}
+			rule.Sunset = &sunset
+		}
+		if len(parts) > 2 {
+			rule.Successor = strings.TrimSpace(parts[2])
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// DeprecationTracker emits Deprecation/Sunset/Link headers on deprecated
+// routes and counts how often each one is still called
+type DeprecationTracker struct {
+	rules         map[string]*DeprecationRule
+	enforceSunset bool
+}
+
+func NewDeprecationTracker(rules []DeprecationRule, enforceSunset bool) *DeprecationTracker {
+	t := &DeprecationTracker{
+		rules:         make(map[string]*DeprecationRule, len(rules)),
+		enforceSunset: enforceSunset,
+	}
+	for i := range rules {
+		rule := rules[i]
+		t.rules[rule.Method+" "+rule.Path] = &rule
+	}
+	return t
+}
+
+// Handle is the gin middleware. It must be registered on the router so that
+// c.FullPath() identifies the matched route.
+func (t *DeprecationTracker) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule, ok := t.rules[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		hits := atomic.AddInt64(&rule.Hits, 1)
+		// Log the first call and then every 100th, so usage is visible without flooding
+		if hits == 1 || hits%100 == 0 {
+			utils.LoggerFromContext(c.Request.Context()).Warn("Deprecated endpoint called",
+				"method", rule.Method,
+				"path", rule.Path,
+				"hits", hits,
+				"user_agent", c.Request.UserAgent())
+		}
+
+		c.Header("Deprecation", "true")
+		if rule.Sunset != nil {
+			c.Header("Sunset", rule.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if rule.Successor != "" {
+			c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", rule.Successor))
+		}
+
+		if t.enforceSunset && rule.Sunset != nil && time.Now().After(*rule.Sunset) {
+			utils.ErrorResponse(c, http.StatusGone, fmt.Errorf("this endpoint was retired on %s", rule.Sunset.Format("2006-01-02")))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Stats returns the deprecated routes with their call counts
+func (t *DeprecationTracker) Stats() []DeprecationRule {
+	stats := make([]DeprecationRule, 0, len(t.rules))
+	for _, rule := range t.rules {
+		snapshot := *rule
+		snapshot.Hits = atomic.LoadInt64(&rule.Hits)
+		stats = append(stats, snapshot)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Method+stats[i].Path < stats[j].Method+stats[j].Path
+	})
+	return stats
+}
+
+// StatsHandler serves Stats for the admin API
+func (t *DeprecationTracker) StatsHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		utils.SuccessResponse(c, http.StatusOK, "Deprecated endpoints retrieved successfully", t.Stats())
+	}
+}
@@ -3,9 +3,11 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-redis/redis/v8"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
 )
@@ -15,16 +17,40 @@ type RateLimiterConfig struct {
 	RequestsPerMinute int
 	BurstSize         int
 	BlockDuration     time.Duration
+
+	// Class namespaces the counters so a route group can have its own quota;
+	// empty is the default class. SkipPaths are path prefixes this limiter
+	// leaves to another class.
+	Class     string
+	SkipPaths []string
 }
 
 // RateLimiterMiddleware implements token bucket algorithm for rate limiting
 func RateLimiterMiddleware(redisClient *redis.Client, config RateLimiterConfig) gin.HandlerFunc {
+	prefix := "rate_limit:"
+	if config.Class != "" {
+		prefix += config.Class + ":"
+	}
+
 	return func(c *gin.Context) {
+		for _, path := range config.SkipPaths {
+			if strings.HasPrefix(c.Request.URL.Path, path) {
+				c.Next()
+				return
+			}
+		}
+
+		// Fail open without waiting on timeouts while Redis is down
+		if utils.RedisDegraded() {
+			c.Next()
+			return
+		}
+
 		ip := c.ClientIP()
 		ctx := c.Request.Context()
 
 		// Check if IP is blocked
-		blockKey := fmt.Sprintf("rate_limit:blocked:%s", ip)
+		blockKey := fmt.Sprintf("%sblocked:%s", prefix, ip)
 		blocked, err := redisClient.Exists(ctx, blockKey).Result()
 		if err == nil && blocked > 0 {
 			remaining, _ := redisClient.TTL(ctx, blockKey).Result()
@@ -35,7 +61,7 @@ func RateLimiterMiddleware(redisClient *redis.Client, config RateLimiterConfig)
 		}
 
 		// Rate limiting key
-		limitKey := fmt.Sprintf("rate_limit:requests:%s", ip)
+		limitKey := fmt.Sprintf("%srequests:%s", prefix, ip)
 
 		// Get current request count
 		count, err := redisClient.Get(ctx, limitKey).Int64()
@@ -64,7 +90,7 @@ func RateLimiterMiddleware(redisClient *redis.Client, config RateLimiterConfig)
 		// Check if limit exceeded
 		if count >= int64(config.RequestsPerMinute) {
 			// Increment violation counter
-			violationKey := fmt.Sprintf("rate_limit:violations:%s", ip)
+			violationKey := fmt.Sprintf("%sviolations:%s", prefix, ip)
 			violations, _ := redisClient.Incr(ctx, violationKey).Result()
 			redisClient.Expire(ctx, violationKey, 10*time.Minute)
 
@@ -73,6 +99,7 @@ func RateLimiterMiddleware(redisClient *redis.Client, config RateLimiterConfig)
 				redisClient.Set(ctx, blockKey, 1, config.BlockDuration)
 				utils.Logger.Warn("IP blocked due to rate limit violations",
 					"ip", ip,
+					"class", config.Class,
 					"violations", violations)
 			}
 
@@ -163,31 +190,41 @@ func AuthRateLimiterMiddleware(redisClient *redis.Client) gin.HandlerFunc {
 
 // ForgotPasswordRateLimiter - Prevent abuse of password reset
 func ForgotPasswordRateLimiter(redisClient *redis.Client) gin.HandlerFunc {
+	return emailCooldownLimiter(redisClient, "forgot_password", "password reset email already sent")
+}
+
+// MagicLinkRateLimiter allows one login link email per address every 5 minutes
+func MagicLinkRateLimiter(redisClient *redis.Client) gin.HandlerFunc {
+	return emailCooldownLimiter(redisClient, "magic_link", "login link already sent")
+}
+
+// emailCooldownLimiter allows one request per "email" in the JSON body every
+// 5 minutes. The body is cached so the handler can still bind it.
+func emailCooldownLimiter(redisClient *redis.Client, action, message string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var request struct {
 			Email string `json:"email"`
 		}
 
 		// Bind request to get email
-		if err := c.ShouldBindJSON(&request); err != nil {
+		if err := c.ShouldBindBodyWith(&request, binding.JSON); err != nil {
 			c.Next()
 			return
 		}
 
-		// Re-set the body for next handlers
 		c.Set("email", request.Email)
 
 		ctx := c.Request.Context()
-		email := request.Email
+		email := strings.ToLower(strings.TrimSpace(request.Email))
 
 		// Rate limit per email (1 request per 5 minutes)
-		emailKey := fmt.Sprintf("rate_limit:forgot_password:%s", email)
+		emailKey := fmt.Sprintf("rate_limit:%s:%s", action, email)
 		exists, _ := redisClient.Exists(ctx, emailKey).Result()
 
 		if exists > 0 {
 			ttl, _ := redisClient.TTL(ctx, emailKey).Result()
 			utils.ErrorResponse(c, http.StatusTooManyRequests,
-				fmt.Errorf("password reset email already sent. Try again in %d seconds", int(ttl.Seconds())))
+				fmt.Errorf("%s. Try again in %d seconds", message, int(ttl.Seconds())))
 			c.Abort()
 			return
 		}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+)
+
+// routeScopes maps route prefixes to the scope needed to read (GET) or change
+// them; an empty scope means scoped keys can't use that method. More specific
+// prefixes come first.
+var routeScopes = []struct {
+	prefix string
+	read   string
+	write  string
+}{
+	{"/v1/api/urls/:id/analytics", models.ScopeAnalyticsRead, ""},
+	{"/v1/api/urls/:id/campaigns", models.ScopeAnalyticsRead, ""},
+	{"/v1/api/analytics", models.ScopeAnalyticsRead, ""},
+	{"/v1/api/urls", models.ScopeURLsRead, models.ScopeURLsWrite},
+}
+
+// routeScope returns the scope a scoped API key needs for the matched route,
+// or "" when no scope covers it (account settings, API keys, webhooks, ...)
+func routeScope(method, fullPath string) string {
+	for _, route := range routeScopes {
+		if fullPath != route.prefix && !strings.HasPrefix(fullPath, route.prefix+"/") {
+			continue
+		}
+		if method == http.MethodGet || method == http.MethodHead {
+			return route.read
+		}
+		return route.write
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey authenticates scripts and CI as its owner via the X-API-Key header.
+// Only a SHA-256 hash of the key is stored; the key itself is shown once.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"user_id" gorm:"type:uuid;index;not null"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix" gorm:"not null"` // First characters of the key, to tell keys apart
+	KeyHash    string     `json:"-" gorm:"uniqueIndex;not null"`
+	Scopes     []string   `json:"scopes,omitempty" gorm:"type:jsonb;serializer:json"` // Empty means full access as the owner
+	Key        string     `json:"key,omitempty" gorm:"-"`                             // Only returned on creation
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// API key scopes. Keys without scopes act with all of their owner's
+// permissions; scoped keys only reach the routes their scopes cover.
+const (
+	ScopeURLsRead      = "urls:read"
+	ScopeURLsWrite     = "urls:write"
+	ScopeAnalyticsRead = "analytics:read"
+)
+
+// APIScopes are the scopes a key may be granted
+var APIScopes = map[string]bool{
+	ScopeURLsRead:      true,
+	ScopeURLsWrite:     true,
+	ScopeAnalyticsRead: true,
+}
+
+// HasScope reports whether the key may be used for scope
+func (k *APIKey) HasScope(scope string) bool {
+	if len(k.Scopes) == 0 {
+		return true
+	}
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"max=10"`
+}
+
+// ValidateScopes rejects scopes that don't exist
+func ValidateScopes(scopes []string) error {
+	for _, scope := range scopes {
+		if !APIScopes[scope] {
+			return fmt.Errorf("unsupported scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// CreateServiceAccountRequest creates a non-interactive account for jobs and integrations
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// CreateServiceTokenRequest issues a token for a service account; service
+// account tokens always carry at least one scope
+type CreateServiceTokenRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required,min=1,max=10"`
+}
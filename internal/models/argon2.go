@@ -0,0 +1,120 @@
+package models
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Argon2Params are the cost parameters used for new password hashes
+type Argon2Params struct {
+	Time     uint32 // Iterations
+	MemoryKB uint32 // Memory in KiB
+	Threads  uint8  // Parallel threads
+}
+
+// Safe minimums (OWASP): at least 19 MiB of memory, and 19 MiB x 2 passes
+// worth of total work, so memory can only be lowered by adding iterations
+const (
+	MinArgon2MemoryKB uint32 = 19 * 1024
+	minArgon2Work     uint64 = 2 * 19 * 1024
+)
+
+var argon2Params = Argon2Params{
+	Time:     Argon2Time,
+	MemoryKB: Argon2Memory,
+	Threads:  Argon2Threads,
+}
+
+// Validate rejects parameters below the safe minimums
+func (p Argon2Params) Validate() error {
+	if p.Time < 1 || p.Threads < 1 {
+		return errors.New("argon2 time and threads must be at least 1")
+	}
+	if p.MemoryKB < MinArgon2MemoryKB {
+		return fmt.Errorf("argon2 memory must be at least %d KiB", MinArgon2MemoryKB)
+	}
+	if uint64(p.Time)*uint64(p.MemoryKB) < minArgon2Work {
+		return fmt.Errorf("argon2 time x memory must be at least %d (use more iterations with less memory)", minArgon2Work)
+	}
+	return nil
+}
+
+// SetArgon2Params changes the parameters for new hashes. Existing hashes keep
+// verifying because each hash records the parameters it was made with.
+func SetArgon2Params(p Argon2Params) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	argon2Params = p
+	return nil
+}
+
+// CurrentArgon2Params returns the parameters used for new hashes
+func CurrentArgon2Params() Argon2Params {
+	return argon2Params
+}
+
+// PasswordHashPrefix identifies hashes made with the current parameters.
+// Stored hashes are versioned by their prefix: legacy "salt$hash" values have
+// none, and PHC strings carry the algorithm version and cost parameters.
+func PasswordHashPrefix() string {
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$",
+		argon2.Version, argon2Params.MemoryKB, argon2Params.Time, argon2Params.Threads)
+}
+
+// encodeArgon2Hash hashes a password into the PHC string format:
+// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<hash>
+func encodeArgon2Hash(password string, p Argon2Params) (string, error) {
+	salt := make([]byte, SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	hash := argon2.IDKey([]byte(password), salt, p.Time, p.MemoryKB, p.Threads, Argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.MemoryKB, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(hash),
+	), nil
+}
+
+// verifyArgon2Hash checks a password against a PHC formatted hash
+func verifyArgon2Hash(password, encoded string) error {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return fmt.Errorf("invalid password format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return fmt.Errorf("unsupported argon2 version")
+	}
+
+	var p Argon2Params
+	// Zero time or threads would make argon2 panic
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.MemoryKB, &p.Time, &p.Threads); err != nil ||
+		p.Time < 1 || p.Threads < 1 {
+		return fmt.Errorf("invalid argon2 parameters")
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return fmt.Errorf("invalid password salt")
+	}
+	storedHash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return fmt.Errorf("invalid password hash")
+	}
+
+	hash := argon2.IDKey([]byte(password), salt, p.Time, p.MemoryKB, p.Threads, uint32(len(storedHash)))
+	if subtle.ConstantTimeCompare(hash, storedHash) != 1 {
+		return fmt.Errorf("incorrect password")
+	}
+	return nil
+}
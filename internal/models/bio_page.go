@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxBioPageLinks caps the links listed on a bio page
+const MaxBioPageLinks = 50
+
+// BioPage is a user's public link-in-bio page, served at /page/{slug}. It
+// lists the chosen links in LinkIDs order; views and per-link clicks are
+// counted in Redis.
+type BioPage struct {
+	ID          uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID      uuid.UUID   `json:"-" gorm:"type:uuid;not null;uniqueIndex"`
+	Slug        string      `json:"slug" gorm:"size:30;not null;uniqueIndex"`
+	Title       string      `json:"title" gorm:"size:100"`
+	Description string      `json:"description,omitempty" gorm:"size:300"`
+	LinkIDs     []uuid.UUID `json:"link_ids" gorm:"type:jsonb;serializer:json"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// BioPageRequest creates or replaces the caller's bio page; LinkIDs is the display order
+type BioPageRequest struct {
+	Slug        string      `json:"slug" binding:"required,min=3,max=30"`
+	Title       string      `json:"title" binding:"max=100"`
+	Description string      `json:"description" binding:"max=300"`
+	LinkIDs     []uuid.UUID `json:"link_ids" binding:"max=50"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BlockedDomain is a destination domain that may no longer be shortened
+type BlockedDomain struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Domain    string     `json:"domain" gorm:"uniqueIndex;not null"`
+	Reason    string     `json:"reason,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type BlocklistRequest struct {
+	Domains []string `json:"domains" binding:"required,min=1,dive,required"`
+	Reason  string   `json:"reason"`
+}
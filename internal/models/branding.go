@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DefaultBrandColor is the primary color of unbranded pages and emails
+const DefaultBrandColor = "#4F46E5"
+
+// Branding is an account's look on the pages its links show and the
+// transactional emails its members get. Empty fields fall back to the
+// Shorteny defaults.
+type Branding struct {
+	UserID       uuid.UUID `json:"-" gorm:"type:uuid;primary_key"`
+	LogoURL      string    `json:"logo_url" gorm:"size:2048"`
+	PrimaryColor string    `json:"primary_color" gorm:"size:9"`
+	SenderName   string    `json:"sender_name" gorm:"size:100"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Color returns the primary color, or the default when none is set
+func (b *Branding) Color() string {
+	if b == nil || b.PrimaryColor == "" {
+		return DefaultBrandColor
+	}
+	return b.PrimaryColor
+}
+
+// BrandingRequest replaces an account's branding
+type BrandingRequest struct {
+	LogoURL      string `json:"logo_url" binding:"omitempty,url,max=2048"`
+	PrimaryColor string `json:"primary_color" binding:"omitempty,hexcolor"`
+	SenderName   string `json:"sender_name" binding:"max=100"`
+}
+
+// Validate rejects logos that browsers and mail clients wouldn't load over
+// HTTPS, and sender names that could break the From header
+func (r *BrandingRequest) Validate() error {
+	if r.LogoURL != "" {
+		u, err := url.Parse(r.LogoURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("logo_url must be an https URL")
+		}
+	}
+	if strings.IndexFunc(r.SenderName, unicode.IsControl) >= 0 || strings.ContainsAny(r.SenderName, `<>"`) {
+		return fmt.Errorf("sender_name contains unsupported characters")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClickEvent is a single recorded redirect, used for detailed analytics.
+// Aggregate click counts live on URL.Clicks and in Redis. Under heavy load
+// only a sample is stored; Weight is the number of clicks an event stands for.
+type ClickEvent struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ShortCode   string    `json:"short_code" gorm:"index;not null"`
+	Referer     string    `json:"referer,omitempty"`
+	UserAgent   string    `json:"user_agent,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	Device      string    `json:"device,omitempty"`
+	UTMSource   string    `json:"utm_source,omitempty" gorm:"index"`
+	UTMMedium   string    `json:"utm_medium,omitempty"`
+	UTMCampaign string    `json:"utm_campaign,omitempty" gorm:"index"`
+	ClickedAt   time.Time `json:"clicked_at" gorm:"index;not null"`
+	Weight      int       `json:"weight" gorm:"not null;default:1"`
+	// Language route taken by the redirect, empty for links without language routes
+	Variant string `json:"variant,omitempty" gorm:"size:35;index"`
+}
+
+// HasUTM reports whether the click carried any campaign parameters
+func (e *ClickEvent) HasUTM() bool {
+	return e.UTMSource != "" || e.UTMMedium != "" || e.UTMCampaign != ""
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ClickRollup holds the daily click total of a link once its raw click
+// events have been aged out by the retention job
+type ClickRollup struct {
+	ShortCode string    `json:"short_code" gorm:"primaryKey;size:20"`
+	Date      time.Time `json:"date" gorm:"primaryKey;type:date"`
+	Clicks    int64     `json:"clicks" gorm:"not null;default:0"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxCollections caps how many collections a user can create
+const MaxCollections = 100
+
+// Collection is a folder of links, e.g. one per campaign. Unlike tags a link
+// sits in at most one collection.
+type Collection struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_collections_user_name"`
+	Name      string    `json:"name" gorm:"size:100;not null;uniqueIndex:idx_collections_user_name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CollectionRequest creates or renames a collection
+type CollectionRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// SetCollectionRequest moves a link into a collection; null takes it out
+type SetCollectionRequest struct {
+	CollectionID *uuid.UUID `json:"collection_id"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DestinationChange is one entry of a link's append-only destination
+// history: who re-pointed the link, from where and to where
+type DestinationChange struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	URLID     uuid.UUID `json:"url_id" gorm:"type:uuid;not null;index:idx_destination_changes_url_created"`
+	EditorID  uuid.UUID `json:"editor_id" gorm:"type:uuid;not null"`
+	OldURL    string    `json:"old_url" gorm:"not null"`
+	NewURL    string    `json:"new_url" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"index:idx_destination_changes_url_created"`
+}
@@ -0,0 +1,28 @@
+package models
+
+// DeviceTargets are per-platform destinations, e.g. the App Store listing
+// for iOS and the Play Store for Android. Empty targets fall back to the
+// link's regular destination. Targets may be app deep links (myapp://...).
+type DeviceTargets struct {
+	IOS     string `json:"ios,omitempty" binding:"omitempty,url,max=2048"`
+	Android string `json:"android,omitempty" binding:"omitempty,url,max=2048"`
+	Desktop string `json:"desktop,omitempty" binding:"omitempty,url,max=2048"`
+}
+
+// IsZero reports whether no target is set
+func (t DeviceTargets) IsZero() bool {
+	return t == DeviceTargets{}
+}
+
+// For returns the target for a platform (see utils.ClientPlatform)
+func (t DeviceTargets) For(platform string) string {
+	switch platform {
+	case "ios":
+		return t.IOS
+	case "android":
+		return t.Android
+	case "desktop":
+		return t.Desktop
+	}
+	return ""
+}
@@ -0,0 +1,19 @@
+package models
+
+// Email delivery event types accepted from the provider webhook
+const (
+	EmailEventBounce    = "bounce"
+	EmailEventComplaint = "complaint"
+)
+
+// EmailEvent is a provider-agnostic bounce/complaint notification
+type EmailEvent struct {
+	Type       string `json:"type" binding:"required,oneof=bounce complaint"`
+	Email      string `json:"email" binding:"required,email"`
+	BounceType string `json:"bounce_type"` // "permanent" (default) or "transient"
+	Reason     string `json:"reason"`
+}
+
+type EmailEventsRequest struct {
+	Events []EmailEvent `json:"events" binding:"required,min=1,dive"`
+}
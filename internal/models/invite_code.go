@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InviteCode grants registration while the service runs in invite-only mode
+type InviteCode struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Code      string     `json:"code" gorm:"uniqueIndex;not null"`
+	MaxUses   int        `json:"max_uses" gorm:"not null;default:1"`
+	Uses      int        `json:"uses" gorm:"not null;default:0"`
+	Note      string     `json:"note,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy *uuid.UUID `json:"created_by,omitempty" gorm:"type:uuid"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// IsUsable reports whether the code can still be redeemed
+func (i *InviteCode) IsUsable() bool {
+	if i.RevokedAt != nil || i.Uses >= i.MaxUses {
+		return false
+	}
+	return i.ExpiresAt == nil || time.Now().Before(*i.ExpiresAt)
+}
+
+type CreateInviteCodesRequest struct {
+	Count          int    `json:"count" binding:"omitempty,min=1,max=100"`
+	MaxUses        int    `json:"max_uses" binding:"omitempty,min=1"`
+	ExpiresInHours int    `json:"expires_in_hours" binding:"omitempty,min=1"`
+	Note           string `json:"note"`
+}
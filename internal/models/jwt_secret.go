@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// JWTSecretState is the single row holding the rotated JWT secrets shared
+// by all instances; it only exists once the secret has been rotated
+type JWTSecretState struct {
+	ID        int                `gorm:"primary_key"`
+	Current   string             `gorm:"not null"`
+	Retired   []RetiredJWTSecret `gorm:"type:jsonb;serializer:json"`
+	RotatedAt time.Time          `gorm:"not null"`
+}
+
+// RetiredJWTSecret is a rotated-out secret still accepted for a grace period
+type RetiredJWTSecret struct {
+	Secret    string    `json:"secret"`
+	RetiredAt time.Time `json:"retired_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Login methods recorded in LoginEvent.Method
+const (
+	LoginMethodPassword  = "password"
+	LoginMethodGoogle    = "google"
+	LoginMethodMagicLink = "magic_link"
+	LoginMethodOIDC      = "oidc"
+)
+
+// LoginEventRetention is how long sign-in attempts are kept before they are purged
+const LoginEventRetention = 90 * 24 * time.Hour
+
+// LoginEvent is one sign-in attempt. UserID is nil when the attempt named an
+// email without an account.
+type LoginEvent struct {
+	ID        uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    *uuid.UUID `json:"-" gorm:"type:uuid;index:idx_login_events_user_created,priority:1"`
+	Email     string     `json:"-" gorm:"size:255"`
+	Method    string     `json:"method" gorm:"size:20;not null"`
+	Success   bool       `json:"success"`
+	Reason    string     `json:"reason,omitempty" gorm:"size:100"` // Why a failed attempt was rejected
+	IP        string     `json:"ip"`
+	UserAgent string     `json:"user_agent"`
+	Browser   string     `json:"browser"`
+	Device    string     `json:"device"`
+	Country   string     `json:"country,omitempty" gorm:"size:2"`
+	CreatedAt time.Time  `json:"created_at" gorm:"index:idx_login_events_user_created,priority:2;index"`
+}
@@ -0,0 +1,32 @@
+package models
+
+// Migrated lists the models the server auto-migrates at startup, in
+// dependency order
+func Migrated() []interface{} {
+	return []interface{}{
+		&User{},
+		&URL{},
+		&BlockedDomain{},
+		&InviteCode{},
+		&ClickEvent{},
+		&ClickRollup{},
+		&Webhook{},
+		&WebhookDelivery{},
+		&APIKey{},
+		&Session{},
+		&JWTSecretState{},
+		&RefreshToken{},
+		&SavedView{},
+		&LoginEvent{},
+		&Branding{},
+		&QRLogo{},
+		&Collection{},
+		&URLVariant{},
+		&DestinationChange{},
+		&ShortCodeAlias{},
+		&BioPage{},
+		&Workspace{},
+		&WorkspaceMember{},
+		&WorkspaceInvite{},
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Retargeting pixel providers
+const (
+	PixelProviderMeta   = "meta"
+	PixelProviderGoogle = "google"
+)
+
+// MaxPixelsPerURL caps the tags loaded on a link's interstitial page
+const MaxPixelsPerURL = 5
+
+// RetargetingPixel is a Meta pixel or Google tag fired on the interstitial
+// page shown before redirecting
+type RetargetingPixel struct {
+	Provider string `json:"provider" binding:"required,oneof=meta google"`
+	ID       string `json:"id" binding:"required,max=40"`
+}
+
+var (
+	metaPixelIDPattern = regexp.MustCompile(`^[0-9]{5,20}$`)
+	googleTagIDPattern = regexp.MustCompile(`^(AW|G|DC)-[A-Z0-9]{4,20}$`)
+)
+
+// Validate checks the ID format of the provider, which also keeps arbitrary
+// markup out of the interstitial page
+func (p RetargetingPixel) Validate() error {
+	switch p.Provider {
+	case PixelProviderMeta:
+		if !metaPixelIDPattern.MatchString(p.ID) {
+			return fmt.Errorf("invalid Meta pixel ID %q", p.ID)
+		}
+	case PixelProviderGoogle:
+		if !googleTagIDPattern.MatchString(p.ID) {
+			return fmt.Errorf("invalid Google tag ID %q, expected AW-, G- or DC-", p.ID)
+		}
+	default:
+		return fmt.Errorf("unknown pixel provider %q", p.Provider)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Limits for uploaded QR logos; larger images are pointless in the center
+// of a QR code
+const (
+	MaxQRLogoBytes     = 256 << 10
+	MaxQRLogoDimension = 1024
+)
+
+// QRLogo is the image an account has composited into the center of its
+// links' QR codes (?logo=true), stored re-encoded as PNG
+type QRLogo struct {
+	UserID    uuid.UUID `json:"-" gorm:"type:uuid;primary_key"`
+	Data      []byte    `json:"-" gorm:"type:bytea;not null"`
+	Width     int       `json:"width"`
+	Height    int       `json:"height"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is one issued refresh token; its ID is the token's "jti" claim
+// and only a SHA-256 of the token is stored. Each use rotates it, and tokens
+// rotated from the same sign-in share a FamilyID so that replaying a used
+// token revokes the whole family.
+type RefreshToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key"`
+	FamilyID  uuid.UUID `gorm:"type:uuid;index;not null"`
+	UserID    uuid.UUID `gorm:"type:uuid;index;not null"`
+	SessionID uuid.UUID `gorm:"type:uuid;index;not null"`
+	TokenHash string    `gorm:"size:64;uniqueIndex;not null"`
+	CreatedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"index;not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// MaxRoutingRules caps the number of time-based rules per link
+const MaxRoutingRules = 20
+
+// RoutingRule sends visitors to Destination while the link's local time is
+// within [Start, End) on one of Days. A window whose End is before its Start
+// runs past midnight into the following day.
+type RoutingRule struct {
+	Days        []int  `json:"days,omitempty" binding:"max=7,dive,min=0,max=6"` // 0 = Sunday; empty means every day
+	Start       string `json:"start" binding:"required"`                        // "HH:MM"
+	End         string `json:"end" binding:"required"`                          // "HH:MM", "24:00" for end of day
+	Destination string `json:"destination" binding:"required,url"`
+}
+
+// Validate checks the time window of the rule
+func (r RoutingRule) Validate() error {
+	start, err := parseClock(r.Start)
+	if err != nil || start == 24*60 {
+		return fmt.Errorf("invalid start time %q, expected HH:MM", r.Start)
+	}
+	end, err := parseClock(r.End)
+	if err != nil {
+		return fmt.Errorf("invalid end time %q, expected HH:MM", r.End)
+	}
+	if start == end {
+		return errors.New("start and end time of a rule must differ")
+	}
+	for _, day := range r.Days {
+		if day < 0 || day > 6 {
+			return fmt.Errorf("invalid day %d, expected 0 (Sunday) to 6 (Saturday)", day)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether t (already in the link's time zone) is inside the window
+func (r RoutingRule) Matches(t time.Time) bool {
+	start, err := parseClock(r.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(r.End)
+	if err != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	day := int(t.Weekday())
+	if start < end {
+		return r.onDay(day) && minute >= start && minute < end
+	}
+	// Overnight window: the part after midnight belongs to the previous day
+	return (r.onDay(day) && minute >= start) || (r.onDay((day+6)%7) && minute < end)
+}
+
+func (r RoutingRule) onDay(day int) bool {
+	if len(r.Days) == 0 {
+		return true
+	}
+	for _, d := range r.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchRoutingRules returns the destination of the first rule matching now
+// in the given time zone
+func MatchRoutingRules(rules []RoutingRule, timezone string, now time.Time) (string, bool) {
+	if len(rules) == 0 {
+		return "", false
+	}
+	loc, err := LoadTimezone(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+
+	local := now.In(loc)
+	for _, rule := range rules {
+		if rule.Matches(local) {
+			return rule.Destination, true
+		}
+	}
+	return "", false
+}
+
+// locations caches loaded time zones, LoadLocation reads from disk every call
+var locations sync.Map
+
+// LoadTimezone loads an IANA time zone name; empty means UTC
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight
+func parseClock(value string) (int, error) {
+	var hour, minute int
+	if len(value) != 5 {
+		return 0, errors.New("invalid clock time")
+	}
+	if _, err := fmt.Sscanf(value, "%02d:%02d", &hour, &minute); err != nil {
+		return 0, err
+	}
+	if hour < 0 || hour > 24 || minute < 0 || minute > 59 || (hour == 24 && minute != 0) {
+		return 0, errors.New("invalid clock time")
+	}
+	return hour*60 + minute, nil
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxSavedViews caps how many views a user can save
+const MaxSavedViews = 50
+
+// SavedViewFilters are the link-list filters a view may store
+var SavedViewFilters = map[string]bool{
+	"search":         true,
+	"tag":            true,
+	"collection":     true,
+	"status":         true,
+	"domain":         true,
+	"created_after":  true,
+	"created_before": true,
+}
+
+// SavedViewSorts are the columns a view may sort the link list by
+var SavedViewSorts = map[string]bool{
+	"created_at": true,
+	"updated_at": true,
+	"clicks":     true,
+	"expires_at": true,
+	"short_code": true,
+}
+
+// SavedView is a named filter/sort combination for the link list, stored
+// server-side so the dashboard can restore it on any device
+type SavedView struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    uuid.UUID         `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_saved_views_user_name"`
+	Name      string            `json:"name" gorm:"size:100;not null;uniqueIndex:idx_saved_views_user_name"`
+	Filters   map[string]string `json:"filters" gorm:"type:jsonb;serializer:json"`
+	SortBy    string            `json:"sort_by,omitempty" gorm:"size:20"`
+	SortOrder string            `json:"sort_order,omitempty" gorm:"size:4"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SavedViewRequest creates or replaces a saved view
+type SavedViewRequest struct {
+	Name      string            `json:"name" binding:"required,max=100"`
+	Filters   map[string]string `json:"filters" binding:"max=10"`
+	SortBy    string            `json:"sort_by"`
+	SortOrder string            `json:"sort_order" binding:"omitempty,oneof=asc desc"`
+}
+
+// Validate rejects filters and sort columns the link list doesn't support
+func (r *SavedViewRequest) Validate() error {
+	for key := range r.Filters {
+		if !SavedViewFilters[key] {
+			return fmt.Errorf("unsupported filter %q", key)
+		}
+	}
+	if r.SortBy != "" && !SavedViewSorts[r.SortBy] {
+		return fmt.Errorf("unsupported sort column %q", r.SortBy)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is one sign-in (device). Its ID is carried in the "sid" claim of
+// the tokens issued at sign-in, so a single device can be signed out.
+type Session struct {
+	ID         uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID  `json:"-" gorm:"type:uuid;index;not null"`
+	UserAgent  string     `json:"user_agent"`
+	Browser    string     `json:"browser"`
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" gorm:"-"` // From Redis, falls back to CreatedAt
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"index"`
+	RevokedAt  *time.Time `json:"-"`
+	Current    bool       `json:"current" gorm:"-"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Workspace member roles. Owners manage the membership; every member can
+// view and manage the workspace's links.
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleMember = "member"
+)
+
+// MaxWorkspaceMembers caps the size of a shared workspace; pending invites
+// count towards it
+const MaxWorkspaceMembers = 50
+
+// WorkspaceInviteTTL is how long an invite can be accepted
+const WorkspaceInviteTTL = 7 * 24 * time.Hour
+
+// Workspace is a pool of links shared by its members. Links join a workspace
+// through URL.WorkspaceID and keep their creator as UserID.
+type Workspace struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Name      string    `json:"name" gorm:"size:100;not null"`
+	OwnerID   uuid.UUID `json:"owner_id" gorm:"type:uuid;not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WorkspaceMember gives a user access to a workspace's links
+type WorkspaceMember struct {
+	WorkspaceID uuid.UUID `json:"workspace_id" gorm:"type:uuid;primaryKey"`
+	UserID      uuid.UUID `json:"user_id" gorm:"type:uuid;primaryKey;index"`
+	Role        string    `json:"role" gorm:"size:20;not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceInvite offers workspace membership to an email address. It is only
+// ever matched against the signed-in invitee's own verified address, so an
+// owner learns nothing about which addresses have accounts.
+type WorkspaceInvite struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WorkspaceID uuid.UUID `json:"workspace_id" gorm:"type:uuid;not null;uniqueIndex:idx_workspace_invite_email"`
+	Email       string    `json:"email" gorm:"size:255;not null;uniqueIndex:idx_workspace_invite_email;index"`
+	InvitedBy   uuid.UUID `json:"invited_by" gorm:"type:uuid;not null"`
+	ExpiresAt   time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WorkspaceRequest creates a workspace
+type WorkspaceRequest struct {
+	Name string `json:"name" binding:"required,max=100"`
+}
+
+// InviteWorkspaceMemberRequest invites an email address to a workspace
+type InviteWorkspaceMemberRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// SetWorkspaceRequest shares a link into a workspace; null makes it private again
+type SetWorkspaceRequest struct {
+	WorkspaceID *uuid.UUID `json:"workspace_id"`
+}
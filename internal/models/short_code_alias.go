@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShortCodeAliasGracePeriod is how long a renamed link's old code keeps forwarding
+const ShortCodeAliasGracePeriod = 90 * 24 * time.Hour
+
+// ShortCodeAlias is the old code of a renamed link. Until it expires the old
+// code redirects (301) to the link's new short URL and can't be claimed by
+// anyone else.
+type ShortCodeAlias struct {
+	Code      string    `json:"code" gorm:"primaryKey;size:20"`
+	URLID     uuid.UUID `json:"url_id" gorm:"type:uuid;not null;index"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null;index"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RenameShortCodeRequest changes a link's short code
+type RenameShortCodeRequest struct {
+	ShortCode string `json:"short_code" binding:"required,max=20"`
+}
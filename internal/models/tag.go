@@ -0,0 +1,66 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MaxTagLength is the longest tag accepted
+const MaxTagLength = 50
+
+// MaxTagsPerURL caps how many tags a single link can carry
+const MaxTagsPerURL = 20
+
+// Tag batch operations
+const (
+	TagBatchSetExpiry  = "set_expiry"
+	TagBatchDeactivate = "deactivate"
+	TagBatchActivate   = "activate"
+)
+
+// NormalizeTag trims and lowercases a tag; it returns "" for invalid tags
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" || len(tag) > MaxTagLength {
+		return ""
+	}
+	return tag
+}
+
+// NormalizeTags normalizes and de-duplicates a link's tags, keeping their order
+func NormalizeTags(tags []string) ([]string, error) {
+	if len(tags) > MaxTagsPerURL {
+		return nil, fmt.Errorf("a link can have at most %d tags", MaxTagsPerURL)
+	}
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		t := NormalizeTag(tag)
+		if t == "" {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	return normalized, nil
+}
+
+// RenameTagRequest renames a tag on all of the user's links
+type RenameTagRequest struct {
+	Name string `json:"name" binding:"required,max=50"`
+}
+
+// MergeTagsRequest replaces several tags with one on all of the user's links
+type MergeTagsRequest struct {
+	From []string `json:"from" binding:"required,min=1,max=50,dive,required,max=50"`
+	To   string   `json:"to" binding:"required,max=50"`
+}
+
+// TagBatchRequest applies an operation to every link carrying a tag
+type TagBatchRequest struct {
+	Operation string     `json:"operation" binding:"required,oneof=set_expiry deactivate activate"`
+	ExpiresAt *time.Time `json:"expires_at"` // Required for set_expiry; null clears the expiry
+}
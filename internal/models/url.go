@@ -1,6 +1,7 @@
 package models
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,6 +9,7 @@ import (
 
 type URLStats struct {
 	TotalClicks    int64     `json:"total_clicks"`
+	UniqueVisitors int64     `json:"unique_visitors"`
 	LastAccessedAt time.Time `json:"last_accessed_at"`
 }
 
@@ -22,17 +24,281 @@ type URL struct {
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`                    // ← Uppercase!
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
-	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"` // ← ADD (optional)
-	User        *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty" gorm:"index"`  // ← ADD (optional)
+	DisabledAt  *time.Time `json:"disabled_at,omitempty" gorm:"index"` // Set when an admin disables the link
+	Moderation  string     `json:"moderation,omitempty" gorm:"index"`  // Abuse review state, empty when never flagged
+	AbuseScore  int        `json:"abuse_score,omitempty"`
+	// Safe Browsing verdict: the threat a quarantined link matched, and when
+	// the destination was last looked up. ThreatAllowed is set when an admin
+	// releases a false positive, so rescans leave the link alone.
+	ThreatType      string     `json:"threat_type,omitempty" gorm:"size:40"`
+	ThreatCheckedAt *time.Time `json:"threat_checked_at,omitempty"`
+	ThreatAllowed   bool       `json:"-" gorm:"not null;default:false"`
+	CreatorIP       string     `json:"-"`
+	// Anonymous links can be attached to an account with the claim token
+	// returned once at creation; only its SHA-256 is stored
+	ClaimTokenHash string `json:"-" gorm:"size:64;index"`
+	ClaimToken     string `json:"claim_token,omitempty" gorm:"-"`
+	// Anonymous creators check, extend or delete their link with the
+	// management token, also returned once at creation
+	ManageTokenHash string `json:"-" gorm:"size:64;index"`
+	ManageToken     string `json:"manage_token,omitempty" gorm:"-"`
+	// Rotator links cycle through Destinations on each click; LongURL mirrors the first one
+	Destinations []string `json:"destinations,omitempty" gorm:"type:jsonb;serializer:json"`
+	RotationMode string   `json:"rotation_mode,omitempty"`
+	// A/B split: each redirect picks one of the variants by weight, see URLVariant
+	Variants []URLVariant `json:"variants,omitempty" gorm:"foreignKey:URLID;constraint:OnDelete:CASCADE"`
+	// Destination edits, see DestinationChange; only loaded for GET /urls/:id/history
+	History []DestinationChange `json:"-" gorm:"foreignKey:URLID;constraint:OnDelete:CASCADE"`
+	// Time-based rules override the destination while their window is open, in RoutingTimezone
+	RoutingRules    []RoutingRule `json:"routing_rules,omitempty" gorm:"type:jsonb;serializer:json"`
+	RoutingTimezone string        `json:"routing_timezone,omitempty" gorm:"size:64"`
+	// Accept-Language routes: language tag ("id", "pt-br") to destination; LongURL is the default
+	LanguageRoutes map[string]string `json:"language_routes,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Per-platform destinations (app store listings, deep links); LongURL is the default
+	DeviceTargets *DeviceTargets `json:"device_targets,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Country routes: ISO 3166 code ("DE") to destination; LongURL is the default
+	CountryRoutes map[string]string `json:"country_routes,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Once this many unique visitors (HyperLogLog estimate) have opened the link, new visitors are turned away
+	MaxUniqueVisitors int64 `json:"max_unique_visitors,omitempty" gorm:"not null;default:0"`
+	// Destination thumbnail in object storage, filled in asynchronously when previews are enabled
+	PreviewImageURL    string     `json:"preview_image_url,omitempty"`
+	PreviewGeneratedAt *time.Time `json:"preview_generated_at,omitempty"`
+	// Destination page's <title> and description, fetched in the background after creation
+	PageTitle       string `json:"page_title,omitempty" gorm:"size:300"`
+	PageDescription string `json:"page_description,omitempty" gorm:"size:300"`
+	// Published links appear in the owner's public feed under Title
+	Title     string `json:"title,omitempty" gorm:"size:200"`
+	Published bool   `json:"published" gorm:"not null;default:false;index"`
+	// Owners can deactivate a link without deleting it; inactive links answer 410
+	IsActive bool `json:"is_active" gorm:"not null;default:true"`
+	// Pinned links are listed before all others
+	IsPinned bool `json:"is_pinned" gorm:"not null;default:false"`
+	// Campaign parameters appended to the destination on redirect
+	UTM *UTMParams `json:"utm,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Free-text context for the team, e.g. "used in March newsletter"
+	Notes string `json:"notes,omitempty" gorm:"type:text"`
+	// Lowercase labels for organizing links, see NormalizeTag
+	Tags []string `json:"tags,omitempty" gorm:"type:jsonb;serializer:json"`
+	// Shared workspace whose members can manage the link, if any
+	WorkspaceID *uuid.UUID `json:"workspace_id,omitempty" gorm:"type:uuid;index"`
+	// Folder the link is filed in, if any
+	CollectionID *uuid.UUID `json:"collection_id,omitempty" gorm:"type:uuid;index"`
+	// Crawler policy: social unfurls are on by default, search indexing and counting bot clicks are opt-in
+	DisableUnfurl bool `json:"disable_unfurl" gorm:"not null;default:false"`
+	AllowIndexing bool `json:"allow_indexing" gorm:"not null;default:false"`
+	CountBots     bool `json:"count_bots" gorm:"not null;default:false"`
+	// Retargeting pixels fire on an interstitial page before the redirect. With
+	// PixelConsentRequired they load in consent-denied mode (no cookies, no ad events).
+	Pixels               []RetargetingPixel `json:"pixels,omitempty" gorm:"type:jsonb;serializer:json"`
+	PixelConsentRequired bool               `json:"pixel_consent_required" gorm:"not null;default:false"`
+	// Visitors see the destination's domain and a "continue" button before
+	// being redirected; takes the place of the pixel page
+	PreviewPage bool `json:"preview_page" gorm:"not null;default:false"`
+	// HTTP status of the redirect (301, 302, 307 or 308); 0 uses the server default
+	RedirectType int `json:"redirect_type,omitempty" gorm:"not null;default:0"`
+	// Result of the last background check of the destination, empty until checked
+	HealthStatus    string     `json:"health_status,omitempty" gorm:"size:20;index"`
+	HealthError     string     `json:"health_error,omitempty" gorm:"size:200"`
+	HealthCheckedAt *time.Time `json:"health_checked_at,omitempty"`
+	User            *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// Abuse review states. Links created by members while approval is required
+// (LINK_APPROVAL_REQUIRED) wait in ModerationAwaitingApproval instead.
+const (
+	ModerationPending          = "pending_review"
+	ModerationAwaitingApproval = "awaiting_approval"
+	ModerationApproved         = "approved"
+	ModerationRejected         = "rejected"
+	// Matched a Safe Browsing threat list; visitors get a warning page
+	ModerationQuarantined = "quarantined"
+)
+
+// Destination health states, see HealthStatus
+const (
+	HealthOK     = "ok"
+	HealthBroken = "broken"
+)
+
+// IsRedirectStatus reports whether status can be used as a link's redirect type
+func IsRedirectStatus(status int) bool {
+	switch status {
+	case 301, 302, 307, 308:
+		return true
+	}
+	return false
+}
+
+// Rotation modes
+const (
+	RotationRoundRobin = "round_robin"
+	RotationRandom     = "random"
+)
+
+// HasCrawlerPolicy reports whether the link deviates from the default crawler policy
+func (u *URL) HasCrawlerPolicy() bool {
+	return u.DisableUnfurl || u.AllowIndexing || u.CountBots
+}
+
+// ClientInfo describes who is creating an anonymous link
+type ClientInfo struct {
+	IP           string
+	CaptchaToken string
+}
+
+// ReviewLinksRequest approves or rejects links awaiting approval in bulk
+// FlushCacheKeyRequest names the cache key an admin wants dropped
+type FlushCacheKeyRequest struct {
+	Key string `json:"key" binding:"required,max=512"`
+}
+
+type ReviewLinksRequest struct {
+	IDs []uuid.UUID `json:"ids" binding:"required,min=1,max=500"`
+}
+
+// TrashRetention is how long deleted links can be restored before they are purged
+const TrashRetention = 30 * 24 * time.Hour
+
+// MaxURLExpiry is the furthest ahead a logged-in user can set a link to expire
+const MaxURLExpiry = 10 * 365 * 24 * time.Hour
+
 type CreateURLRequest struct {
-	LongURL   string `json:"long_url" binding:"required,url"`
-	ShortCode string `json:"short_code" binding:"omitempty,min=3,max=20,alphanum"`
+	LongURL string `json:"long_url" binding:"required,url"`
+	// Letters, digits, "-" and "_"; emoji and other scripts with UNICODE_SHORT_CODES
+	ShortCode    string `json:"short_code" binding:"omitempty,max=20"`
+	CaptchaToken string `json:"captcha_token,omitempty"`
+	// Optional expiry for logged-in users, as a time or hours from now (not both);
+	// anonymous links always expire after 7 days
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	ExpiryHours int        `json:"expiry_hours,omitempty" binding:"omitempty,min=1,max=87600"`
+	Tags        []string   `json:"tags,omitempty" binding:"max=20,dive,required,max=50"`
+	Notes       string     `json:"notes,omitempty" binding:"max=2000"`
+	// Return the caller's existing link to the same destination instead of
+	// creating a new one; ignored when a custom short code is requested
+	ReuseExisting bool `json:"reuse_existing,omitempty"`
+}
+
+// Expiry resolves the requested expiry, nil when the link never expires
+func (r *CreateURLRequest) Expiry(now time.Time) (*time.Time, error) {
+	if r.ExpiresAt != nil && r.ExpiryHours > 0 {
+		return nil, errors.New("set either expires_at or expiry_hours, not both")
+	}
+
+	var expiresAt time.Time
+	switch {
+	case r.ExpiresAt != nil:
+		expiresAt = r.ExpiresAt.UTC()
+	case r.ExpiryHours > 0:
+		expiresAt = now.UTC().Add(time.Duration(r.ExpiryHours) * time.Hour)
+	default:
+		return nil, nil
+	}
+
+	if !expiresAt.After(now) {
+		return nil, errors.New("expires_at must be in the future")
+	}
+	if expiresAt.Sub(now) > MaxURLExpiry {
+		return nil, errors.New("expires_at can be at most 10 years ahead")
+	}
+	return &expiresAt, nil
+}
+
+// SetRotationRequest turns a link into a rotator; an empty list turns rotation off
+type SetRotationRequest struct {
+	Destinations []string `json:"destinations" binding:"max=20,dive,required,url"`
+	Mode         string   `json:"mode" binding:"omitempty,oneof=round_robin random"`
+}
+
+// SetCrawlerPolicyRequest changes how a link treats crawlers; omitted fields are left unchanged
+type SetCrawlerPolicyRequest struct {
+	DisableUnfurl *bool `json:"disable_unfurl"`
+	AllowIndexing *bool `json:"allow_indexing"`
+	CountBots     *bool `json:"count_bots"`
+}
+
+// SetRoutingRulesRequest replaces a link's time-based routing rules; an empty list removes them
+type SetRoutingRulesRequest struct {
+	Timezone string        `json:"timezone"` // IANA name, e.g. "Asia/Jakarta"; defaults to UTC
+	Rules    []RoutingRule `json:"rules" binding:"max=20,dive"`
+}
+
+// ClaimURLsRequest attaches anonymous links to the caller's account
+type ClaimURLsRequest struct {
+	ClaimTokens []string `json:"claim_tokens" binding:"required,min=1,max=50,dive,required,max=64"`
+}
+
+// ExtendAnonymousURLRequest moves an anonymous link's expiry to the given
+// number of hours from now, at most the 7 days a new anonymous link gets
+type ExtendAnonymousURLRequest struct {
+	ExpiryHours int `json:"expiry_hours" binding:"required,min=1,max=168"`
+}
+
+// QRBatchRequest lists the links whose QR codes are exported as one ZIP
+type QRBatchRequest struct {
+	URLIDs []uuid.UUID `json:"url_ids" binding:"required,min=1,max=500"`
+	Format string      `json:"format" binding:"omitempty,oneof=png svg"`
+}
+
+// SetLanguageRoutesRequest replaces a link's language routes; an empty map removes them
+type SetLanguageRoutesRequest struct {
+	Routes map[string]string `json:"routes" binding:"max=50,dive,keys,required,max=35,endkeys,required,url"`
+}
+
+// SetCountryRoutesRequest replaces a link's country routes; an empty map removes them
+type SetCountryRoutesRequest struct {
+	Routes map[string]string `json:"routes" binding:"max=250,dive,keys,required,len=2,endkeys,required,url"`
+}
+
+// SetPixelsRequest replaces a link's retargeting pixels; an empty list removes the interstitial
+type SetPixelsRequest struct {
+	Pixels          []RetargetingPixel `json:"pixels" binding:"max=5,dive"`
+	ConsentRequired bool               `json:"consent_required"`
+}
+
+// SetPreviewPageRequest turns a link's preview page on or off
+type SetPreviewPageRequest struct {
+	Enabled *bool `json:"enabled" binding:"required"`
+}
+
+// SetRedirectTypeRequest picks a link's redirect status; 0 goes back to the server default
+type SetRedirectTypeRequest struct {
+	RedirectType *int `json:"redirect_type" binding:"required,oneof=0 301 302 307 308"`
+}
+
+// SetVisitorLimitRequest caps a link by unique visitors; 0 removes the cap
+type SetVisitorLimitRequest struct {
+	MaxUniqueVisitors *int64 `json:"max_unique_visitors" binding:"required,min=0"`
+}
+
+// SetPublishedRequest adds a link to or removes it from the public feed
+type SetPublishedRequest struct {
+	Published *bool  `json:"published" binding:"required"`
+	Title     string `json:"title" binding:"max=200"`
+}
+
+// URLFilter narrows and orders the link list; zero values don't filter.
+// Sort is one of SavedViewSorts, newest first by default.
+type URLFilter struct {
+	WorkspaceID   *uuid.UUID // only the links shared into this workspace
+	Search        string     // matched against short code, destination, title and notes
+	Tag           string
+	CollectionID  *uuid.UUID
+	Expired       *bool
+	Active        *bool
+	Health        string // "ok", "broken" or "unchecked"
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	Sort          string
+	Order         string
 }
 
 type UpdateURLRequest struct {
 	LongURL string `json:"long_url" binding:"required,url"`
+	// Replaces the link's tags; omitted keeps them, [] removes them all
+	Tags []string `json:"tags" binding:"max=20,dive,required,max=50"`
+	// Replaces the link's notes; omitted keeps them, "" clears them
+	Notes *string `json:"notes" binding:"omitempty,max=2000"`
 }
 
 // Helper: Check if URL is owned by user
@@ -48,6 +314,31 @@ func (u *URL) IsExpired() bool {
 	return time.Now().After(*u.ExpiresAt)
 }
 
+// Helper: Check if URL has been disabled by an admin
+func (u *URL) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// Helper: Check if URL is held, waiting for abuse review or approval
+func (u *URL) IsPendingReview() bool {
+	return u.Moderation == ModerationPending || u.Moderation == ModerationAwaitingApproval
+}
+
+// Helper: Check if URL was quarantined after matching a threat list
+func (u *URL) IsQuarantined() bool {
+	return u.Moderation == ModerationQuarantined
+}
+
+// Helper: Check if URL rotates between several destinations
+func (u *URL) IsRotator() bool {
+	return len(u.Destinations) > 1
+}
+
+// Helper: Check if URL is an A/B split test (Variants must be preloaded)
+func (u *URL) IsSplit() bool {
+	return len(u.Variants) > 1
+}
+
 // Helper: Check if URL can be edited by user
 func (u *URL) CanBeEditedBy(userID uuid.UUID) bool {
 	return !u.IsAnonymous && u.IsOwnedBy(userID)
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxVariantsPerURL caps the destinations of an A/B split
+const MaxVariantsPerURL = 10
+
+// URLVariant is one destination of an A/B split. Each redirect picks a
+// variant at random in proportion to the weights; Clicks counts the clicks
+// it received (recent clicks may still be in Redis).
+type URLVariant struct {
+	ID          uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	URLID       uuid.UUID `json:"-" gorm:"type:uuid;not null;uniqueIndex:idx_url_variants_url_name"`
+	Name        string    `json:"name" gorm:"size:35;not null;uniqueIndex:idx_url_variants_url_name"`
+	Destination string    `json:"destination" gorm:"not null"`
+	Weight      int       `json:"weight" gorm:"not null"`
+	Clicks      int64     `json:"clicks" gorm:"not null;default:0"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// VariantRequest is one destination of a split
+type VariantRequest struct {
+	Name        string `json:"name" binding:"required,max=35,alphanum"`
+	Destination string `json:"destination" binding:"required,url"`
+	Weight      int    `json:"weight" binding:"required,min=1,max=100"`
+}
+
+// SetVariantsRequest replaces a link's A/B split; an empty list ends the test
+type SetVariantsRequest struct {
+	Variants []VariantRequest `json:"variants" binding:"max=10,dive"`
+}
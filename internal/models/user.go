@@ -2,10 +2,12 @@ package models
 
 import (
 	"crypto/rand"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
 	"fmt"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,51 +16,161 @@ import (
 )
 
 type User struct {
-	ID               uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
-	Email            string         `gorm:"uniqueIndex;not null" json:"email"`
-	Password         string         `gorm:"not null" json:"-"`
-	FirstName        string         `gorm:"not null" json:"first_name"`
-	LastName         string         `gorm:"not null" json:"last_name"`
-	CreatedAt        time.Time      `json:"created_at"`
-	UpdatedAt        time.Time      `json:"updated_at"`
-	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
-	ResetToken       *string        `gorm:"index" json:"-"`
-	ResetTokenExpiry *time.Time     `json:"-"`
-	URLs             []URL          `json:"urls,omitempty" gorm:"foreignKey:UserID"`
+	ID                uuid.UUID      `gorm:"type:uuid;primary_key" json:"id"`
+	Email             string         `gorm:"uniqueIndex;not null" json:"email"`
+	Password          string         `gorm:"not null" json:"-"`
+	FirstName         string         `gorm:"not null" json:"first_name"`
+	LastName          string         `gorm:"not null" json:"last_name"`
+	CreatedAt         time.Time      `json:"created_at"`
+	UpdatedAt         time.Time      `json:"updated_at"`
+	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
+	ResetToken        *string        `gorm:"index" json:"-"`
+	ResetTokenExpiry  *time.Time     `json:"-"`
+	EmailVerified     bool           `gorm:"not null;default:false" json:"email_verified"`
+	EmailVerifiedAt   *time.Time     `json:"email_verified_at,omitempty"`
+	Role              string         `gorm:"not null;default:user" json:"role"`
+	Plan              string         `gorm:"not null;default:free" json:"plan"`
+	SuspendedAt       *time.Time     `gorm:"index" json:"suspended_at,omitempty"`
+	EmailStatus       string         `gorm:"not null;default:ok" json:"email_status"`
+	EmailStatusReason string         `json:"email_status_reason,omitempty"`
+	EmailStatusAt     *time.Time     `json:"email_status_at,omitempty"`
+	OnboardingEmails  bool           `gorm:"not null;default:true" json:"onboarding_emails"`
+	GoogleID          *string        `gorm:"uniqueIndex" json:"-"`
+	OIDCSubject       *string        `gorm:"column:oidc_subject;uniqueIndex" json:"-"`
+	URLs              []URL          `json:"urls,omitempty" gorm:"foreignKey:UserID"`
 }
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+	// Non-interactive accounts for jobs and integrations; they have no
+	// password and only authenticate with scoped tokens
+	RoleService = "service"
+)
+
+// Subscription plans
+const (
+	PlanFree = "free"
+	PlanPro  = "pro"
+)
+
+// Email deliverability states
+const (
+	EmailStatusOK         = "ok"
+	EmailStatusBounced    = "bounced"
+	EmailStatusComplained = "complained"
+)
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
 	}
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	if u.Plan == "" {
+		u.Plan = PlanFree
+	}
+	if u.EmailStatus == "" {
+		u.EmailStatus = EmailStatusOK
+	}
 	return nil
 }
 
+// IsAdmin reports whether the user has administrator privileges
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsServiceAccount reports whether the user is a non-interactive service account
+func (u *User) IsServiceAccount() bool {
+	return u.Role == RoleService
+}
+
+// CanReceiveEmail reports whether sends to this address are not suppressed
+func (u *User) CanReceiveEmail() bool {
+	return u.EmailStatus == "" || u.EmailStatus == EmailStatusOK
+}
+
+// IsSuspended reports whether the account has been suspended by an admin
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required"`
+	RememberMe bool   `json:"remember_me"` // Longer-lived refresh token
 }
 
 type RegisterRequest struct {
-	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=8"`
-	FirstName string `json:"first_name" binding:"required"`
-	LastName  string `json:"last_name" binding:"required"`
+	Email      string `json:"email" binding:"required,email"`
+	Password   string `json:"password" binding:"required,min=8"`
+	FirstName  string `json:"first_name" binding:"required"`
+	LastName   string `json:"last_name" binding:"required"`
+	InviteCode string `json:"invite_code,omitempty"`
+}
+
+// OIDCLoginRequest carries an ID token or an authorization code from the
+// configured OIDC issuer
+type OIDCLoginRequest struct {
+	IDToken string `json:"id_token" binding:"required_without=Code"`
+	Code    string `json:"code" binding:"required_without=IDToken"`
+	// State from the OIDC config endpoint, required with a code
+	State string `json:"state" binding:"required_with=Code,max=128"`
+}
+
+// GoogleOAuthRequest carries either a Google ID token (one-tap / mobile)
+// or an authorization code from the redirect flow
+type GoogleOAuthRequest struct {
+	IDToken    string `json:"id_token" binding:"required_without=Code"`
+	Code       string `json:"code" binding:"required_without=IDToken"`
+	InviteCode string `json:"invite_code,omitempty"`
 }
 
 type ResetPasswordRequest struct {
 	Email string `json:"email" binding:"required,email"`
 }
 
+// MagicLinkRequest asks for a passwordless login link
+type MagicLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+// MagicLinkLoginRequest exchanges a login link token for a token pair
+type MagicLinkLoginRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// UnlockAccountRequest lifts a login lock with the token from the unlock email
+type UnlockAccountRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
 type ResetPasswordConfirmRequest struct {
 	Token       string `json:"token" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required,min=8"`
 }
 
+type UpdateEmailPreferencesRequest struct {
+	OnboardingEmails *bool `json:"onboarding_emails" binding:"required"`
+}
+
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// RevokeTokenRequest names an access token to revoke; empty means the one
+// making the request
+type RevokeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
+}
+
 type UpdatePasswordRequest struct {
 	CurrentPassword string `json:"current_password" binding:"required"`
 	NewPassword     string `json:"new_password" binding:"required,min=8"`
@@ -76,6 +188,20 @@ func (r *RegisterRequest) Validate() error {
 	return nil
 }
 
+func (r *UpdatePasswordRequest) Validate() error {
+	if !isValidPassword(r.NewPassword) {
+		return errors.New("password must be at least 8 characters and contain at least one uppercase letter, one lowercase letter, one number, and one special character")
+	}
+
+	if r.NewPassword == r.CurrentPassword {
+		return errors.New("new password must be different from the current password")
+	}
+
+	return nil
+}
+
+// Default Argon2 parameters; hashes in the legacy "salt$hash" format were
+// all made with these
 const (
 	Argon2Time      uint32 = 1         // Iterations
 	Argon2Memory    uint32 = 64 * 1024 // 64MB RAM
@@ -84,42 +210,40 @@ const (
 	SaltLength      int    = 16        // Random salt length
 )
 
-func generateSalt() (string, error) {
-	salt := make([]byte, SaltLength)
-	if _, err := rand.Read(salt); err != nil {
-		return "", err
-	}
-	return base64.RawStdEncoding.EncodeToString(salt), nil
-}
-
 func (u *User) HashPassword() error {
-	// 1️⃣ Generate random salt (16 bytes)
-	salt, err := generateSalt()
+	hash, err := encodeArgon2Hash(u.Password, argon2Params)
 	if err != nil {
 		return err
 	}
-
-	// 2️⃣ Generate Argon2 hash
-	// Argon2(password, salt, time, memory, threads, keyLength)
-	hash := argon2.IDKey(
-		[]byte(u.Password),
-		[]byte(salt),
-		Argon2Time,
-		Argon2Memory,
-		Argon2Threads,
-		Argon2KeyLength,
-	)
-
-	// 3️⃣ Store: salt$hash
-	u.Password = fmt.Sprintf("%s$%s",
-		salt,
-		base64.RawStdEncoding.EncodeToString(hash),
-	)
+	u.Password = hash
 	return nil
 }
 
+// PasswordNeedsRehash reports whether the stored hash uses a legacy format or
+// parameters other than the current ones
+func (u *User) PasswordNeedsRehash() bool {
+	return !strings.HasPrefix(u.Password, PasswordHashPrefix())
+}
+
+// PasswordHashVersion names the stored hash's format, as counted by the
+// admin password hash report: "current", "outdated" or "legacy"
+func (u *User) PasswordHashVersion() string {
+	switch {
+	case strings.HasPrefix(u.Password, PasswordHashPrefix()):
+		return "current"
+	case strings.HasPrefix(u.Password, "$argon2id$"):
+		return "outdated"
+	default:
+		return "legacy"
+	}
+}
+
 func (u *User) CheckPassword(password string) error {
-	// Split the stored password into salt and hash
+	if strings.HasPrefix(u.Password, "$argon2id$") {
+		return verifyArgon2Hash(password, u.Password)
+	}
+
+	// Legacy format: salt$hash with the default parameters
 	parts := splitPassword(u.Password)
 	if len(parts) != 2 {
 		return fmt.Errorf("invalid password format")
@@ -131,8 +255,8 @@ func (u *User) CheckPassword(password string) error {
 	// Generate Argon2 hash using the provided password and extracted salt
 	hash := argon2.IDKey([]byte(password), []byte(salt), Argon2Time, Argon2Memory, Argon2Threads, Argon2KeyLength)
 
-	// Compare the hashes
-	if base64.RawStdEncoding.EncodeToString(hash) != storedHash {
+	// Compare the hashes in constant time
+	if subtle.ConstantTimeCompare([]byte(base64.RawStdEncoding.EncodeToString(hash)), []byte(storedHash)) != 1 {
 		return fmt.Errorf("incorrect password")
 	}
 
@@ -204,3 +328,8 @@ func (u *User) ClearResetToken() {
 	u.ResetToken = nil
 	u.ResetTokenExpiry = nil
 }
+
+// SuspendUserRequest is the optional body of the admin suspend endpoint
+type SuspendUserRequest struct {
+	PauseLinks bool `json:"pause_links"` // Disable the user's links until reinstated
+}
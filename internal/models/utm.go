@@ -0,0 +1,62 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+)
+
+// UTMParams are campaign parameters added to a link's destination at
+// redirect time. They are defaults: parameters the destination already
+// carries are left alone.
+type UTMParams struct {
+	Source   string `json:"utm_source,omitempty" binding:"max=100"`
+	Medium   string `json:"utm_medium,omitempty" binding:"max=100"`
+	Campaign string `json:"utm_campaign,omitempty" binding:"max=100"`
+	Term     string `json:"utm_term,omitempty" binding:"max=100"`
+	Content  string `json:"utm_content,omitempty" binding:"max=100"`
+}
+
+// IsZero reports whether no parameter is set
+func (p UTMParams) IsZero() bool {
+	return p == UTMParams{}
+}
+
+// Trimmed returns the parameters with surrounding whitespace removed
+func (p UTMParams) Trimmed() UTMParams {
+	return UTMParams{
+		Source:   strings.TrimSpace(p.Source),
+		Medium:   strings.TrimSpace(p.Medium),
+		Campaign: strings.TrimSpace(p.Campaign),
+		Term:     strings.TrimSpace(p.Term),
+		Content:  strings.TrimSpace(p.Content),
+	}
+}
+
+// Apply appends the parameters missing from destination's query string.
+// The existing query is kept byte for byte, as is the fragment.
+func (p UTMParams) Apply(destination string) string {
+	u, err := url.Parse(destination)
+	if err != nil {
+		return destination
+	}
+	existing := u.Query()
+
+	query := u.RawQuery
+	for _, param := range [...]struct{ key, value string }{
+		{"utm_source", p.Source},
+		{"utm_medium", p.Medium},
+		{"utm_campaign", p.Campaign},
+		{"utm_term", p.Term},
+		{"utm_content", p.Content},
+	} {
+		if param.value == "" || existing.Has(param.key) {
+			continue
+		}
+		if query != "" {
+			query += "&"
+		}
+		query += param.key + "=" + url.QueryEscape(param.value)
+	}
+	u.RawQuery = query
+	return u.String()
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook receives click notifications for all links owned by a user.
+// Clicks are delivered in batches; MilestoneEvery > 0 additionally sends a
+// notification each time a link's total clicks crosses a multiple of it.
+type Webhook struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID         uuid.UUID `json:"user_id" gorm:"type:uuid;index;not null"`
+	URL            string    `json:"url" gorm:"not null"`
+	Secret         string    `json:"secret,omitempty" gorm:"not null"` // Only returned on creation
+	SendClicks     bool      `json:"send_clicks" gorm:"not null"`
+	MilestoneEvery int64     `json:"milestone_every" gorm:"default:0"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// WebhookDelivery is one delivery attempt, kept for the delivery log
+type WebhookDelivery struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	WebhookID  uuid.UUID `json:"webhook_id" gorm:"type:uuid;index;not null"`
+	Event      string    `json:"event" gorm:"not null"`
+	Payload    string    `json:"payload" gorm:"type:text"`
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code"`
+	Success    bool      `json:"success"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+}
+
+type CreateWebhookRequest struct {
+	URL            string `json:"url" binding:"required,url"`
+	SendClicks     *bool  `json:"send_clicks"`
+	MilestoneEvery int64  `json:"milestone_every" binding:"omitempty,min=1"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// WorkspaceExportVersion is bumped whenever the export format changes
+// incompatibly; imports of other versions are rejected
+const WorkspaceExportVersion = 1
+
+// WorkspaceExport is an account's configuration, portable between
+// environments (e.g. staging to production). Links, analytics and secrets
+// are not part of it: imported webhooks get new signing secrets.
+type WorkspaceExport struct {
+	Version    int                    `json:"version" binding:"required"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Branding   *BrandingRequest       `json:"branding,omitempty"`
+	Webhooks   []CreateWebhookRequest `json:"webhooks" binding:"max=50,dive"`
+	SavedViews []SavedViewRequest     `json:"saved_views" binding:"max=50,dive"`
+}
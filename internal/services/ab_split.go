@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// variantClicksFlushEvery is how many clicks of a variant accumulate in
+// Redis before they are added to url_variants
+const variantClicksFlushEvery = 10
+
+// cachedVariant is a split destination in the redirect cache entry
+type cachedVariant struct {
+	Name   string `json:"n"`
+	URL    string `json:"d"`
+	Weight int    `json:"w"`
+}
+
+// SetVariants replaces a link's A/B split. Two or more variants make it a
+// split test (which replaces any rotation); an empty list ends the test and
+// keeps the current LongURL. Click counts of variants that keep their name
+// are carried over.
+func (s *URLService) SetVariants(ctx context.Context, userID, urlID uuid.UUID, variants []models.VariantRequest) (*models.URL, error) {
+	if len(variants) == 1 {
+		return nil, types.NewValidationError("a split test needs at least two variants")
+	}
+	names := make(map[string]bool, len(variants))
+	for i := range variants {
+		variants[i].Name = strings.ToLower(variants[i].Name)
+		if variants[i].Name == defaultLanguageVariant {
+			return nil, types.NewValidationError(fmt.Sprintf("variant name %q is reserved", defaultLanguageVariant))
+		}
+		if names[variants[i].Name] {
+			return nil, types.NewValidationError(fmt.Sprintf("duplicate variant name %q", variants[i].Name))
+		}
+		names[variants[i].Name] = true
+		destination, err := s.validateDestination(ctx, variants[i].Destination)
+		if err != nil {
+			return nil, err
+		}
+		variants[i].Destination = destination
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		clicks := make(map[string]int64, len(url.Variants))
+		for _, variant := range url.Variants {
+			clicks[variant.Name] = variant.Clicks
+		}
+		if err := tx.Where("url_id = ?", url.ID).Delete(&models.URLVariant{}).Error; err != nil {
+			return err
+		}
+
+		url.Variants = nil
+		for _, req := range variants {
+			url.Variants = append(url.Variants, models.URLVariant{
+				URLID:       url.ID,
+				Name:        req.Name,
+				Destination: req.Destination,
+				Weight:      req.Weight,
+				Clicks:      clicks[req.Name],
+			})
+		}
+		if len(url.Variants) > 0 {
+			if err := tx.Create(&url.Variants).Error; err != nil {
+				return err
+			}
+			url.LongURL = url.Variants[0].Destination
+			url.Destinations = nil
+			url.RotationMode = ""
+		}
+		url.UpdatedAt = time.Now().UTC()
+
+		if err := s.destinationsChanged(tx, userID, &url); err != nil {
+			return err
+		}
+
+		if err := tx.Select("long_url", "destinations", "rotation_mode", "updated_at", "moderation").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx, getCacheKey(url.ShortCode), cacheValue(&url), s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt)).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyHeld(ctx, &url)
+	s.notifyChanged(&url)
+	return &url, nil
+}
+
+// GetVariants returns a link's split variants with their click counts,
+// including clicks not yet flushed from Redis
+func (s *URLService) GetVariants(ctx context.Context, userID, urlID uuid.UUID) ([]models.URLVariant, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at, name")
+	}).Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	pending, err := s.redisClient.HGetAll(ctx, getVariantClicksKey(url.ShortCode)).Result()
+	if err != nil {
+		return nil, err
+	}
+	variants := url.Variants
+	if variants == nil {
+		variants = []models.URLVariant{}
+	}
+	for i := range variants {
+		n, _ := strconv.ParseInt(pending[variants[i].Name], 10, 64)
+		variants[i].Clicks += n
+	}
+	return variants, nil
+}
+
+// pickVariant chooses a split variant at random in proportion to the weights
+func pickVariant(variants []cachedVariant) cachedVariant {
+	total := 0
+	for _, variant := range variants {
+		total += variant.Weight
+	}
+	n := rand.Intn(total)
+	for _, variant := range variants {
+		if n < variant.Weight {
+			return variant
+		}
+		n -= variant.Weight
+	}
+	return variants[len(variants)-1]
+}
+
+// countVariantClick counts a click of a split variant in Redis and moves
+// the count to url_variants every variantClicksFlushEvery clicks
+func (s *URLService) countVariantClick(ctx context.Context, shortCode, variant string) {
+	key := getVariantClicksKey(shortCode)
+	n, err := s.redisClient.HIncrBy(ctx, key, variant, 1).Result()
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to count variant click", "short_code", shortCode, "variant", variant, "error", err)
+		return
+	}
+	if n%variantClicksFlushEvery != 0 {
+		return
+	}
+
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		// Claim the clicks first so concurrent flushes don't add them twice
+		if err := s.redisClient.HIncrBy(bgCtx, key, variant, -variantClicksFlushEvery).Err(); err != nil {
+			return
+		}
+		if err := s.db.WithContext(bgCtx).Model(&models.URLVariant{}).
+			Where("name = ? AND url_id = (SELECT id FROM urls WHERE short_code = ?)", variant, shortCode).
+			UpdateColumn("clicks", gorm.Expr("clicks + ?", variantClicksFlushEvery)).Error; err != nil {
+			utils.Logger.Error("Failed to flush variant clicks", "short_code", shortCode, "variant", variant, "error", err)
+			s.redisClient.HIncrBy(bgCtx, key, variant, variantClicksFlushEvery)
+		}
+	}()
+}
+
+func getVariantClicksKey(shortCode string) string {
+	return fmt.Sprintf("ab:%s", shortCode)
+}
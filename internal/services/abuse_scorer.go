@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+const (
+	abuseBurstWindow    = 10 * time.Minute
+	abuseBurstAllowance = 5
+	abuseNewDomainAge   = 24 * time.Hour
+	// abuseDomainSeenTTL is how long a domain's first sighting is remembered
+	// after it was last submitted; a domain gone for longer counts as new
+	abuseDomainSeenTTL = 30 * 24 * time.Hour
+	abuseReputationTTL = 30 * 24 * time.Hour
+)
+
+// AbuseConfig holds the thresholds and CAPTCHA provider settings
+type AbuseConfig struct {
+	CaptchaThreshold int
+	ReviewThreshold  int
+	CaptchaSecret    string
+	CaptchaVerifyURL string // hCaptcha/reCAPTCHA compatible siteverify endpoint
+}
+
+// AbuseScorer scores anonymous link creations with cheap heuristics
+type AbuseScorer struct {
+	redisClient *redis.Client
+	config      AbuseConfig
+	resolver    *net.Resolver
+	httpClient  *http.Client
+}
+
+func NewAbuseScorer(redisClient *redis.Client, config AbuseConfig) *AbuseScorer {
+	return &AbuseScorer{
+		redisClient: redisClient,
+		config:      config,
+		resolver:    net.DefaultResolver,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Score rates a creation from 0 upwards; higher is more suspicious
+func (a *AbuseScorer) Score(ctx context.Context, longURL, ip string) *types.AbuseAssessment {
+	assessment := &types.AbuseAssessment{Signals: make(map[string]int)}
+
+	parsed, err := url.Parse(longURL)
+	if err != nil || parsed.Hostname() == "" {
+		assessment.Add("unparsable_url", 50)
+		return assessment
+	}
+	host := strings.ToLower(parsed.Hostname())
+
+	// Destination domain
+	if net.ParseIP(host) != nil {
+		assessment.Add("ip_literal_host", 25)
+	} else {
+		assessment.Add("domain", a.domainScore(ctx, utils.NormalizeDomain(host)))
+		if strings.Count(host, ".") >= 4 {
+			assessment.Add("deep_subdomain", 10)
+		}
+	}
+
+	// Random-looking paths are typical for throwaway phishing pages
+	tail := parsed.EscapedPath() + parsed.RawQuery
+	if len(tail) >= 16 && shannonEntropy(tail) > 4.5 {
+		assessment.Add("url_entropy", 15)
+	}
+	if len(longURL) > 512 {
+		assessment.Add("url_length", 5)
+	}
+
+	assessment.Add("ip_reputation", a.ipReputationScore(ctx, ip))
+	assessment.Add("burst_rate", a.burstScore(ctx, ip))
+
+	return assessment
+}
+
+// domainScore approximates domain age: DNS tells us whether the domain
+// resolves at all, and Redis remembers when we first saw it
+func (a *AbuseScorer) domainScore(ctx context.Context, domain string) int {
+	score := 0
+
+	lookupCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if _, err := a.resolver.LookupHost(lookupCtx, domain); err != nil {
+		score += 30
+	} else if ns, err := a.resolver.LookupNS(lookupCtx, registrableDomain(domain)); err != nil || len(ns) == 0 {
+		score += 10
+	}
+
+	key := "abuse:domain_seen:" + domain
+	now := time.Now().Unix()
+	if set, err := a.redisClient.SetNX(ctx, key, now, abuseDomainSeenTTL).Result(); err == nil {
+		if set {
+			score += 15
+		} else {
+			a.redisClient.Expire(ctx, key, abuseDomainSeenTTL)
+			if first, err := a.redisClient.Get(ctx, key).Int64(); err == nil && now-first < int64(abuseNewDomainAge.Seconds()) {
+				score += 10
+			}
+		}
+	}
+
+	return score
+}
+
+// ipReputationScore grows with the number of links from the IP that were
+// rejected in review
+func (a *AbuseScorer) ipReputationScore(ctx context.Context, ip string) int {
+	if ip == "" {
+		return 0
+	}
+	strikes, err := a.redisClient.Get(ctx, getAbuseStrikesKey(ip)).Int()
+	if err != nil {
+		return 0
+	}
+	return min(strikes*15, 45)
+}
+
+// burstScore counts creations from the IP in a sliding window
+func (a *AbuseScorer) burstScore(ctx context.Context, ip string) int {
+	if ip == "" {
+		return 0
+	}
+	key := "abuse:burst:" + ip
+	count, err := a.redisClient.Incr(ctx, key).Result()
+	if err != nil {
+		return 0
+	}
+	if count == 1 {
+		a.redisClient.Expire(ctx, key, abuseBurstWindow)
+	}
+	if count <= abuseBurstAllowance {
+		return 0
+	}
+	return min(int(count-abuseBurstAllowance)*5, 30)
+}
+
+// recordAbuseStrike lowers the reputation of an IP after one of its links was rejected
+func recordAbuseStrike(ctx context.Context, redisClient *redis.Client, ip string) {
+	if ip == "" {
+		return
+	}
+	key := getAbuseStrikesKey(ip)
+	pipe := redisClient.Pipeline()
+	pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, abuseReputationTTL)
+	pipe.Exec(ctx)
+}
+
+func getAbuseStrikesKey(ip string) string {
+	return "abuse:ip_strikes:" + ip
+}
+
+// RequiresCaptcha reports whether the score needs a solved CAPTCHA
+func (a *AbuseScorer) RequiresCaptcha(score int) bool {
+	return a.config.CaptchaThreshold > 0 && score >= a.config.CaptchaThreshold
+}
+
+// RequiresReview reports whether the link must be held for admin review
+func (a *AbuseScorer) RequiresReview(score int) bool {
+	return a.config.ReviewThreshold > 0 && score >= a.config.ReviewThreshold
+}
+
+// CaptchaEnabled reports whether a CAPTCHA provider is configured
+func (a *AbuseScorer) CaptchaEnabled() bool {
+	return a.config.CaptchaSecret != ""
+}
+
+// VerifyCaptcha checks a CAPTCHA token with the provider
+func (a *AbuseScorer) VerifyCaptcha(ctx context.Context, token, ip string) bool {
+	if token == "" || a.config.CaptchaSecret == "" {
+		return false
+	}
+
+	form := url.Values{
+		"secret":   {a.config.CaptchaSecret},
+		"response": {token},
+	}
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.CaptchaVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("CAPTCHA verification failed", "error", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false
+	}
+	return result.Success
+}
+
+// registrableDomain keeps the last two labels of a host ("a.b.example.com" -> "example.com")
+func registrableDomain(host string) string {
+	labels := strings.Split(host, ".")
+	if len(labels) <= 2 {
+		return host
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	entropy := 0.0
+	for _, c := range counts {
+		p := float64(c) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
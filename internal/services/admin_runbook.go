@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// flushablePrefixes are the key families that only cache data the database
+// (or an upstream API) can rebuild. Counters, queues and auth state such as
+// session revocations are never flushed this way.
+var flushablePrefixes = []string{"url:", "rotate:", "feed:", "qr:", "meta:", "brand:", "pwned:", "rate_limit:", "abuse:"}
+
+// negativeCacheValues are the redirect cache entries that stand in for
+// "this short code doesn't redirect"
+var negativeCacheValues = map[string]bool{
+	cacheNotFound:    true,
+	cacheExpired:     true,
+	cacheInactive:    true,
+	cacheHeld:        true,
+	cacheQuarantined: true,
+}
+
+// FlushCacheKey deletes a single cache key
+func (s *AdminService) FlushCacheKey(ctx context.Context, key string, dryRun bool) (*types.AdminActionResult, error) {
+	if !isFlushable(key) {
+		return nil, types.ErrCacheKeyNotFlushable
+	}
+
+	result := types.NewAdminActionResult("flush_cache_key", dryRun)
+	exists, err := s.redisClient.Exists(ctx, key).Result()
+	if err != nil {
+		return nil, err
+	}
+	if exists > 0 {
+		result.Add("cache_keys", key)
+	}
+	if !dryRun && exists > 0 {
+		if err := s.redisClient.Del(ctx, key).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	auditRunbook(ctx, result, key)
+	return result, nil
+}
+
+// RebuildNegativeCache drops every cached "doesn't redirect" answer, so
+// short codes are looked up again, then re-warms the most clicked links
+func (s *AdminService) RebuildNegativeCache(ctx context.Context, dryRun bool) (*types.AdminActionResult, error) {
+	result := types.NewAdminActionResult("rebuild_negative_cache", dryRun)
+
+	var cursor uint64
+	for {
+		keys, next, err := s.redisClient.Scan(ctx, cursor, "url:*", 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(keys) > 0 {
+			values, err := s.redisClient.MGet(ctx, keys...).Result()
+			if err != nil {
+				return nil, err
+			}
+			var stale []string
+			for i, value := range values {
+				if str, ok := value.(string); ok && negativeCacheValues[str] {
+					stale = append(stale, keys[i])
+				}
+			}
+			result.Count("negative_entries", len(stale))
+			if !dryRun && len(stale) > 0 {
+				if err := s.redisClient.Del(ctx, stale...).Err(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if !dryRun && s.cacheWarmer != nil {
+		if err := s.cacheWarmer.WarmTopURLs(ctx); err != nil {
+			utils.LoggerFromContext(ctx).Warn("Cache warm after rebuild failed", "error", err)
+		}
+	}
+
+	auditRunbook(ctx, result, "url:*")
+	return result, nil
+}
+
+// ReconcileClicks brings a link's stored click count and its Redis counter
+// back in line, keeping the higher of the two (clicks are never lost)
+func (s *AdminService) ReconcileClicks(ctx context.Context, urlID uuid.UUID, dryRun bool) (*types.ClickReconciliation, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).Select("id", "short_code", "clicks").
+		Where("id = ? AND deleted_at IS NULL", urlID).First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	key := getClicksKey(url.ShortCode)
+	redisClicks, err := s.redisClient.Get(ctx, key).Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	report := &types.ClickReconciliation{
+		ShortCode:    url.ShortCode,
+		DryRun:       dryRun,
+		StoredClicks: url.Clicks,
+		RedisClicks:  redisClicks,
+		Clicks:       max(url.Clicks, redisClicks),
+	}
+
+	if !dryRun {
+		if err := s.db.WithContext(ctx).Model(&models.URL{}).Where("id = ?", url.ID).
+			UpdateColumn("clicks", report.Clicks).Error; err != nil {
+			return nil, err
+		}
+		// Same expiry incrementClickCount gives the counter
+		if err := s.redisClient.Set(ctx, key, report.Clicks, 30*24*time.Hour).Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", "reconcile_clicks",
+		"dry_run", dryRun,
+		"target", url.ShortCode,
+		"stored_clicks", report.StoredClicks,
+		"redis_clicks", report.RedisClicks,
+		"clicks", report.Clicks)
+	return report, nil
+}
+
+// ListFailedEmails returns the emails that gave up retrying
+func (s *AdminService) ListFailedEmails(ctx context.Context) ([]types.FailedEmail, error) {
+	failed := []types.FailedEmail{}
+	if s.emailQueue == nil {
+		return failed, nil
+	}
+
+	jobs, err := s.emailQueue.FailedJobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs {
+		failed = append(failed, failedEmail(job))
+	}
+	return failed, nil
+}
+
+// RetryFailedEmail queues a failed email again
+func (s *AdminService) RetryFailedEmail(ctx context.Context, jobID string) (*types.FailedEmail, error) {
+	if s.emailQueue == nil {
+		return nil, types.ErrEmailJobNotFound
+	}
+
+	job, err := s.emailQueue.RetryFailed(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", "retry_failed_email",
+		"target", job.ID,
+		"kind", job.Kind,
+		"recipient_id", job.UserID)
+	retried := failedEmail(*job)
+	return &retried, nil
+}
+
+func failedEmail(job EmailJob) types.FailedEmail {
+	return types.FailedEmail{
+		ID:        job.ID,
+		Kind:      job.Kind,
+		UserID:    job.UserID,
+		Attempts:  job.Attempts,
+		LastError: job.LastErr,
+	}
+}
+
+// auditRunbook logs a runbook action like execute does for database actions,
+// with the key it targeted
+func auditRunbook(ctx context.Context, result *types.AdminActionResult, target string) {
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", result.Action,
+		"dry_run", result.DryRun,
+		"target", target,
+		"affected", result.Affected)
+}
+
+func isFlushable(key string) bool {
+	for _, prefix := range flushablePrefixes {
+		if strings.HasPrefix(key, prefix) && len(key) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
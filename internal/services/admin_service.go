@@ -0,0 +1,688 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AdminService struct {
+	db           *gorm.DB
+	redisClient  *redis.Client
+	linkApproval *LinkApproval
+	emailQueue   *EmailQueue
+	cacheWarmer  *CacheWarmer
+}
+
+func NewAdminService(db *gorm.DB, redisClient *redis.Client) *AdminService {
+	return &AdminService{
+		db:          db,
+		redisClient: redisClient,
+	}
+}
+
+// SetLinkApproval enables owner notifications for approval decisions
+func (s *AdminService) SetLinkApproval(approval *LinkApproval) {
+	s.linkApproval = approval
+}
+
+// SetEmailQueue lets admins retry emails that failed permanently
+func (s *AdminService) SetEmailQueue(queue *EmailQueue) {
+	s.emailQueue = queue
+}
+
+// SetCacheWarmer re-warms the redirect cache after it is rebuilt
+func (s *AdminService) SetCacheWarmer(warmer *CacheWarmer) {
+	s.cacheWarmer = warmer
+}
+
+// execute runs a destructive admin action in two phases: plan records everything
+// that would be affected on the result, apply performs the change. Apply is
+// skipped for dry runs, so both modes report exactly the same counts and IDs.
+func (s *AdminService) execute(
+	ctx context.Context,
+	action string,
+	dryRun bool,
+	plan func(tx *gorm.DB, result *types.AdminActionResult) error,
+	apply func(tx *gorm.DB) error,
+) (*types.AdminActionResult, error) {
+	result := types.NewAdminActionResult(action, dryRun)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := plan(tx, result); err != nil {
+			return err
+		}
+		if dryRun || result.Empty() {
+			return nil
+		}
+		return apply(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", action,
+		"dry_run", dryRun,
+		"affected", result.Affected)
+
+	return result, nil
+}
+
+// PurgeExpiredURLs permanently removes every URL whose expiry has passed
+func (s *AdminService) PurgeExpiredURLs(ctx context.Context, dryRun bool) (*types.AdminActionResult, error) {
+	var urls []models.URL
+
+	return s.execute(ctx, "purge_expired_urls", dryRun,
+		func(tx *gorm.DB, result *types.AdminActionResult) error {
+			if err := tx.Where("expires_at IS NOT NULL AND expires_at < ?", time.Now().UTC()).
+				Find(&urls).Error; err != nil {
+				return err
+			}
+			result.Add("urls", urlIDs(urls)...)
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			if err := tx.Unscoped().Where("id IN ?", urlIDs(urls)).Delete(&models.URL{}).Error; err != nil {
+				return err
+			}
+			return s.purgeURLCache(ctx, urls, true)
+		},
+	)
+}
+
+// BanUser suspends an account and disables all of its links
+func (s *AdminService) BanUser(ctx context.Context, userID uuid.UUID, dryRun bool) (*types.AdminActionResult, error) {
+	var user models.User
+	var urls []models.URL
+
+	return s.execute(ctx, "ban_user", dryRun,
+		func(tx *gorm.DB, result *types.AdminActionResult) error {
+			if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return types.ErrUserNotFound
+				}
+				return err
+			}
+			if !user.IsSuspended() {
+				result.Add("users", user.ID.String())
+			}
+
+			if err := tx.Where("user_id = ? AND deleted_at IS NULL AND disabled_at IS NULL", userID).
+				Find(&urls).Error; err != nil {
+				return err
+			}
+			result.Add("urls", urlIDs(urls)...)
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			now := time.Now().UTC()
+			if !user.IsSuspended() {
+				if err := tx.Model(&user).UpdateColumn("suspended_at", now).Error; err != nil {
+					return err
+				}
+			}
+			if err := s.signOutEverywhere(ctx, tx, userID, now); err != nil {
+				return err
+			}
+			if len(urls) == 0 {
+				return nil
+			}
+			if err := tx.Model(&models.URL{}).
+				Where("id IN ?", urlIDs(urls)).
+				UpdateColumn("disabled_at", now).Error; err != nil {
+				return err
+			}
+			return s.purgeURLCache(ctx, urls, false)
+		},
+	)
+}
+
+// SuspendUser locks an account: login fails, every session and API key stops
+// working, and with pauseLinks its active links are disabled too. Paused links
+// are stamped with the suspension time so ReinstateUser restores only those.
+func (s *AdminService) SuspendUser(ctx context.Context, userID uuid.UUID, pauseLinks, dryRun bool) (*types.AdminActionResult, error) {
+	var user models.User
+	var urls []models.URL
+
+	return s.execute(ctx, "suspend_user", dryRun,
+		func(tx *gorm.DB, result *types.AdminActionResult) error {
+			if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return types.ErrUserNotFound
+				}
+				return err
+			}
+			if user.IsSuspended() {
+				return nil
+			}
+			result.Add("users", user.ID.String())
+
+			if pauseLinks {
+				if err := tx.Where("user_id = ? AND deleted_at IS NULL AND disabled_at IS NULL", userID).
+					Find(&urls).Error; err != nil {
+					return err
+				}
+				result.Add("urls", urlIDs(urls)...)
+			}
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			// Postgres keeps microseconds; truncate so the stamps compare equal later
+			now := time.Now().UTC().Truncate(time.Microsecond)
+			if err := tx.Model(&user).UpdateColumn("suspended_at", now).Error; err != nil {
+				return err
+			}
+			if err := s.signOutEverywhere(ctx, tx, userID, now); err != nil {
+				return err
+			}
+			if len(urls) == 0 {
+				return nil
+			}
+			if err := tx.Model(&models.URL{}).
+				Where("id IN ?", urlIDs(urls)).
+				UpdateColumn("disabled_at", now).Error; err != nil {
+				return err
+			}
+			return s.purgeURLCache(ctx, urls, false)
+		},
+	)
+}
+
+// ReinstateUser lifts a suspension and re-enables the links paused with it.
+// Links disabled for other reasons (bans, moderation) stay disabled.
+func (s *AdminService) ReinstateUser(ctx context.Context, userID uuid.UUID, dryRun bool) (*types.AdminActionResult, error) {
+	var user models.User
+	var urls []models.URL
+
+	return s.execute(ctx, "reinstate_user", dryRun,
+		func(tx *gorm.DB, result *types.AdminActionResult) error {
+			if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return types.ErrUserNotFound
+				}
+				return err
+			}
+			if !user.IsSuspended() {
+				return nil
+			}
+			result.Add("users", user.ID.String())
+
+			if err := tx.Where("user_id = ? AND deleted_at IS NULL AND disabled_at = ?", userID, *user.SuspendedAt).
+				Find(&urls).Error; err != nil {
+				return err
+			}
+			result.Add("urls", urlIDs(urls)...)
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			if err := tx.Model(&user).UpdateColumn("suspended_at", nil).Error; err != nil {
+				return err
+			}
+			if len(urls) == 0 {
+				return nil
+			}
+			if err := tx.Model(&models.URL{}).
+				Where("id IN ?", urlIDs(urls)).
+				UpdateColumn("disabled_at", nil).Error; err != nil {
+				return err
+			}
+			return s.purgeURLCache(ctx, urls, false)
+		},
+	)
+}
+
+// signOutEverywhere revokes every token the user holds: the Redis logout mark
+// AuthMiddleware checks, stored sessions and refresh tokens, and cached API
+// key lookups (which would otherwise outlive the suspension for a while)
+func (s *AdminService) signOutEverywhere(ctx context.Context, tx *gorm.DB, userID uuid.UUID, now time.Time) error {
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	var keyHashes []string
+	if err := tx.Model(&models.APIKey{}).Where("user_id = ?", userID).Pluck("key_hash", &keyHashes).Error; err != nil {
+		return err
+	}
+
+	pipe := s.redisClient.Pipeline()
+	pipe.ZAdd(ctx, utils.RevokedSessionsKey, &redis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: userID.String(),
+	})
+	pipe.Del(ctx, utils.ActiveSessionsKey(userID.String()))
+	for _, hash := range keyHashes {
+		pipe.Del(ctx, getAPIKeyCacheKey(hash))
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// AddBlockedDomains adds destination domains to the blocklist and disables
+// existing links that point at them (including subdomains)
+func (s *AdminService) AddBlockedDomains(ctx context.Context, adminID uuid.UUID, domains []string, reason string, dryRun bool) (*types.AdminActionResult, error) {
+	normalized := make([]string, 0, len(domains))
+	seen := make(map[string]bool)
+	for _, d := range domains {
+		domain := utils.NormalizeDomain(d)
+		if domain == "" || !strings.Contains(domain, ".") || strings.ContainsAny(domain, "/:?# ") {
+			return nil, types.NewValidationError(types.ErrInvalidDomain.Error() + ": " + d)
+		}
+		if !seen[domain] {
+			seen[domain] = true
+			normalized = append(normalized, domain)
+		}
+	}
+
+	var newDomains []string
+	var urls []models.URL
+
+	return s.execute(ctx, "add_blocked_domains", dryRun,
+		func(tx *gorm.DB, result *types.AdminActionResult) error {
+			var existing []string
+			if err := tx.Model(&models.BlockedDomain{}).
+				Where("domain IN ?", normalized).
+				Pluck("domain", &existing).Error; err != nil {
+				return err
+			}
+			already := make(map[string]bool, len(existing))
+			for _, d := range existing {
+				already[d] = true
+			}
+			for _, d := range normalized {
+				if !already[d] {
+					newDomains = append(newDomains, d)
+				}
+			}
+			result.Add("domains", newDomains...)
+
+			// Narrow down with LIKE, then match hosts exactly in Go
+			for _, d := range normalized {
+				var candidates []models.URL
+				if err := tx.Where("long_url ILIKE ? AND deleted_at IS NULL AND disabled_at IS NULL", "%"+d+"%").
+					Find(&candidates).Error; err != nil {
+					return err
+				}
+				for _, u := range candidates {
+					if domainMatches(utils.ExtractDomain(u.LongURL), d) {
+						urls = append(urls, u)
+					}
+				}
+			}
+			urls = uniqueURLs(urls)
+			result.Add("urls", urlIDs(urls)...)
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			for _, d := range newDomains {
+				entry := &models.BlockedDomain{
+					ID:        uuid.New(),
+					Domain:    d,
+					Reason:    reason,
+					CreatedBy: &adminID,
+					CreatedAt: time.Now().UTC(),
+				}
+				if err := tx.Create(entry).Error; err != nil {
+					return err
+				}
+			}
+			if len(urls) == 0 {
+				return nil
+			}
+			if err := tx.Model(&models.URL{}).
+				Where("id IN ?", urlIDs(urls)).
+				UpdateColumn("disabled_at", time.Now().UTC()).Error; err != nil {
+				return err
+			}
+			return s.purgeURLCache(ctx, urls, false)
+		},
+	)
+}
+
+// ListPendingReviews returns anonymous links held by the abuse heuristics and
+// links quarantined by Safe Browsing, oldest first
+func (s *AdminService) ListPendingReviews(ctx context.Context, page, perPage int) ([]models.URL, int64, error) {
+	var urls []models.URL
+	var total int64
+
+	query := s.db.WithContext(ctx).Model(&models.URL{}).
+		Where("moderation IN ? AND deleted_at IS NULL", []string{models.ModerationPending, models.ModerationQuarantined})
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at ASC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&urls).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return urls, total, nil
+}
+
+// ReviewURL approves or rejects a held or quarantined link. Rejected links
+// are disabled and count against the reputation of the IP that created them;
+// approving a quarantined link marks the Safe Browsing match a false positive.
+func (s *AdminService) ReviewURL(ctx context.Context, urlID uuid.UUID, approve bool) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND moderation IN ?", urlID, []string{models.ModerationPending, models.ModerationQuarantined}).
+		First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	if approve && url.IsQuarantined() {
+		url.ThreatAllowed = true
+		url.ThreatType = ""
+	}
+	url.Moderation = models.ModerationApproved
+	if !approve {
+		now := time.Now().UTC()
+		url.Moderation = models.ModerationRejected
+		url.DisabledAt = &now
+	}
+	if err := s.db.WithContext(ctx).Model(&url).
+		Select("moderation", "disabled_at", "threat_allowed", "threat_type").
+		Updates(&url).Error; err != nil {
+		return nil, err
+	}
+
+	if !approve {
+		recordAbuseStrike(ctx, s.redisClient, url.CreatorIP)
+	}
+	// Drop any NOT_FOUND placeholder cached while the link was held
+	if err := s.purgeURLCache(ctx, []models.URL{url}, false); err != nil {
+		return nil, err
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", "review_url",
+		"url_id", url.ID,
+		"moderation", url.Moderation)
+
+	return &url, nil
+}
+
+// ListAwaitingApproval returns members' links waiting for approval, oldest first
+func (s *AdminService) ListAwaitingApproval(ctx context.Context, page, perPage int) ([]models.URL, int64, error) {
+	var urls []models.URL
+	var total int64
+
+	query := s.db.WithContext(ctx).Model(&models.URL{}).
+		Where("moderation = ? AND deleted_at IS NULL", models.ModerationAwaitingApproval)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("created_at ASC").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&urls).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return urls, total, nil
+}
+
+// ReviewApprovals approves or rejects links awaiting approval in bulk.
+// Approved links go live; rejected links are disabled. IDs that aren't
+// awaiting approval are skipped. Owners are emailed the decision.
+func (s *AdminService) ReviewApprovals(ctx context.Context, urlIDs []uuid.UUID, approve, dryRun bool) (*types.AdminActionResult, error) {
+	action := "approve_links"
+	if !approve {
+		action = "reject_links"
+	}
+
+	var urls []models.URL
+	result, err := s.execute(ctx, action, dryRun,
+		func(tx *gorm.DB, result *types.AdminActionResult) error {
+			if err := tx.Where("id IN ? AND moderation = ? AND deleted_at IS NULL", urlIDs, models.ModerationAwaitingApproval).
+				Find(&urls).Error; err != nil {
+				return err
+			}
+			for _, url := range urls {
+				result.Add("urls", url.ID.String())
+			}
+			return nil
+		},
+		func(tx *gorm.DB) error {
+			updates := map[string]interface{}{"moderation": models.ModerationApproved}
+			if !approve {
+				updates = map[string]interface{}{
+					"moderation":  models.ModerationRejected,
+					"disabled_at": time.Now().UTC(),
+				}
+			}
+			return tx.Model(&models.URL{}).
+				Where("id IN ? AND moderation = ?", urlIDs, models.ModerationAwaitingApproval).
+				Updates(updates).Error
+		},
+	)
+	if err != nil || dryRun || len(urls) == 0 {
+		return result, err
+	}
+
+	// Drop the held placeholders so the next click sees the decision
+	if err := s.purgeURLCache(ctx, urls, false); err != nil {
+		return nil, err
+	}
+	if s.linkApproval != nil {
+		s.linkApproval.NotifyOwners(ctx, urls, approve)
+	}
+	return result, nil
+}
+
+// CreateInviteCodes generates registration invite codes for invite-only mode
+func (s *AdminService) CreateInviteCodes(ctx context.Context, adminID uuid.UUID, req *models.CreateInviteCodesRequest) ([]models.InviteCode, error) {
+	count := req.Count
+	if count == 0 {
+		count = 1
+	}
+	maxUses := req.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		expiry := time.Now().UTC().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &expiry
+	}
+
+	invites := make([]models.InviteCode, count)
+	for i := range invites {
+		code, err := generateInviteCode()
+		if err != nil {
+			return nil, err
+		}
+		invites[i] = models.InviteCode{
+			ID:        uuid.New(),
+			Code:      code,
+			MaxUses:   maxUses,
+			Note:      req.Note,
+			ExpiresAt: expiresAt,
+			CreatedBy: &adminID,
+			CreatedAt: time.Now().UTC(),
+		}
+	}
+
+	if err := s.db.WithContext(ctx).Create(&invites).Error; err != nil {
+		return nil, err
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", "create_invite_codes",
+		"count", count,
+		"max_uses", maxUses)
+
+	return invites, nil
+}
+
+// ListInviteCodes returns all invite codes, newest first
+func (s *AdminService) ListInviteCodes(ctx context.Context) ([]models.InviteCode, error) {
+	var invites []models.InviteCode
+	if err := s.db.WithContext(ctx).Order("created_at DESC").Find(&invites).Error; err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// RevokeInviteCode stops an invite code from being redeemed
+func (s *AdminService) RevokeInviteCode(ctx context.Context, inviteID uuid.UUID) error {
+	result := s.db.WithContext(ctx).Model(&models.InviteCode{}).
+		Where("id = ? AND revoked_at IS NULL", inviteID).
+		UpdateColumn("revoked_at", time.Now().UTC())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return types.ErrInviteNotFound
+	}
+	return nil
+}
+
+// generateInviteCode returns a human-friendly code like "LYNX-7KQ2-M9XD"
+func generateInviteCode() (string, error) {
+	const alphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no 0/O or 1/I
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	code := make([]byte, len(raw))
+	for i, b := range raw {
+		code[i] = alphabet[int(b)%len(alphabet)]
+	}
+	return "LYNX-" + string(code[:4]) + "-" + string(code[4:]), nil
+}
+
+// domainExpr extracts the normalized host of long_url in SQL (scheme, userinfo,
+// port and a leading "www." stripped), matching utils.NormalizeDomain
+const domainExpr = `lower(regexp_replace(substring(long_url from '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/]*@)?([^/:?#]+)'), '^www\.', ''))`
+
+// GetDomainStats reports the most shortened destination domains, domains
+// first seen in the last 24 hours and the domains with the highest flag rate
+func (s *AdminService) GetDomainStats(ctx context.Context, limit int) (*types.DomainReport, error) {
+	since := time.Now().UTC().Add(-24 * time.Hour)
+
+	query := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&models.URL{}).
+			Select(domainExpr+" AS domain, "+
+				"COUNT(*) AS links, "+
+				"COUNT(*) FILTER (WHERE created_at >= ?) AS links24h, "+
+				"COUNT(*) FILTER (WHERE disabled_at IS NOT NULL OR moderation IN ?) AS flagged, "+
+				"MIN(created_at) AS first_seen, MAX(created_at) AS last_seen",
+				since, []string{models.ModerationPending, models.ModerationRejected}).
+			Where("deleted_at IS NULL").
+			Group("domain").
+			Having(domainExpr + " IS NOT NULL").
+			Limit(limit)
+	}
+
+	report := &types.DomainReport{}
+	if err := query().Order("links DESC").Scan(&report.Top).Error; err != nil {
+		return nil, err
+	}
+	if err := query().Having("MIN(created_at) >= ?", since).Order("links DESC").Scan(&report.New).Error; err != nil {
+		return nil, err
+	}
+	flagged := "COUNT(*) FILTER (WHERE disabled_at IS NOT NULL OR moderation IN ?)"
+	flaggedStates := []string{models.ModerationPending, models.ModerationRejected}
+	if err := query().Having(flagged+" > 0", flaggedStates).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:  flagged + "::float / COUNT(*) DESC, flagged DESC",
+			Vars: []interface{}{flaggedStates},
+		}}).
+		Scan(&report.Flagged).Error; err != nil {
+		return nil, err
+	}
+
+	for _, list := range [][]types.DomainStats{report.Top, report.New, report.Flagged} {
+		for i := range list {
+			if list[i].Links > 0 {
+				list[i].FlagRate = float64(list[i].Flagged) / float64(list[i].Links)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// GetPasswordHashStats counts password hashes by version (current parameters,
+// outdated parameters, legacy format)
+func (s *AdminService) GetPasswordHashStats(ctx context.Context) (*types.PasswordHashReport, error) {
+	const phcPattern = "$argon2id$%"
+	prefix := models.PasswordHashPrefix()
+	report := &types.PasswordHashReport{
+		Params: strings.Trim(prefix, "$"),
+	}
+
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE password LIKE ?) AS current,
+			COUNT(*) FILTER (WHERE password LIKE ? AND password NOT LIKE ?) AS outdated,
+			COUNT(*) FILTER (WHERE password NOT LIKE ?) AS legacy`,
+			prefix+"%", phcPattern, prefix+"%", phcPattern).
+		Scan(report).Error; err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// purgeURLCache drops the cached redirect and QR code of the given links.
+// Click counters and visitor state are only dropped when the links themselves
+// are deleted.
+func (s *AdminService) purgeURLCache(ctx context.Context, urls []models.URL, withClicks bool) error {
+	pipe := s.redisClient.Pipeline()
+	for _, u := range urls {
+		if withClicks {
+			pipe.Del(ctx, urlRedisKeys(u.ShortCode)...)
+		} else {
+			pipe.Del(ctx, append(qrRedisKeys(u.ShortCode), getCacheKey(u.ShortCode))...)
+		}
+	}
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func urlIDs(urls []models.URL) []string {
+	ids := make([]string, len(urls))
+	for i, u := range urls {
+		ids[i] = u.ID.String()
+	}
+	return ids
+}
+
+func uniqueURLs(urls []models.URL) []models.URL {
+	seen := make(map[uuid.UUID]bool, len(urls))
+	unique := urls[:0]
+	for _, u := range urls {
+		if !seen[u.ID] {
+			seen[u.ID] = true
+			unique = append(unique, u)
+		}
+	}
+	return unique
+}
+
+// domainMatches reports whether host equals domain or is one of its subdomains
+func domainMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+type AnalyticsService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	listeners   []interfaces.ClickListener
+	sampler     *ClickSampler
+}
+
+func NewAnalyticsService(db *gorm.DB, redisClient *redis.Client) *AnalyticsService {
+	return &AnalyticsService{
+		db:          db,
+		redisClient: redisClient,
+	}
+}
+
+// SetSampler enables sampling of detailed click events under load
+func (s *AnalyticsService) SetSampler(sampler *ClickSampler) {
+	s.sampler = sampler
+}
+
+// AddClickListener registers a consumer notified of every recorded click
+func (s *AnalyticsService) AddClickListener(listener interfaces.ClickListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+// RecordClick stores a detailed click event asynchronously so the redirect
+// path never waits on Postgres. Aggregate counters are handled by URLService.
+func (s *AnalyticsService) RecordClick(ctx context.Context, event *models.ClickEvent) {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.ClickedAt.IsZero() {
+		event.ClickedAt = time.Now().UTC()
+	}
+	event.Browser, event.Device = utils.ParseUserAgent(event.UserAgent)
+
+	for _, listener := range s.listeners {
+		listener.NotifyClick(event)
+	}
+
+	// Counters stay exact (URLService); only the detailed row is sampled
+	keep, weight := s.sampler.Sample()
+	if !keep {
+		return
+	}
+	// Listeners share event, so the weighted row is a copy
+	row := *event
+	row.Weight = weight
+
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := s.db.WithContext(bgCtx).Create(&row).Error; err != nil {
+			utils.Logger.Error("Failed to record click event",
+				"short_code", row.ShortCode,
+				"error", err)
+		}
+	}()
+}
+
+// GetUserAnalytics aggregates click data across all of a user's links.
+// CTR values are each link's share of the user's total clicks (percent),
+// and AverageCTR is the average number of clicks per link.
+func (s *AnalyticsService) GetUserAnalytics(ctx context.Context, userID uuid.UUID) (*types.Analytics, error) {
+	var urls []models.URL
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Find(&urls).Error; err != nil {
+		return nil, err
+	}
+
+	s.applyRealtimeClicks(ctx, urls)
+
+	analytics := &types.Analytics{
+		TotalLinks:    int64(len(urls)),
+		TopPerformers: []types.URLSummary{},
+	}
+	for _, u := range urls {
+		analytics.TotalClicks += u.Clicks
+	}
+	if analytics.TotalLinks > 0 {
+		analytics.AverageCTR = float64(analytics.TotalClicks) / float64(analytics.TotalLinks)
+	}
+
+	sort.Slice(urls, func(i, j int) bool { return urls[i].Clicks > urls[j].Clicks })
+	for i := 0; i < len(urls) && i < 5; i++ {
+		analytics.TopPerformers = append(analytics.TopPerformers, types.URLSummary{
+			ShortURL:    urls[i].ShortURL,
+			LongURL:     urls[i].LongURL,
+			TotalClicks: urls[i].Clicks,
+			CTR:         percentOf(urls[i].Clicks, analytics.TotalClicks),
+		})
+	}
+
+	periods, err := s.periodStats(s.dailyClicks(ctx, "short_code IN (?)", s.userLinks(userID)))
+	if err != nil {
+		return nil, err
+	}
+	analytics.ClicksByPeriod = periods
+	analytics.Growth = growthFrom(periods)
+
+	return analytics, nil
+}
+
+// GetURLAnalytics returns detailed analytics for a single link owned by the user
+func (s *AnalyticsService) GetURLAnalytics(ctx context.Context, userID, urlID uuid.UUID) (*types.URLAnalytics, error) {
+	url, err := s.findOwnedURL(ctx, userID, urlID)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := []models.URL{*url}
+	s.applyRealtimeClicks(ctx, urls)
+
+	events := func() *gorm.DB {
+		return s.db.WithContext(ctx).Model(&models.ClickEvent{}).Where("short_code = ?", url.ShortCode)
+	}
+
+	periods, err := s.periodStats(s.dailyClicks(ctx, "short_code = ?", url.ShortCode))
+	if err != nil {
+		return nil, err
+	}
+
+	analytics := &types.URLAnalytics{
+		ShortURL:       url.ShortURL,
+		LongURL:        url.LongURL,
+		TotalClicks:    urls[0].Clicks,
+		ClicksByPeriod: periods,
+		Growth:         growthFrom(periods),
+		Countries:      map[string]int64{},
+	}
+
+	if analytics.TopReferrers, err = countBy(events(), "referer", 10); err != nil {
+		return nil, err
+	}
+	if analytics.Browsers, err = countBy(events(), "browser", 10); err != nil {
+		return nil, err
+	}
+	if analytics.Devices, err = countBy(events(), "device", 10); err != nil {
+		return nil, err
+	}
+	if analytics.Variants, err = countBy(events(), "variant", 50); err != nil {
+		return nil, err
+	}
+
+	return analytics, nil
+}
+
+// GetCampaignStats groups UTM-tagged clicks by source/medium/campaign, either
+// across all of the user's links or for a single link when urlID is set
+func (s *AnalyticsService) GetCampaignStats(ctx context.Context, userID uuid.UUID, urlID *uuid.UUID) ([]types.CampaignStats, error) {
+	query := s.userEvents(ctx, userID)
+	if urlID != nil {
+		url, err := s.findOwnedURL(ctx, userID, *urlID)
+		if err != nil {
+			return nil, err
+		}
+		query = s.db.WithContext(ctx).Model(&models.ClickEvent{}).Where("short_code = ?", url.ShortCode)
+	}
+
+	stats := []types.CampaignStats{}
+	err := query.
+		Select(`utm_source, utm_medium, utm_campaign,
+			SUM(weight) AS clicks,
+			COUNT(DISTINCT short_code) AS links,
+			MIN(clicked_at) AS first_click_at,
+			MAX(clicked_at) AS last_click_at`).
+		Where("utm_source <> '' OR utm_medium <> '' OR utm_campaign <> ''").
+		Group("utm_source, utm_medium, utm_campaign").
+		Order("clicks DESC").
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// ComparePeriods returns clicks for [from, to) and for the preceding period of
+// equal length, so the dashboard can show the change with a single call
+func (s *AnalyticsService) ComparePeriods(ctx context.Context, userID uuid.UUID, urlID *uuid.UUID, from, to time.Time) (*types.PeriodComparison, error) {
+	length := to.Sub(from)
+	if length <= 0 || length > 366*24*time.Hour {
+		return nil, types.ErrInvalidDateRange
+	}
+
+	events := func() *gorm.DB { return s.dailyClicks(ctx, "short_code IN (?)", s.userLinks(userID)) }
+	if urlID != nil {
+		url, err := s.findOwnedURL(ctx, userID, *urlID)
+		if err != nil {
+			return nil, err
+		}
+		events = func() *gorm.DB { return s.dailyClicks(ctx, "short_code = ?", url.ShortCode) }
+	}
+
+	current, err := s.periodSummary(events(), from, to)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.periodSummary(events(), from.Add(-length), from)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.PeriodComparison{
+		Current:       *current,
+		Previous:      *previous,
+		ChangePercent: percentChange(current.Clicks, previous.Clicks),
+	}, nil
+}
+
+// periodSummary counts clicks in [from, to) with a per-day breakdown
+func (s *AnalyticsService) periodSummary(query *gorm.DB, from, to time.Time) (*types.PeriodSummary, error) {
+	var rows []struct {
+		Day    time.Time
+		Clicks int64
+	}
+	err := query.
+		Select("date_trunc('day', clicked_at) AS day, SUM(weight) AS clicks").
+		Where("clicked_at >= ? AND clicked_at < ?", from, to).
+		Group("day").
+		Order("day").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &types.PeriodSummary{
+		From:  from,
+		To:    to,
+		Daily: make([]types.DailyClicks, 0, len(rows)),
+	}
+	for _, r := range rows {
+		summary.Clicks += r.Clicks
+		summary.Daily = append(summary.Daily, types.DailyClicks{
+			Date:   r.Day.UTC().Format("2006-01-02"),
+			Clicks: r.Clicks,
+		})
+	}
+
+	return summary, nil
+}
+
+func (s *AnalyticsService) findOwnedURL(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+	return &url, nil
+}
+
+// userEvents scopes click events to the links owned by a user
+func (s *AnalyticsService) userEvents(ctx context.Context, userID uuid.UUID) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.ClickEvent{}).
+		Where("short_code IN (?)", s.userLinks(userID))
+}
+
+// userLinks selects the short codes of the links owned by a user
+func (s *AnalyticsService) userLinks(userID uuid.UUID) *gorm.DB {
+	return s.db.Model(&models.URL{}).
+		Select("short_code").
+		Where("user_id = ? AND deleted_at IS NULL", userID)
+}
+
+// dailyClicks is the click history behind totals and per-day counts: the raw
+// events plus the daily rollups left by RetentionService for events past
+// their retention period. Rows have short_code, clicked_at and weight like
+// click events; breakdowns by referrer, device and the like only exist for
+// raw events and keep querying those.
+func (s *AnalyticsService) dailyClicks(ctx context.Context, where string, args ...interface{}) *gorm.DB {
+	events := s.db.Model(&models.ClickEvent{}).
+		Select("short_code, clicked_at, weight").
+		Where(where, args...)
+	rollups := s.db.Model(&models.ClickRollup{}).
+		Select("short_code, date::timestamptz AS clicked_at, clicks AS weight").
+		Where(where, args...)
+	return s.db.WithContext(ctx).Table("(? UNION ALL ?) AS clicks", events, rollups)
+}
+
+// applyRealtimeClicks replaces DB click counts with the Redis counters when available
+func (s *AnalyticsService) applyRealtimeClicks(ctx context.Context, urls []models.URL) {
+	if len(urls) == 0 {
+		return
+	}
+
+	keys := make([]string, len(urls))
+	for i, u := range urls {
+		keys[i] = getClicksKey(u.ShortCode)
+	}
+
+	values, err := s.redisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return
+	}
+
+	for i, v := range values {
+		str, ok := v.(string)
+		if !ok {
+			continue
+		}
+		if clicks, err := strconv.ParseInt(str, 10, 64); err == nil && clicks > urls[i].Clicks {
+			urls[i].Clicks = clicks
+		}
+	}
+}
+
+func (s *AnalyticsService) periodStats(query *gorm.DB) (*types.PeriodStats, error) {
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	yesterday := today.AddDate(0, 0, -1)
+	weekday := (int(today.Weekday()) + 6) % 7 // Monday = 0
+	thisWeek := today.AddDate(0, 0, -weekday)
+	lastWeek := thisWeek.AddDate(0, 0, -7)
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	lastMonth := thisMonth.AddDate(0, -1, 0)
+
+	var stats types.PeriodStats
+	err := query.Select(`
+		COALESCE(SUM(weight) FILTER (WHERE clicked_at >= ?), 0) AS today,
+		COALESCE(SUM(weight) FILTER (WHERE clicked_at >= ? AND clicked_at < ?), 0) AS yesterday,
+		COALESCE(SUM(weight) FILTER (WHERE clicked_at >= ?), 0) AS this_week,
+		COALESCE(SUM(weight) FILTER (WHERE clicked_at >= ? AND clicked_at < ?), 0) AS last_week,
+		COALESCE(SUM(weight) FILTER (WHERE clicked_at >= ?), 0) AS this_month,
+		COALESCE(SUM(weight) FILTER (WHERE clicked_at >= ? AND clicked_at < ?), 0) AS last_month,
+		COALESCE(SUM(weight), 0) AS total`,
+		today,
+		yesterday, today,
+		thisWeek,
+		lastWeek, thisWeek,
+		thisMonth,
+		lastMonth, thisMonth,
+	).Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
+
+// countBy returns the top N values of a click event column with their counts
+func countBy(query *gorm.DB, column string, limit int) (map[string]int64, error) {
+	var rows []struct {
+		Value string
+		Count int64
+	}
+	err := query.
+		Select(column + " AS value, SUM(weight) AS count").
+		Where(column + " <> ''").
+		Group(column).
+		Order("count DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		result[r.Value] = r.Count
+	}
+	return result, nil
+}
+
+func growthFrom(p *types.PeriodStats) types.GrowthStats {
+	return types.GrowthStats{
+		Daily:   percentChange(p.Today, p.Yesterday),
+		Weekly:  percentChange(p.ThisWeek, p.LastWeek),
+		Monthly: percentChange(p.ThisMonth, p.LastMonth),
+	}
+}
+
+// percentChange returns the relative change from previous to current in percent
+func percentChange(current, previous int64) float64 {
+	if previous == 0 {
+		if current > 0 {
+			return 100
+		}
+		return 0
+	}
+	return float64(current-previous) / float64(previous) * 100
+}
+
+func percentOf(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total) * 100
+}
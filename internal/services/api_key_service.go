@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	apiKeyPrefix      = "lynx_"
+	apiKeyCacheTTL    = 5 * time.Minute
+	apiKeyTouchPeriod = time.Minute
+	maxAPIKeysPerUser = 25
+)
+
+type APIKeyService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+}
+
+func NewAPIKeyService(db *gorm.DB, redisClient *redis.Client) *APIKeyService {
+	return &APIKeyService{
+		db:          db,
+		redisClient: redisClient,
+	}
+}
+
+// CreateAPIKey issues a new key, limited to scopes when any are given; the
+// plaintext key is only returned here
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, scopes []string) (*models.APIKey, error) {
+	var active int64
+	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Count(&active).Error; err != nil {
+		return nil, err
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, types.NewValidationError(fmt.Sprintf("at most %d active API keys are allowed", maxAPIKeysPerUser))
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	key := apiKeyPrefix + hex.EncodeToString(raw)
+
+	apiKey := &models.APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      strings.TrimSpace(name),
+		Prefix:    key[:len(apiKeyPrefix)+6],
+		KeyHash:   hashAPIKey(key),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.db.WithContext(ctx).Create(apiKey).Error; err != nil {
+		return nil, err
+	}
+
+	apiKey.Key = key
+	return apiKey, nil
+}
+
+// ListAPIKeys returns the user's keys (without the keys themselves)
+func (s *APIKeyService) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey disables a key immediately
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, userID, keyID uuid.UUID) error {
+	var apiKey models.APIKey
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", keyID, userID).
+		First(&apiKey).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrAPIKeyNotFound
+		}
+		return err
+	}
+	if apiKey.RevokedAt != nil {
+		return nil
+	}
+
+	if err := s.db.WithContext(ctx).Model(&apiKey).
+		Update("revoked_at", time.Now().UTC()).Error; err != nil {
+		return err
+	}
+	return s.redisClient.Del(ctx, getAPIKeyCacheKey(apiKey.KeyHash)).Err()
+}
+
+// RevokeAllAPIKeys disables every key of the user, e.g. when a service
+// account is deleted
+func (s *APIKeyService) RevokeAllAPIKeys(ctx context.Context, userID uuid.UUID) error {
+	var keys []models.APIKey
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Find(&keys).Error; err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := s.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now().UTC()).Error; err != nil {
+		return err
+	}
+
+	cacheKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		cacheKeys = append(cacheKeys, getAPIKeyCacheKey(key.KeyHash))
+	}
+	return s.redisClient.Del(ctx, cacheKeys...).Err()
+}
+
+// Authenticate resolves an X-API-Key value to its key (owner and scopes).
+// Lookups are cached briefly in Redis (so last_used_at is approximate);
+// revocation clears the cache entry.
+func (s *APIKeyService) Authenticate(ctx context.Context, key string) (*models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, types.ErrInvalidAPIKey
+	}
+	hash := hashAPIKey(key)
+
+	if cached, err := s.redisClient.Get(ctx, getAPIKeyCacheKey(hash)).Result(); err == nil {
+		var apiKey cachedAPIKey
+		if err := json.Unmarshal([]byte(cached), &apiKey); err == nil && apiKey.UserID != uuid.Nil {
+			return &models.APIKey{UserID: apiKey.UserID, Scopes: apiKey.Scopes}, nil
+		}
+	}
+
+	var apiKey models.APIKey
+	err := s.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = api_keys.user_id").
+		Where("api_keys.key_hash = ? AND api_keys.revoked_at IS NULL", hash).
+		Where("users.suspended_at IS NULL AND users.deleted_at IS NULL").
+		First(&apiKey).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	if data, err := json.Marshal(cachedAPIKey{UserID: apiKey.UserID, Scopes: apiKey.Scopes}); err == nil {
+		s.redisClient.Set(ctx, getAPIKeyCacheKey(hash), data, apiKeyCacheTTL)
+	}
+	s.touch(&apiKey)
+	return &apiKey, nil
+}
+
+// cachedAPIKey is what Authenticate needs from a key, cached under auth:apikey:
+type cachedAPIKey struct {
+	UserID uuid.UUID `json:"u"`
+	Scopes []string  `json:"s,omitempty"`
+}
+
+// touch records when a key was last used, at most once per apiKeyTouchPeriod
+func (s *APIKeyService) touch(apiKey *models.APIKey) {
+	now := time.Now().UTC()
+	if apiKey.LastUsedAt != nil && now.Sub(*apiKey.LastUsedAt) < apiKeyTouchPeriod {
+		return
+	}
+
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := s.db.WithContext(bgCtx).Model(&models.APIKey{}).
+			Where("id = ?", apiKey.ID).
+			UpdateColumn("last_used_at", now).Error; err != nil {
+			utils.Logger.Error("Failed to update API key usage", "api_key_id", apiKey.ID, "error", err)
+		}
+	}()
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func getAPIKeyCacheKey(hash string) string {
+	return fmt.Sprintf("auth:apikey:%s", hash)
+}
@@ -2,20 +2,29 @@ package services
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type AuthService struct {
 	db          *gorm.DB
 	redisClient *redis.Client
+	inviteOnly  bool
+	breaches    *BreachChecker
+	disposable  *DisposableEmailChecker
+	emailQueue  *EmailQueue
 }
 
 func NewAuthService(db *gorm.DB, redisClient *redis.Client) *AuthService {
@@ -25,7 +34,35 @@ func NewAuthService(db *gorm.DB, redisClient *redis.Client) *AuthService {
 	}
 }
 
-func (s *AuthService) Register(ctx context.Context, user *models.User) error {
+// SetInviteOnly makes registration require a valid invite code (soft launch)
+func (s *AuthService) SetInviteOnly(enabled bool) {
+	s.inviteOnly = enabled
+}
+
+// SetBreachChecker rejects known-breached passwords on registration and password changes
+func (s *AuthService) SetBreachChecker(checker *BreachChecker) {
+	s.breaches = checker
+}
+
+// SetDisposableEmailChecker rejects throwaway addresses on registration and,
+// for addresses without an account, on password reset
+func (s *AuthService) SetDisposableEmailChecker(checker *DisposableEmailChecker) {
+	s.disposable = checker
+}
+
+func (s *AuthService) Register(ctx context.Context, user *models.User, inviteCode string) error {
+	if err := s.disposable.Check(user.Email); err != nil {
+		return err
+	}
+
+	if s.inviteOnly && strings.TrimSpace(inviteCode) == "" {
+		return types.ErrInviteCodeRequired
+	}
+
+	if err := s.breaches.Check(ctx, user.Password); err != nil {
+		return err
+	}
+
 	var existingUser models.User
 	if err := s.db.WithContext(ctx).Where("email = ?", user.Email).First(&existingUser).Error; err == nil {
 		return types.ErrUserExists
@@ -36,22 +73,225 @@ func (s *AuthService) Register(ctx context.Context, user *models.User) error {
 		return err
 	}
 
-	return s.db.WithContext(ctx).Create(user).Error
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if s.inviteOnly {
+			if err := redeemInviteCode(tx, inviteCode); err != nil {
+				return err
+			}
+		}
+		return tx.Create(user).Error
+	})
+}
+
+// redeemInviteCode atomically consumes one use of a usable invite code
+func redeemInviteCode(tx *gorm.DB, code string) error {
+	result := tx.Model(&models.InviteCode{}).
+		Where("code = ? AND revoked_at IS NULL AND uses < max_uses AND (expires_at IS NULL OR expires_at > ?)",
+			strings.TrimSpace(code), time.Now().UTC()).
+		UpdateColumn("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return types.ErrInvalidInviteCode
+	}
+	return nil
 }
 
 func (s *AuthService) Login(ctx context.Context, email, password string) (*models.User, error) {
+	// Locked emails are rejected before the password is even checked
+	if err := s.checkLoginLock(ctx, email); err != nil {
+		return nil, err
+	}
+
 	var user models.User
 	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		s.recordLoginFailure(ctx, email, nil)
 		return nil, types.ErrInvalidCredentials
 	}
 
-	if err := user.CheckPassword(password); err != nil {
+	// Service accounts have no password and only use their tokens
+	if user.IsServiceAccount() || user.CheckPassword(password) != nil {
+		s.recordLoginFailure(ctx, email, &user)
 		return nil, types.ErrInvalidCredentials
 	}
+	s.clearLoginFailures(ctx, email)
+
+	if user.IsSuspended() {
+		return nil, types.ErrAccountSuspended
+	}
+
+	if user.PasswordNeedsRehash() {
+		s.rehashPassword(ctx, &user, password)
+	}
 
 	return &user, nil
 }
 
+// rehashPassword upgrades a legacy or outdated hash while the plaintext is
+// known (right after a successful login). Failures only delay the upgrade.
+func (s *AuthService) rehashPassword(ctx context.Context, user *models.User, password string) {
+	upgraded := models.User{Password: password}
+	if err := upgraded.HashPassword(); err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to rehash password", "user_id", user.ID, "error", err)
+		return
+	}
+
+	from := user.PasswordHashVersion()
+
+	// Only replace the hash that was verified, in case it changed meanwhile
+	if err := s.db.WithContext(ctx).Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		UpdateColumn("password", upgraded.Password).Error; err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to store rehashed password", "user_id", user.ID, "error", err)
+		return
+	}
+	user.Password = upgraded.Password
+	utils.LoggerFromContext(ctx).Info("Password hash upgraded", "user_id", user.ID, "from", from)
+}
+
+// LoginWithGoogle signs in the account linked to a Google identity. Existing
+// password accounts with the same (Google-verified) email are linked; unknown
+// users are registered, subject to invite-only mode.
+func (s *AuthService) LoginWithGoogle(ctx context.Context, identity *types.OAuthIdentity, inviteCode string) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).
+		Where("google_id = ?", identity.Subject).
+		Or("LOWER(email) = ?", identity.Email).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "google_id IS NULL"}}).
+		First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err == nil {
+		if user.IsSuspended() {
+			return nil, types.ErrAccountSuspended
+		}
+		// Matched by email but already linked to a different Google account
+		if user.GoogleID != nil && *user.GoogleID != identity.Subject {
+			return nil, types.ErrInvalidCredentials
+		}
+		if user.GoogleID == nil || !user.EmailVerified {
+			now := time.Now().UTC()
+			user.GoogleID = &identity.Subject
+			if !user.EmailVerified {
+				user.EmailVerified = true
+				user.EmailVerifiedAt = &now
+			}
+			if err := s.db.WithContext(ctx).Model(&user).
+				Select("google_id", "email_verified", "email_verified_at").
+				Updates(&user).Error; err != nil {
+				return nil, err
+			}
+		}
+		return &user, nil
+	}
+
+	if s.inviteOnly && strings.TrimSpace(inviteCode) == "" {
+		return nil, types.ErrInviteCodeRequired
+	}
+
+	newUser, err := newOAuthUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	newUser.GoogleID = &identity.Subject
+
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if s.inviteOnly {
+			if err := redeemInviteCode(tx, inviteCode); err != nil {
+				return err
+			}
+		}
+		return tx.Create(newUser).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return newUser, nil
+}
+
+// LoginWithOIDC signs in the account linked to an identity from the
+// configured OIDC issuer, linking accounts with the same (issuer-verified)
+// email. Unknown users are created when autoProvision is on; the issuer
+// decides who may sign in, so no invite code is needed.
+func (s *AuthService) LoginWithOIDC(ctx context.Context, identity *types.OAuthIdentity, autoProvision bool) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).
+		Where("oidc_subject = ?", identity.Subject).
+		Or("LOWER(email) = ?", identity.Email).
+		Order(clause.OrderBy{Expression: clause.Expr{SQL: "oidc_subject IS NULL"}}).
+		First(&user).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if err == nil {
+		if user.IsSuspended() {
+			return nil, types.ErrAccountSuspended
+		}
+		if user.IsServiceAccount() {
+			return nil, types.ErrInvalidCredentials
+		}
+		// Matched by email but already bound to a different issuer subject
+		if user.OIDCSubject != nil && *user.OIDCSubject != identity.Subject {
+			return nil, types.ErrInvalidCredentials
+		}
+		if user.OIDCSubject == nil || !user.EmailVerified {
+			now := time.Now().UTC()
+			user.OIDCSubject = &identity.Subject
+			if !user.EmailVerified {
+				user.EmailVerified = true
+				user.EmailVerifiedAt = &now
+			}
+			if err := s.db.WithContext(ctx).Model(&user).
+				Select("oidc_subject", "email_verified", "email_verified_at").
+				Updates(&user).Error; err != nil {
+				return nil, err
+			}
+		}
+		return &user, nil
+	}
+
+	if !autoProvision {
+		return nil, types.ErrSSOAccountNotProvisioned
+	}
+
+	newUser, err := newOAuthUser(identity)
+	if err != nil {
+		return nil, err
+	}
+	newUser.OIDCSubject = &identity.Subject
+	if err := s.db.WithContext(ctx).Create(newUser).Error; err != nil {
+		return nil, err
+	}
+	return newUser, nil
+}
+
+// newOAuthUser builds the account for a first sign-in with a provider. OAuth
+// accounts get an unguessable password until the user sets one via reset.
+func newOAuthUser(identity *types.OAuthIdentity) (*models.User, error) {
+	passwordBytes := make([]byte, 32)
+	if _, err := rand.Read(passwordBytes); err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	user := &models.User{
+		ID:              uuid.New(),
+		Email:           identity.Email,
+		Password:        hex.EncodeToString(passwordBytes),
+		FirstName:       identity.FirstName,
+		LastName:        identity.LastName,
+		EmailVerified:   true,
+		EmailVerifiedAt: &now,
+	}
+	if err := user.HashPassword(); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 // ✅ OPTIMIZED: Hybrid session validation
 func (s *AuthService) GetUserByID(ctx context.Context, userID uuid.UUID) (*models.User, error) {
 	// 🚀 Try Redis cache first
@@ -81,17 +321,166 @@ func (s *AuthService) GetUserByID(ctx context.Context, userID uuid.UUID) (*model
 
 // ✅ OPTIMIZED: Session invalidation (logout)
 func (s *AuthService) InvalidateUserSessions(ctx context.Context, userID uuid.UUID) error {
-	// Store logout timestamp in Redis
-	// All tokens issued before this timestamp are invalid
-	return s.redisClient.Set(ctx,
-		getUserSessionKey(userID),
-		time.Now().Unix(),
-		24*time.Hour, // Keep for 24 hours
-	).Err()
+	// Store logout timestamp (ms) in Redis
+	// All tokens issued at or before this timestamp are rejected by AuthMiddleware
+	pipe := s.redisClient.Pipeline()
+	pipe.ZAdd(ctx, utils.RevokedSessionsKey, &redis.Z{
+		Score:  float64(time.Now().UnixMilli()),
+		Member: userID.String(),
+	})
+	pipe.Del(ctx, utils.ActiveSessionsKey(userID.String()))
+	_, err := pipe.Exec(ctx)
+	return err
 }
 
-func getUserSessionKey(userID uuid.UUID) string {
-	return fmt.Sprintf("session:%s", userID.String())
+// ChangePassword replaces the password after checking the current one and
+// signs out every existing session
+func (s *AuthService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := user.CheckPassword(currentPassword); err != nil {
+		return types.ErrIncorrectPassword
+	}
+
+	if err := s.breaches.Check(ctx, newPassword); err != nil {
+		return err
+	}
+
+	user.Password = newPassword
+	if err := user.HashPassword(); err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := s.db.WithContext(ctx).Model(&user).Update("password", user.Password).Error; err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	s.redisClient.Del(ctx, fmt.Sprintf("user:%s", user.ID.String()))
+
+	return s.InvalidateUserSessions(ctx, user.ID)
+}
+
+// DeleteAccount permanently removes a user after confirming their password,
+// together with their links, click data, webhooks and API keys. Sessions are
+// revoked first so a failure part-way never leaves a usable token behind.
+func (s *AuthService) DeleteAccount(ctx context.Context, userID uuid.UUID, password string) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrUserNotFound
+		}
+		return err
+	}
+	if err := user.CheckPassword(password); err != nil {
+		return types.ErrIncorrectPassword
+	}
+
+	if err := s.InvalidateUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	var shortCodes, keyHashes []string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.URL{}).Where("user_id = ?", userID).
+			Pluck("short_code", &shortCodes).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.APIKey{}).Where("user_id = ?", userID).
+			Pluck("key_hash", &keyHashes).Error; err != nil {
+			return err
+		}
+
+		if len(shortCodes) > 0 {
+			if err := tx.Where("short_code IN ?", shortCodes).Delete(&models.ClickEvent{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("short_code IN ?", shortCodes).Delete(&models.ClickRollup{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.URL{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("webhook_id IN (?)", tx.Model(&models.Webhook{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Webhook{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.APIKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RefreshToken{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.SavedView{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.LoginEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.QRLogo{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.Branding{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&models.BioPage{}).Error; err != nil {
+			return err
+		}
+		// Workspaces the user owns are dissolved; the other members keep their links
+		owned := tx.Model(&models.Workspace{}).Select("id").Where("owner_id = ?", userID)
+		if err := tx.Model(&models.URL{}).Where("workspace_id IN (?)", owned).
+			UpdateColumn("workspace_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? OR workspace_id IN (?)", userID, owned).
+			Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("invited_by = ? OR workspace_id IN (?) OR email = ?", userID, owned, strings.ToLower(user.Email)).
+			Delete(&models.WorkspaceInvite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("owner_id = ?", userID).Delete(&models.Workspace{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.InviteCode{}).Where("created_by = ?", userID).
+			UpdateColumn("created_by", nil).Error; err != nil {
+			return err
+		}
+
+		// Hard delete so the email address can be registered again
+		return tx.Unscoped().Delete(&user).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []string{fmt.Sprintf("user:%s", userID), getFeedKey(userID)}
+	for _, code := range shortCodes {
+		keys = append(keys, urlRedisKeys(code)...)
+	}
+	for _, hash := range keyHashes {
+		keys = append(keys, getAPIKeyCacheKey(hash))
+	}
+	if err := s.redisClient.Del(ctx, keys...).Err(); err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to purge cache after account deletion", "user_id", userID, "error", err)
+	}
+
+	utils.LoggerFromContext(ctx).Info("Account deleted", "user_id", userID, "urls", len(shortCodes))
+	return nil
 }
 
 // RequestPasswordReset generates reset token and returns it
@@ -99,6 +488,13 @@ func (s *AuthService) RequestPasswordReset(ctx context.Context, email string) (s
 	var user models.User
 	if err := s.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Disposable addresses are only refused when there is no account:
+			// accounts registered before their domain was listed can still
+			// recover. Callers answer both errors alike so neither reveals
+			// whether the account exists.
+			if err := s.disposable.Check(email); err != nil {
+				return "", err
+			}
 			// Don't reveal if email exists for security
 			return "", nil
 		}
@@ -138,6 +534,10 @@ func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword stri
 		return fmt.Errorf("database error: %w", err)
 	}
 
+	if err := s.breaches.Check(ctx, newPassword); err != nil {
+		return err
+	}
+
 	user.Password = newPassword
 	if err := user.HashPassword(); err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
@@ -154,8 +554,13 @@ func (s *AuthService) ResetPassword(ctx context.Context, token, newPassword stri
 	pipe := s.redisClient.Pipeline()
 	pipe.Del(ctx, fmt.Sprintf("reset_token:%s", token))
 	pipe.Del(ctx, fmt.Sprintf("user:%s", user.ID.String()))
-	pipe.Del(ctx, getUserSessionKey(user.ID)) // Invalidate all sessions
+	pipe.Del(ctx, loginFailuresKey(user.Email), loginLockKey(user.Email))
 	pipe.Exec(ctx)
 
+	// Invalidate all sessions issued with the old password
+	if err := s.InvalidateUserSessions(ctx, user.ID); err != nil {
+		fmt.Printf("Warning: failed to invalidate sessions after password reset: %v\n", err)
+	}
+
 	return nil
 }
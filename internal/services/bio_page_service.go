@@ -0,0 +1,276 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	bioPageCacheTTL = 5 * time.Minute
+	bioViewsField   = "views"
+)
+
+var bioSlugPattern = regexp.MustCompile(`^[a-z0-9](?:[a-z0-9-]*[a-z0-9])?$`)
+
+// BioPageService manages users' link-in-bio pages
+type BioPageService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	urlPrefix   string
+}
+
+func NewBioPageService(db *gorm.DB, redisClient *redis.Client, urlPrefix string) *BioPageService {
+	return &BioPageService{db: db, redisClient: redisClient, urlPrefix: urlPrefix}
+}
+
+// GetBioPage returns the user's bio page with its view and click counts
+func (s *BioPageService) GetBioPage(ctx context.Context, userID uuid.UUID) (*types.BioPageDetails, error) {
+	var page models.BioPage
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&page).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrBioPageNotFound
+		}
+		return nil, err
+	}
+	return s.details(ctx, &page), nil
+}
+
+// SetBioPage creates or replaces the user's bio page. Every listed link must
+// belong to the user; counters survive a change of address.
+func (s *BioPageService) SetBioPage(ctx context.Context, userID uuid.UUID, req models.BioPageRequest) (*types.BioPageDetails, error) {
+	slug := strings.ToLower(strings.TrimSpace(req.Slug))
+	if !bioSlugPattern.MatchString(slug) {
+		return nil, types.ErrInvalidBioSlug
+	}
+
+	linkIDs := make([]uuid.UUID, 0, len(req.LinkIDs))
+	seen := make(map[uuid.UUID]bool, len(req.LinkIDs))
+	for _, id := range req.LinkIDs {
+		if !seen[id] {
+			seen[id] = true
+			linkIDs = append(linkIDs, id)
+		}
+	}
+	if len(linkIDs) > models.MaxBioPageLinks {
+		return nil, types.NewValidationError(fmt.Sprintf("a bio page can list at most %d links", models.MaxBioPageLinks))
+	}
+
+	var page models.BioPage
+	var oldSlug string
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if len(linkIDs) > 0 {
+			var owned int64
+			if err := tx.Model(&models.URL{}).
+				Where("id IN ? AND user_id = ? AND deleted_at IS NULL", linkIDs, userID).
+				Count(&owned).Error; err != nil {
+				return err
+			}
+			if owned != int64(len(linkIDs)) {
+				return types.ErrURLNotFound
+			}
+		}
+
+		var taken int64
+		if err := tx.Model(&models.BioPage{}).Where("slug = ? AND user_id <> ?", slug, userID).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return types.ErrBioPageSlugTaken
+		}
+
+		err := tx.Where("user_id = ?", userID).First(&page).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		oldSlug = page.Slug
+
+		page.UserID = userID
+		page.Slug = slug
+		page.Title = strings.TrimSpace(req.Title)
+		page.Description = strings.TrimSpace(req.Description)
+		page.LinkIDs = linkIDs
+		return tx.Save(&page).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.redisClient.Del(ctx, getBioPageCacheKey(slug), getBioPageCacheKey(oldSlug))
+	return s.details(ctx, &page), nil
+}
+
+// DeleteBioPage takes the user's bio page offline and drops its counters
+func (s *BioPageService) DeleteBioPage(ctx context.Context, userID uuid.UUID) error {
+	var page models.BioPage
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&page).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrBioPageNotFound
+		}
+		return err
+	}
+	if err := s.db.WithContext(ctx).Delete(&page).Error; err != nil {
+		return err
+	}
+
+	s.redisClient.Del(ctx, getBioPageCacheKey(page.Slug), getBioPageStatsKey(page.ID))
+	return nil
+}
+
+// GetPublicBioPage returns the page at slug with its live links in the
+// owner's order. Paused, expired, disabled and held links are left out. The
+// page is cached for a few minutes; countView records a visit.
+func (s *BioPageService) GetPublicBioPage(ctx context.Context, slug string, countView bool) (*types.PublicBioPage, error) {
+	slug = strings.ToLower(slug)
+	public, pageID, err := s.loadPublicBioPage(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if countView {
+		if err := s.redisClient.HIncrBy(ctx, getBioPageStatsKey(pageID), bioViewsField, 1).Err(); err != nil {
+			utils.LoggerFromContext(ctx).Warn("Failed to count bio page view", "slug", slug, "error", err)
+		}
+	}
+	return public, nil
+}
+
+// FollowBioLink counts a click on one of the page's links and returns the
+// short URL to forward the visitor to
+func (s *BioPageService) FollowBioLink(ctx context.Context, slug string, linkID uuid.UUID) (string, error) {
+	slug = strings.ToLower(slug)
+	public, pageID, err := s.loadPublicBioPage(ctx, slug)
+	if err != nil {
+		return "", err
+	}
+
+	for _, link := range public.Links {
+		if link.ID != linkID {
+			continue
+		}
+		if err := s.redisClient.HIncrBy(ctx, getBioPageStatsKey(pageID), linkID.String(), 1).Err(); err != nil {
+			utils.LoggerFromContext(ctx).Warn("Failed to count bio page click", "slug", slug, "error", err)
+		}
+		return link.ShortURL, nil
+	}
+	return "", types.ErrURLNotFound
+}
+
+// cachedBioPage is the Redis entry of a public bio page
+type cachedBioPage struct {
+	PageID uuid.UUID           `json:"page_id"`
+	Page   types.PublicBioPage `json:"page"`
+}
+
+func (s *BioPageService) loadPublicBioPage(ctx context.Context, slug string) (*types.PublicBioPage, uuid.UUID, error) {
+	if cached, err := s.redisClient.Get(ctx, getBioPageCacheKey(slug)).Bytes(); err == nil {
+		var entry cachedBioPage
+		if err := json.Unmarshal(cached, &entry); err == nil {
+			return &entry.Page, entry.PageID, nil
+		}
+	}
+
+	var page models.BioPage
+	if err := s.db.WithContext(ctx).Where("slug = ?", slug).First(&page).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, uuid.Nil, types.ErrBioPageNotFound
+		}
+		return nil, uuid.Nil, err
+	}
+
+	var urls []models.URL
+	if len(page.LinkIDs) > 0 {
+		if err := s.db.WithContext(ctx).
+			Where("id IN ? AND user_id = ? AND is_active = true AND deleted_at IS NULL AND disabled_at IS NULL", page.LinkIDs, page.UserID).
+			Where("COALESCE(moderation, '') NOT IN ?", []string{models.ModerationPending, models.ModerationAwaitingApproval, models.ModerationQuarantined}).
+			Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+			Find(&urls).Error; err != nil {
+			return nil, uuid.Nil, err
+		}
+	}
+	byID := make(map[uuid.UUID]*models.URL, len(urls))
+	for i := range urls {
+		byID[urls[i].ID] = &urls[i]
+	}
+
+	public := types.PublicBioPage{
+		Slug:        page.Slug,
+		Title:       page.Title,
+		Description: page.Description,
+		Links:       make([]types.BioPageLink, 0, len(urls)),
+	}
+	for _, id := range page.LinkIDs {
+		url, ok := byID[id]
+		if !ok {
+			continue
+		}
+		public.Links = append(public.Links, types.BioPageLink{
+			ID:       url.ID,
+			Title:    bioLinkTitle(url),
+			ShortURL: url.ShortURL,
+			Href:     fmt.Sprintf("%spage/%s/go/%s", s.urlPrefix, page.Slug, url.ID),
+		})
+	}
+
+	if data, err := json.Marshal(cachedBioPage{PageID: page.ID, Page: public}); err == nil {
+		s.redisClient.Set(ctx, getBioPageCacheKey(slug), data, bioPageCacheTTL)
+	}
+	return &public, page.ID, nil
+}
+
+// details adds the page's counters from Redis
+func (s *BioPageService) details(ctx context.Context, page *models.BioPage) *types.BioPageDetails {
+	details := &types.BioPageDetails{BioPage: *page, Clicks: map[uuid.UUID]int64{}}
+
+	counts, err := s.redisClient.HGetAll(ctx, getBioPageStatsKey(page.ID)).Result()
+	if err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to load bio page counters", "page_id", page.ID, "error", err)
+		return details
+	}
+	for field, value := range counts {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			continue
+		}
+		if field == bioViewsField {
+			details.Views = n
+		} else if id, err := uuid.Parse(field); err == nil {
+			details.Clicks[id] = n
+		}
+	}
+	return details
+}
+
+// bioLinkTitle labels a link on the page: its feed title, else the
+// destination's page title, else the short URL
+func bioLinkTitle(url *models.URL) string {
+	switch {
+	case url.Title != "":
+		return url.Title
+	case url.PageTitle != "":
+		return url.PageTitle
+	default:
+		return url.ShortURL
+	}
+}
+
+func getBioPageCacheKey(slug string) string {
+	return fmt.Sprintf("bio:page:%s", slug)
+}
+
+func getBioPageStatsKey(pageID uuid.UUID) string {
+	return fmt.Sprintf("bio:stats:%s", pageID)
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// brandingCacheTTL bounds how long redirects may show outdated branding if
+// an invalidation is lost
+const brandingCacheTTL = time.Hour
+
+// BrandingService stores per-account branding. Lookups are cached because
+// branded pages are served on the redirect path.
+type BrandingService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+}
+
+func NewBrandingService(db *gorm.DB, redisClient *redis.Client) *BrandingService {
+	return &BrandingService{db: db, redisClient: redisClient}
+}
+
+// GetBranding returns the account's branding; accounts that never set one
+// get an empty branding, which renders with the defaults
+func (s *BrandingService) GetBranding(ctx context.Context, userID uuid.UUID) (*models.Branding, error) {
+	key := getBrandingKey(userID)
+	if !utils.RedisDegraded() {
+		if cached, err := s.redisClient.Get(ctx, key).Result(); err == nil {
+			var branding models.Branding
+			if err := json.Unmarshal([]byte(cached), &branding); err == nil {
+				branding.UserID = userID
+				return &branding, nil
+			}
+		}
+	}
+
+	branding := models.Branding{UserID: userID}
+	err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&branding).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(&branding); err == nil {
+		s.redisClient.Set(ctx, key, data, brandingCacheTTL)
+	}
+	return &branding, nil
+}
+
+// UpdateBranding replaces the account's branding
+func (s *BrandingService) UpdateBranding(ctx context.Context, userID uuid.UUID, req models.BrandingRequest) (*models.Branding, error) {
+	branding := &models.Branding{
+		UserID:       userID,
+		LogoURL:      req.LogoURL,
+		PrimaryColor: req.PrimaryColor,
+		SenderName:   req.SenderName,
+		UpdatedAt:    time.Now().UTC(),
+	}
+
+	err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"logo_url", "primary_color", "sender_name", "updated_at"}),
+	}).Create(branding).Error
+	if err != nil {
+		return nil, err
+	}
+
+	s.redisClient.Del(ctx, getBrandingKey(userID))
+	return branding, nil
+}
+
+// DeleteBranding resets the account to the default branding
+func (s *BrandingService) DeleteBranding(ctx context.Context, userID uuid.UUID) error {
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.Branding{}).Error; err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, getBrandingKey(userID))
+	return nil
+}
+
+func getBrandingKey(userID uuid.UUID) string {
+	return fmt.Sprintf("brand:%s", userID)
+}
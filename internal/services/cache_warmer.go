@@ -26,8 +26,8 @@ func NewCacheWarmer(db *gorm.DB, redisClient *redis.Client) *CacheWarmer {
 func (cw *CacheWarmer) WarmTopURLs(ctx context.Context) error {
 	// Get top 1000 most clicked URLs
 	var urls []models.URL
-	if err := cw.db.WithContext(ctx).
-		Where("deleted_at IS NULL").
+	if err := cw.db.WithContext(ctx).Preload("Variants").
+		Where("deleted_at IS NULL AND disabled_at IS NULL AND COALESCE(moderation, '') <> ?", models.ModerationPending).
 		Order("clicks DESC").
 		Limit(1000).
 		Find(&urls).Error; err != nil {
@@ -39,12 +39,7 @@ func (cw *CacheWarmer) WarmTopURLs(ctx context.Context) error {
 	for _, url := range urls {
 		cacheKey := fmt.Sprintf("url:%s", url.ShortCode)
 
-		if url.ExpiresAt != nil {
-			cacheDuration := time.Until(*url.ExpiresAt)
-			pipe.Set(ctx, cacheKey, url.LongURL, cacheDuration)
-		} else {
-			pipe.Set(ctx, cacheKey, url.LongURL, 24*time.Hour)
-		}
+		pipe.Set(ctx, cacheKey, cacheValue(&url), urlCacheTTL(url.Clicks, url.ExpiresAt, false))
 	}
 
 	_, err := pipe.Exec(ctx)
@@ -0,0 +1,77 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// ClickSampler decides which clicks get a detailed event row. Below the QPS
+// threshold every click is kept; above it only a fraction is kept and each
+// kept event carries the weight of the clicks it stands for, so aggregates
+// stay unbiased. QPS is measured per instance.
+type ClickSampler struct {
+	threshold int64
+	rate      float64
+
+	mu       sync.Mutex
+	second   int64
+	current  int64
+	previous int64
+	sampling bool
+}
+
+func NewClickSampler(qpsThreshold int64, rate float64) *ClickSampler {
+	if rate <= 0 || rate > 1 {
+		rate = 0.1
+	}
+	return &ClickSampler{threshold: qpsThreshold, rate: rate}
+}
+
+// Sample records a click and returns whether to store its details and with
+// which weight
+func (s *ClickSampler) Sample() (keep bool, weight int) {
+	if s == nil || s.threshold <= 0 {
+		return true, 1
+	}
+
+	if !s.observe() {
+		return true, 1
+	}
+	if rand.Float64() < s.rate {
+		return true, int(math.Round(1 / s.rate))
+	}
+	return false, 0
+}
+
+// observe counts the click and reports whether the click rate is over the threshold
+func (s *ClickSampler) observe() bool {
+	now := time.Now().Unix()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now != s.second {
+		if now == s.second+1 {
+			s.previous = s.current
+		} else {
+			s.previous = 0
+		}
+		s.second = now
+		s.current = 0
+	}
+	s.current++
+
+	over := max(s.current, s.previous) > s.threshold
+	if over != s.sampling {
+		s.sampling = over
+		utils.Logger.Warn("Click event sampling changed",
+			"sampling", over,
+			"qps", max(s.current, s.previous),
+			"rate", s.rate)
+	}
+	return over
+}
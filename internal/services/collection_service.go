@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// CollectionService manages users' link folders
+type CollectionService struct {
+	db *gorm.DB
+}
+
+func NewCollectionService(db *gorm.DB) *CollectionService {
+	return &CollectionService{db: db}
+}
+
+// ListCollections returns the user's collections in name order with the
+// number of links in each
+func (s *CollectionService) ListCollections(ctx context.Context, userID uuid.UUID) ([]types.CollectionSummary, error) {
+	collections := []types.CollectionSummary{}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT c.*, COUNT(u.id) AS links
+		FROM collections c
+		LEFT JOIN urls u ON u.collection_id = c.id AND u.deleted_at IS NULL
+		WHERE c.user_id = ?
+		GROUP BY c.id
+		ORDER BY c.name`, userID).
+		Scan(&collections).Error
+	return collections, err
+}
+
+// CreateCollection adds a collection; names are unique per user
+func (s *CollectionService) CreateCollection(ctx context.Context, userID uuid.UUID, name string) (*models.Collection, error) {
+	collection := &models.Collection{UserID: userID, Name: strings.TrimSpace(name)}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Collection{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= models.MaxCollections {
+			return types.ErrTooManyCollections
+		}
+		if err := s.checkNameFree(tx, userID, collection.Name, uuid.Nil); err != nil {
+			return err
+		}
+		return tx.Create(collection).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// RenameCollection changes a collection's name
+func (s *CollectionService) RenameCollection(ctx context.Context, userID, collectionID uuid.UUID, name string) (*models.Collection, error) {
+	var collection models.Collection
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", collectionID, userID).First(&collection).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return types.ErrCollectionNotFound
+			}
+			return err
+		}
+
+		collection.Name = strings.TrimSpace(name)
+		if err := s.checkNameFree(tx, userID, collection.Name, collection.ID); err != nil {
+			return err
+		}
+		return tx.Select("name", "updated_at").Updates(&collection).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &collection, nil
+}
+
+// DeleteCollection removes a collection; its links stay, unfiled
+func (s *CollectionService) DeleteCollection(ctx context.Context, userID, collectionID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", collectionID, userID).Delete(&models.Collection{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return types.ErrCollectionNotFound
+		}
+		return tx.Model(&models.URL{}).
+			Where("collection_id = ?", collectionID).
+			UpdateColumn("collection_id", nil).Error
+	})
+}
+
+// SetURLCollection files a link in one of the user's collections, or takes
+// it out when collectionID is nil
+func (s *CollectionService) SetURLCollection(ctx context.Context, userID, urlID uuid.UUID, collectionID *uuid.UUID) (*models.URL, error) {
+	if collectionID != nil {
+		var count int64
+		if err := s.db.WithContext(ctx).Model(&models.Collection{}).
+			Where("id = ? AND user_id = ?", *collectionID, userID).
+			Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, types.ErrCollectionNotFound
+		}
+	}
+
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	url.CollectionID = collectionID
+	url.UpdatedAt = time.Now().UTC()
+	if err := s.db.WithContext(ctx).Model(&url).
+		Select("collection_id", "updated_at").
+		Updates(&url).Error; err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+func (s *CollectionService) checkNameFree(tx *gorm.DB, userID uuid.UUID, name string, exceptID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.Collection{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return types.ErrCollectionExists
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SetCrawlerPolicy updates how a link treats social crawlers, search engines
+// and bot clicks. Fields left nil in the request keep their current value.
+func (s *URLService) SetCrawlerPolicy(ctx context.Context, userID, urlID uuid.UUID, req models.SetCrawlerPolicyRequest) (*models.URL, error) {
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		if req.DisableUnfurl != nil {
+			url.DisableUnfurl = *req.DisableUnfurl
+		}
+		if req.AllowIndexing != nil {
+			url.AllowIndexing = *req.AllowIndexing
+		}
+		if req.CountBots != nil {
+			url.CountBots = *req.CountBots
+		}
+		url.UpdatedAt = time.Now().UTC()
+		if err := tx.Select("disable_unfurl", "allow_indexing", "count_bots", "updated_at").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// maxHistoryEntries bounds the history returned for one link
+const maxHistoryEntries = 200
+
+// recordDestinationChange appends a history entry when an edit moves the
+// link to a new destination; call it before url.LongURL is overwritten
+func recordDestinationChange(tx *gorm.DB, url *models.URL, editorID uuid.UUID, newURL string) error {
+	if url.LongURL == newURL {
+		return nil
+	}
+	return tx.Create(&models.DestinationChange{
+		URLID:     url.ID,
+		EditorID:  editorID,
+		OldURL:    url.LongURL,
+		NewURL:    newURL,
+		CreatedAt: time.Now().UTC(),
+	}).Error
+}
+
+// GetDestinationHistory returns the destination edits of a link the user
+// can manage, newest first
+func (s *URLService) GetDestinationHistory(ctx context.Context, userID, urlID uuid.UUID) ([]types.DestinationChangeEntry, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).Select("id").
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	history := []types.DestinationChangeEntry{}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT c.*, u.email AS editor_email
+		FROM destination_changes c
+		LEFT JOIN users u ON u.id = c.editor_id
+		WHERE c.url_id = ?
+		ORDER BY c.created_at DESC
+		LIMIT ?`, url.ID, maxHistoryEntries).
+		Scan(&history).Error
+	return history, err
+}
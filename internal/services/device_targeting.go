@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// deviceVariantPrefix marks clicks that took a device target, e.g. "device:ios"
+const deviceVariantPrefix = "device:"
+
+// unsafeTargetSchemes can't be redirected to, even as app deep links
+var unsafeTargetSchemes = map[string]bool{"javascript": true, "data": true, "vbscript": true, "file": true}
+
+// SetDeviceTargets replaces a link's per-platform destinations; empty
+// targets remove them
+func (s *URLService) SetDeviceTargets(ctx context.Context, userID, urlID uuid.UUID, targets models.DeviceTargets) (*models.URL, error) {
+	for _, target := range []*string{&targets.IOS, &targets.Android, &targets.Desktop} {
+		if *target == "" {
+			continue
+		}
+		parsed, err := url.Parse(*target)
+		if err != nil || parsed.Scheme == "" || unsafeTargetSchemes[strings.ToLower(parsed.Scheme)] {
+			return nil, types.NewValidationError("device targets must be web URLs or app deep links")
+		}
+		// App deep links are passed through as-is; web URLs get the usual destination checks
+		scheme := strings.ToLower(parsed.Scheme)
+		if scheme != "http" && scheme != "https" {
+			continue
+		}
+		if *target, err = s.validateDestination(ctx, *target); err != nil {
+			return nil, err
+		}
+	}
+
+	var link models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&link).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		link.DeviceTargets = nil
+		if !targets.IsZero() {
+			link.DeviceTargets = &targets
+		}
+		link.UpdatedAt = time.Now().UTC()
+		if err := s.destinationsChanged(tx, userID, &link); err != nil {
+			return err
+		}
+
+		if err := tx.Select("device_targets", "updated_at", "moderation").Updates(&link).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(link.ShortCode),
+			cacheValue(&link),
+			s.memoryBudget.URLCacheTTL(link.Clicks, link.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyHeld(ctx, &link)
+	s.notifyChanged(&link)
+	return &link, nil
+}
+
+// matchDeviceTarget returns the destination for the visitor's platform
+func matchDeviceTarget(targets *models.DeviceTargets, platform string) (destination, variant string, ok bool) {
+	if targets == nil {
+		return "", "", false
+	}
+	destination = targets.For(platform)
+	return destination, deviceVariantPrefix + platform, destination != ""
+}
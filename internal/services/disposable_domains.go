@@ -0,0 +1,72 @@
+package services
+
+// defaultDisposableDomains are well-known throwaway mailbox providers.
+// Extend or override it with DISPOSABLE_EMAIL_DOMAINS / DISPOSABLE_EMAIL_ALLOW.
+var defaultDisposableDomains = []string{
+	"10minutemail.com",
+	"10minutemail.net",
+	"20minutemail.com",
+	"33mail.com",
+	"anonbox.net",
+	"burnermail.io",
+	"discard.email",
+	"discardmail.com",
+	"dispostable.com",
+	"dropmail.me",
+	"emailondeck.com",
+	"fakeinbox.com",
+	"fakemail.net",
+	"getairmail.com",
+	"getnada.com",
+	"guerrillamail.biz",
+	"guerrillamail.com",
+	"guerrillamail.de",
+	"guerrillamail.info",
+	"guerrillamail.net",
+	"guerrillamail.org",
+	"guerrillamailblock.com",
+	"harakirimail.com",
+	"incognitomail.org",
+	"inboxkitten.com",
+	"jetable.org",
+	"mailcatch.com",
+	"maildrop.cc",
+	"mailinator.com",
+	"mailinator.net",
+	"mailinator2.com",
+	"mailnesia.com",
+	"mailpoof.com",
+	"mailsac.com",
+	"mintemail.com",
+	"moakt.com",
+	"mohmal.com",
+	"mytemp.email",
+	"mytrashmail.com",
+	"nada.email",
+	"sharklasers.com",
+	"spam4.me",
+	"spambox.us",
+	"spamgourmet.com",
+	"spamex.com",
+	"temp-mail.io",
+	"temp-mail.org",
+	"tempail.com",
+	"tempinbox.com",
+	"tempmail.com",
+	"tempmail.dev",
+	"tempmail.net",
+	"tempmailo.com",
+	"tempr.email",
+	"throwawaymail.com",
+	"tmail.ws",
+	"tmpmail.net",
+	"tmpmail.org",
+	"trash-mail.com",
+	"trashmail.com",
+	"trashmail.de",
+	"trashmail.net",
+	"wegwerfmail.de",
+	"yopmail.com",
+	"yopmail.fr",
+	"yopmail.net",
+}
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+)
+
+// DisposableEmailChecker rejects addresses at throwaway mailbox providers.
+// Subdomains of a listed domain are blocked too. A nil checker allows
+// every address.
+type DisposableEmailChecker struct {
+	domains map[string]struct{}
+}
+
+// NewDisposableEmailChecker starts from the bundled list (unless
+// useDefaults is false), adds the extra domains and removes the allowed ones
+func NewDisposableEmailChecker(useDefaults bool, extra, allowed []string) *DisposableEmailChecker {
+	domains := make(map[string]struct{}, len(defaultDisposableDomains)+len(extra))
+	if useDefaults {
+		for _, domain := range defaultDisposableDomains {
+			domains[domain] = struct{}{}
+		}
+	}
+	for _, domain := range extra {
+		domains[normalizeDomain(domain)] = struct{}{}
+	}
+	for _, domain := range allowed {
+		delete(domains, normalizeDomain(domain))
+	}
+	return &DisposableEmailChecker{domains: domains}
+}
+
+// Check returns types.ErrDisposableEmail for addresses at a blocked domain
+func (d *DisposableEmailChecker) Check(email string) error {
+	if d == nil {
+		return nil
+	}
+
+	_, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return nil
+	}
+
+	// mail.mailinator.com is checked as itself, then mailinator.com
+	domain = normalizeDomain(domain)
+	for domain != "" {
+		if _, blocked := d.domains[domain]; blocked {
+			return types.ErrDisposableEmail
+		}
+		_, parent, found := strings.Cut(domain, ".")
+		if !found || !strings.Contains(parent, ".") {
+			break
+		}
+		domain = parent
+	}
+	return nil
+}
+
+// Len is the number of blocked domains
+func (d *DisposableEmailChecker) Len() int {
+	if d == nil {
+		return 0
+	}
+	return len(d.domains)
+}
+
+func normalizeDomain(domain string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+}
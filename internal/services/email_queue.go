@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+const (
+	emailQueueKey       = "email:queue"
+	emailFailedKey      = "email:failed"
+	emailMaxAttempts    = 3
+	emailQueueBatchSize = 50
+	emailQueueInterval  = 5 * time.Second
+)
+
+// Email job kinds
+const (
+	EmailJobWelcome       = "welcome"
+	EmailJobOnboarding    = "onboarding"
+	EmailJobVerification  = "verification"
+	EmailJobAccountUnlock = "account_unlock"
+	EmailJobPasswordReset = "password_reset"
+	EmailJobMagicLink     = "magic_link"
+	// Link approval workflow, see LinkApproval
+	EmailJobApprovalDigest = "approval_digest"
+	EmailJobLinksReviewed  = "links_reviewed"
+)
+
+// EmailJob is a queued email. Jobs live in a Redis sorted set scored by the
+// time they are due, which also lets drip emails be scheduled ahead.
+type EmailJob struct {
+	ID       string    `json:"id"`
+	Kind     string    `json:"kind"`
+	UserID   uuid.UUID `json:"user_id"`
+	Step     int       `json:"step,omitempty"`
+	Token    string    `json:"token,omitempty"`
+	Approved bool      `json:"approved,omitempty"`
+	Links    []string  `json:"links,omitempty"`
+	Attempts int       `json:"attempts"`
+	LastErr  string    `json:"last_error,omitempty"`
+}
+
+// EmailFeatures toggles the lifecycle emails
+type EmailFeatures struct {
+	Welcome    bool
+	Onboarding bool
+}
+
+type EmailQueue struct {
+	db           *gorm.DB
+	redisClient  *redis.Client
+	emailService *EmailService
+	features     EmailFeatures
+}
+
+func NewEmailQueue(db *gorm.DB, redisClient *redis.Client, emailService *EmailService, features EmailFeatures) *EmailQueue {
+	return &EmailQueue{
+		db:           db,
+		redisClient:  redisClient,
+		emailService: emailService,
+		features:     features,
+	}
+}
+
+// Enqueue schedules a job to be sent at the given time
+func (q *EmailQueue) Enqueue(ctx context.Context, job EmailJob, at time.Time) error {
+	if job.ID == "" {
+		job.ID = uuid.New().String()
+	}
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return q.redisClient.ZAdd(ctx, emailQueueKey, &redis.Z{
+		Score:  float64(at.Unix()),
+		Member: payload,
+	}).Err()
+}
+
+// EnqueueSignup queues the welcome email and schedules the onboarding drip
+// for a newly registered user, according to the feature flags
+func (q *EmailQueue) EnqueueSignup(ctx context.Context, userID uuid.UUID) error {
+	now := time.Now()
+	if q.features.Welcome {
+		if err := q.Enqueue(ctx, EmailJob{Kind: EmailJobWelcome, UserID: userID}, now); err != nil {
+			return err
+		}
+	}
+	if q.features.Onboarding {
+		for step, o := range onboardingSteps {
+			job := EmailJob{Kind: EmailJobOnboarding, UserID: userID, Step: step}
+			if err := q.Enqueue(ctx, job, now.Add(o.Delay)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FailedJobs returns the jobs that gave up after emailMaxAttempts, oldest first
+func (q *EmailQueue) FailedJobs(ctx context.Context) ([]EmailJob, error) {
+	members, err := q.redisClient.LRange(ctx, emailFailedKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]EmailJob, 0, len(members))
+	for _, member := range members {
+		var job EmailJob
+		if err := json.Unmarshal([]byte(member), &job); err == nil {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs, nil
+}
+
+// RetryFailed moves a failed job back onto the queue with fresh attempts
+func (q *EmailQueue) RetryFailed(ctx context.Context, jobID string) (*EmailJob, error) {
+	members, err := q.redisClient.LRange(ctx, emailFailedKey, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		var job EmailJob
+		if err := json.Unmarshal([]byte(member), &job); err != nil || job.ID != jobID {
+			continue
+		}
+		// LRem succeeds for exactly one caller, like claiming from the queue
+		removed, err := q.redisClient.LRem(ctx, emailFailedKey, 1, member).Result()
+		if err != nil {
+			return nil, err
+		}
+		if removed == 0 {
+			break
+		}
+
+		job.Attempts = 0
+		job.LastErr = ""
+		if err := q.Enqueue(ctx, job, time.Now()); err != nil {
+			return nil, err
+		}
+		return &job, nil
+	}
+	return nil, types.ErrEmailJobNotFound
+}
+
+// StartWorker sends due emails in the background
+func (q *EmailQueue) StartWorker() {
+	ticker := time.NewTicker(emailQueueInterval)
+	go func() {
+		ctx := context.Background()
+		for range ticker.C {
+			if err := q.processDue(ctx); err != nil {
+				fmt.Printf("⚠️  Email queue error: %v\n", err)
+			}
+		}
+	}()
+}
+
+// processDue claims due jobs one by one (ZRem succeeds for exactly one
+// worker) and sends them
+func (q *EmailQueue) processDue(ctx context.Context) error {
+	members, err := q.redisClient.ZRangeByScore(ctx, emailQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().Unix(), 10),
+		Count: emailQueueBatchSize,
+	}).Result()
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		claimed, err := q.redisClient.ZRem(ctx, emailQueueKey, member).Result()
+		if err != nil || claimed == 0 {
+			continue
+		}
+
+		var job EmailJob
+		if err := json.Unmarshal([]byte(member), &job); err != nil {
+			q.redisClient.RPush(ctx, emailFailedKey, member)
+			continue
+		}
+		q.process(ctx, job)
+	}
+	return nil
+}
+
+func (q *EmailQueue) process(ctx context.Context, job EmailJob) {
+	err := q.send(ctx, job)
+	if err == nil || errors.Is(err, types.ErrEmailSuppressed) || errors.Is(err, errEmailSkipped) {
+		return
+	}
+
+	job.Attempts++
+	job.LastErr = err.Error()
+	if job.Attempts >= emailMaxAttempts {
+		fmt.Printf("❌ Email job %s (%s) failed permanently: %v\n", job.ID, job.Kind, err)
+		if payload, mErr := json.Marshal(job); mErr == nil {
+			q.redisClient.RPush(ctx, emailFailedKey, payload)
+		}
+		return
+	}
+
+	backoff := time.Duration(job.Attempts*job.Attempts) * time.Minute
+	if err := q.Enqueue(ctx, job, time.Now().Add(backoff)); err != nil {
+		fmt.Printf("❌ Failed to requeue email job %s: %v\n", job.ID, err)
+	}
+}
+
+// errEmailSkipped marks jobs that no longer apply (user gone or opted out)
+var errEmailSkipped = errors.New("email skipped")
+
+func (q *EmailQueue) send(ctx context.Context, job EmailJob) error {
+	var user models.User
+	if err := q.db.WithContext(ctx).First(&user, "id = ?", job.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errEmailSkipped
+		}
+		return err
+	}
+	fullName := user.FirstName + " " + user.LastName
+
+	switch job.Kind {
+	case EmailJobWelcome:
+		return q.emailService.SendWelcomeEmail(user.Email, fullName)
+	case EmailJobVerification:
+		if user.EmailVerified {
+			return errEmailSkipped
+		}
+		return q.emailService.SendVerificationEmail(user.Email, fullName, job.Token)
+	case EmailJobAccountUnlock:
+		return q.emailService.SendAccountUnlockEmail(user.Email, fullName, job.Token)
+	case EmailJobPasswordReset:
+		return q.emailService.SendResetPasswordEmail(user.Email, fullName, job.Token)
+	case EmailJobMagicLink:
+		if user.IsSuspended() {
+			return errEmailSkipped
+		}
+		return q.emailService.SendMagicLinkEmail(user.Email, fullName, job.Token)
+	case EmailJobOnboarding:
+		// Flags and preferences are checked at send time, so opting out
+		// also cancels drip emails that are already scheduled
+		if !q.features.Onboarding || !user.OnboardingEmails {
+			return errEmailSkipped
+		}
+		return q.emailService.SendOnboardingEmail(user.Email, fullName, job.Step)
+	case EmailJobApprovalDigest:
+		var pending int64
+		if err := q.db.WithContext(ctx).Model(&models.URL{}).
+			Where("moderation = ? AND deleted_at IS NULL", models.ModerationAwaitingApproval).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending == 0 || !user.IsAdmin() {
+			return errEmailSkipped
+		}
+		return q.emailService.SendApprovalDigestEmail(user.Email, fullName, pending)
+	case EmailJobLinksReviewed:
+		return q.emailService.SendLinksReviewedEmail(user.Email, fullName, job.Approved, job.Links)
+	default:
+		return fmt.Errorf("unknown email job kind: %s", job.Kind)
+	}
+}
@@ -1,14 +1,23 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/smtp"
 	"os"
 	"regexp"
 	"strings"
+	"time"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
 )
 
 type EmailService struct {
+	db           *gorm.DB
 	smtpHost     string
 	smtpPort     string
 	smtpUsername string
@@ -18,8 +27,9 @@ type EmailService struct {
 	frontendURL  string
 }
 
-func NewEmailService() *EmailService {
+func NewEmailService(db *gorm.DB) *EmailService {
 	return &EmailService{
+		db:           db,
 		smtpHost:     os.Getenv("SMTP_HOST"),
 		smtpPort:     os.Getenv("SMTP_PORT"),
 		smtpUsername: os.Getenv("SMTP_USERNAME"),
@@ -50,13 +60,173 @@ func (s *EmailService) SendResetPasswordEmail(toEmail, toName, resetToken string
 	resetLink := fmt.Sprintf("%s/reset-password?token=%s", s.frontendURL, resetToken)
 
 	subject := "Reset Password - Shorteny"
-	body := s.buildEmailHTML(toName, resetLink)
+	brand := s.brandingFor(toEmail)
+	body := s.buildEmailHTML(brand, toName, resetLink)
 
 	// ✅ DEBUG: Print SMTP config for troubleshooting
 	fmt.Printf("[DEBUG] SMTP_HOST=%s SMTP_PORT=%s SMTP_USERNAME=%s SMTP_FROM=%s FRONTEND_URL=%s\n",
 		s.smtpHost, s.smtpPort, s.smtpUsername, s.fromEmail, s.frontendURL)
 
-	return s.sendEmail(toEmail, subject, body)
+	return s.sendEmail(brand, toEmail, subject, body)
+}
+
+// SendWelcomeEmail greets a newly registered user
+func (s *EmailService) SendWelcomeEmail(toEmail, toName string) error {
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, "Welcome to Shorteny", "👋 Welcome to Shorteny", toName,
+		[]string{
+			"Thanks for signing up! Your account is ready.",
+			"Create your first short link, share it anywhere and watch the clicks come in on your dashboard.",
+		},
+		"Go to Dashboard", s.frontendURL+"/dashboard")
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), "Welcome to Shorteny", body)
+}
+
+// SendVerificationEmail sends the link confirming ownership of the address
+func (s *EmailService) SendVerificationEmail(toEmail, toName, token string) error {
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	verifyLink := fmt.Sprintf("%s/verify-email?token=%s", s.frontendURL, token)
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, "Verify your email", "✉️ Verify your email address", toName,
+		[]string{
+			"Please confirm that this is your email address to unlock all Shorteny features, such as custom short codes.",
+			"This link will expire in 48 hours.",
+		},
+		"Verify Email", verifyLink)
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), "Verify your email - Shorteny", body)
+}
+
+// SendAccountUnlockEmail tells a user their login was locked after repeated
+// failed attempts, with a link to unlock it
+func (s *EmailService) SendAccountUnlockEmail(toEmail, toName, token string) error {
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	unlockLink := fmt.Sprintf("%s/unlock-account?token=%s", s.frontendURL, token)
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, "Your account was locked", "🔒 Too many failed login attempts", toName,
+		[]string{
+			"We temporarily locked logins to your account after several failed password attempts.",
+			"If this was you, use the button below to unlock it now. If it was not, consider changing your password once you are back in.",
+			"This link will expire in 24 hours.",
+		},
+		"Unlock Account", unlockLink)
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), "Your account was locked - Shorteny", body)
+}
+
+// SendMagicLinkEmail sends a single-use passwordless login link
+func (s *EmailService) SendMagicLinkEmail(toEmail, toName, token string) error {
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	loginLink := fmt.Sprintf("%s/magic-link?token=%s", s.frontendURL, token)
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, "Your login link", "🔑 Log in to Shorteny", toName,
+		[]string{
+			"Click the button below to log in. The link works once and expires in 15 minutes.",
+			"If you did not ask for this link, you can safely ignore this email.",
+		},
+		"Log In", loginLink)
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), "Your login link - Shorteny", body)
+}
+
+// SendApprovalDigestEmail tells an admin how many links are waiting for approval
+func (s *EmailService) SendApprovalDigestEmail(toEmail, toName string, pending int64) error {
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, "Links awaiting approval", "📝 Links awaiting approval", toName,
+		[]string{
+			fmt.Sprintf("%d link(s) created by members are waiting for your approval. They won't redirect until approved.", pending),
+		},
+		"Review Links", s.frontendURL+"/admin/approvals")
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), "Links awaiting approval - Shorteny", body)
+}
+
+// SendLinksReviewedEmail tells a member which of their links were approved or rejected
+func (s *EmailService) SendLinksReviewedEmail(toEmail, toName string, approved bool, links []string) error {
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	title, heading, summary := "Your links were approved", "✅ Links approved", "These links were approved and are now live:"
+	if !approved {
+		title, heading, summary = "Your links were rejected", "⛔ Links rejected", "These links were rejected by an administrator and will not redirect:"
+	}
+	paragraphs := append([]string{summary}, links...)
+
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, title, heading, toName, paragraphs, "View Your Links", s.frontendURL+"/dashboard")
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), title+" - Shorteny", body)
+}
+
+// onboardingStep is one follow-up email of the onboarding drip
+type onboardingStep struct {
+	Delay      time.Duration
+	Subject    string
+	Heading    string
+	Paragraphs []string
+	CTAText    string
+	CTAPath    string
+}
+
+// onboardingSteps are sent in order, each Delay after registration
+var onboardingSteps = []onboardingStep{
+	{
+		Delay:   48 * time.Hour,
+		Subject: "Get more out of your short links",
+		Heading: "🔗 Custom short codes",
+		Paragraphs: []string{
+			"Did you know you can pick your own short code? Memorable links get clicked more.",
+		},
+		CTAText: "Create a Link",
+		CTAPath: "/dashboard",
+	},
+	{
+		Delay:   7 * 24 * time.Hour,
+		Subject: "See who is clicking your links",
+		Heading: "📊 Analytics and QR codes",
+		Paragraphs: []string{
+			"Every link comes with click analytics: referrers, browsers, devices and campaigns.",
+			"You can also download a QR code for any link to use in print.",
+		},
+		CTAText: "View Analytics",
+		CTAPath: "/dashboard/analytics",
+	},
+}
+
+// SendOnboardingEmail sends the given step of the onboarding drip
+func (s *EmailService) SendOnboardingEmail(toEmail, toName string, step int) error {
+	if step < 0 || step >= len(onboardingSteps) {
+		return fmt.Errorf("unknown onboarding step: %d", step)
+	}
+	if err := s.validateSMTPConfig(); err != nil {
+		return fmt.Errorf("SMTP configuration error: %w", err)
+	}
+
+	o := onboardingSteps[step]
+	paragraphs := append(o.Paragraphs, "You can turn off these tips anytime in your account settings.")
+	brand := s.brandingFor(toEmail)
+	body := s.buildLayoutHTML(brand, o.Subject, o.Heading, toName, paragraphs, o.CTAText, s.frontendURL+o.CTAPath)
+
+	return s.sendEmail(brand, strings.TrimSpace(strings.ToLower(toEmail)), o.Subject, body)
 }
 
 // ✅ NEW: Validate all inputs before processing
@@ -120,7 +290,7 @@ func (s *EmailService) validateSMTPConfig() error {
 }
 
 // ✅ NEW: Build HTML email template (separated for clarity)
-func (s *EmailService) buildEmailHTML(toName, resetLink string) string {
+func (s *EmailService) buildEmailHTML(brand *models.Branding, toName, resetLink string) string {
 	// Escape HTML special characters in name to prevent XSS
 	toName = escapeHTML(toName)
 
@@ -133,15 +303,15 @@ func (s *EmailService) buildEmailHTML(toName, resetLink string) string {
 </head>
 <body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
     <div style="max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #ddd; border-radius: 5px;">
-        <h2 style="color: #4F46E5;">🔐 Reset Your Password</h2>
+%s        <h2 style="color: %s;">🔐 Reset Your Password</h2>
         <p>Hi <strong>%s</strong>,</p>
         <p>We received a request to reset your password for your Shorteny account.</p>
         <p>Click the button below to create a new password:</p>
         <div style="text-align: center; margin: 30px 0;">
-            <a href="%s" style="background-color: #4F46E5; color: white; padding: 14px 40px; text-decoration: none; border-radius: 5px; display: inline-block; font-weight: bold;">Reset Password</a>
+            <a href="%s" style="background-color: %s; color: white; padding: 14px 40px; text-decoration: none; border-radius: 5px; display: inline-block; font-weight: bold;">Reset Password</a>
         </div>
         <p>Or copy and paste this link into your browser:</p>
-        <p style="word-break: break-all; color: #4F46E5; background: #f5f5f5; padding: 10px; border-radius: 4px;">%s</p>
+        <p style="word-break: break-all; color: %s; background: #f5f5f5; padding: 10px; border-radius: 4px;">%s</p>
         <p><strong>⏰ This link will expire in 1 hour.</strong></p>
         <p style="margin-top: 30px; color: #666;">If you didn't request a password reset, please ignore this email or contact support if you have concerns.</p>
         <hr style="margin: 30px 0; border: none; border-top: 1px solid #ddd;">
@@ -152,10 +322,47 @@ func (s *EmailService) buildEmailHTML(toName, resetLink string) string {
     </div>
 </body>
 </html>
-	`, toName, resetLink, resetLink)
+	`, emailLogoHTML(brand), brand.Color(), toName, resetLink, brand.Color(), brand.Color(), resetLink)
 }
 
-func (s *EmailService) sendEmail(to, subject, body string) error {
+// buildLayoutHTML renders the shared email layout with a call-to-action button
+func (s *EmailService) buildLayoutHTML(brand *models.Branding, title, heading, toName string, paragraphs []string, ctaText, ctaLink string) string {
+	var content strings.Builder
+	for _, p := range paragraphs {
+		content.WriteString(fmt.Sprintf("        <p>%s</p>\n", escapeHTML(p)))
+	}
+
+	return fmt.Sprintf(`
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>%s</title>
+</head>
+<body style="font-family: Arial, sans-serif; line-height: 1.6; color: #333;">
+    <div style="max-width: 600px; margin: 0 auto; padding: 20px; border: 1px solid #ddd; border-radius: 5px;">
+%s        <h2 style="color: %s;">%s</h2>
+        <p>Hi <strong>%s</strong>,</p>
+%s        <div style="text-align: center; margin: 30px 0;">
+            <a href="%s" style="background-color: %s; color: white; padding: 14px 40px; text-decoration: none; border-radius: 5px; display: inline-block; font-weight: bold;">%s</a>
+        </div>
+        <hr style="margin: 30px 0; border: none; border-top: 1px solid #ddd;">
+        <p style="font-size: 12px; color: #999; text-align: center;">
+            This is an automated message from Shorteny<br>
+            Please do not reply to this email.
+        </p>
+    </div>
+</body>
+</html>
+	`, escapeHTML(title), emailLogoHTML(brand), brand.Color(), escapeHTML(heading), escapeHTML(toName), content.String(), ctaLink, brand.Color(), escapeHTML(ctaText))
+}
+
+func (s *EmailService) sendEmail(brand *models.Branding, to, subject, body string) error {
+	// ✅ Never send to addresses that bounced or complained
+	if s.isSuppressed(to) {
+		return types.ErrEmailSuppressed
+	}
+
 	// ✅ SECURITY: Trim whitespace from password (common issue)
 	password := strings.TrimSpace(s.smtpPassword)
 
@@ -163,7 +370,11 @@ func (s *EmailService) sendEmail(to, subject, body string) error {
 	auth := smtp.PlainAuth("", s.smtpUsername, password, s.smtpHost)
 
 	// Compose email message
-	from := fmt.Sprintf("%s <%s>", s.fromName, s.fromEmail)
+	fromName := s.fromName
+	if brand != nil && brand.SenderName != "" {
+		fromName = brand.SenderName
+	}
+	from := fmt.Sprintf("%s <%s>", fromName, s.fromEmail)
 	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
 	msg := []byte(fmt.Sprintf("From: %s\nTo: %s\nSubject: %s\n%s\n%s", from, to, subject, mime, body))
 
@@ -183,6 +394,84 @@ func (s *EmailService) sendEmail(to, subject, body string) error {
 	return nil
 }
 
+// brandingFor returns the branding of the recipient's account, nil for the
+// defaults (unknown recipients or no branding set)
+func (s *EmailService) brandingFor(email string) *models.Branding {
+	if s.db == nil {
+		return nil
+	}
+	var branding models.Branding
+	err := s.db.Joins("JOIN users ON users.id = brandings.user_id").
+		Where("users.email = ?", strings.TrimSpace(strings.ToLower(email))).
+		First(&branding).Error
+	if err != nil {
+		return nil
+	}
+	return &branding
+}
+
+// emailLogoHTML renders the branded logo above the heading, if one is set
+func emailLogoHTML(brand *models.Branding) string {
+	if brand == nil || brand.LogoURL == "" {
+		return ""
+	}
+	return fmt.Sprintf("        <img src=\"%s\" alt=\"\" style=\"max-height: 48px; max-width: 200px;\">\n", escapeHTML(brand.LogoURL))
+}
+
+// isSuppressed reports whether the recipient is marked undeliverable
+func (s *EmailService) isSuppressed(email string) bool {
+	if s.db == nil {
+		return false
+	}
+
+	var user models.User
+	err := s.db.Select("email_status").Where("LOWER(email) = ?", strings.ToLower(email)).First(&user).Error
+	if err != nil {
+		return false
+	}
+	return !user.CanReceiveEmail()
+}
+
+// HandleDeliveryEvents applies provider bounce/complaint notifications.
+// Permanent bounces and complaints mark the address undeliverable; transient
+// bounces are only logged. Returns the number of accounts updated.
+func (s *EmailService) HandleDeliveryEvents(ctx context.Context, events []models.EmailEvent) (int64, error) {
+	var updated int64
+	for _, event := range events {
+		status := models.EmailStatusBounced
+		switch event.Type {
+		case models.EmailEventComplaint:
+			status = models.EmailStatusComplained
+		case models.EmailEventBounce:
+			if strings.EqualFold(event.BounceType, "transient") {
+				utils.LoggerFromContext(ctx).Info("Transient email bounce", "email", event.Email, "reason", event.Reason)
+				continue
+			}
+		default:
+			return updated, errors.New("unsupported email event type: " + event.Type)
+		}
+
+		result := s.db.WithContext(ctx).Model(&models.User{}).
+			Where("LOWER(email) = ?", strings.ToLower(strings.TrimSpace(event.Email))).
+			Updates(map[string]interface{}{
+				"email_status":        status,
+				"email_status_reason": event.Reason,
+				"email_status_at":     time.Now().UTC(),
+			})
+		if result.Error != nil {
+			return updated, result.Error
+		}
+		updated += result.RowsAffected
+
+		utils.LoggerFromContext(ctx).Warn("Email address marked undeliverable",
+			"email", event.Email,
+			"status", status,
+			"reason", event.Reason)
+	}
+
+	return updated, nil
+}
+
 // ✅ NEW: Email validation using regex
 func isValidEmail(email string) bool {
 	// RFC 5322 compliant email regex (simplified)
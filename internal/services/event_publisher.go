@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+const (
+	eventBatchSize     = 100
+	eventFlushInterval = time.Second
+
+	BusEventClick      = "click"
+	BusEventURLCreated = "url.created"
+)
+
+// BusEvent is the envelope published to the event bus
+type BusEvent struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Key        string          `json:"-"` // partition key (short code)
+	Data       json.RawMessage `json:"data"`
+}
+
+// EventSink delivers a batch of events to a broker
+type EventSink interface {
+	Publish(ctx context.Context, events []BusEvent) error
+	Close() error
+}
+
+// EventPublisher emits click and URL creation events to Kafka or NATS.
+// Publishing never blocks the request path: events go into a bounded buffer
+// and are dropped (and counted) when it is full.
+type EventPublisher struct {
+	sink    EventSink
+	buffer  chan BusEvent
+	dropped int64
+}
+
+func NewEventPublisher(sink EventSink, bufferSize int) *EventPublisher {
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+	return &EventPublisher{
+		sink:   sink,
+		buffer: make(chan BusEvent, bufferSize),
+	}
+}
+
+// NotifyClick implements interfaces.ClickListener
+func (p *EventPublisher) NotifyClick(event *models.ClickEvent) {
+	p.publish(BusEventClick, event.ShortCode, event.ClickedAt, event)
+}
+
+// NotifyURLCreated implements interfaces.URLListener
+func (p *EventPublisher) NotifyURLCreated(url *models.URL) {
+	p.publish(BusEventURLCreated, url.ShortCode, url.CreatedAt, map[string]interface{}{
+		"id":           url.ID,
+		"user_id":      url.UserID,
+		"short_code":   url.ShortCode,
+		"short_url":    url.ShortURL,
+		"long_url":     url.LongURL,
+		"is_anonymous": url.IsAnonymous,
+		"expires_at":   url.ExpiresAt,
+		"created_at":   url.CreatedAt,
+	})
+}
+
+// Dropped returns how many events were discarded because the buffer was full
+func (p *EventPublisher) Dropped() int64 {
+	return atomic.LoadInt64(&p.dropped)
+}
+
+func (p *EventPublisher) publish(eventType, key string, occurredAt time.Time, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	event := BusEvent{
+		ID:         uuid.New().String(),
+		Type:       eventType,
+		OccurredAt: occurredAt,
+		Key:        key,
+		Data:       payload,
+	}
+
+	select {
+	case p.buffer <- event:
+	default:
+		if n := atomic.AddInt64(&p.dropped, 1); n%1000 == 1 {
+			utils.Logger.Warn("Event bus buffer full, dropping events", "type", eventType, "dropped_total", n)
+		}
+	}
+}
+
+// Start drains the buffer in batches in the background
+func (p *EventPublisher) Start() {
+	go func() {
+		ticker := time.NewTicker(eventFlushInterval)
+		defer ticker.Stop()
+
+		batch := make([]BusEvent, 0, eventBatchSize)
+		for {
+			select {
+			case event := <-p.buffer:
+				batch = append(batch, event)
+				if len(batch) < eventBatchSize {
+					continue
+				}
+			case <-ticker.C:
+				if len(batch) == 0 {
+					continue
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := p.sink.Publish(ctx, batch); err != nil {
+				fmt.Printf("⚠️  Event bus publish failed (%d events lost): %v\n", len(batch), err)
+			}
+			cancel()
+			batch = make([]BusEvent, 0, eventBatchSize)
+		}
+	}()
+}
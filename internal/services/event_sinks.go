@@ -0,0 +1,194 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NewEventSink builds the sink for the configured broker ("nats" or "kafka")
+func NewEventSink(kind, brokerURL, topic string) (EventSink, error) {
+	switch strings.ToLower(kind) {
+	case "nats":
+		return NewNATSSink(brokerURL, topic), nil
+	case "kafka":
+		return NewKafkaRESTSink(brokerURL, topic), nil
+	default:
+		return nil, fmt.Errorf("unsupported event bus: %q (expected nats or kafka)", kind)
+	}
+}
+
+// NATSSink publishes events over the NATS text protocol. Each event goes to
+// "<subject prefix>.<event type>", e.g. "lynx.events.click".
+type NATSSink struct {
+	address string
+	prefix  string
+
+	mu   sync.Mutex
+	conn net.Conn
+	rw   *bufio.ReadWriter
+}
+
+func NewNATSSink(brokerURL, subjectPrefix string) *NATSSink {
+	address := strings.TrimPrefix(brokerURL, "nats://")
+	if !strings.Contains(address, ":") {
+		address += ":4222"
+	}
+	return &NATSSink{address: address, prefix: subjectPrefix}
+}
+
+func (s *NATSSink) Publish(ctx context.Context, events []BusEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		if err := s.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.SetDeadline(deadline)
+	}
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(s.rw, "PUB %s.%s %d\r\n", s.prefix, event.Type, len(payload))
+		s.rw.Write(payload)
+		s.rw.WriteString("\r\n")
+	}
+	// PING/PONG round trip confirms the server accepted the batch
+	s.rw.WriteString("PING\r\n")
+	if err := s.rw.Flush(); err != nil {
+		s.reset()
+		return err
+	}
+	if err := s.awaitPong(); err != nil {
+		s.reset()
+		return err
+	}
+	return nil
+}
+
+func (s *NATSSink) connect(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.address)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	info, err := rw.ReadString('\n')
+	if err != nil || !strings.HasPrefix(info, "INFO") {
+		conn.Close()
+		return fmt.Errorf("unexpected NATS greeting: %q", strings.TrimSpace(info))
+	}
+
+	rw.WriteString(`CONNECT {"verbose":false,"pedantic":false,"name":"lynx-backend"}` + "\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+
+	s.conn = conn
+	s.rw = rw
+	return nil
+}
+
+// awaitPong reads until the PONG for our PING, answering server PINGs
+func (s *NATSSink) awaitPong() error {
+	for {
+		line, err := s.rw.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			s.rw.WriteString("PONG\r\n")
+			s.rw.Flush()
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("NATS: " + line)
+		}
+	}
+}
+
+func (s *NATSSink) reset() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn = nil
+	s.rw = nil
+}
+
+func (s *NATSSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reset()
+	return nil
+}
+
+// KafkaRESTSink publishes events through a Kafka REST Proxy (v2 API), keyed
+// by short code so all events of a link land in the same partition
+type KafkaRESTSink struct {
+	endpoint   string
+	httpClient *http.Client
+}
+
+func NewKafkaRESTSink(proxyURL, topic string) *KafkaRESTSink {
+	return &KafkaRESTSink{
+		endpoint:   strings.TrimSuffix(proxyURL, "/") + "/topics/" + url.PathEscape(topic),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (s *KafkaRESTSink) Publish(ctx context.Context, events []BusEvent) error {
+	type record struct {
+		Key   string   `json:"key"`
+		Value BusEvent `json:"value"`
+	}
+	records := make([]record, len(events))
+	for i, event := range events {
+		records[i] = record{Key: event.Key, Value: event}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"records": records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (s *KafkaRESTSink) Close() error {
+	return nil
+}
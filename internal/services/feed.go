@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+const (
+	feedCacheTTL = 5 * time.Minute
+	feedMaxLinks = 100
+)
+
+// SetPublished adds a link to (or removes it from) its creator's public feed.
+// Workspace members can publish the workspace's links as well as their own.
+func (s *URLService) SetPublished(ctx context.Context, userID, urlID uuid.UUID, published bool, title string) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	url.Published = published
+	if title != "" {
+		url.Title = title
+	}
+	url.UpdatedAt = time.Now().UTC()
+	if err := s.db.WithContext(ctx).Model(&url).
+		Select("published", "title", "updated_at").
+		Updates(&url).Error; err != nil {
+		return nil, err
+	}
+
+	if url.UserID != nil {
+		s.redisClient.Del(ctx, getFeedKey(*url.UserID))
+	}
+	return &url, nil
+}
+
+// GetPublishedFeed returns the user's published links for public embedding.
+// The feed is cached in Redis, so click counts may lag by a few minutes.
+func (s *URLService) GetPublishedFeed(ctx context.Context, userID uuid.UUID) (*types.PublishedFeed, error) {
+	if cached, err := s.redisClient.Get(ctx, getFeedKey(userID)).Bytes(); err == nil {
+		var feed types.PublishedFeed
+		if err := json.Unmarshal(cached, &feed); err == nil {
+			return &feed, nil
+		}
+	}
+
+	var urls []models.URL
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND published = true AND is_active = true AND deleted_at IS NULL AND disabled_at IS NULL", userID).
+		Where("COALESCE(moderation, '') NOT IN ?", []string{models.ModerationPending, models.ModerationQuarantined}).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Order("created_at DESC").
+		Limit(feedMaxLinks).
+		Find(&urls).Error; err != nil {
+		return nil, err
+	}
+
+	feed := &types.PublishedFeed{
+		Links:       make([]types.PublishedLink, 0, len(urls)),
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, url := range urls {
+		clicks := url.Clicks
+		if redisClicks, err := s.redisClient.Get(ctx, getClicksKey(url.ShortCode)).Int64(); err == nil && redisClicks > 0 {
+			clicks += redisClicks
+		}
+		feed.Links = append(feed.Links, types.PublishedLink{
+			Title:    url.Title,
+			ShortURL: url.ShortURL,
+			Clicks:   clicks,
+		})
+	}
+
+	if data, err := json.Marshal(feed); err == nil {
+		s.redisClient.Set(ctx, getFeedKey(userID), data, feedCacheTTL)
+	}
+	return feed, nil
+}
+
+func getFeedKey(userID uuid.UUID) string {
+	return fmt.Sprintf("feed:%s", userID)
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// countryVariantPrefix marks clicks that took a country route, e.g. "geo:de"
+const countryVariantPrefix = "geo:"
+
+// SetCountryRoutes replaces a link's per-country destinations. Keys are
+// ISO 3166 alpha-2 codes ("DE"); visitors from other or unknown countries
+// get the regular destination. The country comes from the edge proxy, see
+// utils.ClientCountry.
+func (s *URLService) SetCountryRoutes(ctx context.Context, userID, urlID uuid.UUID, routes map[string]string) (*models.URL, error) {
+	normalized := make(map[string]string, len(routes))
+	for country, destination := range routes {
+		code := strings.ToUpper(strings.TrimSpace(country))
+		if !validCountryCode(code) {
+			return nil, types.NewValidationError(fmt.Sprintf("invalid country code %q", country))
+		}
+		destination, err := s.validateDestination(ctx, destination)
+		if err != nil {
+			return nil, err
+		}
+		normalized[code] = destination
+	}
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		url.CountryRoutes = normalized
+		url.UpdatedAt = time.Now().UTC()
+		if err := s.destinationsChanged(tx, userID, &url); err != nil {
+			return err
+		}
+
+		if err := tx.Select("country_routes", "updated_at", "moderation").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyHeld(ctx, &url)
+	s.notifyChanged(&url)
+	return &url, nil
+}
+
+// matchCountryRoute returns the destination for the visitor's country
+func matchCountryRoute(routes map[string]string, country string) (destination, variant string, ok bool) {
+	if len(routes) == 0 || country == "" {
+		return "", "", false
+	}
+	destination, ok = routes[country]
+	return destination, countryVariantPrefix + strings.ToLower(country), ok
+}
+
+func validCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
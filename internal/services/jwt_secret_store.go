@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/config"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// jwtSecretStateID is the key of the only jwt_secret_states row
+const jwtSecretStateID = 1
+
+// JWTSecretStore keeps rotated JWT secrets in the database, implementing
+// config.SecretStore
+type JWTSecretStore struct {
+	db *gorm.DB
+}
+
+func NewJWTSecretStore(db *gorm.DB) *JWTSecretStore {
+	return &JWTSecretStore{db: db}
+}
+
+// LoadSecrets implements config.SecretStore
+func (s *JWTSecretStore) LoadSecrets(ctx context.Context) (*config.SecretState, error) {
+	var row models.JWTSecretState
+	if err := s.db.WithContext(ctx).Where("id = ?", jwtSecretStateID).First(&row).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return secretStateFromRow(&row), nil
+}
+
+// RotateSecrets implements config.SecretStore. The row is locked so that
+// concurrent rotations on different instances each retire the secret the
+// other one made current.
+func (s *JWTSecretStore) RotateSecrets(ctx context.Context, initial, newSecret string) (*config.SecretState, error) {
+	var row models.JWTSecretState
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", jwtSecretStateID).First(&row).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		first := err != nil
+		if first {
+			row = models.JWTSecretState{ID: jwtSecretStateID, Current: initial}
+		}
+
+		now := time.Now().UTC()
+		retired := []models.RetiredJWTSecret{{Secret: row.Current, RetiredAt: now}}
+		for _, secret := range row.Retired {
+			if now.Sub(secret.RetiredAt) < config.SecretGracePeriod {
+				retired = append(retired, secret)
+			}
+		}
+		row.Current = newSecret
+		row.Retired = retired
+		row.RotatedAt = now
+
+		// A first rotation racing another one fails on the primary key
+		// instead of overwriting it
+		if first {
+			return tx.Create(&row).Error
+		}
+		return tx.Save(&row).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secretStateFromRow(&row), nil
+}
+
+func secretStateFromRow(row *models.JWTSecretState) *config.SecretState {
+	state := &config.SecretState{Current: row.Current, RotatedAt: row.RotatedAt}
+	for _, secret := range row.Retired {
+		state.Retired = append(state.Retired, config.RetiredSecret{Secret: secret.Secret, RetiredAt: secret.RetiredAt})
+	}
+	return state
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// defaultLanguageVariant is recorded for visitors no language route matched
+const defaultLanguageVariant = "default"
+
+// maxAcceptLanguageTags bounds the work done per redirect on hostile headers
+const maxAcceptLanguageTags = 10
+
+// SetLanguageRoutes replaces a link's Accept-Language routes. Keys are
+// language tags ("id", "pt-BR"), matched case-insensitively; visitors whose
+// languages match no route get the regular destination.
+func (s *URLService) SetLanguageRoutes(ctx context.Context, userID, urlID uuid.UUID, routes map[string]string) (*models.URL, error) {
+	normalized := make(map[string]string, len(routes))
+	for tag, destination := range routes {
+		key := strings.ToLower(strings.TrimSpace(tag))
+		if !validLanguageTag(key) {
+			return nil, types.NewValidationError(fmt.Sprintf("invalid language tag %q", tag))
+		}
+		destination, err := s.validateDestination(ctx, destination)
+		if err != nil {
+			return nil, err
+		}
+		normalized[key] = destination
+	}
+	if len(normalized) == 0 {
+		normalized = nil
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		url.LanguageRoutes = normalized
+		url.UpdatedAt = time.Now().UTC()
+		if err := s.destinationsChanged(tx, userID, &url); err != nil {
+			return err
+		}
+
+		if err := tx.Select("language_routes", "updated_at", "moderation").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyHeld(ctx, &url)
+	s.notifyChanged(&url)
+	return &url, nil
+}
+
+// matchLanguageRoute picks the route for the visitor's most preferred
+// language. A full tag ("pt-br") is tried before its primary language ("pt").
+func matchLanguageRoute(routes map[string]string, acceptLanguage string) (destination, variant string, ok bool) {
+	if len(routes) == 0 || acceptLanguage == "" {
+		return "", "", false
+	}
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if destination, ok := routes[tag]; ok {
+			return destination, tag, true
+		}
+		if primary, _, found := strings.Cut(tag, "-"); found {
+			if destination, ok := routes[primary]; ok {
+				return destination, primary, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// parseAcceptLanguage returns the lowercased language tags of an
+// Accept-Language header, most preferred first. Tags with q=0 are dropped.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	parts := strings.Split(header, ",")
+	if len(parts) > maxAcceptLanguageTags {
+		parts = parts[:maxAcceptLanguageTags]
+	}
+
+	tags := make([]weighted, 0, len(parts))
+	for _, part := range parts {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	result := make([]string, len(tags))
+	for i, t := range tags {
+		result[i] = t.tag
+	}
+	return result
+}
+
+// validLanguageTag accepts BCP 47-shaped tags: letter subtags of up to 8
+// characters separated by hyphens, e.g. "en", "pt-br", "zh-hant-tw"
+func validLanguageTag(tag string) bool {
+	if tag == "" || len(tag) > 35 || tag == defaultLanguageVariant {
+		return false
+	}
+	for _, subtag := range strings.Split(tag, "-") {
+		if subtag == "" || len(subtag) > 8 {
+			return false
+		}
+		for _, r := range subtag {
+			if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
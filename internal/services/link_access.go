@@ -0,0 +1,20 @@
+package services
+
+import (
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// linkAccess scopes a query on urls to the links the user may view and
+// manage: the ones they created and the ones shared into a workspace they
+// belong to
+func linkAccess(userID uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		memberships := db.Session(&gorm.Session{NewDB: true}).
+			Model(&models.WorkspaceMember{}).
+			Select("workspace_id").
+			Where("user_id = ?", userID)
+		return db.Where("(urls.user_id = ? OR urls.workspace_id IN (?))", userID, memberships)
+	}
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	// approvalDigestKey throttles the "links awaiting approval" email to
+	// admins; the digest goes out approvalDigestDelay after the first new
+	// held link and counts everything pending at that point
+	approvalDigestKey   = "email:approval_digest"
+	approvalDigestDelay = 15 * time.Minute
+)
+
+// LinkApproval is the policy that holds links created (or re-pointed) by
+// members until an admin approves them. Admins' own links are published
+// immediately. Held links don't redirect.
+type LinkApproval struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	emailQueue  *EmailQueue
+}
+
+func NewLinkApproval(db *gorm.DB, redisClient *redis.Client, emailQueue *EmailQueue) *LinkApproval {
+	return &LinkApproval{
+		db:          db,
+		redisClient: redisClient,
+		emailQueue:  emailQueue,
+	}
+}
+
+// Required reports whether links of the user need approval
+func (a *LinkApproval) Required(tx *gorm.DB, userID uuid.UUID) (bool, error) {
+	var user models.User
+	if err := tx.Select("role").First(&user, "id = ?", userID).Error; err != nil {
+		return false, err
+	}
+	return !user.IsAdmin(), nil
+}
+
+// NotifyAdmins schedules the approval digest for every admin, unless one is
+// already scheduled
+func (a *LinkApproval) NotifyAdmins(ctx context.Context) {
+	scheduled, err := a.redisClient.SetNX(ctx, approvalDigestKey, 1, approvalDigestDelay).Result()
+	if err != nil || !scheduled {
+		return
+	}
+
+	var adminIDs []uuid.UUID
+	if err := a.db.WithContext(ctx).Model(&models.User{}).
+		Where("role = ?", models.RoleAdmin).
+		Pluck("id", &adminIDs).Error; err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to load admins for approval digest", "error", err)
+		return
+	}
+
+	due := time.Now().Add(approvalDigestDelay)
+	for _, adminID := range adminIDs {
+		if err := a.emailQueue.Enqueue(ctx, EmailJob{Kind: EmailJobApprovalDigest, UserID: adminID}, due); err != nil {
+			utils.LoggerFromContext(ctx).Error("Failed to queue approval digest", "user_id", adminID, "error", err)
+		}
+	}
+}
+
+// NotifyOwners tells each owner which of their links were approved or rejected
+func (a *LinkApproval) NotifyOwners(ctx context.Context, urls []models.URL, approved bool) {
+	byOwner := make(map[uuid.UUID][]string)
+	for _, url := range urls {
+		if url.UserID != nil {
+			byOwner[*url.UserID] = append(byOwner[*url.UserID], url.ShortURL)
+		}
+	}
+
+	now := time.Now()
+	for ownerID, links := range byOwner {
+		job := EmailJob{Kind: EmailJobLinksReviewed, UserID: ownerID, Approved: approved, Links: links}
+		if err := a.emailQueue.Enqueue(ctx, job, now); err != nil {
+			utils.LoggerFromContext(ctx).Error("Failed to queue link review email", "user_id", ownerID, "error", err)
+		}
+	}
+}
+
+// holdForApproval marks the link as awaiting approval when its owner needs
+// approval. Rejected links can't be resubmitted by editing them.
+func (s *URLService) holdForApproval(tx *gorm.DB, userID uuid.UUID, url *models.URL) error {
+	if s.linkApproval == nil {
+		return nil
+	}
+	required, err := s.linkApproval.Required(tx, userID)
+	if err != nil || !required {
+		return err
+	}
+	if url.Moderation == models.ModerationRejected {
+		return types.ErrURLDisabled
+	}
+	// Only an admin's review releases a quarantined link
+	if url.Moderation == models.ModerationQuarantined {
+		return nil
+	}
+	url.Moderation = models.ModerationAwaitingApproval
+	return nil
+}
+
+// notifyHeld lets admins know a link is waiting for them
+func (s *URLService) notifyHeld(ctx context.Context, url *models.URL) {
+	if s.linkApproval != nil && url.Moderation == models.ModerationAwaitingApproval {
+		s.linkApproval.NotifyAdmins(ctx)
+	}
+}
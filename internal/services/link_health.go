@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	healthCheckBatchSize = 200
+	healthCheckWorkers   = 4
+)
+
+// LinkHealthChecker periodically requests the destinations of active links
+// and flags those answering 4xx/5xx or whose host doesn't resolve, so owners
+// can find broken links with ?health=broken.
+type LinkHealthChecker struct {
+	db         *gorm.DB
+	httpClient *http.Client
+	interval   time.Duration
+}
+
+func NewLinkHealthChecker(db *gorm.DB, interval time.Duration) *LinkHealthChecker {
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: denyPrivateAddresses}
+	return &LinkHealthChecker{
+		db: db,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 5 {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+		interval: interval,
+	}
+}
+
+// Start checks links that are due every few minutes; a zero interval
+// disables the checker
+func (s *LinkHealthChecker) Start() {
+	if s.interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(5 * time.Minute)
+	go func() {
+		ctx := context.Background()
+		for range ticker.C {
+			checked, err := s.CheckDue(ctx)
+			if err != nil {
+				utils.Logger.Error("Link health check failed", "error", err)
+				continue
+			}
+			if checked > 0 {
+				utils.Logger.Debug("Checked link destinations", "count", checked)
+			}
+		}
+	}()
+}
+
+// CheckDue checks one batch of active links not checked within the interval,
+// never-checked links first
+func (s *LinkHealthChecker) CheckDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	var links []models.URL
+	if err := s.db.WithContext(ctx).Select("id", "long_url").
+		Where("deleted_at IS NULL AND disabled_at IS NULL AND is_active = true").
+		Where("(moderation IS NULL OR moderation IN ?)", []string{"", models.ModerationApproved}).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("health_checked_at IS NULL OR health_checked_at < ?", now.Add(-s.interval)).
+		Order("health_checked_at ASC NULLS FIRST").
+		Limit(healthCheckBatchSize).
+		Find(&links).Error; err != nil {
+		return 0, err
+	}
+
+	jobs := make(chan models.URL)
+	var wg sync.WaitGroup
+	for i := 0; i < healthCheckWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				s.checkLink(ctx, link.ID, link.LongURL)
+			}
+		}()
+	}
+	for _, link := range links {
+		jobs <- link
+	}
+	close(jobs)
+	wg.Wait()
+	return len(links), nil
+}
+
+// checkLink probes one destination and stores the result. Timeouts and other
+// transient failures only move the check time forward, keeping the previous
+// status, so a slow site isn't reported as broken.
+func (s *LinkHealthChecker) checkLink(ctx context.Context, urlID uuid.UUID, destination string) {
+	status, reason, err := s.probe(ctx, destination)
+	columns := map[string]interface{}{"health_checked_at": time.Now().UTC()}
+	if err != nil {
+		utils.Logger.Debug("Link health probe inconclusive", "url_id", urlID, "error", err)
+	} else {
+		columns["health_status"] = status
+		columns["health_error"] = reason
+	}
+	if err := s.db.WithContext(ctx).Model(&models.URL{}).Where("id = ?", urlID).
+		UpdateColumns(columns).Error; err != nil {
+		utils.Logger.Warn("Failed to store link health", "url_id", urlID, "error", err)
+	}
+}
+
+// probe sends a HEAD request, falling back to GET for servers that don't
+// support HEAD, and classifies the answer
+func (s *LinkHealthChecker) probe(ctx context.Context, destination string) (status, reason string, err error) {
+	code, err := s.request(ctx, http.MethodHead, destination)
+	if err == nil && (code == http.StatusMethodNotAllowed || code == http.StatusNotImplemented) {
+		code, err = s.request(ctx, http.MethodGet, destination)
+	}
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && !dnsErr.IsTimeout && !dnsErr.IsTemporary {
+			return models.HealthBroken, "DNS lookup failed", nil
+		}
+		return "", "", err
+	}
+	// Rate limiting says nothing about whether the page exists
+	if code == http.StatusTooManyRequests {
+		return "", "", fmt.Errorf("rate limited by destination")
+	}
+	if code >= 400 {
+		return models.HealthBroken, fmt.Sprintf("HTTP %d", code), nil
+	}
+	return models.HealthOK, "", nil
+}
+
+func (s *LinkHealthChecker) request(ctx context.Context, method, destination string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, destination, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "LynxLinkChecker/1.0")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
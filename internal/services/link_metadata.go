@@ -0,0 +1,349 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+)
+
+const (
+	metadataCacheTTL  = 6 * time.Hour
+	metadataMissTTL   = 10 * time.Minute
+	metadataMaxBytes  = 512 << 10
+	metadataMaxLength = 300
+	pageInfoWorkers   = 2
+)
+
+// LinkMetadataService answers unfurl bots with a link's title, description
+// and image, scraped once from the destination and cached, so chat apps
+// don't need to follow the redirect (and aren't counted as clicks). It also
+// stores each new link's page title and description on the link itself.
+type LinkMetadataService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	httpClient  *http.Client
+	queue       chan uuid.UUID
+	// Matches requested codes under the short code case policy, see
+	// URLService.ShortCodeScope
+	codeScope func(string) func(*gorm.DB) *gorm.DB
+}
+
+func NewLinkMetadataService(db *gorm.DB, redisClient *redis.Client) *LinkMetadataService {
+	dialer := &net.Dialer{Timeout: 3 * time.Second, Control: denyPrivateAddresses}
+	return &LinkMetadataService{
+		db:          db,
+		redisClient: redisClient,
+		httpClient: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= 3 {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		},
+		queue: make(chan uuid.UUID, 1000),
+	}
+}
+
+// SetShortCodeScope makes metadata lookups follow the short code case policy
+func (s *LinkMetadataService) SetShortCodeScope(scope func(string) func(*gorm.DB) *gorm.DB) {
+	s.codeScope = scope
+}
+
+// NotifyURLCreated implements interfaces.URLListener
+func (s *LinkMetadataService) NotifyURLCreated(url *models.URL) {
+	select {
+	case s.queue <- url.ID:
+	default:
+		utils.Logger.Warn("Page title queue full, skipping link", "url_id", url.ID)
+	}
+}
+
+// Start runs the workers that fetch new links' page titles
+func (s *LinkMetadataService) Start() {
+	for i := 0; i < pageInfoWorkers; i++ {
+		go func() {
+			for urlID := range s.queue {
+				ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+				if err := s.fetchPageInfo(ctx, urlID); err != nil {
+					utils.Logger.Debug("Failed to fetch page title", "url_id", urlID, "error", err)
+				}
+				cancel()
+			}
+		}()
+	}
+}
+
+// fetchPageInfo scrapes a link's destination and stores its title and
+// description on the link
+func (s *LinkMetadataService) fetchPageInfo(ctx context.Context, urlID uuid.UUID) error {
+	var link models.URL
+	if err := s.db.WithContext(ctx).Select("id", "long_url", "disabled_at", "moderation").
+		Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// Never fetch destinations that are disabled, under abuse review or quarantined
+	if link.IsDisabled() || link.IsPendingReview() || link.IsQuarantined() {
+		return nil
+	}
+
+	var meta types.LinkMetadata
+	if err := s.scrape(ctx, link.LongURL, &meta); err != nil {
+		return err
+	}
+	if meta.Title == "" && meta.Description == "" {
+		return nil
+	}
+	return s.db.WithContext(ctx).Model(&models.URL{}).Where("id = ?", urlID).
+		UpdateColumns(map[string]interface{}{
+			"page_title":       meta.Title,
+			"page_description": meta.Description,
+		}).Error
+}
+
+// GetMetadata returns the unfurl metadata for a short code. Links that don't
+// redirect (disabled, inactive, held for review, expired) are not found.
+func (s *LinkMetadataService) GetMetadata(ctx context.Context, shortCode string) (*types.LinkMetadata, error) {
+	scope := func(db *gorm.DB) *gorm.DB { return db.Where("short_code = ?", shortCode) }
+	if s.codeScope != nil {
+		scope = s.codeScope(shortCode)
+	}
+	key := getMetaKey(shortCode)
+	if cached, err := s.redisClient.Get(ctx, key).Result(); err == nil {
+		if cached == cacheNotFound {
+			return nil, types.ErrURLNotFound
+		}
+		var meta types.LinkMetadata
+		if json.Unmarshal([]byte(cached), &meta) == nil {
+			return &meta, nil
+		}
+	}
+
+	var link models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(scope).Where("deleted_at IS NULL").
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			s.redisClient.Set(ctx, key, cacheNotFound, metadataMissTTL)
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+	if link.IsDisabled() || link.IsPendingReview() || link.IsQuarantined() || !link.IsActive || link.IsExpired() {
+		s.redisClient.Set(ctx, key, cacheNotFound, metadataMissTTL)
+		return nil, types.ErrURLNotFound
+	}
+
+	meta := &types.LinkMetadata{
+		ShortURL: link.ShortURL,
+		Title:    link.Title,
+	}
+	// Owners who turned unfurls off get a bare card without the destination
+	if !link.DisableUnfurl {
+		meta.URL = link.LongURL
+		if err := s.scrape(ctx, link.LongURL, meta); err != nil {
+			utils.LoggerFromContext(ctx).Debug("Metadata scrape failed", "short_code", shortCode, "error", err)
+		}
+		if meta.Image == "" {
+			meta.Image = link.PreviewImageURL
+		}
+	}
+
+	// Only the stored spelling's entry is dropped when the link changes, so
+	// other spellings are looked up every time
+	if link.ShortCode == shortCode {
+		if data, err := json.Marshal(meta); err == nil {
+			s.redisClient.Set(ctx, key, data, metadataCacheTTL)
+		}
+	}
+	return meta, nil
+}
+
+// GetLinkPreview returns the Open Graph card of one of the user's links for
+// the dashboard. Unlike GetMetadata it works for paused or expired links and
+// ignores the unfurl setting, since only the owner sees it.
+func (s *LinkMetadataService) GetLinkPreview(ctx context.Context, userID, urlID uuid.UUID) (*types.LinkMetadata, error) {
+	var link models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	key := getPreviewCardKey(link.ShortCode)
+	if cached, err := s.redisClient.Get(ctx, key).Bytes(); err == nil {
+		var meta types.LinkMetadata
+		if json.Unmarshal(cached, &meta) == nil {
+			return &meta, nil
+		}
+	}
+
+	meta := &types.LinkMetadata{
+		ShortURL: link.ShortURL,
+		URL:      link.LongURL,
+	}
+	ttl := metadataCacheTTL
+	// Don't fetch destinations an admin disabled or that are still under review
+	if !link.IsDisabled() && !link.IsPendingReview() {
+		if err := s.scrape(ctx, link.LongURL, meta); err != nil {
+			utils.LoggerFromContext(ctx).Debug("Preview scrape failed", "url_id", urlID, "error", err)
+			ttl = metadataMissTTL
+		}
+	}
+	meta.Title = firstNonEmpty(meta.Title, link.PageTitle)
+	meta.Description = firstNonEmpty(meta.Description, link.PageDescription)
+	meta.Image = firstNonEmpty(meta.Image, link.PreviewImageURL)
+
+	if data, err := json.Marshal(meta); err == nil {
+		s.redisClient.Set(ctx, key, data, ttl)
+	}
+	return meta, nil
+}
+
+// scrape fills in metadata from the destination's <head>, preferring Open
+// Graph tags over <title> and the description meta tag
+func (s *LinkMetadataService) scrape(ctx context.Context, destination string, meta *types.LinkMetadata) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, destination, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "LynxMetadataBot/1.0")
+	req.Header.Set("Accept", "text/html")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("destination returned %d", resp.StatusCode)
+	}
+	if !strings.Contains(resp.Header.Get("Content-Type"), "html") {
+		return nil
+	}
+
+	var title, description string
+	tokens := html.NewTokenizer(io.LimitReader(resp.Body, metadataMaxBytes))
+	for {
+		switch tokens.Next() {
+		case html.ErrorToken:
+			meta.Title = firstNonEmpty(meta.Title, title)
+			meta.Description = firstNonEmpty(meta.Description, description)
+			return nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokens.Token()
+			switch token.Data {
+			case "title":
+				if title == "" && tokens.Next() == html.TextToken {
+					title = truncateMetadata(string(tokens.Text()))
+				}
+			case "meta":
+				property, content := metaTagContent(token)
+				switch property {
+				case "og:title":
+					meta.Title = firstNonEmpty(meta.Title, content)
+				case "og:description":
+					meta.Description = firstNonEmpty(meta.Description, content)
+				case "description":
+					description = firstNonEmpty(description, content)
+				case "og:site_name":
+					meta.SiteName = firstNonEmpty(meta.SiteName, content)
+				case "og:image", "og:image:url", "twitter:image":
+					if meta.Image == "" {
+						meta.Image = resolveMetadataURL(resp.Request.URL, content)
+					}
+				}
+			}
+		case html.EndTagToken:
+			// Everything worth reading lives in <head>
+			if name, _ := tokens.TagName(); string(name) == "head" {
+				meta.Title = firstNonEmpty(meta.Title, title)
+				meta.Description = firstNonEmpty(meta.Description, description)
+				return nil
+			}
+		}
+	}
+}
+
+// metaTagContent returns a <meta> tag's property (or name) and its content
+func metaTagContent(token html.Token) (property, content string) {
+	for _, attr := range token.Attr {
+		switch strings.ToLower(attr.Key) {
+		case "property", "name":
+			property = strings.ToLower(strings.TrimSpace(attr.Val))
+		case "content":
+			content = truncateMetadata(attr.Val)
+		}
+	}
+	return property, content
+}
+
+// resolveMetadataURL makes a relative image URL absolute; non-HTTP(S) URLs are dropped
+func resolveMetadataURL(base *url.URL, ref string) string {
+	parsed, err := base.Parse(ref)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	return parsed.String()
+}
+
+func truncateMetadata(value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	if runes := []rune(value); len(runes) > metadataMaxLength {
+		return string(runes[:metadataMaxLength])
+	}
+	return value
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// denyPrivateAddresses stops the scraper from reaching internal services
+// through a link pointed at a private, loopback or link-local address
+func denyPrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 0 {
+		return fmt.Errorf("refusing to connect to %s", host)
+	}
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to connect to %s", host)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	liveClicksQueueSize     = 10000
+	liveClicksFlushInterval = time.Second
+	// Minutes in the realtime series, and the part of it that counts as "live"
+	liveClicksWindow       = 30
+	liveClicksActiveWindow = 5
+	liveClicksTTL          = (liveClicksWindow + 1) * time.Minute
+)
+
+// LiveClicks keeps per-minute click counters per account in Redis for the
+// realtime dashboard: one hash per account and minute, short code -> clicks.
+// Clicks are queued and flushed in batches, so the redirect path never waits;
+// when the queue is full the click is only missing from the live view.
+type LiveClicks struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	queue       chan *models.ClickEvent
+}
+
+func NewLiveClicks(db *gorm.DB, redisClient *redis.Client) *LiveClicks {
+	return &LiveClicks{
+		db:          db,
+		redisClient: redisClient,
+		queue:       make(chan *models.ClickEvent, liveClicksQueueSize),
+	}
+}
+
+// NotifyClick queues a click for the live counters without blocking
+func (l *LiveClicks) NotifyClick(event *models.ClickEvent) {
+	select {
+	case l.queue <- event:
+	default:
+		utils.Logger.Warn("Live clicks queue full, dropping click", "short_code", event.ShortCode)
+	}
+}
+
+// Start flushes queued clicks to Redis in the background
+func (l *LiveClicks) Start() {
+	go func() {
+		ticker := time.NewTicker(liveClicksFlushInterval)
+		defer ticker.Stop()
+
+		var batch []*models.ClickEvent
+		for {
+			select {
+			case event := <-l.queue:
+				batch = append(batch, event)
+				continue
+			case <-ticker.C:
+			}
+			if len(batch) == 0 {
+				continue
+			}
+			l.flush(batch)
+			batch = nil
+		}
+	}()
+}
+
+// flush resolves the owners of a batch of clicks and bumps their counters
+func (l *LiveClicks) flush(batch []*models.ClickEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	codes := make([]string, 0, len(batch))
+	seen := make(map[string]bool)
+	for _, e := range batch {
+		if !seen[e.ShortCode] {
+			seen[e.ShortCode] = true
+			codes = append(codes, e.ShortCode)
+		}
+	}
+
+	var urls []models.URL
+	if err := l.db.WithContext(ctx).
+		Select("short_code", "user_id").
+		Where("short_code IN ? AND user_id IS NOT NULL AND deleted_at IS NULL", codes).
+		Find(&urls).Error; err != nil {
+		utils.Logger.Error("Live clicks: failed to load urls", "error", err)
+		return
+	}
+	owners := make(map[string]uuid.UUID, len(urls))
+	for _, u := range urls {
+		owners[u.ShortCode] = *u.UserID
+	}
+
+	pipe := l.redisClient.Pipeline()
+	for _, e := range batch {
+		owner, ok := owners[e.ShortCode]
+		if !ok {
+			continue
+		}
+		key := getLiveClicksKey(owner, e.ClickedAt)
+		pipe.HIncrBy(ctx, key, e.ShortCode, 1)
+		pipe.Expire(ctx, key, liveClicksTTL)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.Logger.Error("Live clicks: failed to update counters", "error", err)
+	}
+}
+
+// Realtime returns the account's live view from the per-minute counters
+func (l *LiveClicks) Realtime(ctx context.Context, userID uuid.UUID) (*types.RealtimeAnalytics, error) {
+	now := time.Now().UTC()
+	current := now.Truncate(time.Minute)
+
+	pipe := l.redisClient.Pipeline()
+	minutes := make([]*redis.StringStringMapCmd, liveClicksWindow)
+	for i := range minutes {
+		minute := current.Add(-time.Duration(liveClicksWindow-1-i) * time.Minute)
+		minutes[i] = pipe.HGetAll(ctx, getLiveClicksKey(userID, minute))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	realtime := &types.RealtimeAnalytics{
+		GeneratedAt:     now,
+		ClicksPerMinute: make([]types.MinuteClicks, liveClicksWindow),
+	}
+	live := make(map[string]int64)
+	for i, cmd := range minutes {
+		var total int64
+		for code, value := range cmd.Val() {
+			clicks, _ := strconv.ParseInt(value, 10, 64)
+			total += clicks
+			if i >= liveClicksWindow-liveClicksActiveWindow {
+				live[code] += clicks
+			}
+		}
+		realtime.ClicksPerMinute[i] = types.MinuteClicks{
+			Minute: current.Add(-time.Duration(liveClicksWindow-1-i) * time.Minute),
+			Clicks: total,
+		}
+	}
+	realtime.ClicksLastMinute = realtime.ClicksPerMinute[liveClicksWindow-2].Clicks
+	realtime.ActiveLinks = len(live)
+
+	var top string
+	for code, clicks := range live {
+		if clicks > live[top] || (clicks == live[top] && code < top) {
+			top = code
+		}
+	}
+	if top != "" {
+		var url models.URL
+		if err := l.db.WithContext(ctx).Select("short_url").
+			Where("short_code = ?", top).First(&url).Error; err != nil && err != gorm.ErrRecordNotFound {
+			return nil, err
+		}
+		realtime.TopLiveLink = &types.LiveLink{ShortCode: top, ShortURL: url.ShortURL, Clicks: live[top]}
+	}
+
+	return realtime, nil
+}
+
+func getLiveClicksKey(userID uuid.UUID, at time.Time) string {
+	return fmt.Sprintf("live:%s:%d", userID, at.Unix()/60)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	maxLoginEvents           = 100
+	loginEventPurgeBatchSize = 1000
+)
+
+// LoginAuditService keeps a log of sign-in attempts for users to review
+type LoginAuditService struct {
+	db *gorm.DB
+}
+
+func NewLoginAuditService(db *gorm.DB) *LoginAuditService {
+	return &LoginAuditService{db: db}
+}
+
+// RecordLogin stores a sign-in attempt in the background so it never slows
+// down or fails the login itself. Attempts without a UserID are attributed to
+// the account with the attempted email, if there is one.
+func (s *LoginAuditService) RecordLogin(ctx context.Context, event *models.LoginEvent) {
+	logger := utils.LoggerFromContext(ctx)
+	event.Browser, event.Device = utils.ParseUserAgent(event.UserAgent)
+	event.Email = strings.ToLower(strings.TrimSpace(event.Email))
+	event.CreatedAt = time.Now().UTC()
+
+	go func() {
+		bgCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if event.UserID == nil && event.Email != "" {
+			var user models.User
+			if err := s.db.WithContext(bgCtx).Select("id").
+				Where("LOWER(email) = ?", event.Email).First(&user).Error; err == nil {
+				event.UserID = &user.ID
+			}
+		}
+
+		if err := s.db.WithContext(bgCtx).Create(event).Error; err != nil {
+			logger.Warn("Failed to record login event", "error", err)
+		}
+	}()
+}
+
+// ListLoginEvents returns the most recent sign-in attempts on an account
+func (s *LoginAuditService) ListLoginEvents(ctx context.Context, userID uuid.UUID, limit int) ([]models.LoginEvent, error) {
+	if limit < 1 || limit > maxLoginEvents {
+		limit = maxLoginEvents
+	}
+
+	events := []models.LoginEvent{}
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+// PurgeLoginEvents deletes sign-in attempts older than
+// models.LoginEventRetention, in batches so no single statement holds locks
+// on a large part of the table
+func (s *LoginAuditService) PurgeLoginEvents(ctx context.Context) (int64, error) {
+	cutoff := time.Now().UTC().Add(-models.LoginEventRetention)
+	var purged int64
+	for {
+		expired := s.db.Model(&models.LoginEvent{}).Select("id").
+			Where("created_at <= ?", cutoff).
+			Limit(loginEventPurgeBatchSize)
+		result := s.db.WithContext(ctx).Where("id IN (?)", expired).Delete(&models.LoginEvent{})
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+		if result.RowsAffected < loginEventPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// StartPurgeJob purges expired sign-in attempts every hour
+func (s *LoginAuditService) StartPurgeJob() {
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		ctx := context.Background()
+		for range ticker.C {
+			purged, err := s.PurgeLoginEvents(ctx)
+			if err != nil {
+				utils.Logger.Error("Login event purge failed", "error", err)
+				continue
+			}
+			if purged > 0 {
+				utils.Logger.Info("Purged login events", "count", purged)
+			}
+		}
+	}()
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+const magicLinkTTL = 15 * time.Minute
+
+// MagicLinkService issues and redeems single-use passwordless login links
+type MagicLinkService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	signingKey  []byte
+	emailQueue  *EmailQueue
+}
+
+// NewMagicLinkService signs login links with a key derived from the JWT
+// secret, so they can never be accepted as access tokens
+func NewMagicLinkService(db *gorm.DB, redisClient *redis.Client, jwtSecret string, emailQueue *EmailQueue) *MagicLinkService {
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte("magic-link"))
+
+	return &MagicLinkService{
+		db:          db,
+		redisClient: redisClient,
+		signingKey:  mac.Sum(nil),
+		emailQueue:  emailQueue,
+	}
+}
+
+// SendLink queues a login link for the account with this address. Unknown,
+// suspended and undeliverable addresses are skipped silently, and the email
+// is sent by the queue rather than during the request, so neither the answer
+// nor its timing tells callers which emails have an account.
+func (s *MagicLinkService) SendLink(ctx context.Context, email string) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("LOWER(email) = ?", strings.ToLower(email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.IsSuspended() {
+		return nil
+	}
+
+	token, err := s.generateToken(&user)
+	if err != nil {
+		return err
+	}
+
+	return s.emailQueue.Enqueue(ctx, EmailJob{Kind: EmailJobMagicLink, UserID: user.ID, Token: token}, time.Now())
+}
+
+// Redeem returns the user a login link was issued for. Each link works once:
+// its ID is claimed in Redis, and redemption fails closed without Redis.
+func (s *MagicLinkService) Redeem(ctx context.Context, tokenString string) (*models.User, error) {
+	token, err := jwt.Parse(strings.TrimSpace(tokenString), func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, types.ErrInvalidSigningMethod
+		}
+		return s.signingKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, types.ErrInvalidMagicLink
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, types.ErrInvalidMagicLink
+	}
+	userID, err := uuid.Parse(fmt.Sprint(claims["user_id"]))
+	if err != nil {
+		return nil, types.ErrInvalidMagicLink
+	}
+	linkID, _ := claims["jti"].(string)
+	if linkID == "" {
+		return nil, types.ErrInvalidMagicLink
+	}
+
+	claimed, err := s.redisClient.SetNX(ctx, fmt.Sprintf("magic_link:used:%s", linkID), userID.String(), magicLinkTTL).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem login link: %w", err)
+	}
+	if !claimed {
+		return nil, types.ErrInvalidMagicLink
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, types.ErrInvalidMagicLink
+	}
+	// Links are bound to the address they were sent to
+	if !strings.EqualFold(user.Email, fmt.Sprint(claims["email"])) {
+		return nil, types.ErrInvalidMagicLink
+	}
+	if user.IsSuspended() {
+		return nil, types.ErrAccountSuspended
+	}
+
+	return &user, nil
+}
+
+func (s *MagicLinkService) generateToken(user *models.User) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"jti":     uuid.New().String(),
+		"user_id": user.ID.String(),
+		"email":   strings.ToLower(user.Email),
+		"exp":     now.Add(magicLinkTTL).Unix(),
+		"iat":     now.Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.signingKey)
+}
@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+)
+
+const (
+	// oidcDiscoveryTTL is how long the issuer's discovery document is reused
+	oidcDiscoveryTTL = 24 * time.Hour
+	// oidcKeysRefreshInterval limits JWKS refetches on unknown key IDs, so
+	// tokens with made-up kids can't hammer the issuer
+	oidcKeysRefreshInterval = time.Minute
+	// oidcStateTTL is how long users have to come back from the issuer
+	oidcStateTTL = 10 * time.Minute
+)
+
+// oidcSigningMethods are the ID token algorithms accepted from the issuer
+var oidcSigningMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// OIDCConfig configures sign-in with a company's own OpenID Connect issuer
+type OIDCConfig struct {
+	Issuer       string
+	DiscoveryURL string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+	// Claims holding the user's email and name
+	EmailClaim     string
+	FirstNameClaim string
+	LastNameClaim  string
+	// Create accounts for unknown users on first login
+	AutoProvision bool
+	// Accept emails without email_verified, for issuers that don't send it
+	TrustEmail bool
+}
+
+// OIDCProvider verifies ID tokens from a generic OIDC issuer and exchanges
+// authorization codes. Endpoints come from the issuer's discovery document;
+// ID token signatures are checked against its JWKS.
+type OIDCProvider struct {
+	cfg         OIDCConfig
+	httpClient  *http.Client
+	redisClient *redis.Client
+
+	mu            sync.Mutex
+	discovery     *oidcDiscovery
+	discoveredAt  time.Time
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func NewOIDCProvider(cfg OIDCConfig, redisClient *redis.Client) *OIDCProvider {
+	return &OIDCProvider{
+		cfg:         cfg,
+		httpClient:  &http.Client{Timeout: 5 * time.Second},
+		redisClient: redisClient,
+	}
+}
+
+// AutoProvision reports whether unknown users get an account on first login
+func (p *OIDCProvider) AutoProvision() bool {
+	return p != nil && p.cfg.AutoProvision
+}
+
+// AuthorizationInfo tells the frontend where to send users to sign in. Each
+// call issues a one-time state and nonce: the login must present the state,
+// and the ID token must carry the nonce, so a code or token obtained for
+// another login attempt can't be replayed.
+func (p *OIDCProvider) AuthorizationInfo(ctx context.Context) (*types.OIDCAuthorization, error) {
+	if p == nil {
+		return nil, types.ErrOAuthNotConfigured
+	}
+	discovery, err := p.loadDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := newOIDCStateValue()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := newOIDCStateValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.redisClient.Set(ctx, getOIDCStateKey(state), nonce, oidcStateTTL).Err(); err != nil {
+		return nil, err
+	}
+
+	return &types.OIDCAuthorization{
+		State:                 state,
+		Nonce:                 nonce,
+		Issuer:                p.cfg.Issuer,
+		AuthorizationEndpoint: discovery.AuthorizationEndpoint,
+		ClientID:              p.cfg.ClientID,
+		RedirectURI:           p.cfg.RedirectURL,
+		Scope:                 strings.Join(p.cfg.Scopes, " "),
+		ResponseType:          "code",
+	}, nil
+}
+
+// Authenticate resolves an ID token or authorization code to a verified
+// identity. Codes need the state issued by AuthorizationInfo; ID tokens are
+// checked against its nonce whenever a state is given.
+func (p *OIDCProvider) Authenticate(ctx context.Context, idToken, code, state string) (*types.OAuthIdentity, error) {
+	if p == nil {
+		return nil, types.ErrOAuthNotConfigured
+	}
+
+	var nonce string
+	if idToken == "" || state != "" {
+		var err error
+		if nonce, err = p.consumeState(ctx, state); err != nil {
+			return nil, err
+		}
+	}
+
+	discovery, err := p.loadDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if idToken == "" {
+		if p.cfg.ClientSecret == "" {
+			return nil, types.ErrOAuthNotConfigured
+		}
+		if idToken, err = p.exchangeCode(ctx, discovery, code); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.verifyIDToken(ctx, discovery, idToken, nonce)
+}
+
+// consumeState returns the nonce issued with a state, which can only be used once
+func (p *OIDCProvider) consumeState(ctx context.Context, state string) (string, error) {
+	if state == "" {
+		return "", types.ErrInvalidOAuthToken
+	}
+	key := getOIDCStateKey(state)
+	pipe := p.redisClient.TxPipeline()
+	get := pipe.Get(ctx, key)
+	pipe.Del(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", types.ErrInvalidOAuthToken
+		}
+		return "", err
+	}
+	return get.Val(), nil
+}
+
+// loadDiscovery fetches the issuer's discovery document, reusing it for oidcDiscoveryTTL
+func (p *OIDCProvider) loadDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil && time.Since(p.discoveredAt) < oidcDiscoveryTTL {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := p.getJSON(ctx, p.cfg.DiscoveryURL, &discovery); err != nil {
+		// Keep signing users in with the previous document if the issuer blips
+		if p.discovery != nil {
+			return p.discovery, nil
+		}
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if discovery.Issuer != p.cfg.Issuer || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("oidc discovery: document does not match issuer %s", p.cfg.Issuer)
+	}
+
+	p.discovery = &discovery
+	p.discoveredAt = time.Now()
+	return p.discovery, nil
+}
+
+// exchangeCode trades an authorization code for the user's ID token
+func (p *OIDCProvider) exchangeCode(ctx context.Context, discovery *oidcDiscovery, code string) (string, error) {
+	form := url.Values{
+		"code":         {code},
+		"redirect_uri": {p.cfg.RedirectURL},
+		"grant_type":   {"authorization_code"},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("oidc token exchange: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", types.ErrInvalidOAuthToken
+	}
+
+	var result struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.IDToken == "" {
+		return "", types.ErrInvalidOAuthToken
+	}
+	return result.IDToken, nil
+}
+
+// verifyIDToken checks the token's signature, issuer, audience, expiry and,
+// when one was issued, nonce, then maps the configured claims to an identity
+func (p *OIDCProvider) verifyIDToken(ctx context.Context, discovery *oidcDiscovery, idToken, nonce string) (*types.OAuthIdentity, error) {
+	parser := jwt.Parser{ValidMethods: oidcSigningMethods}
+	token, err := parser.Parse(idToken, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.signingKey(ctx, discovery, kid)
+	})
+	if err != nil || !token.Valid {
+		return nil, types.ErrInvalidOAuthToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, types.ErrInvalidOAuthToken
+	}
+	if !claims.VerifyIssuer(discovery.Issuer, true) || !claims.VerifyAudience(p.cfg.ClientID, true) {
+		return nil, types.ErrInvalidOAuthToken
+	}
+	if _, ok := claims["exp"]; !ok {
+		return nil, types.ErrInvalidOAuthToken
+	}
+	if nonce != "" && stringClaim(claims, "nonce") != nonce {
+		return nil, types.ErrInvalidOAuthToken
+	}
+
+	subject := stringClaim(claims, "sub")
+	email := strings.ToLower(strings.TrimSpace(stringClaim(claims, p.cfg.EmailClaim)))
+	if subject == "" || email == "" {
+		return nil, types.ErrInvalidOAuthToken
+	}
+	// Linking by email is only safe when the issuer vouches for the address
+	if !p.cfg.TrustEmail && !boolClaim(claims, "email_verified") {
+		return nil, types.ErrInvalidOAuthToken
+	}
+
+	return &types.OAuthIdentity{
+		Subject:   subject,
+		Email:     email,
+		FirstName: stringClaim(claims, p.cfg.FirstNameClaim),
+		LastName:  stringClaim(claims, p.cfg.LastNameClaim),
+	}, nil
+}
+
+// signingKey returns the issuer's public key with the given ID, refetching
+// the JWKS (at most every oidcKeysRefreshInterval) when the key is unknown,
+// e.g. after the issuer rotated its keys
+func (p *OIDCProvider) signingKey(ctx context.Context, discovery *oidcDiscovery, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	if time.Since(p.keysFetchedAt) < oidcKeysRefreshInterval {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+
+	var jwks struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+	p.keysFetchedAt = time.Now()
+	if err := p.getJSON(ctx, discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.Kid] = key
+		}
+	}
+	p.keys = keys
+
+	if key, ok := p.lookupKey(kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey finds a key by ID; tokens without a kid match a sole key
+func (p *OIDCProvider) lookupKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+func (p *OIDCProvider) getJSON(ctx context.Context, endpoint string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", endpoint, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// oidcJWK is one key of the issuer's JWKS (RSA or EC)
+type oidcJWK struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k *oidcJWK) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, fmt.Errorf("invalid EC key")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// newOIDCStateValue returns a random URL-safe state or nonce
+func newOIDCStateValue() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func getOIDCStateKey(state string) string {
+	return fmt.Sprintf("auth:oidc_state:%s", state)
+}
+
+func stringClaim(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return strings.TrimSpace(value)
+}
+
+// boolClaim reads a boolean claim; some issuers send "true" as a string
+func boolClaim(claims jwt.MapClaims, name string) bool {
+	switch value := claims[name].(type) {
+	case bool:
+		return value
+	case string:
+		return value == "true"
+	}
+	return false
+}
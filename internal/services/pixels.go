@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SetPixels replaces a link's retargeting pixels. Links with pixels redirect
+// through an interstitial page that loads them; an empty list goes back to a
+// plain redirect.
+func (s *URLService) SetPixels(ctx context.Context, userID, urlID uuid.UUID, pixels []models.RetargetingPixel, consentRequired bool) (*models.URL, error) {
+	if len(pixels) > models.MaxPixelsPerURL {
+		return nil, types.NewValidationError(fmt.Sprintf("a link can have at most %d pixels", models.MaxPixelsPerURL))
+	}
+	for _, pixel := range pixels {
+		if err := pixel.Validate(); err != nil {
+			return nil, types.NewValidationError(err.Error())
+		}
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		if len(pixels) == 0 {
+			url.Pixels = nil
+			url.PixelConsentRequired = false
+		} else {
+			url.Pixels = pixels
+			url.PixelConsentRequired = consentRequired
+		}
+		url.UpdatedAt = time.Now().UTC()
+
+		if err := tx.Select("pixels", "pixel_consent_required", "updated_at").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SetPreviewPage turns a link's preview page on or off. With it on, visitors
+// see where the link goes and continue there themselves.
+func (s *URLService) SetPreviewPage(ctx context.Context, userID, urlID uuid.UUID, enabled bool) (*models.URL, error) {
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		url.PreviewPage = enabled
+		url.UpdatedAt = time.Now().UTC()
+		if err := tx.Select("preview_page", "updated_at").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	previewWorkers  = 2
+	previewMaxBytes = 5 << 20
+)
+
+// PreviewService captures a thumbnail of each link's destination with an
+// external headless screenshot service and stores it in object storage.
+// The screenshot URL is a template where "{url}" is replaced by the escaped
+// destination, e.g. "http://screenshots:3000/capture?url={url}&width=1200".
+// Work is queued in memory; links that are dropped or fail can be refreshed.
+type PreviewService struct {
+	db            *gorm.DB
+	store         *ObjectStore
+	screenshotURL string
+	httpClient    *http.Client
+	queue         chan uuid.UUID
+}
+
+func NewPreviewService(db *gorm.DB, store *ObjectStore, screenshotURL string) *PreviewService {
+	return &PreviewService{
+		db:            db,
+		store:         store,
+		screenshotURL: screenshotURL,
+		httpClient:    &http.Client{Timeout: 45 * time.Second},
+		queue:         make(chan uuid.UUID, 1000),
+	}
+}
+
+// NotifyURLCreated implements interfaces.URLListener
+func (p *PreviewService) NotifyURLCreated(url *models.URL) {
+	p.enqueue(url.ID)
+}
+
+// RefreshPreview re-captures the thumbnail of one of the user's links
+func (p *PreviewService) RefreshPreview(ctx context.Context, userID, urlID uuid.UUID) error {
+	var count int64
+	if err := p.db.WithContext(ctx).Model(&models.URL{}).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return types.ErrURLNotFound
+	}
+
+	p.enqueue(urlID)
+	return nil
+}
+
+func (p *PreviewService) enqueue(urlID uuid.UUID) {
+	select {
+	case p.queue <- urlID:
+	default:
+		utils.Logger.Warn("Preview queue full, skipping link", "url_id", urlID)
+	}
+}
+
+// Start runs the capture workers
+func (p *PreviewService) Start() {
+	for i := 0; i < previewWorkers; i++ {
+		go func() {
+			for urlID := range p.queue {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				if err := p.capture(ctx, urlID); err != nil {
+					utils.Logger.Error("Failed to capture link preview", "url_id", urlID, "error", err)
+				}
+				cancel()
+			}
+		}()
+	}
+}
+
+// capture screenshots a link's destination and records the stored image URL
+func (p *PreviewService) capture(ctx context.Context, urlID uuid.UUID) error {
+	var link models.URL
+	if err := p.db.WithContext(ctx).
+		Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	// Never render destinations that are disabled or still under abuse review
+	if link.IsDisabled() || link.IsPendingReview() {
+		return nil
+	}
+
+	image, contentType, err := p.screenshot(ctx, link.LongURL)
+	if err != nil {
+		return err
+	}
+
+	ext := "png"
+	if strings.Contains(contentType, "jpeg") {
+		ext = "jpg"
+	} else if strings.Contains(contentType, "webp") {
+		ext = "webp"
+	}
+	key := fmt.Sprintf("previews/%s.%s", link.ShortCode, ext)
+
+	imageURL, err := p.store.Put(ctx, key, contentType, image)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	return p.db.WithContext(ctx).Model(&models.URL{}).
+		Where("id = ?", link.ID).
+		UpdateColumns(map[string]interface{}{
+			"preview_image_url":    imageURL,
+			"preview_generated_at": now,
+		}).Error
+}
+
+func (p *PreviewService) screenshot(ctx context.Context, destination string) ([]byte, string, error) {
+	target := strings.ReplaceAll(p.screenshotURL, "{url}", url.QueryEscape(destination))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("screenshot service: %w", err)
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(contentType, "image/") {
+		return nil, "", fmt.Errorf("screenshot service: status %d (%s)", resp.StatusCode, contentType)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, previewMaxBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(image) > previewMaxBytes {
+		return nil, "", fmt.Errorf("screenshot exceeds %d bytes", previewMaxBytes)
+	}
+	return image, contentType, nil
+}
@@ -0,0 +1,232 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// The logo covers at most a fifth of the code's width; with the highest
+// error correction (30% recovery) the covered modules stay readable
+const (
+	qrLogoRatio   = 5
+	qrLogoPadding = 4
+)
+
+// qrLogoPresets are the built-in logos usable with ?logo=true&preset=,
+// drawn at render time so they need no stored assets
+var qrLogoPresets = map[string]func(x, y, size int) bool{
+	"circle": func(x, y, size int) bool {
+		r := size / 2
+		dx, dy := x-r, y-r
+		return dx*dx+dy*dy <= r*r
+	},
+	"square": func(x, y, size int) bool {
+		return true
+	},
+	"heart": func(x, y, size int) bool {
+		// Implicit heart curve (x²+y²-1)³ - x²y³ <= 0 scaled to the box
+		fx := (float64(x)/float64(size))*2.6 - 1.3
+		fy := 1.2 - (float64(y)/float64(size))*2.5
+		a := fx*fx + fy*fy - 1
+		return a*a*a-fx*fx*fy*fy*fy <= 0
+	},
+}
+
+// SetQRLogo validates an uploaded PNG or JPEG image and stores it, re-encoded
+// as PNG, as the logo of the account's QR codes
+func (s *QRService) SetQRLogo(ctx context.Context, userID uuid.UUID, data []byte) (*models.QRLogo, error) {
+	if len(data) == 0 || len(data) > models.MaxQRLogoBytes {
+		return nil, types.ErrInvalidQRLogo
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg") {
+		return nil, types.ErrInvalidQRLogo
+	}
+	if config.Width > models.MaxQRLogoDimension || config.Height > models.MaxQRLogoDimension {
+		return nil, types.ErrInvalidQRLogo
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, types.ErrInvalidQRLogo
+	}
+
+	// Re-encoding drops metadata and anything hidden after the image data
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode logo: %w", err)
+	}
+
+	logo := models.QRLogo{
+		UserID:    userID,
+		Data:      buf.Bytes(),
+		Width:     config.Width,
+		Height:    config.Height,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(&logo).Error; err != nil {
+		return nil, err
+	}
+	return &logo, nil
+}
+
+// GetQRLogo returns the account's uploaded logo
+func (s *QRService) GetQRLogo(ctx context.Context, userID uuid.UUID) (*models.QRLogo, error) {
+	var logo models.QRLogo
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).First(&logo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrQRLogoNotFound
+		}
+		return nil, err
+	}
+	return &logo, nil
+}
+
+// DeleteQRLogo removes the account's logo; QR codes requested with
+// ?logo=true and no preset then fail until a new one is uploaded
+func (s *QRService) DeleteQRLogo(ctx context.Context, userID uuid.UUID) error {
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.QRLogo{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return types.ErrQRLogoNotFound
+	}
+	return nil
+}
+
+// GenerateQRCodeWithLogo renders the QR code with the highest error
+// correction and a logo in the center: the named preset, or the link
+// owner's uploaded logo when preset is empty
+func (s *QRService) GenerateQRCodeWithLogo(ctx context.Context, shortCode, preset string) ([]byte, error) {
+	var logo image.Image
+	var variant string
+	if preset != "" {
+		shape, ok := qrLogoPresets[preset]
+		if !ok {
+			return nil, types.ErrUnknownQRLogoPreset
+		}
+		variant = "logo:" + preset
+		if cached, ok := s.cachedQR(ctx, shortCode, variant); ok {
+			return cached, nil
+		}
+		logo = presetLogo(shape, qrSize/qrLogoRatio)
+	} else {
+		ownerID, err := s.linkOwner(ctx, shortCode)
+		if err != nil {
+			return nil, err
+		}
+		// Only the upload time is needed to find a cached rendering; a new
+		// upload changes the variant, leaving the old one to expire
+		var uploaded models.QRLogo
+		if err := s.db.WithContext(ctx).Select("updated_at").Where("user_id = ?", ownerID).First(&uploaded).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, types.ErrQRLogoNotFound
+			}
+			return nil, err
+		}
+		variant = "logo@" + strconv.FormatInt(uploaded.UpdatedAt.UnixNano(), 36)
+		if cached, ok := s.cachedQR(ctx, shortCode, variant); ok {
+			return cached, nil
+		}
+
+		stored, err := s.GetQRLogo(ctx, ownerID)
+		if err != nil {
+			return nil, err
+		}
+		if logo, err = png.Decode(bytes.NewReader(stored.Data)); err != nil {
+			return nil, fmt.Errorf("failed to decode logo: %w", err)
+		}
+	}
+
+	qr, err := qrcode.New(s.shortURL(shortCode), qrcode.Highest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, qrSize, qrSize))
+	draw.Draw(canvas, canvas.Bounds(), qr.Image(qrSize), image.Point{}, draw.Src)
+
+	// A white pad behind the logo keeps its edge from merging with modules
+	box := fitLogo(logo.Bounds(), qrSize/qrLogoRatio)
+	offset := image.Pt((qrSize-box.Dx())/2, (qrSize-box.Dy())/2)
+	pad := box.Add(offset).Inset(-qrLogoPadding)
+	draw.Draw(canvas, pad, image.NewUniform(color.White), image.Point{}, draw.Src)
+	drawScaled(canvas, box.Add(offset), logo)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	s.cacheQR(ctx, shortCode, variant, buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// linkOwner returns the account owning a short code; anonymous links have
+// no owner to take a logo from
+func (s *QRService) linkOwner(ctx context.Context, shortCode string) (uuid.UUID, error) {
+	var link models.URL
+	if err := s.db.WithContext(ctx).Select("user_id").
+		Where("short_code = ? AND deleted_at IS NULL", shortCode).
+		First(&link).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return uuid.Nil, types.ErrURLNotFound
+		}
+		return uuid.Nil, err
+	}
+	if link.UserID == nil {
+		return uuid.Nil, types.ErrQRLogoNotFound
+	}
+	return *link.UserID, nil
+}
+
+// fitLogo returns the rectangle at the origin that fits bounds into a
+// limit×limit square keeping the aspect ratio
+func fitLogo(bounds image.Rectangle, limit int) image.Rectangle {
+	w, h := bounds.Dx(), bounds.Dy()
+	if w >= h {
+		return image.Rect(0, 0, limit, max(1, h*limit/w))
+	}
+	return image.Rect(0, 0, max(1, w*limit/h), limit)
+}
+
+// drawScaled draws src into dst's rect with nearest-neighbor scaling,
+// blending transparent pixels over what is already there
+func drawScaled(dst *image.RGBA, rect image.Rectangle, src image.Image) {
+	sb := src.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	for y := 0; y < rect.Dy(); y++ {
+		for x := 0; x < rect.Dx(); x++ {
+			scaled.Set(x, y, src.At(sb.Min.X+x*sb.Dx()/rect.Dx(), sb.Min.Y+y*sb.Dy()/rect.Dy()))
+		}
+	}
+	draw.Draw(dst, rect, scaled, image.Point{}, draw.Over)
+}
+
+// presetLogo draws a built-in shape in the brand-neutral dark color
+func presetLogo(shape func(x, y, size int) bool, size int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	fill := color.RGBA{R: 33, G: 37, B: 41, A: 255}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			if shape(x, y, size) {
+				img.Set(x, y, fill)
+			}
+		}
+	}
+	return img
+}
@@ -3,9 +3,14 @@ package services
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"image/color"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -14,10 +19,18 @@ import (
 	"gorm.io/gorm"
 )
 
+// qrSize and qrVersion describe the rendering options; bump qrVersion when
+// the output changes so clients drop their cached copies
+const (
+	qrSize    = 256
+	qrVersion = "1"
+)
+
 type QRService struct {
 	db          *gorm.DB
 	redisClient *redis.Client
 	urlPrefix   string
+	cacheTTL    time.Duration
 }
 
 func NewQRService(db *gorm.DB, redisClient *redis.Client, urlPrefix string) *QRService {
@@ -25,19 +38,24 @@ func NewQRService(db *gorm.DB, redisClient *redis.Client, urlPrefix string) *QRS
 		db:          db,
 		redisClient: redisClient,
 		urlPrefix:   urlPrefix,
+		cacheTTL:    24 * time.Hour,
 	}
 }
 
+// SetCacheTTL sets how long rendered QR images stay in Redis; 0 disables
+// the cache
+func (s *QRService) SetCacheTTL(ttl time.Duration) {
+	s.cacheTTL = ttl
+}
+
 func (s *QRService) GenerateQRCode(ctx context.Context, shortCode string) ([]byte, error) {
 	// Check cache first
-	qrKey := getQRCodeKey(shortCode)
-	cachedQR, err := s.redisClient.Get(ctx, qrKey).Bytes()
-	if err == nil {
+	if cachedQR, ok := s.cachedQR(ctx, shortCode, "png"); ok {
 		return cachedQR, nil
 	}
 
 	// Generate QR code
-	fullURL := fmt.Sprintf("%surls/%s", s.urlPrefix, shortCode)
+	fullURL := s.shortURL(shortCode)
 	qr, err := qrcode.New(fullURL, qrcode.Medium)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate QR code: %w", err)
@@ -49,17 +67,53 @@ func (s *QRService) GenerateQRCode(ctx context.Context, shortCode string) ([]byt
 
 	// Get PNG bytes
 	var buf bytes.Buffer
-	err = qr.Write(256, &buf)
+	err = qr.Write(qrSize, &buf)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode QR code: %w", err)
 	}
 
-	// Cache the QR code
-	if err := s.redisClient.Set(ctx, qrKey, buf.Bytes(), 24*time.Hour).Err(); err != nil {
-		// Log error but don't fail the request
-		utils.Logger.Error("Failed to cache QR code", "error", err)
+	s.cacheQR(ctx, shortCode, "png", buf.Bytes())
+	return buf.Bytes(), nil
+}
+
+// GenerateQRCodeSVG renders the QR code as SVG for print material. Each row
+// of dark modules becomes one path segment, so the vector output stays small
+// and scales without blurring.
+func (s *QRService) GenerateQRCodeSVG(ctx context.Context, shortCode string) ([]byte, error) {
+	if cached, ok := s.cachedQR(ctx, shortCode, "svg"); ok {
+		return cached, nil
+	}
+
+	qr, err := qrcode.New(s.shortURL(shortCode), qrcode.Medium)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	// The bitmap includes the quiet zone around the code
+	bitmap := qr.Bitmap()
+	size := len(bitmap)
+
+	var path strings.Builder
+	for y, row := range bitmap {
+		for x := 0; x < len(row); {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			fmt.Fprintf(&path, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
 	}
 
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" shape-rendering="crispEdges">`,
+		size, size, qrSize, qrSize)
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`, size, size)
+	fmt.Fprintf(&buf, `<path d="%s" fill="#000000"/></svg>`, path.String())
+	s.cacheQR(ctx, shortCode, "svg", buf.Bytes())
 	return buf.Bytes(), nil
 }
 
@@ -72,6 +126,71 @@ func (s *QRService) GetQRCodeAsBase64(ctx context.Context, shortCode string) (st
 	return base64.StdEncoding.EncodeToString(qrBytes), nil
 }
 
+// ETag identifies the QR image of a short code. The image only depends on the
+// encoded URL and rendering options, so it can be computed without rendering.
+func (s *QRService) ETag(shortCode string) string {
+	return s.etag(shortCode, "png")
+}
+
+// SVGETag identifies the SVG QR code of a short code, see ETag
+func (s *QRService) SVGETag(shortCode string) string {
+	return s.etag(shortCode, "svg")
+}
+
+func (s *QRService) etag(shortCode, format string) string {
+	input := s.shortURL(shortCode) + "|" + strconv.Itoa(qrSize) + "|" + qrVersion
+	// PNG tags predate the SVG output and stay unchanged
+	if format != "png" {
+		input += "|" + format
+	}
+	sum := sha256.Sum256([]byte(input))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func (s *QRService) shortURL(shortCode string) string {
+	return fmt.Sprintf("%surls/%s", s.urlPrefix, url.PathEscape(shortCode))
+}
+
+// cachedQR returns a rendered variant of the short code's QR code from the
+// cache. All variants of a code share one hash so that deleting or renaming
+// the link drops them together.
+func (s *QRService) cachedQR(ctx context.Context, shortCode, variant string) ([]byte, bool) {
+	if s.cacheTTL <= 0 || utils.RedisDegraded() {
+		return nil, false
+	}
+	data, err := s.redisClient.HGet(ctx, getQRCodeKey(shortCode), variant).Bytes()
+	return data, err == nil
+}
+
+// cacheQR stores a rendered variant; failures are only logged since the
+// image can always be rendered again
+func (s *QRService) cacheQR(ctx context.Context, shortCode, variant string, data []byte) {
+	if s.cacheTTL <= 0 || utils.RedisDegraded() {
+		return
+	}
+	key := getQRCodeKey(shortCode)
+	pipe := s.redisClient.TxPipeline()
+	pipe.HSet(ctx, key, variant, data)
+	pipe.Expire(ctx, key, s.cacheTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LoggerFromContext(ctx).Error("Failed to cache QR code", "short_code", shortCode, "variant", variant, "error", err)
+	}
+}
+
+// getQRCodeKey is the hash of a code's rendered variants. It has its own
+// namespace because legacyQRCodeKey held a plain string, and reading a hash
+// field from it would fail with WRONGTYPE.
 func getQRCodeKey(shortCode string) string {
+	return fmt.Sprintf("qr:v2:%s", shortCode)
+}
+
+// legacyQRCodeKey held a single PNG before variants were cached; such keys
+// expire within a day but are still dropped with the link
+func legacyQRCodeKey(shortCode string) string {
 	return fmt.Sprintf("qr:%s", shortCode)
 }
+
+// qrRedisKeys lists the QR cache keys of a short code
+func qrRedisKeys(shortCode string) []string {
+	return []string{getQRCodeKey(shortCode), legacyQRCodeKey(shortCode)}
+}
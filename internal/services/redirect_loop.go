@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+const (
+	// maxRedirectHops is how many short links one visit may pass through
+	// before it is treated as a loop
+	maxRedirectHops = 10
+	// maxChainHops is how far a destination on another shortener is followed
+	maxChainHops = 5
+)
+
+// knownShorteners are followed when used as a destination, since they are
+// how a chain of short links can lead back here
+var knownShorteners = map[string]bool{
+	"bit.ly": true, "bitly.com": true, "tinyurl.com": true, "t.co": true, "goo.gl": true,
+	"ow.ly": true, "is.gd": true, "v.gd": true, "buff.ly": true, "rebrand.ly": true,
+	"cutt.ly": true, "shorturl.at": true, "s.id": true, "lnkd.in": true, "tiny.cc": true,
+	"rb.gy": true, "t.ly": true, "shorte.st": true, "bl.ink": true,
+}
+
+var redirectChainClient = newRedirectChainClient()
+
+func newRedirectChainClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 2 * time.Second, Control: denyPrivateAddresses}
+	return &http.Client{
+		Timeout:   4 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext},
+		// Hops are inspected one by one in checkRedirectChain
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkRedirectChain follows a destination on a known URL shortener and
+// rejects it when the chain comes back to this service. Lookup failures are
+// not errors: the redirect-time hop limit still catches loops.
+func (s *URLService) checkRedirectChain(ctx context.Context, destination string) error {
+	if !knownShorteners[utils.ExtractDomain(destination)] {
+		return nil
+	}
+
+	next := destination
+	for hop := 0; hop < maxChainHops; hop++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodHead, next, nil)
+		if err != nil {
+			return nil
+		}
+		resp, err := redirectChainClient.Do(req)
+		if err != nil {
+			return nil
+		}
+		resp.Body.Close()
+
+		location, err := resp.Location()
+		if err != nil {
+			return nil // not a redirect: the chain ends elsewhere
+		}
+		next = location.String()
+		if s.isOwnDomain(utils.ExtractDomain(next)) {
+			return types.ErrRedirectChainLoop
+		}
+	}
+	return nil
+}
+
+// checkRedirectLoop rejects a visit that has already passed through this
+// service maxRedirectHops times, as counted by the types.RedirectHopParam that
+// markRedirectHop adds to redirects back to our own domains
+func checkRedirectLoop(ctx context.Context, shortCode string, hops int) error {
+	if hops >= maxRedirectHops {
+		utils.LoggerFromContext(ctx).Warn("Redirect loop detected", "short_code", shortCode)
+		return types.ErrRedirectLoop
+	}
+	return nil
+}
+
+// markRedirectHop counts the hop on a destination that leads back to this
+// service, so the next short link on the way sees how far the visitor has
+// come; destinations elsewhere are returned unchanged
+func (s *URLService) markRedirectHop(destination string, hops int) string {
+	parsed, err := url.Parse(destination)
+	if err != nil || !s.isOwnDomain(utils.ExtractDomain(destination)) {
+		return destination
+	}
+	query := parsed.Query()
+	query.Set(types.RedirectHopParam, strconv.Itoa(hops+1))
+	parsed.RawQuery = query.Encode()
+	return parsed.String()
+}
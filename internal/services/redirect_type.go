@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SetRedirectType sets the HTTP status a link redirects with: 301 or 308
+// let browsers cache the redirect, 302 or 307 keep every click coming back
+// here. 0 goes back to the server default.
+func (s *URLService) SetRedirectType(ctx context.Context, userID, urlID uuid.UUID, status int) (*models.URL, error) {
+	if status != 0 && !models.IsRedirectStatus(status) {
+		return nil, types.NewValidationError("redirect_type must be 301, 302, 307 or 308")
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		url.RedirectType = status
+		url.UpdatedAt = time.Now().UTC()
+		if err := tx.Select("redirect_type", "updated_at").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
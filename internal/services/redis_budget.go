@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+const (
+	budgetInterval   = 5 * time.Minute
+	budgetSampleSize = 50
+
+	// Eviction policy that only evicts keys with a TTL, shortest TTL first.
+	// Protected keys (session revocations) are stored without a TTL.
+	budgetEvictionPolicy = "volatile-ttl"
+)
+
+// budgetPrefixes are the key families tracked in the memory report
+var budgetPrefixes = []string{"url:", "clicks:", "rotate:", "uniques:", "feed:", "qr:", "rate_limit:", "abuse:", "webhook:", "email:", "auth:", "pwned:", "tagjob:", "meta:", "brand:", "live:", "ab:", "bio:"}
+
+// URL cache TTL tiers: cold links expire from cache first under volatile-ttl
+const (
+	urlCacheHotClicks  = 1000
+	urlCacheWarmClicks = 10
+	urlCacheHotTTL     = 24 * time.Hour
+	urlCacheWarmTTL    = 6 * time.Hour
+	urlCacheColdTTL    = time.Hour
+)
+
+// RedisBudget tracks Redis memory per key family and flags memory pressure,
+// which shortens cache TTLs so counters and auth keys are not crowded out
+type RedisBudget struct {
+	redisClient   *redis.Client
+	pressureRatio float64
+	managePolicy  bool
+	underPressure int32
+	mu            sync.RWMutex
+	lastReport    *types.RedisMemoryReport
+}
+
+func NewRedisBudget(redisClient *redis.Client, pressureRatio float64, managePolicy bool) *RedisBudget {
+	if pressureRatio <= 0 || pressureRatio > 1 {
+		pressureRatio = 0.85
+	}
+	return &RedisBudget{
+		redisClient:   redisClient,
+		pressureRatio: pressureRatio,
+		managePolicy:  managePolicy,
+	}
+}
+
+// UnderPressure reports whether memory usage crossed the pressure ratio at the last check
+func (b *RedisBudget) UnderPressure() bool {
+	return b != nil && atomic.LoadInt32(&b.underPressure) == 1
+}
+
+// URLCacheTTL picks the cache TTL of a redirect by popularity, never past the
+// link's expiry. Under memory pressure every tier is cut to a quarter.
+func (b *RedisBudget) URLCacheTTL(clicks int64, expiresAt *time.Time) time.Duration {
+	return urlCacheTTL(clicks, expiresAt, b.UnderPressure())
+}
+
+func urlCacheTTL(clicks int64, expiresAt *time.Time, underPressure bool) time.Duration {
+	ttl := urlCacheColdTTL
+	switch {
+	case clicks >= urlCacheHotClicks:
+		ttl = urlCacheHotTTL
+	case clicks >= urlCacheWarmClicks:
+		ttl = urlCacheWarmTTL
+	}
+	if underPressure {
+		ttl /= 4
+	}
+	if expiresAt != nil {
+		if untilExpiry := time.Until(*expiresAt); untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	return ttl
+}
+
+// Start checks the eviction policy, then refreshes the report and prunes
+// expired session revocations periodically
+func (b *RedisBudget) Start() {
+	go func() {
+		ctx := context.Background()
+		b.ensureEvictionPolicy(ctx)
+
+		ticker := time.NewTicker(budgetInterval)
+		defer ticker.Stop()
+		for {
+			if _, err := b.Refresh(ctx); err != nil {
+				fmt.Printf("⚠️  Redis memory report failed: %v\n", err)
+			}
+			b.pruneRevokedSessions(ctx)
+			<-ticker.C
+		}
+	}()
+}
+
+// Report returns the last memory report, computing one if none exists yet
+func (b *RedisBudget) Report(ctx context.Context) (*types.RedisMemoryReport, error) {
+	b.mu.RLock()
+	report := b.lastReport
+	b.mu.RUnlock()
+	if report != nil {
+		return report, nil
+	}
+	return b.Refresh(ctx)
+}
+
+// Refresh measures memory usage and the key families now
+func (b *RedisBudget) Refresh(ctx context.Context) (*types.RedisMemoryReport, error) {
+	info, err := b.redisClient.Info(ctx, "memory").Result()
+	if err != nil {
+		return nil, err
+	}
+	fields := parseRedisInfo(info)
+
+	report := &types.RedisMemoryReport{
+		UsedBytes:      parseInt64(fields["used_memory"]),
+		MaxBytes:       parseInt64(fields["maxmemory"]),
+		EvictionPolicy: fields["maxmemory_policy"],
+		CheckedAt:      time.Now().UTC(),
+	}
+	if report.MaxBytes > 0 {
+		report.UsagePercent = float64(report.UsedBytes) / float64(report.MaxBytes) * 100
+		report.UnderPressure = float64(report.UsedBytes) >= float64(report.MaxBytes)*b.pressureRatio
+	}
+
+	for _, prefix := range budgetPrefixes {
+		usage, err := b.measurePrefix(ctx, prefix)
+		if err != nil {
+			return nil, err
+		}
+		report.Prefixes = append(report.Prefixes, *usage)
+	}
+
+	var pressure int32
+	if report.UnderPressure {
+		pressure = 1
+		utils.Logger.Warn("Redis memory pressure, shortening cache TTLs",
+			"used_bytes", report.UsedBytes,
+			"max_bytes", report.MaxBytes)
+	}
+	atomic.StoreInt32(&b.underPressure, pressure)
+
+	b.mu.Lock()
+	b.lastReport = report
+	b.mu.Unlock()
+
+	return report, nil
+}
+
+// measurePrefix counts keys of a family with SCAN and estimates their size
+// from MEMORY USAGE of the first sampled keys
+func (b *RedisBudget) measurePrefix(ctx context.Context, prefix string) (*types.KeyFamilyUsage, error) {
+	usage := &types.KeyFamilyUsage{Prefix: prefix}
+	var sampledBytes int64
+	var sampled int64
+
+	var cursor uint64
+	for {
+		keys, next, err := b.redisClient.Scan(ctx, cursor, prefix+"*", 1000).Result()
+		if err != nil {
+			return nil, err
+		}
+		usage.Keys += int64(len(keys))
+
+		for _, key := range keys {
+			if sampled >= budgetSampleSize {
+				break
+			}
+			size, err := b.redisClient.MemoryUsage(ctx, key).Result()
+			if err != nil {
+				continue
+			}
+			ttl, err := b.redisClient.TTL(ctx, key).Result()
+			if err == nil && ttl < 0 {
+				usage.SampledWithoutTTL++
+			}
+			sampledBytes += size
+			sampled++
+		}
+
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	if sampled > 0 {
+		usage.AvgKeyBytes = sampledBytes / sampled
+		usage.EstimatedBytes = usage.AvgKeyBytes * usage.Keys
+	}
+	return usage, nil
+}
+
+// ensureEvictionPolicy switches Redis to volatile-ttl when allowed, otherwise
+// warns about policies that may evict protected keys
+func (b *RedisBudget) ensureEvictionPolicy(ctx context.Context) {
+	policy, err := b.redisClient.ConfigGet(ctx, "maxmemory-policy").Result()
+	if err != nil || len(policy) < 2 {
+		return // managed Redis often disables CONFIG
+	}
+	current := fmt.Sprint(policy[1])
+	if current == budgetEvictionPolicy {
+		return
+	}
+
+	if b.managePolicy {
+		if err := b.redisClient.ConfigSet(ctx, "maxmemory-policy", budgetEvictionPolicy).Err(); err == nil {
+			fmt.Printf("✅ Redis maxmemory-policy set to %s (was %s)\n", budgetEvictionPolicy, current)
+			return
+		}
+	}
+	if strings.HasPrefix(current, "allkeys-") {
+		fmt.Printf("⚠️  Redis maxmemory-policy is %s: auth/session keys may be evicted, %s is recommended\n",
+			current, budgetEvictionPolicy)
+	}
+}
+
+// pruneRevokedSessions drops revocations older than any token they could reject
+func (b *RedisBudget) pruneRevokedSessions(ctx context.Context) {
+	cutoff := time.Now().Add(-utils.SessionRevocationTTL).UnixMilli()
+	b.redisClient.ZRemRangeByScore(ctx, utils.RevokedSessionsKey, "-inf", strconv.FormatInt(cutoff, 10))
+	b.redisClient.ZRemRangeByScore(ctx, utils.RevokedSessionIDsKey, "-inf", strconv.FormatInt(cutoff, 10))
+	// Revoked tokens are scored by their own expiry
+	b.redisClient.ZRemRangeByScore(ctx, utils.RevokedTokenIDsKey, "-inf", strconv.FormatInt(time.Now().UnixMilli(), 10))
+}
+
+func parseRedisInfo(info string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(info, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			fields[key] = value
+		}
+	}
+	return fields
+}
+
+func parseInt64(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+	return n
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+// Redis connectivity modes reported by RedisWatcher
+const (
+	RedisModeNormal   = "normal"
+	RedisModeDegraded = "degraded"
+)
+
+const (
+	redisWatchInterval    = 5 * time.Second
+	redisPingTimeout      = 2 * time.Second
+	redisFailuresToTrip   = 2 // consecutive failed pings before degrading
+	redisSuccessToRecover = 2 // consecutive good pings before recovering
+)
+
+// RedisWatcher pings Redis in the background and switches the app between
+// normal and degraded mode, logging each transition once. The client
+// reconnects on its own; the watcher only decides when to trust it again.
+type RedisWatcher struct {
+	redisClient *redis.Client
+
+	mu          sync.RWMutex
+	mode        string
+	since       time.Time
+	transitions int64
+	lastError   string
+	onRecover   []func()
+}
+
+func NewRedisWatcher(redisClient *redis.Client) *RedisWatcher {
+	return &RedisWatcher{
+		redisClient: redisClient,
+		mode:        RedisModeNormal,
+		since:       time.Now().UTC(),
+	}
+}
+
+// OnRecover registers a callback run (in its own goroutine) whenever Redis
+// comes back, e.g. to re-warm the redirect cache
+func (w *RedisWatcher) OnRecover(fn func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.onRecover = append(w.onRecover, fn)
+}
+
+// Start runs the watcher until the process exits
+func (w *RedisWatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(redisWatchInterval)
+		defer ticker.Stop()
+
+		failures, successes := 0, 0
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), redisPingTimeout)
+			err := w.redisClient.Ping(ctx).Err()
+			cancel()
+
+			if err != nil {
+				failures, successes = failures+1, 0
+				if failures >= redisFailuresToTrip {
+					w.transition(RedisModeDegraded, err.Error())
+				}
+				continue
+			}
+			failures, successes = 0, successes+1
+			if successes >= redisSuccessToRecover {
+				w.transition(RedisModeNormal, "")
+			}
+		}
+	}()
+}
+
+func (w *RedisWatcher) transition(mode, lastError string) {
+	w.mu.Lock()
+	if w.mode == mode {
+		if lastError != "" {
+			w.lastError = lastError
+		}
+		w.mu.Unlock()
+		return
+	}
+	w.mode = mode
+	w.since = time.Now().UTC()
+	w.transitions++
+	w.lastError = lastError
+	callbacks := w.onRecover
+	w.mu.Unlock()
+
+	utils.SetRedisDegraded(mode == RedisModeDegraded)
+	if mode == RedisModeDegraded {
+		utils.Logger.Error("Redis unreachable, switching to degraded mode", "error", lastError)
+		return
+	}
+
+	utils.Logger.Info("Redis reachable again, leaving degraded mode")
+	for _, fn := range callbacks {
+		go fn()
+	}
+}
+
+// Status returns the current mode and when it started
+func (w *RedisWatcher) Status() types.RedisStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return types.RedisStatus{
+		Mode:        w.mode,
+		Since:       w.since,
+		Transitions: w.transitions,
+		LastError:   w.lastError,
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import "strings"
+
+// defaultReservedCodes can't be claimed as custom short codes: words that
+// collide with routes or impersonate the service, plus common profanity.
+// Extend it with RESERVED_SHORT_CODES.
+var defaultReservedCodes = []string{
+	// Routes and well-known paths
+	"api", "v1", "v2", "urls", "qr", "health", "metrics", "meta", "feed",
+	"static", "assets", "public", "favicon", "robots", "sitemap", "well-known",
+	// Account and service pages
+	"admin", "administrator", "root", "login", "logout", "signin", "signup",
+	"register", "auth", "oauth", "sso", "account", "accounts", "user", "users",
+	"me", "settings", "dashboard", "billing", "support", "help", "docs",
+	"status", "security", "privacy", "terms", "legal", "abuse", "report",
+	"verify", "reset", "password", "lynx", "www", "mail", "null", "undefined",
+	// Profanity
+	"fuck", "fucker", "fucking", "shit", "bitch", "cunt", "dick", "cock",
+	"pussy", "asshole", "bastard", "slut", "whore", "nigger", "nigga", "faggot",
+	"retard", "porn", "sex", "xxx",
+}
+
+// ReservedShortCodes is the set of custom short codes users can't claim.
+// Codes are compared case-insensitively. A nil set reserves nothing.
+type ReservedShortCodes struct {
+	codes map[string]struct{}
+}
+
+// NewReservedShortCodes reserves the bundled codes plus extra
+func NewReservedShortCodes(extra []string) *ReservedShortCodes {
+	codes := make(map[string]struct{}, len(defaultReservedCodes)+len(extra))
+	for _, code := range defaultReservedCodes {
+		codes[code] = struct{}{}
+	}
+	for _, code := range extra {
+		if code = strings.ToLower(strings.TrimSpace(code)); code != "" {
+			codes[code] = struct{}{}
+		}
+	}
+	return &ReservedShortCodes{codes: codes}
+}
+
+// Contains reports whether code is reserved
+func (r *ReservedShortCodes) Contains(code string) bool {
+	if r == nil {
+		return false
+	}
+	_, reserved := r.codes[strings.ToLower(code)]
+	return reserved
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// RetentionPolicy controls how long raw click events are kept
+type RetentionPolicy struct {
+	DefaultDays int            // 0 keeps events forever
+	PlanDays    map[string]int // per-plan overrides of DefaultDays
+	Aggregate   bool           // roll events up into daily totals before deleting
+}
+
+type RetentionService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	policy      RetentionPolicy
+}
+
+func NewRetentionService(db *gorm.DB, redisClient *redis.Client, policy RetentionPolicy) *RetentionService {
+	return &RetentionService{
+		db:          db,
+		redisClient: redisClient,
+		policy:      policy,
+	}
+}
+
+// retentionScope selects the click events governed by one retention period
+type retentionScope struct {
+	name  string
+	days  int
+	where string
+	args  []interface{}
+}
+
+// scopes splits click events by the plan of the link owner. Links without a
+// plan override, anonymous links and deleted links fall under the default.
+func (s *RetentionService) scopes() []retentionScope {
+	const planLinks = "SELECT urls.short_code FROM urls JOIN users ON users.id = urls.user_id WHERE users.plan IN (?)"
+
+	plans := make([]string, 0, len(s.policy.PlanDays))
+	for plan := range s.policy.PlanDays {
+		plans = append(plans, plan)
+	}
+	sort.Strings(plans)
+
+	scopes := make([]retentionScope, 0, len(plans)+1)
+	for _, plan := range plans {
+		scopes = append(scopes, retentionScope{
+			name:  plan,
+			days:  s.policy.PlanDays[plan],
+			where: "short_code IN (" + planLinks + ")",
+			args:  []interface{}{[]string{plan}},
+		})
+	}
+
+	defaultScope := retentionScope{name: "default", days: s.policy.DefaultDays, where: "TRUE"}
+	if len(plans) > 0 {
+		defaultScope.where = "short_code NOT IN (" + planLinks + ")"
+		defaultScope.args = []interface{}{plans}
+	}
+	return append(scopes, defaultScope)
+}
+
+// Purge removes click events older than their retention period, rolling them
+// up into daily totals first when aggregation is enabled
+func (s *RetentionService) Purge(ctx context.Context, dryRun bool) (*types.AdminActionResult, error) {
+	result := types.NewAdminActionResult("purge_click_events", dryRun)
+	now := time.Now().UTC()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, scope := range s.scopes() {
+			if scope.days <= 0 {
+				continue
+			}
+			cutoff := now.AddDate(0, 0, -scope.days)
+			where := "clicked_at < ? AND " + scope.where
+			args := append([]interface{}{cutoff}, scope.args...)
+
+			var count int64
+			if err := tx.Model(&models.ClickEvent{}).Where(where, args...).Count(&count).Error; err != nil {
+				return err
+			}
+			result.Count("click_events", int(count))
+			result.Count("click_events:"+scope.name, int(count))
+			if dryRun || count == 0 {
+				continue
+			}
+
+			if s.policy.Aggregate {
+				if err := tx.Exec(
+					"INSERT INTO click_rollups (short_code, date, clicks) "+
+						"SELECT short_code, DATE(clicked_at), SUM(weight) FROM click_events WHERE "+where+
+						" GROUP BY 1, 2 "+
+						"ON CONFLICT (short_code, date) DO UPDATE SET clicks = click_rollups.clicks + EXCLUDED.clicks",
+					args...,
+				).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Where(where, args...).Delete(&models.ClickEvent{}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Admin action",
+		"action", result.Action,
+		"dry_run", dryRun,
+		"affected", result.Affected)
+
+	return result, nil
+}
+
+// StartRetentionJob purges aged-out click events once a day
+func (s *RetentionService) StartRetentionJob() {
+	ticker := time.NewTicker(24 * time.Hour)
+	go func() {
+		ctx := context.Background()
+		for range ticker.C {
+			if _, err := s.Purge(ctx, false); err != nil {
+				fmt.Printf("⚠️  Click event retention purge failed: %v\n", err)
+			}
+		}
+	}()
+}
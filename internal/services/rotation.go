@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SetRotation replaces a link's destinations. Two or more destinations make
+// it a rotator; an empty list turns rotation off and keeps the current LongURL.
+func (s *URLService) SetRotation(ctx context.Context, userID, urlID uuid.UUID, destinations []string, mode string) (*models.URL, error) {
+	if len(destinations) == 1 {
+		return nil, types.NewValidationError("a rotator needs at least two destinations")
+	}
+	for i := range destinations {
+		destination, err := s.validateDestination(ctx, destinations[i])
+		if err != nil {
+			return nil, err
+		}
+		destinations[i] = destination
+	}
+	if mode == "" {
+		mode = models.RotationRoundRobin
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		if len(destinations) == 0 {
+			url.Destinations = nil
+			url.RotationMode = ""
+		} else {
+			url.Destinations = destinations
+			url.RotationMode = mode
+			if err := recordDestinationChange(tx, &url, userID, destinations[0]); err != nil {
+				return err
+			}
+			url.LongURL = destinations[0]
+			// Rotation replaces an A/B split
+			if err := tx.Where("url_id = ?", url.ID).Delete(&models.URLVariant{}).Error; err != nil {
+				return err
+			}
+			url.Variants = nil
+		}
+		url.UpdatedAt = time.Now().UTC()
+
+		if err := s.destinationsChanged(tx, userID, &url); err != nil {
+			return err
+		}
+
+		if err := tx.Select("long_url", "destinations", "rotation_mode", "updated_at", "moderation").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		pipe := s.redisClient.Pipeline()
+		pipe.Set(ctx, getCacheKey(url.ShortCode), cacheValue(&url), s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt))
+		pipe.Del(ctx, getRotationKey(url.ShortCode))
+		_, err := pipe.Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyHeld(ctx, &url)
+	s.notifyChanged(&url)
+	return &url, nil
+}
+
+// pickDestination chooses the next destination of a rotator and returns its
+// index. Round-robin uses a shared Redis counter so all instances cycle together.
+func (s *URLService) pickDestination(ctx context.Context, shortCode string, destinations []string, mode string) (string, int) {
+	if len(destinations) == 1 {
+		return destinations[0], 0
+	}
+
+	if mode != models.RotationRandom {
+		n, err := s.redisClient.Incr(ctx, getRotationKey(shortCode)).Result()
+		if err == nil {
+			i := int((n - 1) % int64(len(destinations)))
+			return destinations[i], i
+		}
+	}
+	i := rand.Intn(len(destinations))
+	return destinations[i], i
+}
+
+// rotationVariant names a rotator destination in click analytics, e.g. "dest:2"
+// for the second one, so clicks can be broken down per destination
+func rotationVariant(i int) string {
+	return fmt.Sprintf("dest:%d", i+1)
+}
+
+func getRotationKey(shortCode string) string {
+	return fmt.Sprintf("rotate:%s", shortCode)
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SetRoutingRules replaces a link's time-based routing rules. Rules are
+// evaluated in order on every redirect and the first open window wins;
+// outside all windows the link behaves as before. An empty list removes them.
+func (s *URLService) SetRoutingRules(ctx context.Context, userID, urlID uuid.UUID, timezone string, rules []models.RoutingRule) (*models.URL, error) {
+	if len(rules) > models.MaxRoutingRules {
+		return nil, types.NewValidationError(fmt.Sprintf("a link can have at most %d routing rules", models.MaxRoutingRules))
+	}
+	if _, err := models.LoadTimezone(timezone); err != nil {
+		return nil, types.NewValidationError(fmt.Sprintf("unknown timezone %q", timezone))
+	}
+	for i, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, types.NewValidationError(fmt.Sprintf("rule %d: %v", i+1, err))
+		}
+		destination, err := s.validateDestination(ctx, rule.Destination)
+		if err != nil {
+			return nil, err
+		}
+		rules[i].Destination = destination
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		if len(rules) == 0 {
+			url.RoutingRules = nil
+			url.RoutingTimezone = ""
+		} else {
+			url.RoutingRules = rules
+			url.RoutingTimezone = timezone
+		}
+		url.UpdatedAt = time.Now().UTC()
+
+		if err := s.destinationsChanged(tx, userID, &url); err != nil {
+			return err
+		}
+
+		if err := tx.Select("routing_rules", "routing_timezone", "updated_at", "moderation").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyHeld(ctx, &url)
+	s.notifyChanged(&url)
+	return &url, nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	safeBrowsingEndpoint = "https://safebrowsing.googleapis.com/v4/threatMatches:find"
+	// The Lookup API takes at most 500 URLs per request
+	safeBrowsingMaxEntries = 500
+	safeBrowsingBatchSize  = 200
+)
+
+var safeBrowsingThreatTypes = []string{"MALWARE", "SOCIAL_ENGINEERING", "UNWANTED_SOFTWARE", "POTENTIALLY_HARMFUL_APPLICATION"}
+
+// SafeBrowsingScanner looks up link destinations in Google Safe Browsing:
+// new links right after creation and live links again every scan interval.
+// Links with a match are quarantined: visitors get a warning page instead of
+// the redirect, and the link waits in the admin review queue.
+type SafeBrowsingScanner struct {
+	db           *gorm.DB
+	redisClient  *redis.Client
+	httpClient   *http.Client
+	apiKey       string
+	scanInterval time.Duration
+	queue        chan uuid.UUID
+}
+
+func NewSafeBrowsingScanner(db *gorm.DB, redisClient *redis.Client, apiKey string, scanInterval time.Duration) *SafeBrowsingScanner {
+	return &SafeBrowsingScanner{
+		db:           db,
+		redisClient:  redisClient,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		apiKey:       apiKey,
+		scanInterval: scanInterval,
+		queue:        make(chan uuid.UUID, 1000),
+	}
+}
+
+// NotifyURLCreated implements interfaces.URLListener
+func (s *SafeBrowsingScanner) NotifyURLCreated(url *models.URL) {
+	select {
+	case s.queue <- url.ID:
+	default:
+		utils.Logger.Warn("Safe Browsing queue full, link left for the next scan", "url_id", url.ID)
+	}
+}
+
+// NotifyURLChanged implements interfaces.URLChangeListener: new destinations
+// are looked up like those of a new link
+func (s *SafeBrowsingScanner) NotifyURLChanged(url *models.URL) {
+	s.NotifyURLCreated(url)
+}
+
+// Start runs the worker checking new links and, unless the scan interval is
+// zero, the periodic rescan
+func (s *SafeBrowsingScanner) Start() {
+	go func() {
+		for urlID := range s.queue {
+			ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+			var links []models.URL
+			err := s.db.WithContext(ctx).Preload("Variants").Where("id = ? AND deleted_at IS NULL", urlID).Find(&links).Error
+			if err == nil {
+				err = s.check(ctx, links)
+			}
+			if err != nil {
+				utils.Logger.Warn("Safe Browsing check failed", "url_id", urlID, "error", err)
+			}
+			cancel()
+		}
+	}()
+
+	if s.scanInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(10 * time.Minute)
+	go func() {
+		ctx := context.Background()
+		for range ticker.C {
+			scanned, err := s.ScanDue(ctx)
+			if err != nil {
+				utils.Logger.Error("Safe Browsing scan failed", "error", err)
+				continue
+			}
+			if scanned > 0 {
+				utils.Logger.Debug("Rescanned links with Safe Browsing", "count", scanned)
+			}
+		}
+	}()
+}
+
+// ScanDue rescans one batch of live links not looked up within the scan
+// interval, never-scanned links first
+func (s *SafeBrowsingScanner) ScanDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	var links []models.URL
+	if err := s.db.WithContext(ctx).Preload("Variants").
+		Where("deleted_at IS NULL AND disabled_at IS NULL AND threat_allowed = false").
+		Where("COALESCE(moderation, '') NOT IN ?", []string{models.ModerationQuarantined, models.ModerationRejected}).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Where("threat_checked_at IS NULL OR threat_checked_at < ?", now.Add(-s.scanInterval)).
+		Order("threat_checked_at ASC NULLS FIRST").
+		Limit(safeBrowsingBatchSize).
+		Find(&links).Error; err != nil {
+		return 0, err
+	}
+	if len(links) == 0 {
+		return 0, nil
+	}
+	return len(links), s.check(ctx, links)
+}
+
+// check looks up every destination of the links, quarantines the matches
+// and records the lookup time on all of them
+func (s *SafeBrowsingScanner) check(ctx context.Context, links []models.URL) error {
+	byDestination := make(map[string][]int)
+	var destinations []string
+	for i := range links {
+		if links[i].ThreatAllowed || links[i].IsQuarantined() {
+			continue
+		}
+		for _, destination := range linkDestinations(&links[i]) {
+			if _, seen := byDestination[destination]; !seen && len(destinations) < safeBrowsingMaxEntries {
+				destinations = append(destinations, destination)
+			}
+			byDestination[destination] = append(byDestination[destination], i)
+		}
+	}
+	if len(destinations) == 0 {
+		return nil
+	}
+
+	matches, err := s.lookup(ctx, destinations)
+	if err != nil {
+		return err
+	}
+
+	quarantined := make(map[int]bool)
+	for destination, threatType := range matches {
+		for _, i := range byDestination[destination] {
+			if quarantined[i] {
+				continue
+			}
+			quarantined[i] = true
+			if err := s.quarantine(ctx, &links[i], threatType); err != nil {
+				return err
+			}
+		}
+	}
+
+	ids := make([]uuid.UUID, len(links))
+	for i := range links {
+		ids[i] = links[i].ID
+	}
+	return s.db.WithContext(ctx).Model(&models.URL{}).Where("id IN ?", ids).
+		UpdateColumn("threat_checked_at", time.Now().UTC()).Error
+}
+
+// quarantine swaps a link's redirect for the warning page and puts it in the
+// admin review queue
+func (s *SafeBrowsingScanner) quarantine(ctx context.Context, link *models.URL, threatType string) error {
+	if err := s.db.WithContext(ctx).Model(&models.URL{}).Where("id = ?", link.ID).
+		UpdateColumns(map[string]interface{}{
+			"moderation":  models.ModerationQuarantined,
+			"threat_type": threatType,
+		}).Error; err != nil {
+		return err
+	}
+
+	utils.LoggerFromContext(ctx).Warn("Link quarantined by Safe Browsing",
+		"url_id", link.ID,
+		"short_code", link.ShortCode,
+		"threat_type", threatType)
+
+	return s.redisClient.Del(ctx,
+		getCacheKey(link.ShortCode),
+		getMetaKey(link.ShortCode),
+		getPreviewCardKey(link.ShortCode),
+	).Err()
+}
+
+// linkDestinations lists every URL a link can redirect to
+func linkDestinations(link *models.URL) []string {
+	destinations := []string{link.LongURL}
+	destinations = append(destinations, link.Destinations...)
+	for _, variant := range link.Variants {
+		destinations = append(destinations, variant.Destination)
+	}
+	for _, rule := range link.RoutingRules {
+		destinations = append(destinations, rule.Destination)
+	}
+	for _, destination := range link.LanguageRoutes {
+		destinations = append(destinations, destination)
+	}
+	for _, destination := range link.CountryRoutes {
+		destinations = append(destinations, destination)
+	}
+	if link.DeviceTargets != nil {
+		for _, target := range []string{link.DeviceTargets.IOS, link.DeviceTargets.Android, link.DeviceTargets.Desktop} {
+			if target != "" {
+				destinations = append(destinations, target)
+			}
+		}
+	}
+	return destinations
+}
+
+type safeBrowsingRequest struct {
+	Client struct {
+		ClientID      string `json:"clientId"`
+		ClientVersion string `json:"clientVersion"`
+	} `json:"client"`
+	ThreatInfo struct {
+		ThreatTypes      []string            `json:"threatTypes"`
+		PlatformTypes    []string            `json:"platformTypes"`
+		ThreatEntryTypes []string            `json:"threatEntryTypes"`
+		ThreatEntries    []map[string]string `json:"threatEntries"`
+	} `json:"threatInfo"`
+}
+
+type safeBrowsingResponse struct {
+	Matches []struct {
+		ThreatType string `json:"threatType"`
+		Threat     struct {
+			URL string `json:"url"`
+		} `json:"threat"`
+	} `json:"matches"`
+}
+
+// lookup returns the matched threat type by URL; URLs without a match are absent
+func (s *SafeBrowsingScanner) lookup(ctx context.Context, urls []string) (map[string]string, error) {
+	var body safeBrowsingRequest
+	body.Client.ClientID = "lynx-url-shortener"
+	body.Client.ClientVersion = "1.0"
+	body.ThreatInfo.ThreatTypes = safeBrowsingThreatTypes
+	body.ThreatInfo.PlatformTypes = []string{"ANY_PLATFORM"}
+	body.ThreatInfo.ThreatEntryTypes = []string{"URL"}
+	for _, u := range urls {
+		body.ThreatInfo.ThreatEntries = append(body.ThreatInfo.ThreatEntries, map[string]string{"url": u})
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, safeBrowsingEndpoint+"?key="+s.apiKey, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("safe browsing returned %d", resp.StatusCode)
+	}
+
+	var result safeBrowsingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	matches := make(map[string]string, len(result.Matches))
+	for _, match := range result.Matches {
+		matches[match.Threat.URL] = match.ThreatType
+	}
+	return matches, nil
+}
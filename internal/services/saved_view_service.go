@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// SavedViewService stores users' named link-list views
+type SavedViewService struct {
+	db *gorm.DB
+}
+
+func NewSavedViewService(db *gorm.DB) *SavedViewService {
+	return &SavedViewService{db: db}
+}
+
+// ListViews returns the user's saved views in name order
+func (s *SavedViewService) ListViews(ctx context.Context, userID uuid.UUID) ([]models.SavedView, error) {
+	views := []models.SavedView{}
+	err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("name").
+		Find(&views).Error
+	return views, err
+}
+
+// CreateView saves a new view; names are unique per user
+func (s *SavedViewService) CreateView(ctx context.Context, userID uuid.UUID, req models.SavedViewRequest) (*models.SavedView, error) {
+	view := &models.SavedView{UserID: userID}
+	applySavedView(view, req)
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.SavedView{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= models.MaxSavedViews {
+			return types.ErrTooManySavedViews
+		}
+		if err := s.checkNameFree(tx, userID, view.Name, uuid.Nil); err != nil {
+			return err
+		}
+		return tx.Create(view).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// UpdateView replaces a saved view's name, filters and sort
+func (s *SavedViewService) UpdateView(ctx context.Context, userID, viewID uuid.UUID, req models.SavedViewRequest) (*models.SavedView, error) {
+	var view models.SavedView
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", viewID, userID).First(&view).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return types.ErrSavedViewNotFound
+			}
+			return err
+		}
+
+		applySavedView(&view, req)
+		if err := s.checkNameFree(tx, userID, view.Name, view.ID); err != nil {
+			return err
+		}
+		return tx.Select("name", "filters", "sort_by", "sort_order", "updated_at").Updates(&view).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// DeleteView removes a saved view
+func (s *SavedViewService) DeleteView(ctx context.Context, userID, viewID uuid.UUID) error {
+	result := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", viewID, userID).
+		Delete(&models.SavedView{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return types.ErrSavedViewNotFound
+	}
+	return nil
+}
+
+func (s *SavedViewService) checkNameFree(tx *gorm.DB, userID uuid.UUID, name string, exceptID uuid.UUID) error {
+	var count int64
+	if err := tx.Model(&models.SavedView{}).
+		Where("user_id = ? AND LOWER(name) = LOWER(?) AND id <> ?", userID, name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return types.ErrSavedViewExists
+	}
+	return nil
+}
+
+func applySavedView(view *models.SavedView, req models.SavedViewRequest) {
+	view.Name = strings.TrimSpace(req.Name)
+	view.Filters = req.Filters
+	if view.Filters == nil {
+		view.Filters = map[string]string{}
+	}
+	view.SortBy = req.SortBy
+	view.SortOrder = req.SortOrder
+}
@@ -0,0 +1,289 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	maxSessions int
+}
+
+func NewSessionService(db *gorm.DB, redisClient *redis.Client) *SessionService {
+	return &SessionService{
+		db:          db,
+		redisClient: redisClient,
+	}
+}
+
+// SetMaxSessions caps concurrent sessions per user; signing in beyond the
+// cap signs out the oldest sessions. Zero disables the cap.
+func (s *SessionService) SetMaxSessions(max int) {
+	s.maxSessions = max
+}
+
+// CreateSession records a sign-in made at createdAt that lasts until expiresAt
+// (the refresh token lifetime); the returned ID goes into the token "sid" claim
+func (s *SessionService) CreateSession(ctx context.Context, userID uuid.UUID, userAgent, ip string, createdAt, expiresAt time.Time) (*models.Session, error) {
+	browser, device := utils.ParseUserAgent(userAgent)
+	session := &models.Session{
+		ID:        uuid.New(),
+		UserID:    userID,
+		UserAgent: userAgent,
+		Browser:   browser,
+		Device:    device,
+		IP:        ip,
+		CreatedAt: createdAt.UTC(),
+		ExpiresAt: expiresAt.UTC(),
+	}
+	if err := s.db.WithContext(ctx).Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	key := utils.ActiveSessionsKey(userID.String())
+	pipe := s.redisClient.Pipeline()
+	pipe.ZAdd(ctx, key, &redis.Z{Score: float64(session.ExpiresAt.UnixMilli()), Member: session.ID.String()})
+	pipe.Expire(ctx, key, utils.SessionRevocationTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to track active session", "user_id", userID, "error", err)
+	}
+
+	s.enforceSessionCap(ctx, userID)
+	return session, nil
+}
+
+// CountSessions returns how many of the user's sessions are live
+func (s *SessionService) CountSessions(ctx context.Context, userID uuid.UUID) (int64, error) {
+	key := utils.ActiveSessionsKey(userID.String())
+	pipe := s.redisClient.Pipeline()
+	pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	count := pipe.ZCard(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return count.Val(), nil
+}
+
+// enforceSessionCap signs out the oldest sessions beyond maxSessions. The
+// Redis set makes the common case (under the cap) a single round trip.
+func (s *SessionService) enforceSessionCap(ctx context.Context, userID uuid.UUID) {
+	if s.maxSessions <= 0 {
+		return
+	}
+	count, err := s.CountSessions(ctx, userID)
+	if err != nil || count <= int64(s.maxSessions) {
+		return
+	}
+
+	var excess []uuid.UUID
+	if err := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC()).
+		Order("created_at DESC").
+		Offset(s.maxSessions).
+		Pluck("id", &excess).Error; err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to load sessions over the cap", "user_id", userID, "error", err)
+		return
+	}
+	for _, sessionID := range excess {
+		if err := s.RevokeSession(ctx, userID, sessionID); err != nil {
+			utils.LoggerFromContext(ctx).Warn("Failed to sign out session over the cap", "session_id", sessionID, "error", err)
+		}
+	}
+	if len(excess) > 0 {
+		utils.LoggerFromContext(ctx).Info("Signed out oldest sessions over the cap", "user_id", userID, "sessions", len(excess))
+	}
+}
+
+// RevokeAccessToken revokes a single access token by its "jti" claim until
+// it expires; the rest of its session stays signed in
+func (s *SessionService) RevokeAccessToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	if !expiresAt.After(time.Now()) {
+		return nil
+	}
+	return s.redisClient.ZAdd(ctx, utils.RevokedTokenIDsKey, &redis.Z{
+		Score:  float64(expiresAt.UnixMilli()),
+		Member: tokenID,
+	}).Err()
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *SessionService) ListSessions(ctx context.Context, userID uuid.UUID, currentID string) ([]models.Session, error) {
+	sessions := []models.Session{}
+	query := s.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now().UTC())
+
+	// Sessions older than a sign-out-everywhere are dead too
+	if revokedAt, ok := s.UserRevokedAt(ctx, userID); ok {
+		query = query.Where("created_at > ?", revokedAt)
+	}
+
+	if err := query.Order("created_at DESC").Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return sessions, nil
+	}
+
+	keys := make([]string, len(sessions))
+	for i := range sessions {
+		keys[i] = utils.SessionSeenKey(sessions[i].ID.String())
+	}
+	seen, _ := s.redisClient.MGet(ctx, keys...).Result()
+	for i := range sessions {
+		sessions[i].LastSeenAt = sessions[i].CreatedAt
+		if i < len(seen) {
+			if value, ok := seen[i].(string); ok {
+				if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+					sessions[i].LastSeenAt = time.UnixMilli(ms).UTC()
+				}
+			}
+		}
+		sessions[i].Current = sessions[i].ID.String() == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession signs out a single session
+func (s *SessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	var session models.Session
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", sessionID, userID).
+		First(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrSessionNotFound
+		}
+		return err
+	}
+
+	// Redis first: it is what AuthMiddleware checks
+	now := time.Now().UTC()
+	if err := s.redisClient.ZAdd(ctx, utils.RevokedSessionIDsKey, &redis.Z{
+		Score:  float64(now.UnixMilli()),
+		Member: sessionID.String(),
+	}).Err(); err != nil {
+		return err
+	}
+	s.redisClient.Del(ctx, utils.SessionSeenKey(sessionID.String()))
+	s.redisClient.ZRem(ctx, utils.ActiveSessionsKey(userID.String()), sessionID.String())
+
+	if session.RevokedAt != nil {
+		return nil
+	}
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&session).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("session_id = ? AND revoked_at IS NULL", sessionID).
+			Update("revoked_at", now).Error
+	})
+}
+
+// StoreRefreshToken records a newly issued refresh token (hashed)
+func (s *SessionService) StoreRefreshToken(ctx context.Context, record *models.RefreshToken, token string) error {
+	record.TokenHash = hashRefreshToken(token)
+	return s.db.WithContext(ctx).Create(record).Error
+}
+
+// RotateRefreshToken marks the refresh token as used and returns its record,
+// whose session and family the replacement token is issued under. Presenting
+// a token that was already used means it was copied: the whole family and its
+// session are revoked, signing out both the thief and the legitimate client.
+func (s *SessionService) RotateRefreshToken(ctx context.Context, tokenID uuid.UUID, token string) (*models.RefreshToken, error) {
+	var record models.RefreshToken
+	reused := false
+	now := time.Now().UTC()
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND token_hash = ?", tokenID, hashRefreshToken(token)).
+			First(&record).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return types.ErrInvalidToken
+			}
+			return err
+		}
+
+		if record.RevokedAt != nil || !record.ExpiresAt.After(now) {
+			return types.ErrInvalidToken
+		}
+		if record.UsedAt != nil {
+			reused = true
+			return tx.Model(&models.RefreshToken{}).
+				Where("family_id = ? AND revoked_at IS NULL", record.FamilyID).
+				Update("revoked_at", now).Error
+		}
+
+		record.UsedAt = &now
+		return tx.Model(&record).Update("used_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		utils.LoggerFromContext(ctx).Warn("Refresh token reuse detected, revoking family",
+			"user_id", record.UserID, "family_id", record.FamilyID)
+		if err := s.RevokeSession(ctx, record.UserID, record.SessionID); err != nil && err != types.ErrSessionNotFound {
+			return nil, err
+		}
+		return nil, types.ErrRefreshTokenReused
+	}
+
+	if s.isSessionRevoked(ctx, &record) {
+		return nil, types.ErrSessionRevoked
+	}
+	return &record, nil
+}
+
+// isSessionRevoked applies the AuthMiddleware checks to a refresh token: its
+// session was signed out, or the user signed out everywhere after it was issued
+func (s *SessionService) isSessionRevoked(ctx context.Context, record *models.RefreshToken) bool {
+	var session models.Session
+	if err := s.db.WithContext(ctx).
+		Select("revoked_at").
+		Where("id = ?", record.SessionID).
+		First(&session).Error; err != nil || session.RevokedAt != nil {
+		return true
+	}
+
+	if revokedAt, ok := s.UserRevokedAt(ctx, record.UserID); ok {
+		return !record.CreatedAt.After(revokedAt)
+	}
+	return false
+}
+
+// UserRevokedAt returns the user's last sign-out-everywhere, also honoring
+// one recorded under the legacy per-user key
+func (s *SessionService) UserRevokedAt(ctx context.Context, userID uuid.UUID) (time.Time, bool) {
+	var latest int64
+	if score, err := s.redisClient.ZScore(ctx, utils.RevokedSessionsKey, userID.String()).Result(); err == nil {
+		latest = int64(score)
+	}
+	if legacy, err := s.redisClient.Get(ctx, utils.LegacySessionKey(userID.String())).Int64(); err == nil && legacy > latest {
+		latest = legacy
+	}
+	if latest == 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(latest).UTC(), true
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
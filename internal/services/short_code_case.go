@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Short code case policies (SHORT_CODE_CASE)
+const (
+	// New codes, generated and custom, are stored lowercase and any spelling
+	// of a code redirects; older mixed-case codes are matched case-insensitively
+	ShortCodeCaseInsensitive = "insensitive"
+	// Codes keep their case and only the exact spelling redirects
+	ShortCodeCaseSensitive = "sensitive"
+)
+
+// SetShortCodeCase picks the short code case policy; unknown policies fall
+// back to case-insensitive codes
+func (s *URLService) SetShortCodeCase(policy string) {
+	switch policy {
+	case ShortCodeCaseSensitive:
+		s.caseSensitiveCodes = true
+	case ShortCodeCaseInsensitive, "":
+		s.caseSensitiveCodes = false
+	default:
+		utils.Logger.Warn("Unknown short code case policy, using case-insensitive codes", "policy", policy)
+		s.caseSensitiveCodes = false
+	}
+}
+
+// NormalizeShortCode applies the case policy to a new or requested code.
+// Codes are also NFC-normalized, so an emoji or accented letter matches
+// however the visitor's device composed it.
+func (s *URLService) NormalizeShortCode(code string) string {
+	code = norm.NFC.String(code)
+	if s.caseSensitiveCodes {
+		return code
+	}
+	return strings.ToLower(code)
+}
+
+// codeEquals is the condition matching a code column against a normalized
+// code: under the insensitive policy older mixed-case codes count as taken too
+func (s *URLService) codeEquals(column string) string {
+	if s.caseSensitiveCodes {
+		return column + " = ?"
+	}
+	return "LOWER(" + column + ") = ?"
+}
+
+// ShortCodeScope matches the link stored under a requested code: exactly
+// under the sensitive policy, otherwise in any spelling with the exact one
+// first, so codes generated mixed-case before the insensitive policy keep
+// resolving under their stored spelling
+func (s *URLService) ShortCodeScope(code string) func(*gorm.DB) *gorm.DB {
+	code = norm.NFC.String(code)
+	return func(db *gorm.DB) *gorm.DB {
+		if s.caseSensitiveCodes {
+			return db.Where("short_code = ?", code)
+		}
+		return db.Where("LOWER(short_code) = ?", strings.ToLower(code)).
+			Order(clause.Expr{SQL: "short_code = ? DESC", Vars: []interface{}{code}})
+	}
+}
+
+// ServableShortCode returns the stored spelling of a code that currently
+// redirects, with the errors ResolveRedirect would give for links that
+// don't. Unlike ResolveRedirect it counts no click and touches no visitor
+// state, for callers such as QR codes that only need to know the link is up.
+// Old codes of renamed links are returned as requested since they forward.
+func (s *URLService) ServableShortCode(ctx context.Context, code string) (string, error) {
+	requested := norm.NFC.String(code)
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Select("short_code", "is_active", "expires_at", "moderation", "disabled_at").
+		Scopes(s.ShortCodeScope(requested)).Where("deleted_at IS NULL").
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			if _, ok := s.resolveAlias(ctx, s.NormalizeShortCode(requested)); ok {
+				return requested, nil
+			}
+			return "", types.ErrURLNotFound
+		}
+		return "", err
+	}
+
+	switch {
+	case url.IsDisabled():
+		return "", types.ErrURLDisabled
+	case url.IsPendingReview():
+		return "", types.ErrURLUnderReview
+	case url.IsQuarantined():
+		return "", types.ErrURLQuarantined
+	case !url.IsActive:
+		return "", types.ErrURLInactive
+	case url.IsExpired():
+		return "", types.ErrURLNotFound
+	}
+	return url.ShortCode, nil
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// cacheMovedPrefix marks the cached redirect of a renamed link's old code;
+// the rest of the value is the new short URL
+const cacheMovedPrefix = "MOVED:"
+
+// RenameShortCode changes a link's short code. The old code becomes an alias
+// that forwards to the new short URL for models.ShortCodeAliasGracePeriod;
+// click history and counters move to the new code.
+func (s *URLService) RenameShortCode(ctx context.Context, userID, urlID uuid.UUID, newCode string) (*models.URL, error) {
+	newCode = s.NormalizeShortCode(newCode)
+	if !s.validCustomCode(newCode) {
+		return nil, types.ErrInvalidShortCode
+	}
+	if s.reservedCodes.Contains(newCode) {
+		return nil, types.ErrShortCodeReserved
+	}
+	if err := s.requireVerifiedEmail(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var url models.URL
+	var oldCode string
+	var aliasExpiry time.Time
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+		if url.ShortCode == newCode {
+			return nil
+		}
+
+		// The link may take back one of its own old codes
+		result := tx.Where("code = ? AND url_id = ?", newCode, url.ID).Delete(&models.ShortCodeAlias{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			taken, err := s.isShortCodeTaken(ctx, newCode)
+			if err != nil {
+				return err
+			}
+			if taken {
+				return types.ErrShortCodeTaken
+			}
+		}
+
+		now := time.Now().UTC()
+		alias := models.ShortCodeAlias{
+			Code:      url.ShortCode,
+			URLID:     url.ID,
+			ExpiresAt: now.Add(models.ShortCodeAliasGracePeriod),
+			CreatedAt: now,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "code"}},
+			DoUpdates: clause.AssignmentColumns([]string{"url_id", "expires_at", "created_at"}),
+		}).Create(&alias).Error; err != nil {
+			return err
+		}
+
+		oldCode, aliasExpiry = url.ShortCode, alias.ExpiresAt
+		url.ShortCode = newCode
+		url.ShortURL = s.shortURLFor(newCode)
+		url.UpdatedAt = now
+		if err := tx.Select("short_code", "short_url", "updated_at").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		// Analytics are keyed by short code
+		if err := tx.Model(&models.ClickEvent{}).Where("short_code = ?", oldCode).
+			Update("short_code", newCode).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ClickRollup{}).Where("short_code = ?", oldCode).
+			Update("short_code", newCode).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if oldCode == "" {
+		return &url, nil
+	}
+
+	s.moveRedisKeys(ctx, oldCode, aliasExpiry, &url)
+	return &url, nil
+}
+
+// moveRedisKeys carries a renamed link's counters over to its new code,
+// drops the caches of the old one and caches the old code's forward
+func (s *URLService) moveRedisKeys(ctx context.Context, oldCode string, aliasExpiry time.Time, url *models.URL) {
+	renames := map[string]string{
+		getClicksKey(oldCode):        getClicksKey(url.ShortCode),
+		getUniquesKey(oldCode):       getUniquesKey(url.ShortCode),
+		getVariantClicksKey(oldCode): getVariantClicksKey(url.ShortCode),
+		getRotationKey(oldCode):      getRotationKey(url.ShortCode),
+	}
+	for from, to := range renames {
+		if err := s.redisClient.Rename(ctx, from, to).Err(); err != nil && !strings.Contains(err.Error(), "no such key") {
+			utils.LoggerFromContext(ctx).Warn("Failed to move Redis key of renamed URL", "from", from, "to", to, "error", err)
+		}
+	}
+
+	pipe := s.redisClient.Pipeline()
+	pipe.Del(ctx, append(qrRedisKeys(oldCode), getMetaKey(oldCode), getPreviewCardKey(oldCode))...)
+	pipe.Set(ctx, getCacheKey(oldCode), cacheMovedPrefix+url.ShortURL, s.memoryBudget.URLCacheTTL(0, &aliasExpiry))
+	pipe.Set(ctx, getCacheKey(url.ShortCode), cacheValue(url), s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt))
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to update cache of renamed URL", "short_code", url.ShortCode, "error", err)
+	}
+}
+
+// resolveAlias looks up a live alias of a renamed link and returns the link's
+// current short URL
+func (s *URLService) resolveAlias(ctx context.Context, code string) (string, bool) {
+	var shortURL string
+	err := s.db.WithContext(ctx).Model(&models.ShortCodeAlias{}).
+		Joins("JOIN urls ON urls.id = short_code_aliases.url_id AND urls.deleted_at IS NULL").
+		Where("short_code_aliases.code = ? AND short_code_aliases.expires_at > ?", code, time.Now().UTC()).
+		Pluck("urls.short_url", &shortURL).Error
+	if err != nil || shortURL == "" {
+		return "", false
+	}
+	return shortURL, true
+}
+
+// movedTarget forwards an old code to the renamed link; the click is counted
+// when the visitor lands on the new code. The redirect is temporary since
+// the old code can be claimed again once the alias expires.
+func movedTarget(shortURL string) *types.RedirectTarget {
+	return &types.RedirectTarget{URL: shortURL, Unfurl: true, Status: http.StatusFound}
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+)
+
+const (
+	suggestionMinLength = 3
+	suggestionMaxLength = 10 // short_code column size
+	suggestionAttempts  = 30
+)
+
+// suggestionAlphabet leaves out characters that are easy to misread (0/o, 1/l/i)
+const suggestionAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// genericHostLabels are domain labels that say nothing about the destination
+var genericHostLabels = map[string]bool{"www": true, "m": true, "com": true, "co": true, "org": true, "net": true, "ac": true, "gov": true, "edu": true}
+
+// SuggestShortCodes proposes up to count available custom short codes for a
+// destination, built from its domain and title (or, without a title, the
+// words of its path) plus short random suffixes
+func (s *URLService) SuggestShortCodes(ctx context.Context, longURL, title string, count int) ([]string, error) {
+	normalized, err := utils.NormalizeDestination(longURL)
+	if err != nil {
+		return nil, err
+	}
+	parsed, _ := url.Parse(normalized)
+
+	stems := suggestionStems(parsed, title)
+	candidates := append([]string{}, stems...)
+	if len(stems) >= 2 {
+		candidates = append(candidates, stems[0]+stems[1])
+	}
+	for i := 0; len(candidates) < suggestionAttempts; i++ {
+		stem := "go"
+		if len(stems) > 0 {
+			stem = stems[i%len(stems)]
+		}
+		suffix := randomSuggestionSuffix(2 + i%3)
+		// Shorten the stem rather than cutting off the suffix
+		if len(stem)+len(suffix) > suggestionMaxLength {
+			stem = stem[:suggestionMaxLength-len(suffix)]
+		}
+		candidates = append(candidates, stem+suffix)
+	}
+
+	suggestions := make([]string, 0, count)
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if len(suggestions) == count {
+			break
+		}
+		if len(candidate) > suggestionMaxLength {
+			candidate = candidate[:suggestionMaxLength]
+		}
+		if len(candidate) < suggestionMinLength || seen[candidate] || s.reservedCodes.Contains(candidate) {
+			continue
+		}
+		seen[candidate] = true
+
+		taken, err := s.isShortCodeTaken(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			suggestions = append(suggestions, candidate)
+		}
+	}
+	return suggestions, nil
+}
+
+// suggestionStems returns up to three lowercase alphanumeric words
+// describing the destination, the site name first
+func suggestionStems(parsed *url.URL, title string) []string {
+	var stems []string
+	add := func(word string) {
+		if len(stems) < 3 && len(word) >= suggestionMinLength && !slices.Contains(stems, word) {
+			stems = append(stems, word)
+		}
+	}
+
+	labels := strings.Split(utils.NormalizeDomain(parsed.Hostname()), ".")
+	for i := len(labels) - 2; i >= 0; i-- {
+		if !genericHostLabels[labels[i]] {
+			add(alphanumeric(labels[i]))
+			break
+		}
+	}
+
+	text := title
+	if strings.TrimSpace(text) == "" {
+		text = parsed.Path
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		add(alphanumeric(word))
+	}
+	return stems
+}
+
+// alphanumeric keeps the ASCII letters and digits of a word, capped to the short code length
+func alphanumeric(word string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(word) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	result := b.String()
+	if len(result) > suggestionMaxLength {
+		result = result[:suggestionMaxLength]
+	}
+	return result
+}
+
+func randomSuggestionSuffix(length int) string {
+	suffix := make([]byte, length)
+	for i := range suffix {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(suggestionAlphabet))))
+		if err != nil {
+			return ""
+		}
+		suffix[i] = suggestionAlphabet[n.Int64()]
+	}
+	return string(suffix)
+}
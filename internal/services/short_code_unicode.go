@@ -0,0 +1,46 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	customCodeMinLength = 3
+	// short_code column size, in characters
+	customCodeMaxLength = 10
+)
+
+// unicodeShortCodePattern admits letters and digits of any script, combining
+// marks and emoji (including skin tones, ZWJ sequences and flags) besides
+// hyphens and underscores
+var unicodeShortCodePattern = regexp.MustCompile(`^[\p{L}\p{N}\p{M}\p{So}\p{Sk}\x{200D}\x{FE0F}_-]+$`)
+
+// SetUnicodeShortCodes allows custom short codes with non-ASCII letters and
+// emoji, e.g. "🍕" or "café"
+func (s *URLService) SetUnicodeShortCodes(enabled bool) {
+	if enabled {
+		s.shortCodePattern = unicodeShortCodePattern
+	}
+}
+
+// validCustomCode checks a normalized custom short code. Codes with emoji or
+// other non-ASCII characters may be shorter than ASCII ones: a single "🍕"
+// is fine.
+func (s *URLService) validCustomCode(code string) bool {
+	if !s.shortCodePattern.MatchString(code) {
+		return false
+	}
+	length := utf8.RuneCountInString(code)
+	if length > customCodeMaxLength {
+		return false
+	}
+	return length >= customCodeMinLength || len(code) != length
+}
+
+// shortURLFor builds a link's short URL; non-ASCII codes are percent-encoded
+func (s *URLService) shortURLFor(code string) string {
+	return fmt.Sprintf("%surls/%s", s.urlPrefix, url.PathEscape(code))
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	tagBatchSize = 100
+	tagJobTTL    = 24 * time.Hour
+)
+
+// tagsArray guards jsonb array functions against links whose tags are NULL
+const tagsArray = "CASE WHEN jsonb_typeof(tags) = 'array' THEN tags ELSE '[]'::jsonb END"
+
+// TagService manages tags across all of a user's links
+type TagService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+}
+
+func NewTagService(db *gorm.DB, redisClient *redis.Client) *TagService {
+	return &TagService{
+		db:          db,
+		redisClient: redisClient,
+	}
+}
+
+// ListTags returns the user's tags with the number of links carrying each
+func (s *TagService) ListTags(ctx context.Context, userID uuid.UUID) ([]types.TagCount, error) {
+	tags := []types.TagCount{}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT t AS tag, COUNT(*) AS links
+		FROM urls, jsonb_array_elements_text(`+tagsArray+`) AS t
+		WHERE urls.user_id = ? AND urls.deleted_at IS NULL
+		GROUP BY t
+		ORDER BY links DESC, tag`, userID).
+		Scan(&tags).Error
+	return tags, err
+}
+
+// RenameTag renames a tag on all of the user's links, merging it into the
+// new name where a link already has both
+func (s *TagService) RenameTag(ctx context.Context, userID uuid.UUID, tag, name string) (int64, error) {
+	return s.MergeTags(ctx, userID, []string{tag}, name)
+}
+
+// MergeTags replaces every tag in from with to on all of the user's links
+func (s *TagService) MergeTags(ctx context.Context, userID uuid.UUID, from []string, to string) (int64, error) {
+	to = models.NormalizeTag(to)
+	if to == "" {
+		return 0, types.ErrInvalidTag
+	}
+
+	sources := make([]string, 0, len(from))
+	conditions := make([]string, 0, len(from))
+	args := []interface{}{}
+	for _, tag := range from {
+		tag = models.NormalizeTag(tag)
+		if tag == "" {
+			return 0, types.ErrInvalidTag
+		}
+		if tag == to {
+			continue
+		}
+		sources = append(sources, tag)
+		conditions = append(conditions, "tags @> ?::jsonb")
+		args = append(args, jsonArray(tag))
+	}
+	if len(sources) == 0 {
+		return 0, nil
+	}
+
+	result := s.db.WithContext(ctx).Exec(`
+		UPDATE urls
+		SET tags = (
+			SELECT COALESCE(jsonb_agg(DISTINCT CASE WHEN t IN ? THEN ? ELSE t END), '[]'::jsonb)
+			FROM jsonb_array_elements_text(tags) AS t
+		), updated_at = ?
+		WHERE user_id = ? AND deleted_at IS NULL AND (`+strings.Join(conditions, " OR ")+`)`,
+		append([]interface{}{sources, to, time.Now().UTC(), userID}, args...)...)
+	return result.RowsAffected, result.Error
+}
+
+// DeleteTag removes a tag from all of the user's links (the links are kept)
+func (s *TagService) DeleteTag(ctx context.Context, userID uuid.UUID, tag string) (int64, error) {
+	tag = models.NormalizeTag(tag)
+	if tag == "" {
+		return 0, types.ErrInvalidTag
+	}
+
+	result := s.db.WithContext(ctx).Exec(`
+		UPDATE urls SET tags = tags - ?::text, updated_at = ?
+		WHERE user_id = ? AND deleted_at IS NULL AND tags @> ?::jsonb`,
+		tag, time.Now().UTC(), userID, jsonArray(tag))
+	return result.RowsAffected, result.Error
+}
+
+// StartBatch applies an operation to every link carrying a tag in the
+// background. Progress is reported through GetBatchJob.
+func (s *TagService) StartBatch(ctx context.Context, userID uuid.UUID, tag string, req models.TagBatchRequest) (*types.TagJob, error) {
+	tag = models.NormalizeTag(tag)
+	if tag == "" {
+		return nil, types.ErrInvalidTag
+	}
+	if req.Operation == models.TagBatchSetExpiry && req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, types.NewValidationError("expires_at must be in the future")
+	}
+
+	var total int64
+	if err := s.taggedURLs(ctx, userID, tag).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	job := &types.TagJob{
+		ID:        uuid.New().String(),
+		Tag:       tag,
+		Operation: req.Operation,
+		Status:    types.TagJobQueued,
+		Total:     total,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.saveJob(ctx, userID, job); err != nil {
+		return nil, err
+	}
+
+	logger := utils.LoggerFromContext(ctx)
+	go s.runBatch(userID, job, req, logger)
+
+	return job, nil
+}
+
+// GetBatchJob returns a batch job started by the user
+func (s *TagService) GetBatchJob(ctx context.Context, userID uuid.UUID, jobID string) (*types.TagJob, error) {
+	data, err := s.redisClient.Get(ctx, tagJobKey(userID, jobID)).Bytes()
+	if err == redis.Nil {
+		return nil, types.ErrTagJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var job types.TagJob
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// runBatch walks the tagged links in id order, one chunk at a time, and
+// drops their redirect cache entries so the change applies immediately
+func (s *TagService) runBatch(userID uuid.UUID, job *types.TagJob, req models.TagBatchRequest, logger *slog.Logger) {
+	ctx := context.Background()
+	job.Status = types.TagJobRunning
+	s.saveJob(ctx, userID, job)
+
+	fail := func(err error) {
+		logger.Error("Tag batch job failed", "job_id", job.ID, "error", err)
+		now := time.Now().UTC()
+		job.Status = types.TagJobFailed
+		job.Error = err.Error()
+		job.FinishedAt = &now
+		s.saveJob(ctx, userID, job)
+	}
+
+	lastID := uuid.Nil
+	for {
+		var chunk []models.URL
+		if err := s.taggedURLs(ctx, userID, job.Tag).
+			Select("id", "short_code").
+			Where("id > ?", lastID).
+			Order("id").
+			Limit(tagBatchSize).
+			Find(&chunk).Error; err != nil {
+			fail(err)
+			return
+		}
+		if len(chunk) == 0 {
+			break
+		}
+
+		ids := make([]uuid.UUID, len(chunk))
+		cacheKeys := make([]string, len(chunk))
+		for i, url := range chunk {
+			ids[i] = url.ID
+			cacheKeys[i] = getCacheKey(url.ShortCode)
+		}
+
+		if err := s.applyBatch(ctx, ids, req).Error; err != nil {
+			fail(err)
+			return
+		}
+		s.redisClient.Del(ctx, cacheKeys...)
+
+		lastID = chunk[len(chunk)-1].ID
+		job.Processed += int64(len(chunk))
+		s.saveJob(ctx, userID, job)
+	}
+
+	now := time.Now().UTC()
+	job.Status = types.TagJobCompleted
+	job.FinishedAt = &now
+	s.saveJob(ctx, userID, job)
+}
+
+func (s *TagService) applyBatch(ctx context.Context, ids []uuid.UUID, req models.TagBatchRequest) *gorm.DB {
+	updates := map[string]interface{}{"updated_at": time.Now().UTC()}
+	switch req.Operation {
+	case models.TagBatchSetExpiry:
+		updates["expires_at"] = req.ExpiresAt
+	case models.TagBatchDeactivate:
+		updates["is_active"] = false
+	case models.TagBatchActivate:
+		updates["is_active"] = true
+	}
+	return s.db.WithContext(ctx).Model(&models.URL{}).Where("id IN ?", ids).Updates(updates)
+}
+
+func (s *TagService) taggedURLs(ctx context.Context, userID uuid.UUID, tag string) *gorm.DB {
+	return s.db.WithContext(ctx).Model(&models.URL{}).
+		Where("user_id = ? AND deleted_at IS NULL AND tags @> ?::jsonb", userID, jsonArray(tag))
+}
+
+func (s *TagService) saveJob(ctx context.Context, userID uuid.UUID, job *types.TagJob) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return s.redisClient.Set(ctx, tagJobKey(userID, job.ID), data, tagJobTTL).Err()
+}
+
+// jsonArray encodes a single tag as a jsonb array for containment checks
+func jsonArray(tag string) string {
+	data, _ := json.Marshal([]string{tag})
+	return string(data)
+}
+
+// Jobs are keyed by user so one user cannot read another's progress
+func tagJobKey(userID uuid.UUID, jobID string) string {
+	return fmt.Sprintf("tagjob:%s:%s", userID, jobID)
+}
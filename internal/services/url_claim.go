@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// ClaimURLs attaches anonymous links to the user's account by their claim
+// tokens: they stop being anonymous and lose the 7-day expiry. Tokens that
+// are unknown, already claimed or belong to expired links are skipped.
+func (s *URLService) ClaimURLs(ctx context.Context, userID uuid.UUID, tokens []string) ([]models.URL, error) {
+	hashes := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		if token != "" {
+			hashes = append(hashes, hashClaimToken(token))
+		}
+	}
+
+	claimed := []models.URL{}
+	if len(hashes) == 0 {
+		return claimed, nil
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("claim_token_hash IN ? AND is_anonymous = ? AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+			hashes, true, time.Now().UTC()).
+			Find(&claimed).Error; err != nil {
+			return err
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, len(claimed))
+		for i := range claimed {
+			ids[i] = claimed[i].ID
+		}
+		return tx.Model(&models.URL{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+			"user_id":          userID,
+			"is_anonymous":     false,
+			"expires_at":       nil,
+			"claim_token_hash": "",
+			// Claimed links are managed from the account from now on
+			"manage_token_hash": "",
+			"updated_at":        time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The cached redirects expire with the old 7-day limit; re-cache them
+	pipe := s.redisClient.Pipeline()
+	for i := range claimed {
+		url := &claimed[i]
+		url.UserID = &userID
+		url.IsAnonymous = false
+		url.ExpiresAt = nil
+		if !url.IsPendingReview() {
+			pipe.Set(ctx, getCacheKey(url.ShortCode), cacheValue(url), s.memoryBudget.URLCacheTTL(url.Clicks, nil))
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to refresh cache for claimed URLs", "error", err)
+	}
+
+	return claimed, nil
+}
+
+// generateClaimToken returns a random URL-safe token for an anonymous link
+func generateClaimToken() (string, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func hashClaimToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+const urlExportBatchSize = 500
+
+// ExportURLs walks all of the user's links, handing them to fn one batch at a
+// time (keyset-paginated by id) so large accounts are never loaded at once.
+// Clicks are the higher of the stored count and the live Redis counter.
+func (s *URLService) ExportURLs(ctx context.Context, userID uuid.UUID, fn func([]types.ExportedURL) error) error {
+	var urls []models.URL
+	return s.db.WithContext(ctx).
+		Select("id", "short_code", "short_url", "long_url", "clicks", "is_active", "tags", "created_at", "expires_at").
+		Where("user_id = ? AND is_anonymous = false AND deleted_at IS NULL", userID).
+		FindInBatches(&urls, urlExportBatchSize, func(tx *gorm.DB, _ int) error {
+			keys := make([]string, len(urls))
+			for i := range urls {
+				keys[i] = getClicksKey(urls[i].ShortCode)
+			}
+			counters, err := s.redisClient.MGet(ctx, keys...).Result()
+			if err != nil && err != redis.Nil {
+				return err
+			}
+
+			batch := make([]types.ExportedURL, len(urls))
+			for i, url := range urls {
+				clicks := url.Clicks
+				if i < len(counters) {
+					if value, ok := counters[i].(string); ok {
+						if live, err := strconv.ParseInt(value, 10, 64); err == nil {
+							clicks = max(clicks, live)
+						}
+					}
+				}
+				tags := url.Tags
+				if tags == nil {
+					tags = []string{}
+				}
+				batch[i] = types.ExportedURL{
+					ID:        url.ID,
+					ShortCode: url.ShortCode,
+					ShortURL:  url.ShortURL,
+					LongURL:   url.LongURL,
+					Clicks:    clicks,
+					IsActive:  url.IsActive,
+					Tags:      tags,
+					CreatedAt: url.CreatedAt,
+					ExpiresAt: url.ExpiresAt,
+				}
+			}
+			return fn(batch)
+		}).Error
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+// GetManagedURL returns an anonymous link and its stats by management token
+func (s *URLService) GetManagedURL(ctx context.Context, token string) (*types.ManagedURL, error) {
+	url, err := s.findManagedURL(s.db.WithContext(ctx), token)
+	if err != nil {
+		return nil, err
+	}
+
+	stats, err := s.GetURLStats(ctx, url.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &types.ManagedURL{URL: url, Stats: stats}, nil
+}
+
+// ExtendManagedURL moves an anonymous link's expiry to expiryHours from now
+func (s *URLService) ExtendManagedURL(ctx context.Context, token string, expiryHours int) (*models.URL, error) {
+	var url *models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if url, err = s.findManagedURL(tx, token); err != nil {
+			return err
+		}
+
+		expiresAt := time.Now().UTC().Add(time.Duration(expiryHours) * time.Hour)
+		url.ExpiresAt = &expiresAt
+		url.UpdatedAt = time.Now().UTC()
+		if err := tx.Select("expires_at", "updated_at").Updates(url).Error; err != nil {
+			return err
+		}
+
+		// Held links must not be served from cache until approved
+		if url.IsPendingReview() {
+			return nil
+		}
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return url, nil
+}
+
+// DeleteManagedURL deletes an anonymous link by management token. There is
+// no trash to restore it from; it is purged with the other deleted links.
+func (s *URLService) DeleteManagedURL(ctx context.Context, token string) error {
+	url, err := s.findManagedURL(s.db.WithContext(ctx), token)
+	if err != nil {
+		return err
+	}
+
+	if err := s.db.WithContext(ctx).Model(url).
+		UpdateColumn("deleted_at", time.Now().UTC()).Error; err != nil {
+		return err
+	}
+	return s.redisClient.Del(ctx, append(qrRedisKeys(url.ShortCode), getCacheKey(url.ShortCode))...).Err()
+}
+
+// findManagedURL looks up a live anonymous link by management token; claimed,
+// deleted and expired links are not found
+func (s *URLService) findManagedURL(tx *gorm.DB, token string) (*models.URL, error) {
+	if token == "" {
+		return nil, types.ErrURLNotFound
+	}
+
+	var url models.URL
+	if err := tx.Where("manage_token_hash = ? AND is_anonymous = ? AND deleted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)",
+		hashClaimToken(token), true, time.Now().UTC()).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+	return &url, nil
+}
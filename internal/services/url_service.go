@@ -4,23 +4,41 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/interfaces"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"golang.org/x/text/unicode/norm"
 	"gorm.io/gorm"
 )
 
 type URLService struct {
-	db               *gorm.DB
-	redisClient      *redis.Client
-	urlPrefix        string
+	db          *gorm.DB
+	redisClient *redis.Client
+	urlPrefix   string
+	// Other domains serving this service's links, see SetOwnDomains
+	ownDomains       map[string]bool
 	shortCodePattern *regexp.Regexp
+	reservedCodes    *ReservedShortCodes
+	// New anonymous links get the preview page
+	anonymousPreviewPage bool
+	// Redirect status of links without their own redirect type
+	defaultRedirectStatus int
+	// Keep short codes' case, see SetShortCodeCase
+	caseSensitiveCodes bool
+	abuseScorer        *AbuseScorer
+	listeners          []interfaces.URLListener
+	memoryBudget       *RedisBudget
+	linkApproval       *LinkApproval
 }
 
 func NewURLService(db *gorm.DB, redisClient *redis.Client, urlPrefix string) *URLService {
@@ -29,23 +47,131 @@ func NewURLService(db *gorm.DB, redisClient *redis.Client, urlPrefix string) *UR
 		redisClient:      redisClient,
 		urlPrefix:        urlPrefix,
 		shortCodePattern: regexp.MustCompile("^[a-zA-Z0-9-_]+$"),
+		reservedCodes:    NewReservedShortCodes(nil),
+
+		defaultRedirectStatus: http.StatusFound,
+	}
+}
+
+// SetAnonymousPreviewPage turns the preview page on for anonymous links created from now on
+func (s *URLService) SetAnonymousPreviewPage(enabled bool) {
+	s.anonymousPreviewPage = enabled
+}
+
+// SetDefaultRedirectStatus sets the redirect status of links without their
+// own redirect type; anything but 301, 302, 307 or 308 is ignored
+func (s *URLService) SetDefaultRedirectStatus(status int) {
+	if models.IsRedirectStatus(status) {
+		s.defaultRedirectStatus = status
+	} else {
+		utils.Logger.Warn("Ignoring unsupported default redirect status", "status", status)
+	}
+}
+
+// SetOwnDomains lists the domains besides the URL prefix's that reach this
+// service (the app's base URL, branded short domains); links to them are
+// rejected as destinations since they would redirect back here
+func (s *URLService) SetOwnDomains(domains []string) {
+	s.ownDomains = make(map[string]bool, len(domains))
+	for _, domain := range domains {
+		if domain = utils.NormalizeDomain(domain); domain != "" {
+			s.ownDomains[domain] = true
+		}
+	}
+}
+
+// isOwnDomain reports whether a destination's domain reaches this service
+func (s *URLService) isOwnDomain(domain string) bool {
+	if domain == "" {
+		return false
+	}
+	return domain == utils.ExtractDomain(s.urlPrefix) || s.ownDomains[domain]
+}
+
+// SetReservedCodes replaces the custom short codes users can't claim
+func (s *URLService) SetReservedCodes(reserved *ReservedShortCodes) {
+	s.reservedCodes = reserved
+}
+
+// SetAbuseScorer enables abuse scoring of anonymous link creations
+func (s *URLService) SetAbuseScorer(scorer *AbuseScorer) {
+	s.abuseScorer = scorer
+}
+
+// SetMemoryBudget makes redirect cache TTLs follow link popularity and Redis memory pressure
+func (s *URLService) SetMemoryBudget(budget *RedisBudget) {
+	s.memoryBudget = budget
+}
+
+// SetLinkApproval holds members' new links and destination changes for admin
+// approval; nil (the default) publishes them immediately
+func (s *URLService) SetLinkApproval(approval *LinkApproval) {
+	s.linkApproval = approval
+}
+
+// AddURLListener registers a listener notified of every created URL
+func (s *URLService) AddURLListener(listener interfaces.URLListener) {
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *URLService) notifyCreated(url *models.URL) {
+	for _, listener := range s.listeners {
+		listener.NotifyURLCreated(url)
+	}
+}
+
+// notifyChanged tells the listeners that also watch edits about new destinations
+func (s *URLService) notifyChanged(url *models.URL) {
+	for _, listener := range s.listeners {
+		if changes, ok := listener.(interfaces.URLChangeListener); ok {
+			changes.NotifyURLChanged(url)
+		}
+	}
+}
+
+// destinationsChanged runs in the transaction storing new destinations: the
+// link may need approval again, and an admin's release from quarantine only
+// covered the old destinations, so they are scanned again
+func (s *URLService) destinationsChanged(tx *gorm.DB, userID uuid.UUID, url *models.URL) error {
+	if err := s.holdForApproval(tx, userID, url); err != nil {
+		return err
 	}
+	url.ThreatAllowed = false
+	url.ThreatCheckedAt = nil
+	return tx.Model(&models.URL{}).Where("id = ?", url.ID).
+		UpdateColumns(map[string]interface{}{"threat_allowed": false, "threat_checked_at": nil}).Error
 }
 
 // ✅ UPDATED: CreateShortURL for authenticated users
-func (s *URLService) CreateShortURL(ctx context.Context, userID uuid.UUID, longURL string, customShortCode string) (*models.URL, error) {
+func (s *URLService) CreateShortURL(ctx context.Context, userID uuid.UUID, longURL string, customShortCode string, expiresAt *time.Time, tags []string, notes string) (*models.URL, error) {
 	// Validate long URL
 	if longURL == "" {
 		return nil, types.NewValidationError("long URL is required")
 	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, types.NewValidationError("expires_at must be in the future")
+	}
+	tags, err := models.NormalizeTags(tags)
+	if err != nil {
+		return nil, types.NewValidationError(err.Error())
+	}
+	if longURL, err = s.validateDestination(ctx, longURL); err != nil {
+		return nil, err
+	}
 
 	// Generate or validate short code
 	shortCode := customShortCode
 	if shortCode != "" {
-		if !s.shortCodePattern.MatchString(shortCode) {
+		shortCode = s.NormalizeShortCode(shortCode)
+		if !s.validCustomCode(shortCode) {
 			return nil, types.ErrInvalidShortCode
 		}
-		shortCode = strings.ToLower(shortCode)
+		if s.reservedCodes.Contains(shortCode) {
+			return nil, types.ErrShortCodeReserved
+		}
+		if err := s.requireVerifiedEmail(ctx, userID); err != nil {
+			return nil, err
+		}
 
 		exists, err := s.isShortCodeTaken(ctx, shortCode)
 		if err != nil {
@@ -68,25 +194,31 @@ func (s *URLService) CreateShortURL(ctx context.Context, userID uuid.UUID, longU
 		UserID:      &userID, // ✅ Changed to pointer
 		LongURL:     longURL,
 		ShortCode:   shortCode, // ✅ Added
-		ShortURL:    fmt.Sprintf("%surls/%s", s.urlPrefix, shortCode),
+		ShortURL:    s.shortURLFor(shortCode),
 		Clicks:      0,
-		IsAnonymous: false, // ✅ Added
-		ExpiresAt:   nil,   // ✅ Added (no expiry for auth users)
+		IsAnonymous: false,     // ✅ Added
+		ExpiresAt:   expiresAt, // nil: never expires
+		IsActive:    true,
+		Tags:        tags,
+		Notes:       strings.TrimSpace(notes),
 		CreatedAt:   time.Now().UTC(),
 		UpdatedAt:   time.Now().UTC(),
 	}
 
 	// Save to database with transaction
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := s.holdForApproval(tx, userID, url); err != nil {
+			return err
+		}
 		if err := tx.Create(url).Error; err != nil {
 			return err
 		}
 
-		// Cache the URL
+		// Cache the URL (new links start in the cold tier)
 		return s.redisClient.Set(ctx,
 			getCacheKey(shortCode),
-			longURL,
-			24*time.Hour,
+			cacheValue(url),
+			s.memoryBudget.URLCacheTTL(0, expiresAt),
 		).Err()
 	})
 
@@ -94,23 +226,61 @@ func (s *URLService) CreateShortURL(ctx context.Context, userID uuid.UUID, longU
 		return nil, err
 	}
 
+	s.notifyHeld(ctx, url)
+	s.notifyCreated(url)
 	return url, nil
 }
 
+// FindExistingURL returns the user's newest live link to longURL, nil when
+// there is none. Disabled, paused, expired and held links don't count.
+func (s *URLService) FindExistingURL(ctx context.Context, userID uuid.UUID, longURL string) (*models.URL, error) {
+	// Destinations are stored normalized; an invalid one can't match anything
+	if normalized, err := utils.NormalizeDestination(longURL); err == nil {
+		longURL = normalized
+	}
+
+	var url models.URL
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND long_url = ? AND deleted_at IS NULL AND disabled_at IS NULL AND is_active = ?", userID, longURL, true).
+		Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC()).
+		Where("moderation IS NULL OR moderation NOT IN ?", []string{models.ModerationPending, models.ModerationAwaitingApproval, models.ModerationRejected, models.ModerationQuarantined}).
+		Order("created_at DESC").
+		First(&url).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
 // ✅ NEW: CreateAnonymousURL for unauthenticated users
-func (s *URLService) CreateAnonymousURL(ctx context.Context, longURL string, customShortCode string, expiryHours int) (*models.URL, error) {
+func (s *URLService) CreateAnonymousURL(ctx context.Context, longURL string, customShortCode string, expiryHours int, client models.ClientInfo) (*models.URL, error) {
 	// Validate long URL
 	if longURL == "" {
 		return nil, types.NewValidationError("long URL is required")
 	}
+	longURL, err := s.validateDestination(ctx, longURL)
+	if err != nil {
+		return nil, err
+	}
+
+	score, moderation, err := s.assessAnonymousCreation(ctx, longURL, client)
+	if err != nil {
+		return nil, err
+	}
 
 	// Generate or validate short code
 	shortCode := customShortCode
 	if shortCode != "" {
-		if !s.shortCodePattern.MatchString(shortCode) {
+		shortCode = s.NormalizeShortCode(shortCode)
+		if !s.validCustomCode(shortCode) {
 			return nil, types.ErrInvalidShortCode
 		}
-		shortCode = strings.ToLower(shortCode)
+		if s.reservedCodes.Contains(shortCode) {
+			return nil, types.ErrShortCodeReserved
+		}
 
 		exists, err := s.isShortCodeTaken(ctx, shortCode)
 		if err != nil {
@@ -138,32 +308,54 @@ func (s *URLService) CreateAnonymousURL(ctx context.Context, longURL string, cus
 		expiresAt = &expiry
 	}
 
+	claimToken, err := generateClaimToken()
+	if err != nil {
+		return nil, err
+	}
+	manageToken, err := generateClaimToken()
+	if err != nil {
+		return nil, err
+	}
+
 	// Create URL model
 	url := &models.URL{
-		ID:          uuid.New(),
-		UserID:      nil, // No user (anonymous)
-		LongURL:     longURL,
-		ShortCode:   shortCode,
-		ShortURL:    fmt.Sprintf("%surls/%s", s.urlPrefix, shortCode),
-		Clicks:      0,
-		IsAnonymous: true, // Anonymous URL
-		ExpiresAt:   expiresAt,
-		CreatedAt:   time.Now().UTC(),
-		UpdatedAt:   time.Now().UTC(),
+		ID:              uuid.New(),
+		UserID:          nil, // No user (anonymous)
+		LongURL:         longURL,
+		ShortCode:       shortCode,
+		ShortURL:        s.shortURLFor(shortCode),
+		Clicks:          0,
+		IsAnonymous:     true, // Anonymous URL
+		IsActive:        true,
+		PreviewPage:     s.anonymousPreviewPage,
+		ExpiresAt:       expiresAt,
+		Moderation:      moderation,
+		AbuseScore:      score,
+		CreatorIP:       client.IP,
+		ClaimTokenHash:  hashClaimToken(claimToken),
+		ClaimToken:      claimToken,
+		ManageTokenHash: hashClaimToken(manageToken),
+		ManageToken:     manageToken,
+		CreatedAt:       time.Now().UTC(),
+		UpdatedAt:       time.Now().UTC(),
 	}
 
 	// Save to database with transaction
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(url).Error; err != nil {
 			return err
 		}
 
-		// Cache with expiry
-		cacheDuration := time.Until(*expiresAt)
+		// Held links must not be served from cache until approved
+		if url.IsPendingReview() {
+			return nil
+		}
+
+		// Cached like any other link so the preview page flag survives
 		return s.redisClient.Set(ctx,
 			getCacheKey(shortCode),
-			longURL,
-			cacheDuration,
+			cacheValue(url),
+			s.memoryBudget.URLCacheTTL(0, expiresAt),
 		).Err()
 	})
 
@@ -171,6 +363,7 @@ func (s *URLService) CreateAnonymousURL(ctx context.Context, longURL string, cus
 		return nil, err
 	}
 
+	s.notifyCreated(url)
 	return url, nil
 }
 
@@ -178,7 +371,7 @@ func (s *URLService) CreateAnonymousURL(ctx context.Context, longURL string, cus
 func (s *URLService) GetURLByID(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error) {
 	var url models.URL
 	err := s.db.WithContext(ctx).
-		Where("id = ? AND user_id = ? AND deleted_at IS NULL", urlID, userID).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
 		First(&url).Error
 
 	if err != nil {
@@ -191,11 +384,53 @@ func (s *URLService) GetURLByID(ctx context.Context, userID, urlID uuid.UUID) (*
 	return &url, nil
 }
 
-// UpdateURL updates an existing URL
-func (s *URLService) UpdateURL(ctx context.Context, userID, urlID uuid.UUID, longURL string) (*models.URL, error) {
+// GetURLsByIDs returns the caller's links with the given IDs in request
+// order; an unknown, foreign or deleted ID fails the whole lookup
+func (s *URLService) GetURLsByIDs(ctx context.Context, userID uuid.UUID, urlIDs []uuid.UUID) ([]models.URL, error) {
+	var urls []models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id IN ? AND deleted_at IS NULL", urlIDs).
+		Find(&urls).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]models.URL, len(urls))
+	for _, url := range urls {
+		byID[url.ID] = url
+	}
+	ordered := make([]models.URL, 0, len(urlIDs))
+	seen := make(map[uuid.UUID]bool, len(urlIDs))
+	for _, id := range urlIDs {
+		url, ok := byID[id]
+		if !ok {
+			return nil, types.ErrURLNotFound
+		}
+		if !seen[id] {
+			seen[id] = true
+			ordered = append(ordered, url)
+		}
+	}
+	return ordered, nil
+}
+
+// UpdateURL changes a link's destination and, unless nil, replaces its tags and notes
+func (s *URLService) UpdateURL(ctx context.Context, userID, urlID uuid.UUID, longURL string, tags []string, notes *string) (*models.URL, error) {
+	longURL, err := s.validateDestination(ctx, longURL)
+	if err != nil {
+		return nil, err
+	}
+	if tags != nil {
+		normalized, err := models.NormalizeTags(tags)
+		if err != nil {
+			return nil, types.NewValidationError(err.Error())
+		}
+		tags = normalized
+	}
+
 	var url models.URL
-	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("id = ? AND user_id = ? AND deleted_at IS NULL", urlID, userID).
+	var changed bool
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
 			First(&url).Error; err != nil {
 			if err == gorm.ErrRecordNotFound {
 				return types.ErrURLNotFound
@@ -203,103 +438,312 @@ func (s *URLService) UpdateURL(ctx context.Context, userID, urlID uuid.UUID, lon
 			return err
 		}
 
+		if err := recordDestinationChange(tx, &url, userID, longURL); err != nil {
+			return err
+		}
+		changed = url.LongURL != longURL
 		url.LongURL = longURL
+		if tags != nil {
+			url.Tags = tags
+		}
+		if notes != nil {
+			url.Notes = strings.TrimSpace(*notes)
+		}
+		if url.IsRotator() {
+			url.Destinations[0] = longURL
+		}
 		url.UpdatedAt = time.Now().UTC()
+		if changed {
+			if err := s.destinationsChanged(tx, userID, &url); err != nil {
+				return err
+			}
+		} else if err := s.holdForApproval(tx, userID, &url); err != nil {
+			return err
+		}
 
 		if err := tx.Save(&url).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("url_id = ?", url.ID).Find(&url.Variants).Error; err != nil {
+			return err
+		}
 
-		return s.redisClient.Set(ctx,
+		// The cached preview belongs to the old destination
+		pipe := s.redisClient.Pipeline()
+		pipe.Set(ctx,
 			getCacheKey(url.ShortCode),
-			longURL,
-			24*time.Hour,
-		).Err()
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		)
+		pipe.Del(ctx, getMetaKey(url.ShortCode), getPreviewCardKey(url.ShortCode))
+		_, err := pipe.Exec(ctx)
+		return err
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
+	s.notifyHeld(ctx, &url)
+	if changed {
+		s.notifyChanged(&url)
+	}
 	return &url, nil
 }
 
-// ✅ UPDATED: DeleteURL with HARD delete (permanently remove from database)
-func (s *URLService) DeleteURL(ctx context.Context, userID, urlID uuid.UUID) error {
-	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		var url models.URL
-		if err := tx.Where("id = ? AND user_id = ? AND deleted_at IS NULL", urlID, userID).
-			First(&url).Error; err != nil {
-			if err == gorm.ErrRecordNotFound {
-				return types.ErrURLNotFound
-			}
-			return err
+// ToggleActive pauses an active link or reactivates a paused one. Paused
+// links answer 410 but keep their clicks and analytics.
+func (s *URLService) ToggleActive(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, types.ErrURLNotFound
 		}
+		return nil, err
+	}
 
-		// ✅ HARD DELETE: Permanently remove from database
-		if err := tx.Unscoped().Delete(&url).Error; err != nil {
-			return err
+	url.IsActive = !url.IsActive
+	url.UpdatedAt = time.Now().UTC()
+	if err := s.db.WithContext(ctx).Model(&url).
+		Select("is_active", "updated_at").
+		Updates(&url).Error; err != nil {
+		return nil, err
+	}
+
+	// The next redirect reloads the link and caches its new state
+	s.redisClient.Del(ctx, getCacheKey(url.ShortCode), getFeedKey(*url.UserID))
+	return &url, nil
+}
+
+// TogglePinned pins a link to the top of the user's list or unpins it.
+// Pinning isn't an edit, so UpdatedAt is left alone.
+func (s *URLService) TogglePinned(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, types.ErrURLNotFound
 		}
+		return nil, err
+	}
 
-		// Remove from cache
-		pipe := s.redisClient.Pipeline()
-		pipe.Del(ctx, getCacheKey(url.ShortCode))
-		pipe.Del(ctx, getClicksKey(url.ShortCode))
-		_, err := pipe.Exec(ctx)
+	url.IsPinned = !url.IsPinned
+	if err := s.db.WithContext(ctx).Model(&url).
+		UpdateColumn("is_pinned", url.IsPinned).Error; err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+// DeleteURL moves a link to the trash: it stops redirecting at once and can
+// be restored for models.TrashRetention before it is purged for good
+func (s *URLService) DeleteURL(ctx context.Context, userID, urlID uuid.UUID) error {
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return types.ErrURLNotFound
+		}
 		return err
-	})
+	}
+
+	now := time.Now().UTC()
+	url.DeletedAt = &now
+	if err := s.db.WithContext(ctx).Model(&url).
+		UpdateColumn("deleted_at", url.DeletedAt).Error; err != nil {
+		return err
+	}
+
+	// Old codes of a renamed link stop forwarding too; counters are kept for a
+	// restore, rendered QR codes are not
+	var aliases []string
+	if err := s.db.WithContext(ctx).Model(&models.ShortCodeAlias{}).
+		Where("url_id = ?", url.ID).Pluck("code", &aliases).Error; err != nil {
+		return err
+	}
+	keys := append(qrRedisKeys(url.ShortCode), getCacheKey(url.ShortCode), getFeedKey(*url.UserID))
+	for _, alias := range aliases {
+		keys = append(keys, getCacheKey(alias))
+		keys = append(keys, qrRedisKeys(alias)...)
+	}
+	return s.redisClient.Del(ctx, keys...).Err()
 }
 
-// ✅ OPTIMIZED: Hybrid cache strategy
+// GetLongURL resolves a short code to the destination of the current click
 func (s *URLService) GetLongURL(ctx context.Context, shortCode string) (string, error) {
-	shortCode = strings.TrimPrefix(shortCode, "urls/")
+	target, err := s.ResolveRedirect(ctx, shortCode, types.Visitor{})
+	if err != nil {
+		return "", err
+	}
+	return target.URL, nil
+}
+
+// ✅ OPTIMIZED: Hybrid cache strategy
+func (s *URLService) ResolveRedirect(ctx context.Context, shortCode string, visitor types.Visitor) (*types.RedirectTarget, error) {
+	requested := norm.NFC.String(strings.TrimPrefix(shortCode, "urls/"))
+	shortCode = s.NormalizeShortCode(requested)
 
-	fmt.Printf("🔍 [DEBUG] GetLongURL called with shortCode: %s\n", shortCode) // ✅ ADD
+	fmt.Printf("🔍 [DEBUG] ResolveRedirect called with shortCode: %s\n", shortCode) // ✅ ADD
 
 	// Try Redis cache first
-	longURL, err := s.redisClient.Get(ctx, getCacheKey(shortCode)).Result()
+	var target *cachedTarget
+	cached, err := s.redisClient.Get(ctx, getCacheKey(shortCode)).Result()
+	if err != nil && requested != shortCode {
+		// Links are cached under their stored spelling, which for codes
+		// created mixed-case is usually the one requested
+		if value, getErr := s.redisClient.Get(ctx, getCacheKey(requested)).Result(); getErr == nil {
+			cached, err, shortCode = value, nil, requested
+		}
+	}
 	if err == nil {
 		fmt.Printf("✅ [DEBUG] Cache HIT for: %s\n", shortCode) // ✅ ADD
-		// ✅ SYNCHRONOUS: Increment immediately before return
-		s.incrementClickCount(ctx, shortCode)
-		return longURL, nil
-	}
+		if cached == cacheNotFound || cached == cacheExpired {
+			return nil, types.ErrURLNotFound
+		}
+		if cached == cacheInactive {
+			return nil, types.ErrURLInactive
+		}
+		if cached == cacheHeld {
+			return nil, types.ErrURLUnderReview
+		}
+		if cached == cacheQuarantined {
+			return nil, types.ErrURLQuarantined
+		}
+		if shortURL, found := strings.CutPrefix(cached, cacheMovedPrefix); found {
+			return movedTarget(shortURL), nil
+		}
+		target = decodeCacheValue(cached)
+	} else {
+		fmt.Printf("⚠️  [DEBUG] Cache MISS for: %s, fetching from DB...\n", shortCode) // ✅ ADD
+
+		// Cache MISS - Fetch from PostgreSQL
+		var url models.URL
+		if err := s.db.WithContext(ctx).Preload("Variants").
+			Scopes(s.ShortCodeScope(requested)).Where("deleted_at IS NULL").
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				if shortURL, ok := s.resolveAlias(ctx, shortCode); ok {
+					s.redisClient.Set(ctx, getCacheKey(shortCode), cacheMovedPrefix+shortURL, 5*time.Minute)
+					return movedTarget(shortURL), nil
+				}
+				fmt.Printf("❌ [DEBUG] URL not found in DB: %s\n", shortCode) // ✅ ADD
+				s.redisClient.Set(ctx, getCacheKey(shortCode), cacheNotFound, 5*time.Minute)
+				return nil, types.ErrURLNotFound
+			}
+			return nil, err
+		}
 
-	fmt.Printf("⚠️  [DEBUG] Cache MISS for: %s, fetching from DB...\n", shortCode) // ✅ ADD
+		fmt.Printf("✅ [DEBUG] URL found in DB: %s → %s\n", shortCode, url.LongURL) // ✅ ADD
 
-	// Cache MISS - Fetch from PostgreSQL
-	var url models.URL
-	if err := s.db.WithContext(ctx).
-		Where("short_code = ? AND deleted_at IS NULL", shortCode).
-		First(&url).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			fmt.Printf("❌ [DEBUG] URL not found in DB: %s\n", shortCode) // ✅ ADD
-			s.redisClient.Set(ctx, getCacheKey(shortCode), "NOT_FOUND", 5*time.Minute)
-			return "", types.ErrURLNotFound
+		// From here on the link is cached and counted under its stored code
+		shortCode = url.ShortCode
+
+		if url.IsDisabled() {
+			return nil, types.ErrURLDisabled
 		}
-		return "", err
+		if url.IsPendingReview() {
+			return nil, types.ErrURLUnderReview
+		}
+		if url.IsQuarantined() {
+			s.redisClient.Set(ctx, getCacheKey(shortCode), cacheQuarantined, 5*time.Minute)
+			return nil, types.ErrURLQuarantined
+		}
+		if !url.IsActive {
+			s.redisClient.Set(ctx, getCacheKey(shortCode), cacheInactive, 5*time.Minute)
+			return nil, types.ErrURLInactive
+		}
+
+		// Check expiry
+		if url.IsExpired() {
+			// Owners keep their expired links (and the stats) until they delete them
+			if url.IsAnonymous {
+				go s.deleteExpiredURL(context.Background(), url.ID)
+			}
+			s.redisClient.Set(ctx, getCacheKey(shortCode), cacheExpired, 5*time.Minute)
+			return nil, types.ErrURLNotFound
+		}
+
+		// Write-through cache, TTL by popularity
+		s.redisClient.Set(ctx, getCacheKey(shortCode), cacheValue(&url), s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt))
+		target = newCachedTarget(&url)
+	}
+
+	// A visit that keeps coming back through our short links is stuck in a loop
+	if err := checkRedirectLoop(ctx, shortCode, visitor.Hops); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("✅ [DEBUG] URL found in DB: %s → %s\n", shortCode, url.LongURL) // ✅ ADD
+	// Bots neither count as clicks nor use up unique-visitor slots unless the owner opted in
+	counted := !visitor.Bot || target.CountBots
+	if counted {
+		if err := s.trackVisitor(ctx, shortCode, visitor.ID, target.MaxVisitors); err != nil {
+			return nil, err
+		}
 
-	// Check expiry
-	if url.IsExpired() {
-		go s.deleteExpiredURL(context.Background(), url.ID)
-		s.redisClient.Set(ctx, getCacheKey(shortCode), "EXPIRED", 5*time.Minute)
-		return "", types.ErrURLNotFound
+		// ✅ SYNCHRONOUS: Increment before return
+		s.incrementClickCount(ctx, shortCode)
 	}
 
-	// Write-through cache
-	if url.ExpiresAt != nil {
-		cacheDuration := time.Until(*url.ExpiresAt)
-		s.redisClient.Set(ctx, getCacheKey(shortCode), url.LongURL, cacheDuration)
-	} else {
-		s.redisClient.Set(ctx, getCacheKey(shortCode), url.LongURL, 24*time.Hour)
+	result := &types.RedirectTarget{
+		ShortCode: shortCode,
+		Rotating:  len(target.Destinations) > 1 || len(target.Split) > 0 || len(target.Rules) > 0 || len(target.Languages) > 0 || len(target.Countries) > 0 || target.Devices != nil,
+		Unfurl:    !target.NoUnfurl,
+		Indexable: target.Index,
+		Counted:   counted,
+
+		Pixels:               target.Pixels,
+		PixelConsentRequired: target.PixelConsent,
+		OwnerID:              target.Owner,
+		PreviewPage:          target.PreviewPage,
+		Status:               target.RedirectType,
+	}
+	if result.Status == 0 {
+		result.Status = s.defaultRedirectStatus
+	}
+	// Browsers cache permanent redirects, which would pin visitors of routed links to one destination
+	if result.Rotating {
+		switch result.Status {
+		case http.StatusMovedPermanently:
+			result.Status = http.StatusFound
+		case http.StatusPermanentRedirect:
+			result.Status = http.StatusTemporaryRedirect
+		}
 	}
 
-	// ✅ SYNCHRONOUS: Increment before return
-	s.incrementClickCount(ctx, shortCode)
-	return url.LongURL, nil
+	// An open time window wins over country routes, then device targets,
+	// then language routes, then an A/B split, then the regular destination(s)
+	if destination, ok := models.MatchRoutingRules(target.Rules, target.Timezone, time.Now()); ok {
+		result.URL = destination
+	} else if destination, variant, ok := matchCountryRoute(target.Countries, visitor.Country); ok {
+		result.URL, result.Variant = destination, variant
+	} else if destination, variant, ok := matchDeviceTarget(target.Devices, visitor.Platform); ok {
+		result.URL, result.Variant = destination, variant
+	} else if destination, variant, ok := matchLanguageRoute(target.Languages, visitor.AcceptLanguage); ok {
+		result.URL, result.Variant = destination, variant
+	} else if len(target.Split) > 0 {
+		variant := pickVariant(target.Split)
+		result.URL, result.Variant = variant.URL, variant.Name
+		if counted {
+			s.countVariantClick(ctx, shortCode, variant.Name)
+		}
+	} else {
+		destination, i := s.pickDestination(ctx, shortCode, target.Destinations, target.Mode)
+		result.URL = destination
+		if len(target.Languages) > 0 {
+			result.Variant = defaultLanguageVariant
+		} else if len(target.Destinations) > 1 {
+			result.Variant = rotationVariant(i)
+		}
+	}
+	if target.UTM != nil {
+		result.URL = target.UTM.Apply(result.URL)
+	}
+	result.URL = s.markRedirectHop(result.URL, visitor.Hops)
+	return result, nil
 }
 
 // ✅ FIXED: Synchronous click counter with proper error handling
@@ -357,7 +801,9 @@ func (s *URLService) incrementClickCount(ctx context.Context, shortCode string)
 }
 
 // ✅ UPDATED: GetUserURLsPaginated dengan real-time clicks
-func (s *URLService) GetUserURLsPaginated(ctx context.Context, userID uuid.UUID, page, perPage int) ([]models.URL, int64, error) {
+// GetUserURLsPaginated lists the user's links and the links shared with them
+// through workspaces, pinned ones first and then newest first, narrowed by filter
+func (s *URLService) GetUserURLsPaginated(ctx context.Context, userID uuid.UUID, page, perPage int, filter models.URLFilter) ([]models.URL, int64, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -368,16 +814,53 @@ func (s *URLService) GetUserURLsPaginated(ctx context.Context, userID uuid.UUID,
 	var urls []models.URL
 	var total int64
 
-	err := s.db.WithContext(ctx).Model(&models.URL{}).
-		Where("user_id = ? AND is_anonymous = false AND deleted_at IS NULL", userID).
-		Count(&total).Error
+	query := s.db.WithContext(ctx).Model(&models.URL{}).
+		Scopes(linkAccess(userID)).
+		Where("is_anonymous = false AND deleted_at IS NULL")
+	if filter.WorkspaceID != nil {
+		query = query.Where("workspace_id = ?", *filter.WorkspaceID)
+	}
+	if filter.Search != "" {
+		pattern := "%" + escapeLike(filter.Search) + "%"
+		query = query.Where("short_code ILIKE ? OR long_url ILIKE ? OR title ILIKE ? OR notes ILIKE ?",
+			pattern, pattern, pattern, pattern)
+	}
+	if filter.Tag != "" {
+		query = query.Where("tags @> ?::jsonb", jsonArray(filter.Tag))
+	}
+	if filter.CollectionID != nil {
+		query = query.Where("collection_id = ?", *filter.CollectionID)
+	}
+	if filter.Expired != nil {
+		if *filter.Expired {
+			query = query.Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now().UTC())
+		} else {
+			query = query.Where("expires_at IS NULL OR expires_at > ?", time.Now().UTC())
+		}
+	}
+	if filter.Active != nil {
+		query = query.Where("is_active = ?", *filter.Active)
+	}
+	switch filter.Health {
+	case models.HealthOK, models.HealthBroken:
+		query = query.Where("health_status = ?", filter.Health)
+	case "unchecked":
+		query = query.Where("health_status IS NULL OR health_status = ''")
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+
+	err := query.Session(&gorm.Session{}).Count(&total).Error
 	if err != nil {
 		return nil, 0, err
 	}
 
-	err = s.db.WithContext(ctx).
-		Where("user_id = ? AND is_anonymous = false AND deleted_at IS NULL", userID).
-		Order("created_at DESC").
+	err = query.
+		Order(urlListOrder(filter)).
 		Offset((page - 1) * perPage).
 		Limit(perPage).
 		Find(&urls).Error
@@ -400,6 +883,21 @@ func (s *URLService) GetUserURLsPaginated(ctx context.Context, userID uuid.UUID,
 	return urls, total, nil
 }
 
+// urlListOrder builds the ORDER BY clause for the link list. Sort columns
+// are validated against models.SavedViewSorts before they get here; links
+// that never expire sort last either way.
+func urlListOrder(filter models.URLFilter) string {
+	column := filter.Sort
+	if !models.SavedViewSorts[column] {
+		column = "created_at"
+	}
+	direction := "DESC"
+	if filter.Order == "asc" {
+		direction = "ASC"
+	}
+	return fmt.Sprintf("is_pinned DESC, %s %s NULLS LAST, id", column, direction)
+}
+
 // GetURLStats retrieves statistics for a URL
 func (s *URLService) GetURLStats(ctx context.Context, urlID uuid.UUID) (*models.URLStats, error) {
 	var url models.URL
@@ -418,8 +916,14 @@ func (s *URLService) GetURLStats(ctx context.Context, urlID uuid.UUID) (*models.
 		clicks = url.Clicks
 	}
 
+	uniques, err := s.redisClient.PFCount(ctx, getUniquesKey(url.ShortCode)).Result()
+	if err != nil {
+		uniques = 0
+	}
+
 	stats := &models.URLStats{
 		TotalClicks:    clicks,
+		UniqueVisitors: uniques,
 		LastAccessedAt: url.UpdatedAt,
 	}
 
@@ -433,9 +937,20 @@ func (s *URLService) isShortCodeTaken(ctx context.Context, shortCode string) (bo
 		return true, nil
 	}
 
+	// Trashed links keep their code until they are purged
 	var count int64
 	if err := s.db.WithContext(ctx).Model(&models.URL{}).
-		Where("short_code = ? AND deleted_at IS NULL", shortCode).
+		Where(s.codeEquals("short_code"), shortCode).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	// Old codes of renamed links stay taken while they forward
+	if err := s.db.WithContext(ctx).Model(&models.ShortCodeAlias{}).
+		Where(s.codeEquals("code")+" AND expires_at > ?", shortCode, time.Now().UTC()).
 		Count(&count).Error; err != nil {
 		return false, err
 	}
@@ -443,6 +958,94 @@ func (s *URLService) isShortCodeTaken(ctx context.Context, shortCode string) (bo
 	return count > 0, nil
 }
 
+// requireVerifiedEmail gates features reserved for verified accounts
+func (s *URLService) requireVerifiedEmail(ctx context.Context, userID uuid.UUID) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).Select("email_verified").First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return types.ErrUserNotFound
+		}
+		return err
+	}
+	if !user.EmailVerified {
+		return types.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// validateDestination checks a destination before it is stored: http(s)
+// only, no private addresses, no links back to this shortener (directly or
+// through another shortener) and no blocked domains. It returns the
+// normalized URL to store.
+func (s *URLService) validateDestination(ctx context.Context, longURL string) (string, error) {
+	normalized, err := utils.NormalizeDestination(longURL)
+	if err != nil {
+		return "", err
+	}
+	if s.isOwnDomain(utils.ExtractDomain(normalized)) {
+		return "", types.ErrSelfReferencingURL
+	}
+	if err := s.checkDomainAllowed(ctx, normalized); err != nil {
+		return "", err
+	}
+	if err := s.checkRedirectChain(ctx, normalized); err != nil {
+		return "", err
+	}
+	return normalized, nil
+}
+
+// checkDomainAllowed rejects destinations on (a subdomain of) a blocked domain
+func (s *URLService) checkDomainAllowed(ctx context.Context, longURL string) error {
+	domain := utils.ExtractDomain(longURL)
+	if domain == "" {
+		return nil
+	}
+
+	var count int64
+	if err := s.db.WithContext(ctx).Model(&models.BlockedDomain{}).
+		Where("domain IN ?", utils.DomainCandidates(domain)).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return types.ErrDomainBlocked
+	}
+	return nil
+}
+
+// assessAnonymousCreation scores an anonymous creation. Above the CAPTCHA
+// threshold a solved CAPTCHA is required (or, when no provider is configured,
+// the link is held for review); above the review threshold it is always held.
+func (s *URLService) assessAnonymousCreation(ctx context.Context, longURL string, client models.ClientInfo) (int, string, error) {
+	if s.abuseScorer == nil {
+		return 0, "", nil
+	}
+
+	assessment := s.abuseScorer.Score(ctx, longURL, client.IP)
+	moderation := ""
+
+	if s.abuseScorer.RequiresCaptcha(assessment.Score) &&
+		!s.abuseScorer.VerifyCaptcha(ctx, client.CaptchaToken, client.IP) {
+		if s.abuseScorer.CaptchaEnabled() {
+			return 0, "", types.ErrCaptchaRequired
+		}
+		moderation = models.ModerationPending
+	}
+	if s.abuseScorer.RequiresReview(assessment.Score) {
+		moderation = models.ModerationPending
+	}
+
+	if assessment.Score > 0 {
+		utils.LoggerFromContext(ctx).Info("Anonymous link abuse score",
+			"score", assessment.Score,
+			"signals", assessment.Signals,
+			"ip", client.IP,
+			"held_for_review", moderation == models.ModerationPending)
+	}
+
+	return assessment.Score, moderation, nil
+}
+
 // ✅ NEW: Delete expired URL (hard delete)
 func (s *URLService) deleteExpiredURL(ctx context.Context, urlID uuid.UUID) {
 	s.db.WithContext(ctx).
@@ -457,6 +1060,7 @@ func (s *URLService) generateUniqueShortCode(ctx context.Context) (string, error
 		if err != nil {
 			continue
 		}
+		code = s.NormalizeShortCode(code)
 
 		exists, err := s.isShortCodeTaken(ctx, code)
 		if err != nil || !exists {
@@ -478,11 +1082,142 @@ func generateShortCode() (string, error) {
 	return code, nil
 }
 
+// cachedTarget is the redirect cache entry of a link with rotation, a visitor
+// cap, a crawler policy or routing rules. Plain links are cached as their bare long URL, so
+// entries starting with "{" are JSON.
+type cachedTarget struct {
+	Destinations []string                  `json:"d"`
+	Mode         string                    `json:"m,omitempty"`
+	MaxVisitors  int64                     `json:"v,omitempty"`
+	NoUnfurl     bool                      `json:"nu,omitempty"`
+	Index        bool                      `json:"ix,omitempty"`
+	CountBots    bool                      `json:"cb,omitempty"`
+	Rules        []models.RoutingRule      `json:"r,omitempty"`
+	Timezone     string                    `json:"tz,omitempty"`
+	Languages    map[string]string         `json:"l,omitempty"`
+	Countries    map[string]string         `json:"g,omitempty"`
+	Devices      *models.DeviceTargets     `json:"dv,omitempty"`
+	Pixels       []models.RetargetingPixel `json:"px,omitempty"`
+	PixelConsent bool                      `json:"pc,omitempty"`
+	Owner        *uuid.UUID                `json:"o,omitempty"`
+	PreviewPage  bool                      `json:"pp,omitempty"`
+	UTM          *models.UTMParams         `json:"u,omitempty"`
+	Split        []cachedVariant           `json:"ab,omitempty"`
+	RedirectType int                       `json:"rt,omitempty"`
+}
+
+func newCachedTarget(url *models.URL) *cachedTarget {
+	target := &cachedTarget{
+		Destinations: []string{url.LongURL},
+		MaxVisitors:  url.MaxUniqueVisitors,
+		NoUnfurl:     url.DisableUnfurl,
+		Index:        url.AllowIndexing,
+		CountBots:    url.CountBots,
+		Rules:        url.RoutingRules,
+		Timezone:     url.RoutingTimezone,
+		Languages:    url.LanguageRoutes,
+		Countries:    url.CountryRoutes,
+		Devices:      url.DeviceTargets,
+		Pixels:       url.Pixels,
+		PixelConsent: url.PixelConsentRequired,
+		UTM:          url.UTM,
+		PreviewPage:  url.PreviewPage,
+		RedirectType: url.RedirectType,
+	}
+	if url.IsRotator() {
+		target.Destinations = url.Destinations
+		target.Mode = url.RotationMode
+	}
+	if url.IsSplit() {
+		for _, variant := range url.Variants {
+			target.Split = append(target.Split, cachedVariant{Name: variant.Name, URL: variant.Destination, Weight: variant.Weight})
+		}
+	}
+	// Only the pixel and preview pages are branded, so plain links stay plain
+	if len(url.Pixels) > 0 || url.PreviewPage {
+		target.Owner = url.UserID
+	}
+	return target
+}
+
+// cacheValue encodes the redirect cache entry for a link
+func cacheValue(url *models.URL) string {
+	if url.IsPendingReview() {
+		return cacheHeld
+	}
+	if url.IsQuarantined() {
+		return cacheQuarantined
+	}
+	target := newCachedTarget(url)
+	if target.plain() {
+		return url.LongURL
+	}
+	data, err := json.Marshal(target)
+	if err != nil {
+		return url.LongURL
+	}
+	return string(data)
+}
+
+// plain reports whether the entry is just a single destination with no options
+func (t *cachedTarget) plain() bool {
+	return len(t.Destinations) == 1 && t.MaxVisitors == 0 &&
+		!t.NoUnfurl && !t.Index && !t.CountBots &&
+		len(t.Rules) == 0 && len(t.Languages) == 0 && len(t.Countries) == 0 && t.Devices == nil && len(t.Pixels) == 0 && t.UTM == nil && len(t.Split) == 0 && !t.PreviewPage && t.RedirectType == 0
+}
+
+func decodeCacheValue(value string) *cachedTarget {
+	if strings.HasPrefix(value, "{") {
+		var target cachedTarget
+		if err := json.Unmarshal([]byte(value), &target); err == nil && len(target.Destinations) > 0 {
+			return &target
+		}
+	}
+	return &cachedTarget{Destinations: []string{value}}
+}
+
+// Negative cache entries stored under the redirect cache key
+const (
+	cacheNotFound    = "NOT_FOUND"
+	cacheExpired     = "EXPIRED"
+	cacheInactive    = "INACTIVE"
+	cacheHeld        = "HELD"        // waiting for review or approval
+	cacheQuarantined = "QUARANTINED" // matched a Safe Browsing threat list
+)
+
+// urlRedisKeys lists every Redis key kept for a link, for when it is deleted
+func urlRedisKeys(shortCode string) []string {
+	return []string{
+		getCacheKey(shortCode),
+		getClicksKey(shortCode),
+		getQRCodeKey(shortCode),
+		legacyQRCodeKey(shortCode),
+		getRotationKey(shortCode),
+		getUniquesKey(shortCode),
+		getMetaKey(shortCode),
+		getPreviewCardKey(shortCode),
+		getVariantClicksKey(shortCode),
+	}
+}
+
+// escapeLike makes user input match literally inside a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // Cache key helpers
 func getCacheKey(shortCode string) string {
 	return fmt.Sprintf("url:%s", shortCode)
 }
 
+func getMetaKey(shortCode string) string {
+	return fmt.Sprintf("meta:%s", shortCode)
+}
+
+func getPreviewCardKey(shortCode string) string {
+	return fmt.Sprintf("meta:card:%s", shortCode)
+}
+
 func getClicksKey(shortCode string) string {
 	return fmt.Sprintf("clicks:%s", shortCode)
 }
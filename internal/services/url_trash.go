@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// trashPurgeBatchSize bounds how many links one purge transaction removes
+const trashPurgeBatchSize = 500
+
+// GetTrashedURLs lists the user's deleted links that can still be restored,
+// most recently deleted first
+func (s *URLService) GetTrashedURLs(ctx context.Context, userID uuid.UUID, page, perPage int) ([]models.URL, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > 100 {
+		perPage = 10
+	}
+
+	var urls []models.URL
+	var total int64
+	query := s.db.WithContext(ctx).Model(&models.URL{}).
+		Scopes(linkAccess(userID)).Where("deleted_at IS NOT NULL AND deleted_at > ?", trashCutoff())
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.
+		Order("deleted_at DESC, id").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&urls).Error; err != nil {
+		return nil, 0, err
+	}
+	return urls, total, nil
+}
+
+// RestoreURL takes a link out of the trash; it redirects again right away
+func (s *URLService) RestoreURL(ctx context.Context, userID, urlID uuid.UUID) (*models.URL, error) {
+	var url models.URL
+	if err := s.db.WithContext(ctx).Preload("Variants").
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NOT NULL AND deleted_at > ?", urlID, trashCutoff()).
+		First(&url).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+
+	url.DeletedAt = nil
+	if err := s.db.WithContext(ctx).Model(&url).
+		UpdateColumn("deleted_at", nil).Error; err != nil {
+		return nil, err
+	}
+
+	pipe := s.redisClient.Pipeline()
+	pipe.Set(ctx, getCacheKey(url.ShortCode), cacheValue(&url), s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt))
+	pipe.Del(ctx, getFeedKey(*url.UserID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		utils.LoggerFromContext(ctx).Warn("Failed to cache restored URL", "short_code", url.ShortCode, "error", err)
+	}
+	return &url, nil
+}
+
+// PurgeTrash permanently removes links that have been in the trash longer
+// than models.TrashRetention, with their aliases and Redis keys
+func (s *URLService) PurgeTrash(ctx context.Context) (int64, error) {
+	var purged int64
+	for {
+		var urls []models.URL
+		if err := s.db.WithContext(ctx).Select("id", "short_code").
+			Where("deleted_at IS NOT NULL AND deleted_at <= ?", trashCutoff()).
+			Limit(trashPurgeBatchSize).
+			Find(&urls).Error; err != nil {
+			return purged, err
+		}
+		if len(urls) == 0 {
+			return purged, nil
+		}
+
+		ids := make([]uuid.UUID, len(urls))
+		for i := range urls {
+			ids[i] = urls[i].ID
+		}
+
+		var aliases []string
+		err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.ShortCodeAlias{}).Where("url_id IN ?", ids).Pluck("code", &aliases).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("url_id IN ?", ids).Delete(&models.ShortCodeAlias{}).Error; err != nil {
+				return err
+			}
+			return tx.Unscoped().Where("id IN ?", ids).Delete(&models.URL{}).Error
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged += int64(len(urls))
+
+		pipe := s.redisClient.Pipeline()
+		for i := range urls {
+			pipe.Del(ctx, urlRedisKeys(urls[i].ShortCode)...)
+		}
+		for _, alias := range aliases {
+			pipe.Del(ctx, append(qrRedisKeys(alias), getCacheKey(alias))...)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			utils.Logger.Warn("Failed to drop Redis keys of purged URLs", "error", err)
+		}
+
+		if len(urls) < trashPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// StartTrashPurgeJob purges expired trash every hour
+func (s *URLService) StartTrashPurgeJob() {
+	ticker := time.NewTicker(time.Hour)
+	go func() {
+		ctx := context.Background()
+		for range ticker.C {
+			purged, err := s.PurgeTrash(ctx)
+			if err != nil {
+				utils.Logger.Error("Trash purge failed", "error", err)
+				continue
+			}
+			if purged > 0 {
+				utils.Logger.Info("Purged trashed URLs", "count", purged)
+			}
+		}
+	}()
+}
+
+// trashCutoff is the deletion time before which trashed links are purged
+func trashCutoff() time.Time {
+	return time.Now().UTC().Add(-models.TrashRetention)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+)
+
+const (
+	verificationTokenTTL = 48 * time.Hour
+	verificationCooldown = time.Minute
+)
+
+type VerificationService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	signingKey  []byte
+	emailQueue  *EmailQueue
+}
+
+// NewVerificationService signs verification tokens with a key derived from the
+// JWT secret, so they can never be accepted as access tokens
+func NewVerificationService(db *gorm.DB, redisClient *redis.Client, jwtSecret string, emailQueue *EmailQueue) *VerificationService {
+	mac := hmac.New(sha256.New, []byte(jwtSecret))
+	mac.Write([]byte("email-verification"))
+
+	return &VerificationService{
+		db:          db,
+		redisClient: redisClient,
+		signingKey:  mac.Sum(nil),
+		emailQueue:  emailQueue,
+	}
+}
+
+// SendVerification queues a verification email, at most once per minute per user
+func (s *VerificationService) SendVerification(ctx context.Context, userID uuid.UUID) error {
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrUserNotFound
+		}
+		return err
+	}
+	if user.EmailVerified {
+		return types.ErrEmailAlreadyVerified
+	}
+
+	cooldownKey := fmt.Sprintf("verify_email:cooldown:%s", user.ID)
+	allowed, err := s.redisClient.SetNX(ctx, cooldownKey, 1, verificationCooldown).Result()
+	if err == nil && !allowed {
+		return types.ErrVerificationCooldown
+	}
+
+	token, err := s.generateToken(&user)
+	if err != nil {
+		return err
+	}
+
+	return s.emailQueue.Enqueue(ctx, EmailJob{
+		Kind:   EmailJobVerification,
+		UserID: user.ID,
+		Token:  token,
+	}, time.Now())
+}
+
+// VerifyEmail marks the address in the token as verified. Tokens are bound to
+// the email they were issued for, so changing the address invalidates them.
+func (s *VerificationService) VerifyEmail(ctx context.Context, tokenString string) (*models.User, error) {
+	token, err := jwt.Parse(strings.TrimSpace(tokenString), func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, types.ErrInvalidSigningMethod
+		}
+		return s.signingKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, types.ErrInvalidVerificationToken
+	}
+	userID, err := uuid.Parse(fmt.Sprint(claims["user_id"]))
+	if err != nil {
+		return nil, types.ErrInvalidVerificationToken
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", userID).Error; err != nil {
+		return nil, types.ErrInvalidVerificationToken
+	}
+	if !strings.EqualFold(user.Email, fmt.Sprint(claims["email"])) {
+		return nil, types.ErrInvalidVerificationToken
+	}
+	if user.EmailVerified {
+		return &user, nil
+	}
+
+	now := time.Now().UTC()
+	user.EmailVerified = true
+	user.EmailVerifiedAt = &now
+	if err := s.db.WithContext(ctx).Model(&user).
+		Select("email_verified", "email_verified_at").
+		Updates(&user).Error; err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (s *VerificationService) generateToken(user *models.User) (string, error) {
+	claims := jwt.MapClaims{
+		"user_id": user.ID.String(),
+		"email":   strings.ToLower(user.Email),
+		"exp":     time.Now().Add(verificationTokenTTL).Unix(),
+		"iat":     time.Now().Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.signingKey)
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// trackVisitorScript adds a visitor to a link's HyperLogLog. Once the cap is
+// reached, visitors are tested against a scratch copy instead so newcomers
+// are refused without being counted; returns -1 for a refused visitor.
+var trackVisitorScript = redis.NewScript(`
+local limit = tonumber(ARGV[2])
+if limit > 0 and redis.call('PFCOUNT', KEYS[1]) >= limit then
+	redis.call('PFMERGE', KEYS[2], KEYS[1])
+	local new = redis.call('PFADD', KEYS[2], ARGV[1])
+	redis.call('DEL', KEYS[2])
+	if new == 1 then
+		return -1
+	end
+	return 0
+end
+return redis.call('PFADD', KEYS[1], ARGV[1])
+`)
+
+// trackVisitor counts a unique visitor and enforces the link's visitor cap.
+// Visitors already counted keep access after the cap is reached.
+func (s *URLService) trackVisitor(ctx context.Context, shortCode, visitorID string, limit int64) error {
+	if visitorID == "" {
+		return nil
+	}
+
+	key := getUniquesKey(shortCode)
+	result, err := trackVisitorScript.Run(ctx, s.redisClient, []string{key, key + ":probe"}, visitorID, limit).Int64()
+	if err != nil {
+		// Fail open: a Redis hiccup should not break redirects
+		utils.LoggerFromContext(ctx).Warn("Failed to track unique visitor", "short_code", shortCode, "error", err)
+		return nil
+	}
+	if result < 0 {
+		return types.ErrVisitorLimitReached
+	}
+	return nil
+}
+
+// SetVisitorLimit caps a link by unique visitors (0 removes the cap)
+func (s *URLService) SetVisitorLimit(ctx context.Context, userID, urlID uuid.UUID, maxUniqueVisitors int64) (*models.URL, error) {
+	var url models.URL
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Variants").Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+			First(&url).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return types.ErrURLNotFound
+			}
+			return err
+		}
+
+		url.MaxUniqueVisitors = maxUniqueVisitors
+		url.UpdatedAt = time.Now().UTC()
+		if err := tx.Select("max_unique_visitors", "updated_at").Updates(&url).Error; err != nil {
+			return err
+		}
+
+		return s.redisClient.Set(ctx,
+			getCacheKey(url.ShortCode),
+			cacheValue(&url),
+			s.memoryBudget.URLCacheTTL(url.Clicks, url.ExpiresAt),
+		).Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &url, nil
+}
+
+func getUniquesKey(shortCode string) string {
+	return fmt.Sprintf("uniques:%s", shortCode)
+}
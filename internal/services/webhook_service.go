@@ -0,0 +1,411 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	webhookQueueSize     = 10000
+	webhookBatchSize     = 100
+	webhookFlushInterval = 10 * time.Second
+	webhookMaxAttempts   = 3
+
+	WebhookEventClicksBatch    = "clicks.batch"
+	WebhookEventClickMilestone = "clicks.milestone"
+)
+
+type WebhookService struct {
+	db          *gorm.DB
+	redisClient *redis.Client
+	httpClient  *http.Client
+	queue       chan *models.ClickEvent
+}
+
+func NewWebhookService(db *gorm.DB, redisClient *redis.Client) *WebhookService {
+	// Receivers are checked when registered, but a public name can later
+	// resolve to an internal address, so every delivery dials through the guard
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: denyPrivateAddresses}
+	return &WebhookService{
+		db:          db,
+		redisClient: redisClient,
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			// Deliveries are signed POSTs; a redirect is not followed
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		queue: make(chan *models.ClickEvent, webhookQueueSize),
+	}
+}
+
+// webhookClick is the per-click payload sent to webhook receivers
+type webhookClick struct {
+	ShortCode   string    `json:"short_code"`
+	ShortURL    string    `json:"short_url"`
+	ClickedAt   time.Time `json:"clicked_at"`
+	Referer     string    `json:"referer,omitempty"`
+	Browser     string    `json:"browser,omitempty"`
+	Device      string    `json:"device,omitempty"`
+	UTMSource   string    `json:"utm_source,omitempty"`
+	UTMMedium   string    `json:"utm_medium,omitempty"`
+	UTMCampaign string    `json:"utm_campaign,omitempty"`
+}
+
+// CreateWebhook registers a new webhook; the signing secret is only returned here
+func (s *WebhookService) CreateWebhook(ctx context.Context, userID uuid.UUID, req *models.CreateWebhookRequest) (*models.Webhook, error) {
+	sendClicks, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	secretBytes := make([]byte, 32)
+	if _, err := rand.Read(secretBytes); err != nil {
+		return nil, err
+	}
+
+	hook := &models.Webhook{
+		ID:             uuid.New(),
+		UserID:         userID,
+		URL:            req.URL,
+		Secret:         hex.EncodeToString(secretBytes),
+		SendClicks:     sendClicks,
+		MilestoneEvery: req.MilestoneEvery,
+		CreatedAt:      time.Now().UTC(),
+		UpdatedAt:      time.Now().UTC(),
+	}
+
+	if err := s.db.WithContext(ctx).Create(hook).Error; err != nil {
+		return nil, err
+	}
+
+	return hook, nil
+}
+
+// validateWebhookRequest checks the target URL and events, normalizing the
+// URL in place, and returns whether clicks are delivered. Receivers on
+// private or loopback addresses are refused like link destinations.
+func validateWebhookRequest(req *models.CreateWebhookRequest) (bool, error) {
+	target, err := utils.NormalizeDestination(req.URL)
+	if err == types.ErrPrivateDestination {
+		return false, err
+	}
+	if err != nil {
+		return false, types.ErrInvalidWebhookURL
+	}
+	req.URL = target
+
+	sendClicks := req.SendClicks == nil || *req.SendClicks
+	if !sendClicks && req.MilestoneEvery == 0 {
+		return false, types.NewValidationError("webhook must receive clicks, milestones, or both")
+	}
+	return sendClicks, nil
+}
+
+// ListWebhooks returns the user's webhooks without their secrets
+func (s *WebhookService) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]models.Webhook, error) {
+	hooks := []models.Webhook{}
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&hooks).Error; err != nil {
+		return nil, err
+	}
+
+	for i := range hooks {
+		hooks[i].Secret = ""
+	}
+	return hooks, nil
+}
+
+// DeleteWebhook removes a webhook together with its delivery log
+func (s *WebhookService) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", webhookID, userID).Delete(&models.Webhook{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return types.ErrWebhookNotFound
+		}
+		return tx.Where("webhook_id = ?", webhookID).Delete(&models.WebhookDelivery{}).Error
+	})
+}
+
+// ListDeliveries returns the most recent delivery attempts of a webhook
+func (s *WebhookService) ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID, limit int) ([]models.WebhookDelivery, error) {
+	var hook models.Webhook
+	if err := s.db.WithContext(ctx).
+		Where("id = ? AND user_id = ?", webhookID, userID).
+		First(&hook).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrWebhookNotFound
+		}
+		return nil, err
+	}
+
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	deliveries := []models.WebhookDelivery{}
+	if err := s.db.WithContext(ctx).
+		Where("webhook_id = ?", webhookID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// NotifyClick queues a click for webhook delivery. It never blocks the
+// redirect path: when the queue is full the click is dropped for webhooks.
+func (s *WebhookService) NotifyClick(event *models.ClickEvent) {
+	select {
+	case s.queue <- event:
+	default:
+		utils.Logger.Warn("Webhook queue full, dropping click", "short_code", event.ShortCode)
+	}
+}
+
+// StartDispatcher batches queued clicks and delivers them in the background
+func (s *WebhookService) StartDispatcher() {
+	go func() {
+		ticker := time.NewTicker(webhookFlushInterval)
+		defer ticker.Stop()
+
+		batch := make([]*models.ClickEvent, 0, webhookBatchSize)
+		for {
+			select {
+			case event := <-s.queue:
+				batch = append(batch, event)
+				if len(batch) < webhookBatchSize {
+					continue
+				}
+			case <-ticker.C:
+				if len(batch) == 0 {
+					continue
+				}
+			}
+
+			s.dispatch(batch)
+			batch = make([]*models.ClickEvent, 0, webhookBatchSize)
+		}
+	}()
+}
+
+// dispatch resolves link owners for a batch of clicks and fans out to their webhooks
+func (s *WebhookService) dispatch(batch []*models.ClickEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	codes := make([]string, 0, len(batch))
+	seen := make(map[string]bool)
+	for _, e := range batch {
+		if !seen[e.ShortCode] {
+			seen[e.ShortCode] = true
+			codes = append(codes, e.ShortCode)
+		}
+	}
+
+	var urls []models.URL
+	if err := s.db.WithContext(ctx).
+		Where("short_code IN ? AND user_id IS NOT NULL AND deleted_at IS NULL", codes).
+		Find(&urls).Error; err != nil {
+		utils.Logger.Error("Webhook dispatch: failed to load urls", "error", err)
+		return
+	}
+
+	urlsByCode := make(map[string]models.URL, len(urls))
+	var userIDs []uuid.UUID
+	for _, u := range urls {
+		urlsByCode[u.ShortCode] = u
+		userIDs = append(userIDs, *u.UserID)
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	var hooks []models.Webhook
+	if err := s.db.WithContext(ctx).Where("user_id IN ?", userIDs).Find(&hooks).Error; err != nil {
+		utils.Logger.Error("Webhook dispatch: failed to load webhooks", "error", err)
+		return
+	}
+	if len(hooks) == 0 {
+		return
+	}
+
+	clicksByUser := make(map[uuid.UUID][]webhookClick)
+	for _, e := range batch {
+		u, ok := urlsByCode[e.ShortCode]
+		if !ok {
+			continue
+		}
+		clicksByUser[*u.UserID] = append(clicksByUser[*u.UserID], webhookClick{
+			ShortCode:   e.ShortCode,
+			ShortURL:    u.ShortURL,
+			ClickedAt:   e.ClickedAt,
+			Referer:     e.Referer,
+			Browser:     e.Browser,
+			Device:      e.Device,
+			UTMSource:   e.UTMSource,
+			UTMMedium:   e.UTMMedium,
+			UTMCampaign: e.UTMCampaign,
+		})
+	}
+
+	for _, hook := range hooks {
+		clicks := clicksByUser[hook.UserID]
+		if len(clicks) == 0 {
+			continue
+		}
+
+		if hook.SendClicks {
+			go s.deliver(hook, WebhookEventClicksBatch, map[string]interface{}{
+				"event":  WebhookEventClicksBatch,
+				"count":  len(clicks),
+				"clicks": clicks,
+			})
+		}
+
+		if hook.MilestoneEvery > 0 {
+			s.checkMilestones(ctx, hook, clicks)
+		}
+	}
+}
+
+// checkMilestones notifies once per milestone crossed since the last notification
+func (s *WebhookService) checkMilestones(ctx context.Context, hook models.Webhook, clicks []webhookClick) {
+	checked := make(map[string]bool)
+	for _, click := range clicks {
+		if checked[click.ShortCode] {
+			continue
+		}
+		checked[click.ShortCode] = true
+
+		total, err := s.redisClient.Get(ctx, getClicksKey(click.ShortCode)).Int64()
+		if err != nil {
+			continue
+		}
+
+		milestone := total / hook.MilestoneEvery * hook.MilestoneEvery
+		if milestone == 0 {
+			continue
+		}
+
+		key := fmt.Sprintf("webhook:milestone:%s:%s", hook.ID, click.ShortCode)
+		last, _ := s.redisClient.Get(ctx, key).Int64()
+		if milestone <= last {
+			continue
+		}
+		s.redisClient.Set(ctx, key, milestone, 0)
+
+		go s.deliver(hook, WebhookEventClickMilestone, map[string]interface{}{
+			"event":      WebhookEventClickMilestone,
+			"short_code": click.ShortCode,
+			"short_url":  click.ShortURL,
+			"milestone":  milestone,
+			"clicks":     total,
+		})
+	}
+}
+
+// deliver POSTs a signed payload, retrying with backoff and logging every attempt
+func (s *WebhookService) deliver(hook models.Webhook, event string, payload interface{}) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		utils.Logger.Error("Webhook payload encoding failed", "webhook_id", hook.ID, "error", err)
+		return
+	}
+
+	for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+		statusCode, err := s.post(hook, event, body)
+
+		delivery := &models.WebhookDelivery{
+			ID:         uuid.New(),
+			WebhookID:  hook.ID,
+			Event:      event,
+			Payload:    string(body),
+			Attempt:    attempt,
+			StatusCode: statusCode,
+			Success:    err == nil,
+			CreatedAt:  time.Now().UTC(),
+		}
+		if err != nil {
+			delivery.Error = err.Error()
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if dbErr := s.db.WithContext(ctx).Create(delivery).Error; dbErr != nil {
+			utils.Logger.Error("Failed to log webhook delivery", "webhook_id", hook.ID, "error", dbErr)
+		}
+		cancel()
+
+		if err == nil {
+			return
+		}
+
+		if attempt < webhookMaxAttempts {
+			time.Sleep(time.Duration(attempt*attempt) * time.Second)
+		}
+	}
+
+	utils.Logger.Warn("Webhook delivery failed", "webhook_id", hook.ID, "event", event)
+}
+
+func (s *WebhookService) post(hook models.Webhook, event string, body []byte) (int, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Lynx-Webhooks/1.0")
+	req.Header.Set("X-Lynx-Event", event)
+	req.Header.Set("X-Lynx-Timestamp", timestamp)
+	req.Header.Set("X-Lynx-Signature", "sha256="+SignWebhookPayload(hook.Secret, timestamp, body))
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// SignWebhookPayload computes the hex HMAC-SHA256 of "timestamp.body".
+// Receivers should recompute it with their secret and compare in constant time.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
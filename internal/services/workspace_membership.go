@@ -0,0 +1,429 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// WorkspaceMembershipService manages shared workspaces and their members.
+// Which links a member can reach is decided by linkAccess.
+type WorkspaceMembershipService struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceMembershipService(db *gorm.DB) *WorkspaceMembershipService {
+	return &WorkspaceMembershipService{db: db}
+}
+
+// CreateWorkspace creates a workspace owned by the user
+func (s *WorkspaceMembershipService) CreateWorkspace(ctx context.Context, userID uuid.UUID, name string) (*models.Workspace, error) {
+	workspace := &models.Workspace{
+		ID:      uuid.New(),
+		Name:    strings.TrimSpace(name),
+		OwnerID: userID,
+	}
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: workspace.ID,
+			UserID:      userID,
+			Role:        models.WorkspaceRoleOwner,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return workspace, nil
+}
+
+// ListWorkspaces returns the workspaces the user belongs to, by name
+func (s *WorkspaceMembershipService) ListWorkspaces(ctx context.Context, userID uuid.UUID) ([]types.WorkspaceSummary, error) {
+	workspaces := []types.WorkspaceSummary{}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT w.*, m.role,
+			(SELECT COUNT(*) FROM workspace_members wm WHERE wm.workspace_id = w.id) AS members,
+			(SELECT COUNT(*) FROM urls u WHERE u.workspace_id = w.id AND u.deleted_at IS NULL) AS links
+		FROM workspaces w
+		JOIN workspace_members m ON m.workspace_id = w.id AND m.user_id = ?
+		ORDER BY w.name`, userID).
+		Scan(&workspaces).Error
+	return workspaces, err
+}
+
+// DeleteWorkspace removes a workspace; its links go back to being private
+// to their creators. Only the owner can delete it.
+func (s *WorkspaceMembershipService) DeleteWorkspace(ctx context.Context, userID, workspaceID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.requireOwner(tx, userID, workspaceID); err != nil {
+			return err
+		}
+		if err := tx.Model(&models.URL{}).Where("workspace_id = ?", workspaceID).
+			UpdateColumn("workspace_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&models.WorkspaceMember{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ?", workspaceID).Delete(&models.WorkspaceInvite{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", workspaceID).Delete(&models.Workspace{}).Error
+	})
+}
+
+// ListMembers returns a workspace's members; any member may look
+func (s *WorkspaceMembershipService) ListMembers(ctx context.Context, userID, workspaceID uuid.UUID) ([]types.WorkspaceMemberInfo, error) {
+	if _, err := s.membership(s.db.WithContext(ctx), userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	members := []types.WorkspaceMemberInfo{}
+	err := s.db.WithContext(ctx).Raw(`
+		SELECT m.user_id, u.email, u.first_name, u.last_name, m.role, m.created_at AS joined_at
+		FROM workspace_members m
+		JOIN users u ON u.id = m.user_id
+		WHERE m.workspace_id = ?
+		ORDER BY m.created_at`, workspaceID).
+		Scan(&members).Error
+	return members, err
+}
+
+// InviteMember invites an email address to the workspace. Only the owner can
+// invite. The answer is the same whether or not the address has an account;
+// the invitee joins by accepting the invite while signed in with it.
+func (s *WorkspaceMembershipService) InviteMember(ctx context.Context, userID, workspaceID uuid.UUID, email string) (*models.WorkspaceInvite, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	now := time.Now().UTC()
+
+	var invite models.WorkspaceInvite
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := s.requireOwner(tx, userID, workspaceID); err != nil {
+			return err
+		}
+		// Concurrent invites and accepts wait here, so the seat count below
+		// can't be overshot by two of them each seeing one free seat
+		if err := s.lockWorkspace(tx, workspaceID); err != nil {
+			return err
+		}
+		if err := tx.Where("workspace_id = ? AND expires_at <= ?", workspaceID, now).
+			Delete(&models.WorkspaceInvite{}).Error; err != nil {
+			return err
+		}
+
+		// Only members' addresses are checked, and the owner can list those anyway
+		var members int64
+		if err := tx.Model(&models.WorkspaceMember{}).
+			Joins("JOIN users ON users.id = workspace_members.user_id").
+			Where("workspace_members.workspace_id = ? AND LOWER(users.email) = ?", workspaceID, email).
+			Count(&members).Error; err != nil {
+			return err
+		}
+		if members > 0 {
+			return types.ErrAlreadyMember
+		}
+
+		// Inviting the same address again just extends the invite
+		err := tx.Where("workspace_id = ? AND email = ?", workspaceID, email).First(&invite).Error
+		if err == nil {
+			invite.InvitedBy = userID
+			invite.ExpiresAt = now.Add(models.WorkspaceInviteTTL)
+			return tx.Model(&invite).Select("invited_by", "expires_at").Updates(&invite).Error
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		seats, err := s.seatsTaken(tx, workspaceID)
+		if err != nil {
+			return err
+		}
+		if seats >= models.MaxWorkspaceMembers {
+			return types.ErrTooManyMembers
+		}
+
+		invite = models.WorkspaceInvite{
+			ID:          uuid.New(),
+			WorkspaceID: workspaceID,
+			Email:       email,
+			InvitedBy:   userID,
+			ExpiresAt:   now.Add(models.WorkspaceInviteTTL),
+			CreatedAt:   now,
+		}
+		return tx.Create(&invite).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
+
+// ListInvites returns a workspace's pending invites. Only the owner can see them.
+func (s *WorkspaceMembershipService) ListInvites(ctx context.Context, userID, workspaceID uuid.UUID) ([]models.WorkspaceInvite, error) {
+	if _, err := s.requireOwner(s.db.WithContext(ctx), userID, workspaceID); err != nil {
+		return nil, err
+	}
+
+	invites := []models.WorkspaceInvite{}
+	err := s.db.WithContext(ctx).
+		Where("workspace_id = ? AND expires_at > ?", workspaceID, time.Now().UTC()).
+		Order("created_at").Find(&invites).Error
+	return invites, err
+}
+
+// RevokeInvite withdraws a pending invite. Only the owner can revoke it.
+func (s *WorkspaceMembershipService) RevokeInvite(ctx context.Context, userID, workspaceID, inviteID uuid.UUID) error {
+	if _, err := s.requireOwner(s.db.WithContext(ctx), userID, workspaceID); err != nil {
+		return err
+	}
+
+	result := s.db.WithContext(ctx).Where("id = ? AND workspace_id = ?", inviteID, workspaceID).
+		Delete(&models.WorkspaceInvite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return types.ErrWorkspaceInviteNotFound
+	}
+	return nil
+}
+
+// ListMyInvites returns the pending invites addressed to the user's email
+func (s *WorkspaceMembershipService) ListMyInvites(ctx context.Context, userID uuid.UUID) ([]types.WorkspaceInviteInfo, error) {
+	email, err := s.inviteeEmail(s.db.WithContext(ctx), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	invites := []types.WorkspaceInviteInfo{}
+	err = s.db.WithContext(ctx).Raw(`
+		SELECT i.*, w.name AS workspace_name
+		FROM workspace_invites i
+		JOIN workspaces w ON w.id = i.workspace_id
+		WHERE i.email = ? AND i.expires_at > ?
+		ORDER BY i.created_at`, email, time.Now().UTC()).
+		Scan(&invites).Error
+	return invites, err
+}
+
+// AcceptInvite makes the user a member of the invite's workspace
+func (s *WorkspaceMembershipService) AcceptInvite(ctx context.Context, userID, inviteID uuid.UUID) (*models.Workspace, error) {
+	var workspace models.Workspace
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		invite, err := s.pendingInvite(tx, userID, inviteID)
+		if err != nil {
+			return err
+		}
+		if err := s.lockWorkspace(tx, invite.WorkspaceID); err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", invite.WorkspaceID).First(&workspace).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(invite).Error; err != nil {
+			return err
+		}
+
+		if _, err := s.membership(tx, userID, invite.WorkspaceID); err == nil {
+			return nil
+		} else if err != types.ErrWorkspaceNotFound {
+			return err
+		}
+
+		// The accepted invite already held a seat; a race with a lapsed
+		// invite being renewed is all that can push past the limit here
+		var count int64
+		if err := tx.Model(&models.WorkspaceMember{}).Where("workspace_id = ?", invite.WorkspaceID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count >= models.MaxWorkspaceMembers {
+			return types.ErrTooManyMembers
+		}
+
+		return tx.Create(&models.WorkspaceMember{
+			WorkspaceID: invite.WorkspaceID,
+			UserID:      userID,
+			Role:        models.WorkspaceRoleMember,
+			CreatedAt:   time.Now().UTC(),
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+// DeclineInvite discards an invite addressed to the user
+func (s *WorkspaceMembershipService) DeclineInvite(ctx context.Context, userID, inviteID uuid.UUID) error {
+	invite, err := s.pendingInvite(s.db.WithContext(ctx), userID, inviteID)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Delete(invite).Error
+}
+
+// RemoveMember takes a member out of the workspace. The owner can remove
+// anyone else; members can remove themselves. The links they created stay
+// in the workspace.
+func (s *WorkspaceMembershipService) RemoveMember(ctx context.Context, userID, workspaceID, memberID uuid.UUID) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		caller, err := s.membership(tx, userID, workspaceID)
+		if err != nil {
+			return err
+		}
+		if memberID == userID {
+			if caller.Role == models.WorkspaceRoleOwner {
+				return types.ErrWorkspaceOwnerLeaving
+			}
+		} else if caller.Role != models.WorkspaceRoleOwner {
+			return types.ErrNotWorkspaceOwner
+		}
+
+		result := tx.Where("workspace_id = ? AND user_id = ?", workspaceID, memberID).Delete(&models.WorkspaceMember{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return types.ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+// SetURLWorkspace shares a link into one of the user's workspaces, or makes
+// it private to its creator again when workspaceID is nil. Only the link's
+// creator or the owner of its current workspace may move it; other members
+// can edit the link but not take it out of the workspace.
+func (s *WorkspaceMembershipService) SetURLWorkspace(ctx context.Context, userID, urlID uuid.UUID, workspaceID *uuid.UUID) (*models.URL, error) {
+	if workspaceID != nil {
+		if _, err := s.membership(s.db.WithContext(ctx), userID, *workspaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	var url models.URL
+	if err := s.db.WithContext(ctx).
+		Scopes(linkAccess(userID)).Where("id = ? AND deleted_at IS NULL", urlID).
+		First(&url).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrURLNotFound
+		}
+		return nil, err
+	}
+	if url.UserID == nil || *url.UserID != userID {
+		if url.WorkspaceID == nil {
+			return nil, types.ErrNotLinkCreator
+		}
+		if _, err := s.requireOwner(s.db.WithContext(ctx), userID, *url.WorkspaceID); err != nil {
+			if err == types.ErrNotWorkspaceOwner {
+				return nil, types.ErrNotLinkCreator
+			}
+			return nil, err
+		}
+	}
+
+	url.WorkspaceID = workspaceID
+	url.UpdatedAt = time.Now().UTC()
+	if err := s.db.WithContext(ctx).Model(&url).
+		Select("workspace_id", "updated_at").
+		Updates(&url).Error; err != nil {
+		return nil, err
+	}
+	return &url, nil
+}
+
+// membership returns the user's membership of a workspace; workspaces the
+// user doesn't belong to are reported as not found
+func (s *WorkspaceMembershipService) membership(db *gorm.DB, userID, workspaceID uuid.UUID) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	if err := db.Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrWorkspaceNotFound
+		}
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (s *WorkspaceMembershipService) requireOwner(db *gorm.DB, userID, workspaceID uuid.UUID) (*models.WorkspaceMember, error) {
+	member, err := s.membership(db, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role != models.WorkspaceRoleOwner {
+		return nil, types.ErrNotWorkspaceOwner
+	}
+	return member, nil
+}
+
+func (s *WorkspaceMembershipService) lockWorkspace(tx *gorm.DB, workspaceID uuid.UUID) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", workspaceID).First(&models.Workspace{}).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return types.ErrWorkspaceNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// seatsTaken counts a workspace's members plus its unexpired invites
+func (s *WorkspaceMembershipService) seatsTaken(tx *gorm.DB, workspaceID uuid.UUID) (int64, error) {
+	var members, invites int64
+	if err := tx.Model(&models.WorkspaceMember{}).Where("workspace_id = ?", workspaceID).
+		Count(&members).Error; err != nil {
+		return 0, err
+	}
+	if err := tx.Model(&models.WorkspaceInvite{}).
+		Where("workspace_id = ? AND expires_at > ?", workspaceID, time.Now().UTC()).
+		Count(&invites).Error; err != nil {
+		return 0, err
+	}
+	return members + invites, nil
+}
+
+// inviteeEmail returns the address invites are matched against. It has to
+// be verified, or anyone could sign up with an invited address and accept.
+func (s *WorkspaceMembershipService) inviteeEmail(db *gorm.DB, userID uuid.UUID) (string, error) {
+	var user models.User
+	if err := db.Select("email", "email_verified").Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", types.ErrUserNotFound
+		}
+		return "", err
+	}
+	if !user.EmailVerified {
+		return "", types.ErrEmailNotVerified
+	}
+	return strings.ToLower(strings.TrimSpace(user.Email)), nil
+}
+
+// pendingInvite returns an unexpired invite addressed to the user; anyone
+// else's invites are reported as not found
+func (s *WorkspaceMembershipService) pendingInvite(db *gorm.DB, userID, inviteID uuid.UUID) (*models.WorkspaceInvite, error) {
+	email, err := s.inviteeEmail(db, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var invite models.WorkspaceInvite
+	if err := db.Where("id = ? AND email = ? AND expires_at > ?", inviteID, email, time.Now().UTC()).
+		First(&invite).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, types.ErrWorkspaceInviteNotFound
+		}
+		return nil, err
+	}
+	return &invite, nil
+}
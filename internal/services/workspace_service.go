@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
+	"gorm.io/gorm"
+)
+
+// WorkspaceService exports an account's configuration (branding, webhooks,
+// saved views) and imports it into another account or environment
+type WorkspaceService struct {
+	db         *gorm.DB
+	branding   *BrandingService
+	webhooks   *WebhookService
+	savedViews *SavedViewService
+}
+
+func NewWorkspaceService(db *gorm.DB, branding *BrandingService, webhooks *WebhookService, savedViews *SavedViewService) *WorkspaceService {
+	return &WorkspaceService{
+		db:         db,
+		branding:   branding,
+		webhooks:   webhooks,
+		savedViews: savedViews,
+	}
+}
+
+// Export returns the account's configuration in the import format
+func (s *WorkspaceService) Export(ctx context.Context, userID uuid.UUID) (*models.WorkspaceExport, error) {
+	export := &models.WorkspaceExport{
+		Version:    models.WorkspaceExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Webhooks:   []models.CreateWebhookRequest{},
+		SavedViews: []models.SavedViewRequest{},
+	}
+
+	var branding models.Branding
+	result := s.db.WithContext(ctx).Where("user_id = ?", userID).Limit(1).Find(&branding)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		export.Branding = &models.BrandingRequest{
+			LogoURL:      branding.LogoURL,
+			PrimaryColor: branding.PrimaryColor,
+			SenderName:   branding.SenderName,
+		}
+	}
+
+	hooks, err := s.webhooks.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range hooks {
+		sendClicks := hook.SendClicks
+		export.Webhooks = append(export.Webhooks, models.CreateWebhookRequest{
+			URL:            hook.URL,
+			SendClicks:     &sendClicks,
+			MilestoneEvery: hook.MilestoneEvery,
+		})
+	}
+
+	views, err := s.savedViews.ListViews(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, view := range views {
+		export.SavedViews = append(export.SavedViews, models.SavedViewRequest{
+			Name:      view.Name,
+			Filters:   view.Filters,
+			SortBy:    view.SortBy,
+			SortOrder: view.SortOrder,
+		})
+	}
+
+	return export, nil
+}
+
+// Import merges an export into the account: branding is replaced when the
+// export has one, webhooks are added unless one already targets the same
+// URL, and saved views are created or overwritten by name. The whole
+// export is validated before anything is changed.
+func (s *WorkspaceService) Import(ctx context.Context, userID uuid.UUID, export *models.WorkspaceExport) (*types.WorkspaceImportResult, error) {
+	if err := validateWorkspaceExport(export); err != nil {
+		return nil, err
+	}
+
+	result := &types.WorkspaceImportResult{WebhooksCreated: []models.Webhook{}}
+
+	if export.Branding != nil {
+		if _, err := s.branding.UpdateBranding(ctx, userID, *export.Branding); err != nil {
+			return nil, err
+		}
+		result.BrandingUpdated = true
+	}
+
+	hooks, err := s.webhooks.ListWebhooks(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existingHooks := make(map[string]bool, len(hooks))
+	for _, hook := range hooks {
+		existingHooks[hook.URL] = true
+	}
+	for i := range export.Webhooks {
+		req := &export.Webhooks[i]
+		if existingHooks[req.URL] {
+			result.WebhooksSkipped++
+			continue
+		}
+		hook, err := s.webhooks.CreateWebhook(ctx, userID, req)
+		if err != nil {
+			return nil, err
+		}
+		existingHooks[req.URL] = true
+		result.WebhooksCreated = append(result.WebhooksCreated, *hook)
+	}
+
+	views, err := s.savedViews.ListViews(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	existingViews := make(map[string]uuid.UUID, len(views))
+	for _, view := range views {
+		existingViews[strings.ToLower(view.Name)] = view.ID
+	}
+	for _, req := range export.SavedViews {
+		if viewID, ok := existingViews[strings.ToLower(strings.TrimSpace(req.Name))]; ok {
+			if _, err := s.savedViews.UpdateView(ctx, userID, viewID, req); err != nil {
+				return nil, err
+			}
+			result.SavedViewsUpdated++
+			continue
+		}
+		if _, err := s.savedViews.CreateView(ctx, userID, req); err != nil {
+			return nil, err
+		}
+		result.SavedViewsCreated++
+	}
+
+	utils.LoggerFromContext(ctx).Info("Workspace configuration imported", "user_id", userID,
+		"webhooks", len(result.WebhooksCreated), "saved_views", result.SavedViewsCreated+result.SavedViewsUpdated)
+	return result, nil
+}
+
+func validateWorkspaceExport(export *models.WorkspaceExport) error {
+	if export.Version != models.WorkspaceExportVersion {
+		return types.NewValidationError(fmt.Sprintf("unsupported export version %d, expected %d", export.Version, models.WorkspaceExportVersion))
+	}
+
+	if export.Branding != nil {
+		if err := export.Branding.Validate(); err != nil {
+			return types.NewValidationError("branding: " + err.Error())
+		}
+	}
+
+	for i := range export.Webhooks {
+		if _, err := validateWebhookRequest(&export.Webhooks[i]); err != nil {
+			return types.NewValidationError(fmt.Sprintf("webhooks[%d]: %v", i, err))
+		}
+	}
+
+	names := make(map[string]bool, len(export.SavedViews))
+	for i := range export.SavedViews {
+		view := &export.SavedViews[i]
+		if err := view.Validate(); err != nil {
+			return types.NewValidationError(fmt.Sprintf("saved_views[%d]: %v", i, err))
+		}
+		name := strings.ToLower(strings.TrimSpace(view.Name))
+		if names[name] {
+			return types.NewValidationError(fmt.Sprintf("saved_views[%d]: duplicate name %q", i, view.Name))
+		}
+		names[name] = true
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AdminActionResult describes the outcome of a destructive admin operation.
+// When DryRun is true nothing was changed and the counts/IDs describe what
+// would have been affected.
+type AdminActionResult struct {
+	Action   string              `json:"action"`
+	DryRun   bool                `json:"dry_run"`
+	Affected map[string]int      `json:"affected"`
+	IDs      map[string][]string `json:"ids"`
+}
+
+func NewAdminActionResult(action string, dryRun bool) *AdminActionResult {
+	return &AdminActionResult{
+		Action:   action,
+		DryRun:   dryRun,
+		Affected: make(map[string]int),
+		IDs:      make(map[string][]string),
+	}
+}
+
+// Add records the affected IDs for a given resource kind
+func (r *AdminActionResult) Add(kind string, ids ...string) {
+	r.IDs[kind] = append(r.IDs[kind], ids...)
+	r.Affected[kind] = len(r.IDs[kind])
+}
+
+// Count records an affected count for resources too numerous to list by ID
+func (r *AdminActionResult) Count(kind string, n int) {
+	r.Affected[kind] += n
+}
+
+// Empty reports whether the action would touch anything at all
+func (r *AdminActionResult) Empty() bool {
+	for _, n := range r.Affected {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DomainStats aggregates the links pointing at one destination domain.
+// Flagged links are disabled, rejected or pending abuse review.
+type DomainStats struct {
+	Domain    string    `json:"domain"`
+	Links     int64     `json:"links"`
+	Links24h  int64     `json:"links_24h"`
+	Flagged   int64     `json:"flagged"`
+	FlagRate  float64   `json:"flag_rate"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// DomainReport is the admin overview of shortened destination domains
+type DomainReport struct {
+	Top     []DomainStats `json:"top"`
+	New     []DomainStats `json:"new_last_24h"`
+	Flagged []DomainStats `json:"most_flagged"`
+}
+
+// PasswordHashReport counts stored password hashes by version, to track how
+// many accounts still wait for a rehash on their next login
+type PasswordHashReport struct {
+	Total    int64  `json:"total"`
+	Current  int64  `json:"current"`
+	Outdated int64  `json:"outdated"` // PHC format with other parameters
+	Legacy   int64  `json:"legacy"`   // pre-versioning "salt$hash" format
+	Params   string `json:"current_params"`
+}
+
+// RedisMemoryReport summarizes Redis memory usage per key family
+type RedisMemoryReport struct {
+	UsedBytes      int64            `json:"used_bytes"`
+	MaxBytes       int64            `json:"max_bytes"`
+	UsagePercent   float64          `json:"usage_percent"`
+	EvictionPolicy string           `json:"eviction_policy"`
+	UnderPressure  bool             `json:"under_pressure"`
+	Prefixes       []KeyFamilyUsage `json:"prefixes"`
+	CheckedAt      time.Time        `json:"checked_at"`
+}
+
+// RedisStatus is the Redis connectivity mode tracked by the health watcher
+type RedisStatus struct {
+	Mode        string    `json:"mode"`
+	Since       time.Time `json:"since"`
+	Transitions int64     `json:"transitions"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+// KeyFamilyUsage is the key count and estimated size of one key prefix.
+// Sizes are extrapolated from a sample of keys.
+type KeyFamilyUsage struct {
+	Prefix            string `json:"prefix"`
+	Keys              int64  `json:"keys"`
+	AvgKeyBytes       int64  `json:"avg_key_bytes"`
+	EstimatedBytes    int64  `json:"estimated_bytes"`
+	SampledWithoutTTL int64  `json:"sampled_without_ttl"`
+}
+
+// ClickReconciliation is a link's click count before and after its database
+// and Redis counters were brought back in line
+type ClickReconciliation struct {
+	ShortCode    string `json:"short_code"`
+	DryRun       bool   `json:"dry_run"`
+	StoredClicks int64  `json:"stored_clicks"`
+	RedisClicks  int64  `json:"redis_clicks"`
+	Clicks       int64  `json:"clicks"`
+}
+
+// FailedEmail is a queued email that gave up after its retries
+type FailedEmail struct {
+	ID        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	UserID    uuid.UUID `json:"user_id"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error,omitempty"`
+}
@@ -0,0 +1,43 @@
+package types
+
+import "time"
+
+// OAuthIdentity is the verified profile returned by a sign-in provider
+type OAuthIdentity struct {
+	Subject   string
+	Email     string
+	FirstName string
+	LastName  string
+}
+
+// OIDCAuthorization is what the frontend needs to start an OIDC sign-in;
+// the resulting code goes to POST /auth/oauth/oidc
+type OIDCAuthorization struct {
+	// One-time values to send with the authorization request; the state
+	// comes back with the login
+	State                 string `json:"state"`
+	Nonce                 string `json:"nonce"`
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	ClientID              string `json:"client_id"`
+	RedirectURI           string `json:"redirect_uri"`
+	Scope                 string `json:"scope"`
+	ResponseType          string `json:"response_type"`
+}
+
+// TokenLifetimes are the configured token durations. Refresh tokens issued
+// at sign-in last Refresh, or RememberMe when the user asked to stay signed
+// in; access tokens always last Access.
+type TokenLifetimes struct {
+	Access     time.Duration
+	Refresh    time.Duration
+	RememberMe time.Duration
+}
+
+// RefreshFor returns the refresh token lifetime for a sign-in
+func (l TokenLifetimes) RefreshFor(rememberMe bool) time.Duration {
+	if rememberMe {
+		return l.RememberMe
+	}
+	return l.Refresh
+}
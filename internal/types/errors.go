@@ -16,12 +16,80 @@ func NewValidationError(message string) *ValidationError {
 
 // URL related errors
 var (
-	ErrShortCodeTaken    = errors.New("short code is already taken")
-	ErrInvalidShortCode  = errors.New("short code can only contain letters, numbers, hyphens, and underscores")
-	ErrGenerateShortCode = errors.New("failed to generate unique short code")
-	ErrURLNotFound       = errors.New("url not found")
-	ErrInvalidURLID      = errors.New("invalid url id")
-	ErrUnauthorized      = errors.New("unauthorized access")
+	ErrShortCodeTaken      = errors.New("short code is already taken")
+	ErrInvalidShortCode    = errors.New("short code can only contain letters, numbers, hyphens, and underscores")
+	ErrShortCodeReserved   = errors.New("short code is reserved, please choose another")
+	ErrGenerateShortCode   = errors.New("failed to generate unique short code")
+	ErrURLNotFound         = errors.New("url not found")
+	ErrInvalidURLID        = errors.New("invalid url id")
+	ErrUnauthorized        = errors.New("unauthorized access")
+	ErrURLDisabled         = errors.New("url has been disabled")
+	ErrDomainBlocked       = errors.New("destination domain is blocked")
+	ErrURLUnderReview      = errors.New("url is pending review")
+	ErrCaptchaRequired     = errors.New("captcha verification required")
+	ErrVisitorLimitReached = errors.New("url has reached its unique visitor limit")
+	ErrURLInactive         = errors.New("url has been deactivated by its owner")
+	ErrURLQuarantined      = errors.New("url destination was flagged as unsafe")
+	ErrRedirectLoop        = errors.New("url redirects in a loop")
+)
+
+// Destination validation errors (400 like other validation errors)
+var (
+	ErrInvalidDestination = NewValidationError("destination must be an absolute URL with a host")
+	ErrUnsupportedScheme  = NewValidationError("destination must be an http or https URL")
+	ErrPrivateDestination = NewValidationError("destination must not point to a private or loopback address")
+	ErrSelfReferencingURL = NewValidationError("destination must not point to this URL shortener")
+	ErrRedirectChainLoop  = NewValidationError("destination redirects back to this URL shortener")
+)
+
+// QR logo errors
+var (
+	ErrQRLogoNotFound      = errors.New("qr logo not found")
+	ErrInvalidQRLogo       = NewValidationError("logo must be a PNG or JPEG image of at most 256 KB and 1024x1024 pixels")
+	ErrUnknownQRLogoPreset = NewValidationError("unknown qr logo preset")
+)
+
+// Tag errors
+var (
+	ErrInvalidTag     = errors.New("tags must be 1-50 characters")
+	ErrTagJobNotFound = errors.New("tag batch job not found")
+)
+
+// Saved view errors
+var (
+	ErrSavedViewNotFound = errors.New("saved view not found")
+	ErrSavedViewExists   = errors.New("a saved view with this name already exists")
+	ErrTooManySavedViews = errors.New("saved view limit reached")
+)
+
+// Collection errors
+var (
+	ErrCollectionNotFound = errors.New("collection not found")
+	ErrCollectionExists   = errors.New("a collection with this name already exists")
+	ErrTooManyCollections = errors.New("collection limit reached")
+)
+
+// Workspace errors
+var (
+	ErrWorkspaceNotFound       = errors.New("workspace not found")
+	ErrNotWorkspaceOwner       = errors.New("only the workspace owner can do this")
+	ErrNotLinkCreator          = errors.New("only the link's creator or the workspace owner can move it")
+	ErrAlreadyMember           = errors.New("user is already a member of this workspace")
+	ErrTooManyMembers          = errors.New("workspace member limit reached")
+	ErrWorkspaceOwnerLeaving   = errors.New("the owner can't leave the workspace, delete it instead")
+	ErrWorkspaceInviteNotFound = errors.New("workspace invite not found")
+)
+
+// Bio page errors
+var (
+	ErrBioPageNotFound  = errors.New("bio page not found")
+	ErrBioPageSlugTaken = errors.New("bio page address is already taken")
+	ErrInvalidBioSlug   = errors.New("bio page address can only contain lowercase letters, numbers and hyphens")
+)
+
+// Analytics errors
+var (
+	ErrInvalidDateRange = errors.New("invalid date range: 'to' must be after 'from' and span at most 366 days")
 )
 
 var (
@@ -32,6 +100,8 @@ var (
 	ErrInvalidClaims        = errors.New("invalid token claims")
 	ErrInvalidUserID        = errors.New("invalid user ID in token")
 	ErrInvalidUUID          = errors.New("invalid UUID format")
+	ErrSessionRevoked       = errors.New("session has been revoked, please log in again")
+	ErrRefreshTokenReused   = errors.New("refresh token has already been used, please log in again")
 )
 
 // User related errors
@@ -39,11 +109,62 @@ var (
 	ErrUserExists                 = errors.New("user already exists")
 	ErrUserNotFound               = errors.New("user not found")
 	ErrInvalidCredentials         = errors.New("invalid credentials")
+	ErrIncorrectPassword          = errors.New("current password is incorrect")
+	ErrPasswordBreached           = errors.New("this password has appeared in a data breach, please choose a different one")
+	ErrDisposableEmail            = NewValidationError("disposable email addresses are not allowed, please use a permanent address")
+	ErrSessionNotFound            = errors.New("session not found")
 	ErrInvalidToken               = errors.New("invalid token")
 	ErrTokenExpired               = errors.New("token has expired")
 	ErrPasswordMismatch           = errors.New("password does not match")
 	ErrInvalidOrExpiredResetToken = errors.New("invalid or expired reset token")
 	ErrResetTokenHasExpired       = errors.New("reset token has expired")
+	ErrAccountSuspended           = errors.New("account has been suspended")
+	ErrAccountLocked              = errors.New("too many failed login attempts, please try again later or use the unlock link sent to your email")
+	ErrInvalidUnlockToken         = errors.New("invalid or expired unlock token")
+	ErrEmailNotVerified           = errors.New("email address must be verified first")
+	ErrEmailAlreadyVerified       = errors.New("email address is already verified")
+	ErrInvalidVerificationToken   = errors.New("invalid or expired verification token")
+	ErrVerificationCooldown       = errors.New("verification email was sent recently, please wait a minute")
+	ErrInviteCodeRequired         = errors.New("registration requires an invite code")
+	ErrInvalidInviteCode          = errors.New("invite code is invalid, expired or used up")
+	ErrOAuthNotConfigured         = errors.New("sign-in provider is not configured")
+	ErrInvalidOAuthToken          = errors.New("invalid or expired sign-in token")
+	ErrInvalidMagicLink           = errors.New("invalid, expired or already used login link")
+	ErrSSOAccountNotProvisioned   = errors.New("no account exists for this sign-in, ask an administrator for access")
+)
+
+// API key errors
+var (
+	ErrInvalidAPIKey  = errors.New("invalid or revoked api key")
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// Returned for actions that must not be reachable with an API key alone
+	ErrInteractiveLoginRequired = errors.New("this action requires signing in, api keys are not accepted")
+	ErrInsufficientScope        = errors.New("api key is missing the scope for this action")
+	ErrServiceAccountNotFound   = errors.New("service account not found")
+)
+
+// Email errors
+var (
+	ErrEmailSuppressed  = errors.New("email address is marked as undeliverable")
+	ErrEmailJobNotFound = errors.New("failed email job not found")
+)
+
+// Runbook errors
+var (
+	ErrCacheKeyNotFlushable = errors.New("only cache keys can be flushed (url:, rotate:, feed:, qr:, meta:, brand:, pwned:, rate_limit:, abuse:)")
+)
+
+// Webhook errors
+var (
+	ErrWebhookNotFound   = errors.New("webhook not found")
+	ErrInvalidWebhookURL = errors.New("webhook url must be an absolute http(s) url")
+)
+
+// Admin errors
+var (
+	ErrAdminRequired  = errors.New("admin privileges required")
+	ErrInvalidDomain  = errors.New("invalid domain")
+	ErrInviteNotFound = errors.New("invite code not found")
 )
 
 // Generic errors
@@ -1,6 +1,8 @@
 package types
 
 import (
+	"time"
+
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 )
 
@@ -62,6 +64,7 @@ type URLAnalytics struct {
 	Browsers       map[string]int64 `json:"browsers"`
 	Devices        map[string]int64 `json:"devices"`
 	Countries      map[string]int64 `json:"countries"`
+	Variants       map[string]int64 `json:"variants,omitempty"`
 }
 
 type URLSummary struct {
@@ -86,3 +89,64 @@ type GrowthStats struct {
 	Weekly  float64 `json:"weekly"`
 	Monthly float64 `json:"monthly"`
 }
+
+type CampaignStats struct {
+	UTMSource    string    `json:"utm_source"`
+	UTMMedium    string    `json:"utm_medium"`
+	UTMCampaign  string    `json:"utm_campaign"`
+	Clicks       int64     `json:"clicks"`
+	Links        int64     `json:"links"`
+	FirstClickAt time.Time `json:"first_click_at"`
+	LastClickAt  time.Time `json:"last_click_at"`
+}
+
+type PeriodComparison struct {
+	Current       PeriodSummary `json:"current"`
+	Previous      PeriodSummary `json:"previous"`
+	ChangePercent float64       `json:"change_percent"`
+}
+
+type PeriodSummary struct {
+	From   time.Time     `json:"from"`
+	To     time.Time     `json:"to"`
+	Clicks int64         `json:"clicks"`
+	Daily  []DailyClicks `json:"daily"`
+}
+
+type DailyClicks struct {
+	Date   string `json:"date"`
+	Clicks int64  `json:"clicks"`
+}
+
+// WorkspaceImportResult is what an import changed. Created webhooks carry
+// their new signing secrets, which are only shown here.
+type WorkspaceImportResult struct {
+	BrandingUpdated   bool             `json:"branding_updated"`
+	WebhooksCreated   []models.Webhook `json:"webhooks_created"`
+	WebhooksSkipped   int              `json:"webhooks_skipped"`
+	SavedViewsCreated int              `json:"saved_views_created"`
+	SavedViewsUpdated int              `json:"saved_views_updated"`
+}
+
+// RealtimeAnalytics is the live view of an account's clicks: a per-minute
+// series for the last half hour (oldest first, the current minute last)
+type RealtimeAnalytics struct {
+	GeneratedAt     time.Time      `json:"generated_at"`
+	ClicksPerMinute []MinuteClicks `json:"clicks_per_minute"`
+	// Clicks in the last complete minute
+	ClicksLastMinute int64 `json:"clicks_last_minute"`
+	// Links clicked in the last five minutes, and the most clicked of them
+	ActiveLinks int       `json:"active_links"`
+	TopLiveLink *LiveLink `json:"top_live_link,omitempty"`
+}
+
+type MinuteClicks struct {
+	Minute time.Time `json:"minute"`
+	Clicks int64     `json:"clicks"`
+}
+
+type LiveLink struct {
+	ShortCode string `json:"short_code"`
+	ShortURL  string `json:"short_url"`
+	Clicks    int64  `json:"clicks"`
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 )
 
@@ -23,3 +24,196 @@ func ConvertURLStats(stats *models.URLStats) *URLStats {
 		LastAccessedAt: stats.LastAccessedAt,
 	}
 }
+
+// RedirectTarget is where a short link sends the current visitor. Rotating
+// targets change between clicks (rotators, time-based rules) and must not be
+// cached by browsers.
+type RedirectTarget struct {
+	URL string
+	// Stored spelling of the resolved code, which clicks are recorded under;
+	// empty for moved codes
+	ShortCode string
+	Rotating  bool
+	// Crawler policy of the link
+	Unfurl    bool // social crawlers may follow the link to build a preview card
+	Indexable bool // search engines may index the short link
+	Counted   bool // the click was counted; false for bots unless the link counts them
+	// Route that was taken: a language tag ("pt-br"), "geo:de", "device:ios",
+	// an A/B variant name or a rotator destination ("dest:2"). Links with language routes record "default" for
+	// the fallback; empty for links without any routing.
+	Variant string
+	// Retargeting pixels to fire on an interstitial page before redirecting
+	Pixels               []models.RetargetingPixel
+	PixelConsentRequired bool
+	// Account whose branding the interstitial page uses; only set for links with pixels or a preview page
+	OwnerID *uuid.UUID
+	// Show the destination and a "continue" button instead of redirecting
+	PreviewPage bool
+	// HTTP status of the redirect: the link's redirect type or the server default
+	Status int
+}
+
+// ManagedURL is what an anonymous creator sees with the link's management token
+type ManagedURL struct {
+	URL   *models.URL      `json:"url"`
+	Stats *models.URLStats `json:"stats"`
+}
+
+// ShortCodeSuggestions are available custom short codes for a destination
+type ShortCodeSuggestions struct {
+	Suggestions []string `json:"suggestions"`
+}
+
+// Visitor describes who is following a short link
+type Visitor struct {
+	ID             string // anonymous fingerprint, see utils.VisitorID
+	Bot            bool
+	AcceptLanguage string
+	Country        string // ISO 3166 code from the edge proxy, "" when unknown
+	Platform       string // see utils.ClientPlatform
+	Hops           int    // short links of ours passed through so far, see RedirectHopParam
+}
+
+// RedirectHopParam carries how many of our short links a visit has passed
+// through on redirects back to this service
+const RedirectHopParam = "lynx_hop"
+
+// PublishedLink is one entry of a user's public link feed
+type PublishedLink struct {
+	Title    string `json:"title,omitempty"`
+	ShortURL string `json:"short_url"`
+	Clicks   int64  `json:"clicks"`
+}
+
+// PublishedFeed is the public, embeddable list of a user's published links
+type PublishedFeed struct {
+	Links       []PublishedLink `json:"links"`
+	GeneratedAt time.Time       `json:"generated_at"`
+}
+
+// DestinationChangeEntry is a destination edit with the editor's email
+// (empty once their account is deleted)
+type DestinationChangeEntry struct {
+	models.DestinationChange
+	EditorEmail string `json:"editor_email,omitempty"`
+}
+
+// WorkspaceSummary is a workspace with the caller's role and its size
+type WorkspaceSummary struct {
+	models.Workspace
+	Role    string `json:"role"`
+	Members int64  `json:"members"`
+	Links   int64  `json:"links"`
+}
+
+// WorkspaceMemberInfo is a member of a workspace with their account details
+type WorkspaceMemberInfo struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Email     string    `json:"email"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Role      string    `json:"role"`
+	JoinedAt  time.Time `json:"joined_at"`
+}
+
+// WorkspaceInviteInfo is a pending invite as its invitee sees it
+type WorkspaceInviteInfo struct {
+	models.WorkspaceInvite
+	WorkspaceName string `json:"workspace_name"`
+}
+
+// BioPageDetails is the owner's view of their bio page with its counters
+type BioPageDetails struct {
+	models.BioPage
+	Views  int64               `json:"views"`
+	Clicks map[uuid.UUID]int64 `json:"clicks"` // by link ID
+}
+
+// BioPageLink is one entry of a public bio page; Href counts the click
+// before forwarding to the short URL
+type BioPageLink struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	ShortURL string    `json:"short_url"`
+	Href     string    `json:"href"`
+}
+
+// PublicBioPage is a bio page as visitors see it
+type PublicBioPage struct {
+	Slug        string        `json:"slug"`
+	Title       string        `json:"title"`
+	Description string        `json:"description,omitempty"`
+	Links       []BioPageLink `json:"links"`
+}
+
+// AbuseAssessment is the heuristic abuse score of an anonymous link creation
+type AbuseAssessment struct {
+	Score   int            `json:"score"`
+	Signals map[string]int `json:"signals"`
+}
+
+// Add records a heuristic's contribution to the score
+func (a *AbuseAssessment) Add(signal string, points int) {
+	if points <= 0 {
+		return
+	}
+	a.Signals[signal] = points
+	a.Score += points
+}
+
+// LinkMetadata is the unfurl card for a short link. URL is the destination,
+// left empty when the owner disabled unfurls.
+type LinkMetadata struct {
+	ShortURL    string `json:"short_url"`
+	URL         string `json:"url,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Image       string `json:"image,omitempty"`
+	SiteName    string `json:"site_name,omitempty"`
+}
+
+// CollectionSummary is a collection and the number of links filed in it
+type CollectionSummary struct {
+	models.Collection
+	Links int64 `json:"links"`
+}
+
+// TagCount is a tag and the number of links carrying it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Links int64  `json:"links"`
+}
+
+// Tag batch job states
+const (
+	TagJobQueued    = "queued"
+	TagJobRunning   = "running"
+	TagJobCompleted = "completed"
+	TagJobFailed    = "failed"
+)
+
+// TagJob reports the progress of a batch operation over a tag's links
+type TagJob struct {
+	ID         string     `json:"id"`
+	Tag        string     `json:"tag"`
+	Operation  string     `json:"operation"`
+	Status     string     `json:"status"`
+	Total      int64      `json:"total"`
+	Processed  int64      `json:"processed"`
+	Error      string     `json:"error,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// ExportedURL is one link in a user's links export
+type ExportedURL struct {
+	ID        uuid.UUID  `json:"id"`
+	ShortCode string     `json:"short_code"`
+	ShortURL  string     `json:"short_url"`
+	LongURL   string     `json:"long_url"`
+	Clicks    int64      `json:"clicks"`
+	IsActive  bool       `json:"is_active"`
+	Tags      []string   `json:"tags"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+)
+
+// NormalizeDestination checks that rawURL is an absolute http(s) URL whose
+// host is not a private, loopback or otherwise internal address, and returns
+// it with the scheme and host lowercased and default ports removed. Host
+// names aren't resolved here; servers fetching destinations dial through
+// their own private-address guard.
+func NormalizeDestination(rawURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Scheme == "" {
+		return "", types.ErrInvalidDestination
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", types.ErrUnsupportedScheme
+	}
+
+	host := strings.TrimSuffix(strings.ToLower(parsed.Hostname()), ".")
+	if host == "" {
+		return "", types.ErrInvalidDestination
+	}
+	if isInternalHost(host) {
+		return "", types.ErrPrivateDestination
+	}
+
+	port := parsed.Port()
+	if (parsed.Scheme == "http" && port == "80") || (parsed.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	parsed.Host = host
+	return parsed.String(), nil
+}
+
+// isInternalHost reports whether host names this machine or a private network
+func isInternalHost(host string) bool {
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") || strings.HasSuffix(host, ".internal") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		var numeric bool
+		if ip, numeric = parseNumericIPv4(host); !numeric {
+			return false
+		}
+		// A numeric host browsers can't read as an address is no use either
+		if ip == nil {
+			return true
+		}
+	}
+	// 0.0.0.0/8 reaches the local host on many systems
+	if ip4 := ip.To4(); ip4 != nil && ip4[0] == 0 {
+		return true
+	}
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast()
+}
+
+// parseNumericIPv4 reads a host the way browsers do when its last label is
+// numeric, as in "2130706433", "0x7f.1" or "0177.0.0.1": each dot-separated
+// part may be decimal, octal (leading 0) or hex (0x) and the last part fills
+// the remaining bytes. numeric reports whether the host is such an address;
+// ip is nil when it is but the parts are out of range.
+func parseNumericIPv4(host string) (ip net.IP, numeric bool) {
+	parts := strings.Split(host, ".")
+	if _, ok := parseIPv4Part(parts[len(parts)-1]); !ok {
+		return nil, false
+	}
+	if len(parts) > 4 {
+		return nil, true
+	}
+
+	var addr uint64
+	for i, part := range parts {
+		n, ok := parseIPv4Part(part)
+		if !ok {
+			return nil, true
+		}
+		if i < len(parts)-1 {
+			if n > 255 {
+				return nil, true
+			}
+			addr = addr<<8 | n
+			continue
+		}
+		// The last part holds all bytes not given by the others
+		width := uint(8 * (5 - len(parts)))
+		if n >= 1<<width {
+			return nil, true
+		}
+		addr = addr<<width | n
+	}
+	return net.IPv4(byte(addr>>24), byte(addr>>16), byte(addr>>8), byte(addr)), true
+}
+
+func parseIPv4Part(part string) (uint64, bool) {
+	base := 10
+	switch {
+	case strings.HasPrefix(part, "0x") || strings.HasPrefix(part, "0X"):
+		part, base = part[2:], 16
+		if part == "" {
+			return 0, true
+		}
+	case len(part) > 1 && part[0] == '0':
+		part, base = part[1:], 8
+	}
+	n, err := strconv.ParseUint(part, base, 32)
+	return n, err == nil
+}
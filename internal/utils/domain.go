@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"net/url"
+	"strings"
+)
+
+// ExtractDomain returns the lowercased host of a URL without port or "www." prefix
+func ExtractDomain(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return NormalizeDomain(parsed.Hostname())
+}
+
+// NormalizeDomain lowercases a bare domain and strips a trailing dot and "www." prefix
+func NormalizeDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimSuffix(domain, ".")
+	return strings.TrimPrefix(domain, "www.")
+}
+
+// DomainCandidates returns the domain and all of its parent domains,
+// e.g. "a.b.example.com" → ["a.b.example.com", "b.example.com", "example.com"]
+func DomainCandidates(domain string) []string {
+	parts := strings.Split(domain, ".")
+	var candidates []string
+	for i := 0; i < len(parts)-1; i++ {
+		candidates = append(candidates, strings.Join(parts[i:], "."))
+	}
+	return candidates
+}
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -8,17 +9,105 @@ import (
 )
 
 func HandleError(c *gin.Context, err error) {
+	var validationErr *types.ValidationError
+	if errors.As(err, &validationErr) {
+		ErrorResponse(c, http.StatusBadRequest, err)
+		return
+	}
+
 	switch err {
 	case types.ErrShortCodeTaken:
 		ErrorResponse(c, http.StatusConflict, err)
 	case types.ErrInvalidShortCode:
 		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrShortCodeReserved:
+		ErrorResponse(c, http.StatusUnprocessableEntity, err)
 	case types.ErrURLNotFound:
 		ErrorResponse(c, http.StatusNotFound, err)
 	case types.ErrUnauthorized:
 		ErrorResponse(c, http.StatusForbidden, err)
 	case types.ErrInvalidUUID:
 		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrURLDisabled, types.ErrVisitorLimitReached, types.ErrURLInactive:
+		ErrorResponse(c, http.StatusGone, err)
+	case types.ErrURLQuarantined:
+		ErrorResponse(c, http.StatusForbidden, err)
+	case types.ErrRedirectLoop:
+		ErrorResponse(c, http.StatusLoopDetected, err)
+	case types.ErrQRLogoNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrInvalidTag, types.ErrCacheKeyNotFlushable:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrTagJobNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrSavedViewNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrSavedViewExists, types.ErrTooManySavedViews:
+		ErrorResponse(c, http.StatusConflict, err)
+	case types.ErrCollectionNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrCollectionExists, types.ErrTooManyCollections:
+		ErrorResponse(c, http.StatusConflict, err)
+	case types.ErrWorkspaceNotFound, types.ErrWorkspaceInviteNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrNotWorkspaceOwner, types.ErrNotLinkCreator:
+		ErrorResponse(c, http.StatusForbidden, err)
+	case types.ErrAlreadyMember, types.ErrTooManyMembers, types.ErrWorkspaceOwnerLeaving:
+		ErrorResponse(c, http.StatusConflict, err)
+	case types.ErrBioPageNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrBioPageSlugTaken:
+		ErrorResponse(c, http.StatusConflict, err)
+	case types.ErrInvalidBioSlug:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrURLUnderReview:
+		ErrorResponse(c, http.StatusForbidden, err)
+	case types.ErrCaptchaRequired:
+		ErrorResponse(c, http.StatusPreconditionRequired, err)
+	case types.ErrDomainBlocked:
+		ErrorResponse(c, http.StatusUnprocessableEntity, err)
+	case types.ErrInvalidDateRange:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrWebhookNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrInvalidWebhookURL:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrSessionNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrInvalidToken, types.ErrSessionRevoked, types.ErrRefreshTokenReused:
+		ErrorResponse(c, http.StatusUnauthorized, err)
+	case types.ErrIncorrectPassword, types.ErrPasswordBreached:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrInvalidAPIKey:
+		ErrorResponse(c, http.StatusUnauthorized, err)
+	case types.ErrAPIKeyNotFound, types.ErrServiceAccountNotFound, types.ErrEmailJobNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrInsufficientScope:
+		ErrorResponse(c, http.StatusForbidden, err)
+	case types.ErrUserNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrEmailNotVerified:
+		ErrorResponse(c, http.StatusForbidden, err)
+	case types.ErrEmailAlreadyVerified:
+		ErrorResponse(c, http.StatusConflict, err)
+	case types.ErrAccountLocked:
+		ErrorResponse(c, http.StatusTooManyRequests, err)
+	case types.ErrInvalidVerificationToken, types.ErrInvalidUnlockToken:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrVerificationCooldown:
+		ErrorResponse(c, http.StatusTooManyRequests, err)
+	case types.ErrInviteCodeRequired, types.ErrSSOAccountNotProvisioned:
+		ErrorResponse(c, http.StatusForbidden, err)
+	case types.ErrInvalidInviteCode:
+		ErrorResponse(c, http.StatusBadRequest, err)
+	case types.ErrOAuthNotConfigured:
+		ErrorResponse(c, http.StatusNotImplemented, err)
+	case types.ErrInvalidOAuthToken, types.ErrInvalidMagicLink:
+		ErrorResponse(c, http.StatusUnauthorized, err)
+	case types.ErrInviteNotFound:
+		ErrorResponse(c, http.StatusNotFound, err)
+	case types.ErrAdminRequired, types.ErrAccountSuspended:
+		ErrorResponse(c, http.StatusForbidden, err)
 	case types.ErrGenerateShortCode:
 		ErrorResponse(c, http.StatusInternalServerError, err)
 	default:
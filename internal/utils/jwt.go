@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/config"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
+)
+
+// Values of the "typ" claim, so a refresh token can't be used as an access token
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// ParseJWT verifies an HMAC-signed token against each secret in turn, so
+// tokens signed before a secret rotation keep working during the grace period
+func ParseJWT(tokenString string, secrets []string) (*jwt.Token, error) {
+	var lastErr error = types.ErrInvalidToken
+	for _, secret := range secrets {
+		key := []byte(secret)
+		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+				return nil, types.ErrInvalidSigningMethod
+			}
+			return key, nil
+		})
+		if err == nil {
+			return token, nil
+		}
+		lastErr = err
+
+		// Only a signature mismatch is worth retrying with the next secret
+		var validationErr *jwt.ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Errors&jwt.ValidationErrorSignatureInvalid == 0 {
+			return nil, err
+		}
+	}
+	return nil, lastErr
+}
+
+// ParseTokenWith verifies a token signed with the manager's asymmetric key,
+// falling back on the HMAC secrets for tokens issued before it was configured
+func ParseTokenWith(tokenString string, secrets *config.SecretManager) (*jwt.Token, error) {
+	if key := secrets.SigningKey(); key != nil {
+		unverified, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+		if err != nil {
+			return nil, err
+		}
+		if unverified.Method.Alg() == key.Method.Alg() {
+			return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+				return key.PublicKey, nil
+			}, jwt.WithValidMethods([]string{key.Method.Alg()}))
+		}
+	}
+	token, err := ParseJWT(tokenString, secrets.GetValidSecrets())
+	// Signed by another instance right after a rotation this one missed
+	if err != nil && secrets.Reload() {
+		return ParseJWT(tokenString, secrets.GetValidSecrets())
+	}
+	return token, err
+}
@@ -73,6 +73,7 @@ func (l *LoggerMiddleware) Handle() gin.HandlerFunc {
 			getLogLevel(statusCode),
 			"Request completed",
 			slog.String("request_id", requestID),
+			slog.String("user_id", c.GetString(string(UserIDKey))),
 			slog.String("client_ip", clientIP),
 			slog.String("method", method),
 			slog.String("path", path),
@@ -111,6 +112,25 @@ func GetUserIDFromContext(ctx context.Context) string {
 	return ""
 }
 
+// LoggerFromContext returns Logger with the request_id and user_id attributes
+// set by the logger and auth middleware, so service-layer logs can be correlated
+// with the request that produced them
+func LoggerFromContext(ctx context.Context) *slog.Logger {
+	logger := Logger
+	if ctx == nil {
+		return logger
+	}
+
+	if requestID := GetRequestIDFromContext(ctx); requestID != "" {
+		logger = logger.With(slog.String("request_id", requestID))
+	}
+	if userID := GetUserIDFromContext(ctx); userID != "" {
+		logger = logger.With(slog.String("user_id", userID))
+	}
+
+	return logger
+}
+
 // ✅ Helper untuk set user ID di context (untuk middleware auth)
 func SetUserIDInContext(c *gin.Context, userID string) {
 	c.Set(string(UserIDKey), userID)
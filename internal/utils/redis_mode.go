@@ -0,0 +1,16 @@
+package utils
+
+import "sync/atomic"
+
+var redisDegraded atomic.Bool
+
+// SetRedisDegraded is called by the Redis health watcher on state changes
+func SetRedisDegraded(degraded bool) {
+	redisDegraded.Store(degraded)
+}
+
+// RedisDegraded reports whether Redis is currently unreachable. Request paths
+// with a fail-open fallback skip Redis entirely instead of waiting on timeouts.
+func RedisDegraded() bool {
+	return redisDegraded.Load()
+}
@@ -1,6 +1,10 @@
 package utils
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -15,3 +19,25 @@ func GetRequestID(c *gin.Context) string {
 func GenerateRequestID() string {
 	return uuid.New().String()
 }
+
+// VisitorID fingerprints the client (IP + user agent) for unique visitor counting
+func VisitorID(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return hex.EncodeToString(sum[:16])
+}
+
+// countryHeaders are set by CDNs and load balancers in front of the API
+var countryHeaders = []string{"CF-IPCountry", "X-Vercel-IP-Country", "CloudFront-Viewer-Country", "X-Country-Code"}
+
+// ClientCountry returns the ISO 3166 country code reported by the edge proxy,
+// or "" when unknown. There is no local GeoIP lookup.
+func ClientCountry(c *gin.Context) string {
+	for _, header := range countryHeaders {
+		country := strings.ToUpper(strings.TrimSpace(c.GetHeader(header)))
+		// Cloudflare uses XX for unknown and T1 for Tor
+		if len(country) == 2 && country != "XX" && country != "T1" {
+			return country
+		}
+	}
+	return ""
+}
@@ -17,6 +17,9 @@ type Meta struct {
 	PerPage   int   `json:"per_page"`
 	Total     int64 `json:"total"`
 	TotalPage int64 `json:"total_page"`
+
+	// The user's saved filter/sort views, on the link list only
+	SavedViews interface{} `json:"saved_views,omitempty"`
 }
 
 type PaginationRequest struct {
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"time"
+)
+
+// RevokedSessionsKey is a sorted set of user IDs scored by the Unix time (ms)
+// of their last logout; tokens issued at or before it are rejected. It has no
+// TTL so volatile-* eviction policies never drop it under memory pressure;
+// entries are pruned once older than SessionRevocationTTL.
+const RevokedSessionsKey = "auth:revoked_sessions"
+
+// SessionRevocationTTL covers the longest token lifetime (refresh tokens), so a
+// revocation outlives every token it revokes. Raised at startup when longer
+// refresh lifetimes are configured.
+var SessionRevocationTTL = 7 * 24 * time.Hour
+
+// RevokedSessionIDsKey is a sorted set of individually revoked session IDs
+// (the "sid" token claim) scored by revocation time (ms); pruned like
+// RevokedSessionsKey
+const RevokedSessionIDsKey = "auth:revoked_sids"
+
+// LegacySessionKey is where logouts were recorded (Unix ms) before
+// RevokedSessionsKey. The keys expire after SessionRevocationTTL and are still
+// read until then, so logouts from before the switch are not forgotten.
+func LegacySessionKey(userID string) string {
+	return "session:" + userID
+}
+
+// SessionSeenKey holds the last time (Unix ms) a session made a request
+func SessionSeenKey(sessionID string) string {
+	return "auth:seen:" + sessionID
+}
+
+// ActiveSessionsKey is a sorted set of the user's live session IDs scored by
+// their expiry (Unix ms); expired members are pruned when it is counted
+func ActiveSessionsKey(userID string) string {
+	return "auth:sessions:" + userID
+}
+
+// RevokedTokenIDsKey is a sorted set of individually revoked access tokens
+// (the "jti" claim) scored by the token's expiry (ms). Like RevokedSessionsKey
+// it has no TTL, so eviction never brings a revoked token back; members are
+// pruned once the token would have expired anyway.
+const RevokedTokenIDsKey = "auth:revoked_jtis"
+
+// LegacyRevokedTokenKey is where single token revocations were kept, with a
+// TTL, before RevokedTokenIDsKey. They expire with the access tokens they
+// revoke and are still read until then.
+func LegacyRevokedTokenKey(tokenID string) string {
+	return "auth:revoked_jti:" + tokenID
+}
@@ -0,0 +1,128 @@
+package utils
+
+import "strings"
+
+// Device classes returned by ParseUserAgent
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// ParseUserAgent does a lightweight classification of a User-Agent header
+// into a browser family and device class. It is intentionally simple; it only
+// needs to be good enough for analytics breakdowns.
+func ParseUserAgent(ua string) (browser, device string) {
+	lower := strings.ToLower(ua)
+	if lower == "" {
+		return "Unknown", DeviceUnknown
+	}
+
+	switch {
+	case strings.Contains(lower, "bot"), strings.Contains(lower, "crawler"),
+		strings.Contains(lower, "spider"), strings.Contains(lower, "curl"),
+		strings.Contains(lower, "wget"):
+		device = DeviceBot
+	case strings.Contains(lower, "ipad"), strings.Contains(lower, "tablet"):
+		device = DeviceTablet
+	case strings.Contains(lower, "android") && !strings.Contains(lower, "mobile"):
+		device = DeviceTablet
+	case strings.Contains(lower, "mobile"), strings.Contains(lower, "iphone"),
+		strings.Contains(lower, "android"):
+		device = DeviceMobile
+	default:
+		device = DeviceDesktop
+	}
+
+	// Order matters: Edge and Opera also advertise Chrome, Chrome advertises Safari
+	switch {
+	case strings.Contains(lower, "edg/"), strings.Contains(lower, "edge/"):
+		browser = "Edge"
+	case strings.Contains(lower, "opr/"), strings.Contains(lower, "opera"):
+		browser = "Opera"
+	case strings.Contains(lower, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(lower, "chrome/"), strings.Contains(lower, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(lower, "safari/"):
+		browser = "Safari"
+	default:
+		browser = "Other"
+	}
+
+	return browser, device
+}
+
+// socialCrawlers are the link-preview fetchers of chat apps and social networks.
+// Several of them (WhatsApp, Telegram, Skype) do not call themselves bots.
+var socialCrawlers = []string{
+	"facebookexternalhit", "facebot", "twitterbot", "linkedinbot", "slackbot",
+	"discordbot", "whatsapp", "telegrambot", "skypeuripreview", "pinterest",
+	"redditbot", "embedly", "vkshare", "mastodon",
+}
+
+// IsSocialCrawler reports whether the User-Agent belongs to a link-preview fetcher
+func IsSocialCrawler(ua string) bool {
+	lower := strings.ToLower(ua)
+	for _, name := range socialCrawlers {
+		if strings.Contains(lower, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// messagingPreviewFetchers are the chat apps whose preview fetchers only
+// need Open Graph tags and never show the destination page itself
+var messagingPreviewFetchers = []string{
+	"whatsapp", "telegrambot", "skypeuripreview", "viber",
+}
+
+// IsMessagingPreviewFetcher reports whether the User-Agent belongs to a
+// messaging app's link-preview fetcher
+func IsMessagingPreviewFetcher(ua string) bool {
+	lower := strings.ToLower(ua)
+	for _, name := range messagingPreviewFetchers {
+		if strings.Contains(lower, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsBot reports whether the User-Agent is a crawler, preview fetcher or script
+func IsBot(ua string) bool {
+	if IsSocialCrawler(ua) {
+		return true
+	}
+	_, device := ParseUserAgent(ua)
+	return device == DeviceBot
+}
+
+// Platforms returned by ClientPlatform
+const (
+	PlatformIOS     = "ios"
+	PlatformAndroid = "android"
+	PlatformDesktop = "desktop"
+)
+
+// ClientPlatform tells iOS, Android and desktop visitors apart for device
+// targeting; "" for bots and anything else. iPads asking for the desktop
+// site look like macOS and count as desktop.
+func ClientPlatform(ua string) string {
+	lower := strings.ToLower(ua)
+	_, device := ParseUserAgent(ua)
+	switch {
+	case device == DeviceBot || device == DeviceUnknown:
+		return ""
+	case strings.Contains(lower, "iphone"), strings.Contains(lower, "ipad"), strings.Contains(lower, "ipod"):
+		return PlatformIOS
+	case strings.Contains(lower, "android"):
+		return PlatformAndroid
+	case device == DeviceDesktop:
+		return PlatformDesktop
+	}
+	return ""
+}
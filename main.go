@@ -20,6 +20,7 @@ import (
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/middleware"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/services"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/types"
 	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/utils"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -27,10 +28,11 @@ import (
 )
 
 type App struct {
-	config *config.Config
-	db     *gorm.DB
-	redis  *redis.Client
-	router *gin.Engine
+	config       *config.Config
+	db           *gorm.DB
+	redis        *redis.Client
+	redisWatcher *services.RedisWatcher
+	router       *gin.Engine
 }
 
 func main() {
@@ -46,12 +48,6 @@ func main() {
 	}
 	app.config.Port = port
 
-	// Baca DATABASE_URL dari environment (sudah di-handle di config.go)
-	dbURL := os.Getenv("DATABASE_URL")
-	if dbURL != "" {
-		log.Printf("DATABASE_URL: %s", dbURL)
-	}
-
 	log.Printf("Starting server on port %s", port)
 	app.Run()
 }
@@ -67,8 +63,26 @@ func (a *App) Initialize() error {
 	// ✅ FIX: Initialize logger FIRST (before using utils.Logger)
 	utils.InitLogger(cfg.AppEnv)
 
+	// ✅ Logout marks must outlive the longest refresh token
+	utils.SessionRevocationTTL = max(utils.SessionRevocationTTL, cfg.RememberMeTTL)
+
 	// ✅ NOW safe to use utils.Logger
 	utils.Logger.Info("JWT Secret validated", "length", len(cfg.JWTSecret))
+	utils.Logger.Info("Connection settings",
+		"precedence", cfg.ConnectionPrecedence,
+		"database_source", cfg.DBSource,
+		"database", cfg.DatabaseSummary(),
+		"redis_source", cfg.RedisSource,
+		"redis", cfg.RedisSummary())
+
+	// ✅ Password hashing cost (rejects values below the safe minimums)
+	if err := models.SetArgon2Params(models.Argon2Params{
+		Time:     uint32(cfg.Argon2Time),
+		MemoryKB: uint32(cfg.Argon2MemoryKB),
+		Threads:  uint8(cfg.Argon2Threads),
+	}); err != nil {
+		return fmt.Errorf("invalid password hashing config: %w", err)
+	}
 
 	// Initialize database
 	db, err := a.initDatabase()
@@ -84,6 +98,10 @@ func (a *App) Initialize() error {
 	}
 	a.redis = redis
 
+	// ✅ Background Redis health watcher (normal/degraded mode)
+	a.redisWatcher = services.NewRedisWatcher(a.redis)
+	a.redisWatcher.Start()
+
 	// Run migrations
 	if err := a.initMigrations(); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
@@ -95,6 +113,9 @@ func (a *App) Initialize() error {
 	// ✅ NEW: Start cache warming service
 	cacheWarmer := services.NewCacheWarmer(a.db, a.redis)
 	cacheWarmer.StartCacheWarmer()
+	a.redisWatcher.OnRecover(func() {
+		cacheWarmer.WarmTopURLs(context.Background())
+	})
 
 	return nil
 }
@@ -156,8 +177,17 @@ func (a *App) setupRouter() *gin.Engine {
 		RequestsPerMinute: 100,
 		BurstSize:         20,
 		BlockDuration:     30 * time.Minute,
+		SkipPaths:         []string{"/api/meta/"}, // unfurl bots have their own class
 	}))
 
+	// ✅ Deprecation/Sunset headers for routes marked deprecated in config
+	deprecationRules, err := middleware.ParseDeprecationRules(a.config.DeprecatedEndpoints)
+	if err != nil {
+		log.Printf("⚠️  Ignoring DEPRECATED_ENDPOINTS: %v", err)
+	}
+	deprecations := middleware.NewDeprecationTracker(deprecationRules, a.config.EnforceSunset)
+	router.Use(deprecations.Handle())
+
 	baseURL := a.config.BaseURL
 	if baseURL == "" {
 		baseURL = fmt.Sprintf("http://%s:%s", a.config.Host, a.config.Port)
@@ -172,13 +202,200 @@ func (a *App) setupRouter() *gin.Engine {
 	}
 
 	// ✅ Initialize services with interfaces
-	var authService interfaces.AuthService = services.NewAuthService(a.db, a.redis)
-	var urlService interfaces.URLService = services.NewURLService(a.db, a.redis, a.config.URLPrefix)
-	var qrService interfaces.QRService = services.NewQRService(a.db, a.redis, a.config.URLPrefix)
+	authServiceImpl := services.NewAuthService(a.db, a.redis)
+	authServiceImpl.SetInviteOnly(a.config.InviteOnly)
+	if a.config.PasswordBreachCheck {
+		authServiceImpl.SetBreachChecker(services.NewBreachChecker(a.redis))
+	}
+	// ✅ Disposable email domains: bundled list unless disabled, custom domains always apply
+	if a.config.DisposableEmailBlocking || len(a.config.DisposableEmailDomains) > 0 {
+		disposable := services.NewDisposableEmailChecker(a.config.DisposableEmailBlocking,
+			a.config.DisposableEmailDomains, a.config.DisposableEmailAllow)
+		authServiceImpl.SetDisposableEmailChecker(disposable)
+		log.Printf("✅ Blocking %d disposable email domains", disposable.Len())
+	}
+	var authService interfaces.AuthService = authServiceImpl
+	// ✅ Redis memory budget: popularity-based cache TTLs, shortened under pressure
+	memoryBudget := services.NewRedisBudget(a.redis, a.config.RedisPressureRatio, a.config.RedisManagePolicy)
+	memoryBudget.Start()
+
+	// ✅ Anonymous creations are scored for abuse (CAPTCHA / review above thresholds)
+	urlServiceImpl := services.NewURLService(a.db, a.redis, a.config.URLPrefix)
+	urlServiceImpl.SetMemoryBudget(memoryBudget)
+	urlServiceImpl.SetReservedCodes(services.NewReservedShortCodes(a.config.ReservedShortCodes))
+	urlServiceImpl.SetShortCodeCase(a.config.ShortCodeCase)
+	// ✅ Links back to any domain of this service are refused as destinations
+	urlServiceImpl.SetOwnDomains(append([]string{utils.ExtractDomain(a.config.BaseURL)}, a.config.ShortLinkDomains...))
+	urlServiceImpl.SetUnicodeShortCodes(a.config.UnicodeShortCodes)
+	urlServiceImpl.SetAnonymousPreviewPage(a.config.AnonymousPreviewPage)
+	urlServiceImpl.SetDefaultRedirectStatus(a.config.DefaultRedirectStatus)
+	// ✅ Trashed links are purged for good after 30 days
+	urlServiceImpl.StartTrashPurgeJob()
+	// ✅ Destinations answering 4xx/5xx or failing DNS are flagged as broken
+	services.NewLinkHealthChecker(a.db, a.config.LinkHealthCheckInterval).Start()
+	abuseScorer := services.NewAbuseScorer(a.redis, services.AbuseConfig{
+		CaptchaThreshold: a.config.AbuseCaptchaThreshold,
+		ReviewThreshold:  a.config.AbuseReviewThreshold,
+		CaptchaSecret:    a.config.CaptchaSecret,
+		CaptchaVerifyURL: a.config.CaptchaVerifyURL,
+	})
+	urlServiceImpl.SetAbuseScorer(abuseScorer)
+
+	// ✅ Optional link previews (screenshot service + object storage)
+	var previewHandler *handlers.PreviewHandler
+	if a.config.ScreenshotServiceURL != "" && a.config.ObjectStoreBucket != "" {
+		store := services.NewObjectStore(services.ObjectStoreConfig{
+			Endpoint:  a.config.ObjectStoreEndpoint,
+			Bucket:    a.config.ObjectStoreBucket,
+			Region:    a.config.ObjectStoreRegion,
+			AccessKey: a.config.ObjectStoreAccessKey,
+			SecretKey: a.config.ObjectStoreSecretKey,
+			PublicURL: a.config.ObjectStorePublicURL,
+		})
+		previewService := services.NewPreviewService(a.db, store, a.config.ScreenshotServiceURL)
+		previewService.Start()
+		urlServiceImpl.AddURLListener(previewService)
+		previewHandler = handlers.NewPreviewHandler(previewService)
+		log.Printf("✅ Link previews enabled (bucket %s)", a.config.ObjectStoreBucket)
+	}
+	var urlService interfaces.URLService = urlServiceImpl
+	qrServiceImpl := services.NewQRService(a.db, a.redis, a.config.URLPrefix)
+	// ✅ Rendered QR images (PNG, SVG, logo) cached per short code
+	qrServiceImpl.SetCacheTTL(a.config.QRCacheTTL)
+	var qrService interfaces.QRService = qrServiceImpl
+	adminServiceImpl := services.NewAdminService(a.db, a.redis)
+	adminServiceImpl.SetCacheWarmer(services.NewCacheWarmer(a.db, a.redis))
+	var adminService interfaces.AdminService = adminServiceImpl
+
+	// ✅ Click event retention (global and per-plan), purged daily
+	retentionService := services.NewRetentionService(a.db, a.redis, services.RetentionPolicy{
+		DefaultDays: a.config.AnalyticsRetentionDays,
+		PlanDays:    a.config.AnalyticsRetentionPlans,
+		Aggregate:   a.config.AnalyticsRetentionMode != "delete",
+	})
+	retentionService.StartRetentionJob()
+
+	// ✅ Click events fan out to listeners (webhooks) after being recorded
+	webhookService := services.NewWebhookService(a.db, a.redis)
+	webhookService.StartDispatcher()
+	analyticsService := services.NewAnalyticsService(a.db, a.redis)
+	analyticsService.AddClickListener(webhookService)
+	// ✅ Per-minute live counters for the realtime dashboard
+	liveClicks := services.NewLiveClicks(a.db, a.redis)
+	liveClicks.Start()
+	analyticsService.AddClickListener(liveClicks)
+	if a.config.ClickSamplingQPS > 0 {
+		analyticsService.SetSampler(services.NewClickSampler(int64(a.config.ClickSamplingQPS), a.config.ClickSamplingRate))
+		log.Printf("✅ Click event sampling above %d req/s at rate %.2f", a.config.ClickSamplingQPS, a.config.ClickSamplingRate)
+	}
+
+	// ✅ Optional event bus (Kafka/NATS) for downstream pipelines
+	if a.config.EventBus != "" {
+		sink, err := services.NewEventSink(a.config.EventBus, a.config.EventBusURL, a.config.EventBusTopic)
+		if err != nil {
+			log.Printf("⚠️  Event bus disabled: %v", err)
+		} else {
+			publisher := services.NewEventPublisher(sink, a.config.EventBusBufferSize)
+			publisher.Start()
+			analyticsService.AddClickListener(publisher)
+			urlServiceImpl.AddURLListener(publisher)
+			log.Printf("✅ Publishing events to %s (%s)", a.config.EventBus, a.config.EventBusTopic)
+		}
+	}
+
+	// ✅ Async email queue (welcome + onboarding drip, password resets, login links)
+	emailService := services.NewEmailService(a.db)
+	emailQueue := services.NewEmailQueue(a.db, a.redis, emailService, services.EmailFeatures{
+		Welcome:    a.config.WelcomeEmailEnabled,
+		Onboarding: a.config.OnboardingEmailsEnabled,
+	})
+	emailQueue.StartWorker()
+	authServiceImpl.SetEmailQueue(emailQueue)
+	adminServiceImpl.SetEmailQueue(emailQueue)
+
+	// ✅ Members' links wait for admin approval when required; decisions
+	// are emailed even after the policy is switched off
+	linkApproval := services.NewLinkApproval(a.db, a.redis, emailQueue)
+	adminServiceImpl.SetLinkApproval(linkApproval)
+	if a.config.LinkApprovalRequired {
+		urlServiceImpl.SetLinkApproval(linkApproval)
+		log.Printf("✅ Link approval required for members")
+	}
+	// ✅ JWT signing secret, rotatable from the admin API
+	jwtSecrets := config.NewSecretManager(a.config.JWTSecret)
+	jwtSecrets.SetSigningKey(a.config.JWTSigningKey)
+	// ✅ Rotated secrets are shared by all instances and survive restarts
+	if err := jwtSecrets.SetStore(context.Background(), services.NewJWTSecretStore(a.db)); err != nil {
+		log.Printf("⚠️  Failed to load rotated JWT secrets, will retry: %v", err)
+	}
+	jwtSecrets.StartRefresh(time.Minute)
+
+	magicLinks := services.NewMagicLinkService(a.db, a.redis, a.config.JWTSecret, emailQueue)
+	verificationService := services.NewVerificationService(a.db, a.redis, a.config.JWTSecret, emailQueue)
+
 	// ✅ Initialize handlers
-	authHandler := handlers.NewAuthHandler(authService, a.config.JWTSecret, a.db)
-	urlHandler := handlers.NewURLHandler(urlService, baseURL)
+	// ✅ Google sign-in is enabled when a client ID is configured
+	var googleOAuth *services.GoogleOAuth
+	if a.config.GoogleClientID != "" {
+		googleOAuth = services.NewGoogleOAuth(a.config.GoogleClientID, a.config.GoogleClientSecret, a.config.GoogleRedirectURL)
+	}
+	// ✅ Company SSO is enabled when an OIDC issuer is configured
+	var oidcProvider *services.OIDCProvider
+	if a.config.OIDCIssuer != "" {
+		oidcProvider = services.NewOIDCProvider(services.OIDCConfig{
+			Issuer:         a.config.OIDCIssuer,
+			DiscoveryURL:   a.config.OIDCDiscoveryURL,
+			ClientID:       a.config.OIDCClientID,
+			ClientSecret:   a.config.OIDCClientSecret,
+			RedirectURL:    a.config.OIDCRedirectURL,
+			Scopes:         a.config.OIDCScopes,
+			EmailClaim:     a.config.OIDCEmailClaim,
+			FirstNameClaim: a.config.OIDCFirstNameClaim,
+			LastNameClaim:  a.config.OIDCLastNameClaim,
+			AutoProvision:  a.config.OIDCAutoProvision,
+			TrustEmail:     a.config.OIDCTrustEmail,
+		}, a.redis)
+	}
+	sessionService := services.NewSessionService(a.db, a.redis)
+	sessionService.SetMaxSessions(a.config.MaxSessions)
+	// ✅ Sign-in attempts are purged after 90 days
+	loginAudit := services.NewLoginAuditService(a.db)
+	loginAudit.StartPurgeJob()
+	authHandler := handlers.NewAuthHandler(authService, jwtSecrets, a.db, emailQueue, verificationService, googleOAuth, oidcProvider, magicLinks, sessionService, loginAudit, types.TokenLifetimes{
+		Access:     a.config.AccessTokenTTL,
+		Refresh:    a.config.RefreshTokenTTL,
+		RememberMe: a.config.RememberMeTTL,
+	})
+	savedViewService := services.NewSavedViewService(a.db)
+	brandingService := services.NewBrandingService(a.db, a.redis)
+	linkMetadata := services.NewLinkMetadataService(a.db, a.redis)
+	linkMetadata.SetShortCodeScope(urlServiceImpl.ShortCodeScope)
+	linkMetadata.Start()
+	urlServiceImpl.AddURLListener(linkMetadata)
+	// ✅ Destinations matching Google Safe Browsing are quarantined behind a warning page
+	if a.config.SafeBrowsingAPIKey != "" {
+		safeBrowsing := services.NewSafeBrowsingScanner(a.db, a.redis, a.config.SafeBrowsingAPIKey, a.config.SafeBrowsingScanInterval)
+		safeBrowsing.Start()
+		urlServiceImpl.AddURLListener(safeBrowsing)
+	}
+	urlHandler := handlers.NewURLHandler(urlService, analyticsService, savedViewService, brandingService, linkMetadata, baseURL)
+	brandingHandler := handlers.NewBrandingHandler(brandingService)
+	workspaceHandler := handlers.NewWorkspaceHandler(services.NewWorkspaceService(a.db, brandingService, webhookService, savedViewService))
+	savedViewHandler := handlers.NewSavedViewHandler(savedViewService)
+	collectionHandler := handlers.NewCollectionHandler(services.NewCollectionService(a.db))
+	workspaceMembershipHandler := handlers.NewWorkspaceMembershipHandler(services.NewWorkspaceMembershipService(a.db))
+	bioPageHandler := handlers.NewBioPageHandler(services.NewBioPageService(a.db, a.redis, a.config.URLPrefix))
+	metaHandler := handlers.NewMetaHandler(linkMetadata)
 	qrHandler := handlers.NewQRHandler(qrService, urlService)
+	adminHandler := handlers.NewAdminHandler(adminService, retentionService, memoryBudget, jwtSecrets)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, liveClicks)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	emailWebhookHandler := handlers.NewEmailWebhookHandler(emailService, a.config.EmailWebhookSecret)
+	apiKeyService := services.NewAPIKeyService(a.db, a.redis)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	serviceAccountHandler := handlers.NewServiceAccountHandler(services.NewServiceAccountService(a.db, apiKeyService))
+	var tagService interfaces.TagService = services.NewTagService(a.db, a.redis)
+	tagHandler := handlers.NewTagHandler(tagService)
 
 	// ============================================================
 	// PUBLIC ROUTES (No Authentication)
@@ -186,14 +403,24 @@ func (a *App) setupRouter() *gin.Engine {
 
 	// Health check
 	router.GET("/health", a.healthCheck())
+	router.GET("/health/ready", a.readinessCheck())
+	router.GET("/metrics", a.metrics())
+
+	// Public keys for verifying access tokens (RS256/EdDSA)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
 
 	// QR Code generation
 	router.GET("/qr/:shortCode", qrHandler.GetQRCode)
 	router.GET("/qr/:shortCode/base64", qrHandler.GetQRCodeBase64)
+	router.GET("/qr/:shortCode/svg", qrHandler.GetQRCodeSVG)
 
 	// URL Redirect
 	router.GET("/urls/:shortCode", urlHandler.RedirectToLongURL)
 
+	// ✅ Link-in-bio pages; links go through /go/ so clicks are counted per page
+	router.GET("/page/:slug", bioPageHandler.RenderBioPage)
+	router.GET("/page/:slug/go/:id", bioPageHandler.FollowBioLink)
+
 	fmt.Println("✅ [ROUTER] Redirect route registered: GET /urls/:shortCode")
 	fmt.Println("🔧 [ROUTER] Registering public routes...")
 
@@ -201,6 +428,25 @@ func (a *App) setupRouter() *gin.Engine {
 	publicAPI := router.Group("/api")
 	{
 		publicAPI.POST("/urls", urlHandler.CreateAnonymousURL)
+		// ✅ Anonymous creators manage their link with the token returned at creation
+		publicAPI.GET("/urls/manage/:token", urlHandler.GetManagedURL)
+		publicAPI.PATCH("/urls/manage/:token", urlHandler.ExtendManagedURL)
+		publicAPI.DELETE("/urls/manage/:token", urlHandler.DeleteManagedURL)
+
+		// Unfurl metadata: a generous limit of its own so chat-app bots
+		// neither eat into user quotas nor get IPs blocked
+		metaLimiter := middleware.RateLimiterMiddleware(a.redis, middleware.RateLimiterConfig{
+			RequestsPerMinute: 600,
+			BurstSize:         100,
+			BlockDuration:     5 * time.Minute,
+			Class:             "meta",
+		})
+		publicAPI.GET("/meta/:shortCode", metaLimiter, metaHandler.GetMetadata)
+		// The same card as bare HTML for messaging-app preview fetchers
+		publicAPI.GET("/meta/:shortCode/html", metaLimiter, metaHandler.GetPreviewPage)
+
+		// Bio page as JSON for embedding (views are only counted on the HTML page)
+		publicAPI.GET("/pages/:slug", bioPageHandler.GetPublicBioPage)
 	}
 
 	// ============================================================
@@ -211,24 +457,74 @@ func (a *App) setupRouter() *gin.Engine {
 		// Auth routes (public) - WITH STRICT RATE LIMITING
 		auth := v1.Group("/auth")
 		auth.Use(middleware.AuthRateLimiterMiddleware(a.redis))
+		// ✅ Optional CAPTCHA against credential stuffing (same provider as anonymous links)
+		authCaptcha := middleware.CaptchaMiddleware(abuseScorer, a.config.AuthCaptcha)
 		{
-			auth.POST("/register", authHandler.Register)
-			auth.POST("/login", authHandler.Login)
+			auth.POST("/register", authCaptcha, authHandler.Register)
+			auth.POST("/login", authCaptcha, authHandler.Login)
+			auth.POST("/refresh", authHandler.RefreshToken)
+			auth.POST("/oauth/google", authHandler.GoogleLogin)
+			auth.GET("/oauth/oidc", authHandler.OIDCConfig)
+			auth.POST("/oauth/oidc", authHandler.OIDCLogin)
 			auth.POST("/forgot-password",
+				authCaptcha,
 				middleware.ForgotPasswordRateLimiter(a.redis),
 				authHandler.ForgotPassword)
 			auth.POST("/reset-password", authHandler.ResetPasswordConfirm)
+			auth.POST("/magic-link",
+				middleware.MagicLinkRateLimiter(a.redis),
+				authHandler.RequestMagicLink)
+			auth.POST("/magic-link/verify", authHandler.MagicLinkLogin)
+			auth.POST("/unlock-account", authHandler.UnlockAccount)
+			auth.GET("/verify-email", authHandler.VerifyEmail)
 		}
 
+		// Inbound provider webhooks (shared-secret authenticated)
+		v1.POST("/webhooks/email", emailWebhookHandler.HandleEvents)
+
+		// Public feed of a user's published links (no API key required)
+		v1.GET("/feeds/:userID", urlHandler.GetPublishedFeed)
+
 		// Protected routes (authentication required)
 		api := v1.Group("/api")
-		api.Use(middleware.AuthMiddleware(a.config.JWTSecret))
+		api.Use(middleware.AuthMiddleware(jwtSecrets, a.redis, apiKeyService))
 		{
 			// User routes
 			user := api.Group("/user")
 			{
 				user.GET("/me", authHandler.GetUserDetails)
+				user.DELETE("/me", authHandler.DeleteAccount)
 				user.POST("/logout", authHandler.Logout)
+				user.PUT("/email-preferences", authHandler.UpdateEmailPreferences)
+				user.PUT("/password", authHandler.ChangePassword)
+				user.GET("/sessions", authHandler.ListSessions)
+				user.DELETE("/sessions/:id", authHandler.RevokeSession)
+				user.POST("/tokens/revoke", authHandler.RevokeToken)
+				user.GET("/security/logins", authHandler.ListLoginEvents)
+				user.POST("/resend-verification", authHandler.ResendVerification)
+
+				// Logo, color and sender name for link pages and emails
+				user.GET("/branding", brandingHandler.GetBranding)
+				user.PUT("/branding", brandingHandler.UpdateBranding)
+				user.DELETE("/branding", brandingHandler.DeleteBranding)
+				// ✅ Logo composited into QR codes requested with ?logo=true
+				user.GET("/qr-logo", qrHandler.GetQRLogo)
+				user.PUT("/qr-logo", qrHandler.UploadQRLogo)
+				user.DELETE("/qr-logo", qrHandler.DeleteQRLogo)
+
+				// Link-in-bio page served at /page/:slug
+				user.GET("/bio-page", bioPageHandler.GetBioPage)
+				user.PUT("/bio-page", bioPageHandler.SetBioPage)
+				user.DELETE("/bio-page", bioPageHandler.DeleteBioPage)
+
+				// Configuration export/import, e.g. to promote staging to production
+				user.GET("/workspace/export", workspaceHandler.ExportWorkspace)
+				user.POST("/workspace/import", workspaceHandler.ImportWorkspace)
+
+				// API keys for scripts and CI (sent as X-API-Key)
+				user.POST("/api-keys", apiKeyHandler.CreateAPIKey)
+				user.GET("/api-keys", apiKeyHandler.ListAPIKeys)
+				user.DELETE("/api-keys/:id", apiKeyHandler.RevokeAPIKey)
 			}
 
 			// URL routes (authenticated users only)
@@ -236,9 +532,144 @@ func (a *App) setupRouter() *gin.Engine {
 			{
 				urls.POST("", urlHandler.CreateShortURL)
 				urls.GET("", urlHandler.GetUserURLs)
+				urls.GET("/export", urlHandler.ExportURLs)
+				urls.GET("/suggest", urlHandler.SuggestShortCodes)
+				// ✅ ZIP of QR codes for printing many links at once
+				urls.POST("/qr/batch", qrHandler.ExportQRBatch)
+				// Deleted links stay restorable for 30 days
+				urls.GET("/trash", urlHandler.GetTrashedURLs)
+				urls.POST("/:id/restore", urlHandler.RestoreURL)
+				urls.POST("/claim", urlHandler.ClaimURLs)
+
+				// Tags across all of the user's links
+				urls.GET("/tags", tagHandler.ListTags)
+				urls.POST("/tags/merge", tagHandler.MergeTags)
+				urls.PUT("/tags/:tag", tagHandler.RenameTag)
+				urls.DELETE("/tags/:tag", tagHandler.DeleteTag)
+				urls.POST("/tags/:tag/batch", tagHandler.StartBatch)
+				urls.GET("/tag-jobs/:jobID", tagHandler.GetBatchJob)
+
+				// Saved filter/sort views for the link list
+				urls.GET("/saved-views", savedViewHandler.ListViews)
+				urls.POST("/saved-views", savedViewHandler.CreateView)
+				urls.PUT("/saved-views/:id", savedViewHandler.UpdateView)
+				urls.DELETE("/saved-views/:id", savedViewHandler.DeleteView)
+
+				// Collections (one folder per link); list a folder's links with ?collection=
+				urls.GET("/collections", collectionHandler.ListCollections)
+				urls.POST("/collections", collectionHandler.CreateCollection)
+				urls.PUT("/collections/:id", collectionHandler.RenameCollection)
+				urls.DELETE("/collections/:id", collectionHandler.DeleteCollection)
+
 				urls.GET("/:id", urlHandler.GetURL)
+				urls.PUT("/:id", urlHandler.UpdateURL)
+				urls.PATCH("/:id", urlHandler.UpdateURL)
 				urls.DELETE("/:id", urlHandler.DeleteURL)
+				urls.PUT("/:id/rotation", urlHandler.SetRotation)
+				urls.GET("/:id/variants", urlHandler.GetVariants)
+				urls.PUT("/:id/variants", urlHandler.SetVariants)
+				urls.PUT("/:id/routing-rules", urlHandler.SetRoutingRules)
+				urls.PUT("/:id/language-routes", urlHandler.SetLanguageRoutes)
+				urls.PUT("/:id/country-routes", urlHandler.SetCountryRoutes)
+				urls.PUT("/:id/device-targets", urlHandler.SetDeviceTargets)
+				urls.PUT("/:id/short-code", urlHandler.RenameShortCode)
+				urls.PUT("/:id/preview-page", urlHandler.SetPreviewPage)
+				urls.PUT("/:id/redirect-type", urlHandler.SetRedirectType)
+				urls.PUT("/:id/pixels", urlHandler.SetPixels)
+				urls.PUT("/:id/visitor-limit", urlHandler.SetVisitorLimit)
+				urls.PUT("/:id/crawler-policy", urlHandler.SetCrawlerPolicy)
+				urls.PUT("/:id/utm", urlHandler.SetUTM)
+				urls.PUT("/:id/publish", urlHandler.SetPublished)
+				urls.POST("/:id/toggle", urlHandler.ToggleURL)
+				urls.POST("/:id/pin", urlHandler.PinURL)
+				urls.PUT("/:id/collection", collectionHandler.SetURLCollection)
+				urls.PUT("/:id/workspace", workspaceMembershipHandler.SetURLWorkspace)
+				urls.GET("/:id/preview", urlHandler.GetLinkPreview)
+				if previewHandler != nil {
+					urls.POST("/:id/preview", previewHandler.RefreshPreview)
+				}
+				urls.GET("/:id/analytics", analyticsHandler.GetURLAnalytics)
+				urls.GET("/:id/history", urlHandler.GetDestinationHistory)
+				urls.GET("/:id/campaigns", analyticsHandler.GetURLCampaignStats)
+			}
+
+			// Analytics routes
+			analytics := api.Group("/analytics")
+			{
+				analytics.GET("", analyticsHandler.GetUserAnalytics)
+				analytics.GET("/campaigns", analyticsHandler.GetCampaignStats)
+				analytics.GET("/compare", analyticsHandler.ComparePeriods)
+				analytics.GET("/realtime", analyticsHandler.GetRealtime)
+				analytics.GET("/realtime/ws", analyticsHandler.StreamRealtime)
 			}
+
+			// ✅ Shared workspaces: every member can view and manage the workspace's links
+			workspaces := api.Group("/workspaces")
+			{
+				workspaces.GET("", workspaceMembershipHandler.ListWorkspaces)
+				workspaces.POST("", workspaceMembershipHandler.CreateWorkspace)
+				workspaces.DELETE("/:id", workspaceMembershipHandler.DeleteWorkspace)
+				workspaces.GET("/:id/members", workspaceMembershipHandler.ListMembers)
+				workspaces.GET("/:id/invites", workspaceMembershipHandler.ListInvites)
+				workspaces.POST("/:id/invites", workspaceMembershipHandler.InviteMember)
+				workspaces.DELETE("/:id/invites/:inviteID", workspaceMembershipHandler.RevokeInvite)
+				// ✅ Invites addressed to the caller's verified email
+				workspaces.GET("/invites", workspaceMembershipHandler.ListMyInvites)
+				workspaces.POST("/invites/:inviteID/accept", workspaceMembershipHandler.AcceptInvite)
+				workspaces.DELETE("/invites/:inviteID", workspaceMembershipHandler.DeclineInvite)
+				workspaces.DELETE("/:id/members/:userID", workspaceMembershipHandler.RemoveMember)
+			}
+
+			// Webhook routes
+			webhooks := api.Group("/webhooks")
+			{
+				webhooks.POST("", webhookHandler.CreateWebhook)
+				webhooks.GET("", webhookHandler.ListWebhooks)
+				webhooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+				webhooks.GET("/:id/deliveries", webhookHandler.ListDeliveries)
+			}
+		}
+
+		// Admin routes (admin role required)
+		admin := v1.Group("/admin")
+		// Admin endpoints only accept interactive (JWT) sessions, not API keys
+		admin.Use(middleware.AuthMiddleware(jwtSecrets, a.redis, nil), middleware.AdminMiddleware(a.db))
+		{
+			admin.POST("/urls/purge-expired", adminHandler.PurgeExpiredURLs)
+			admin.POST("/users/:id/ban", adminHandler.BanUser)
+			admin.POST("/users/:id/suspend", adminHandler.SuspendUser)
+			admin.POST("/users/:id/reinstate", adminHandler.ReinstateUser)
+			admin.POST("/blocklist", adminHandler.AddBlockedDomains)
+			admin.POST("/analytics/purge", adminHandler.PurgeClickEvents)
+			admin.GET("/reviews", adminHandler.ListPendingReviews)
+			admin.GET("/domains", adminHandler.GetDomainStats)
+			admin.GET("/deprecations", deprecations.StatsHandler())
+			admin.GET("/redis/memory", adminHandler.GetRedisMemory)
+			admin.GET("/password-hashes", adminHandler.GetPasswordHashStats)
+			admin.POST("/jwt/rotate", adminHandler.RotateJWTSecret)
+			admin.POST("/invites", adminHandler.CreateInviteCodes)
+			admin.GET("/invites", adminHandler.ListInviteCodes)
+			admin.DELETE("/invites/:id", adminHandler.RevokeInviteCode)
+			admin.POST("/reviews/:id/approve", adminHandler.ApproveURL)
+			admin.POST("/reviews/:id/reject", adminHandler.RejectURL)
+			admin.GET("/approvals", adminHandler.ListAwaitingApproval)
+			admin.POST("/approvals/approve", adminHandler.ApproveLinks)
+			admin.POST("/approvals/reject", adminHandler.RejectLinks)
+
+			// Runbook actions, so operators don't need direct Redis/DB access
+			admin.POST("/ops/cache/flush", adminHandler.FlushCacheKey)
+			admin.POST("/ops/cache/rebuild", adminHandler.RebuildNegativeCache)
+			admin.POST("/ops/urls/:id/reconcile-clicks", adminHandler.ReconcileClicks)
+			admin.GET("/ops/emails/failed", adminHandler.ListFailedEmails)
+			admin.POST("/ops/emails/failed/:id/retry", adminHandler.RetryFailedEmail)
+
+			// Service accounts for jobs and integrations, with scoped tokens
+			admin.POST("/service-accounts", serviceAccountHandler.CreateServiceAccount)
+			admin.GET("/service-accounts", serviceAccountHandler.ListServiceAccounts)
+			admin.DELETE("/service-accounts/:id", serviceAccountHandler.DeleteServiceAccount)
+			admin.POST("/service-accounts/:id/tokens", serviceAccountHandler.CreateToken)
+			admin.GET("/service-accounts/:id/tokens", serviceAccountHandler.ListTokens)
+			admin.DELETE("/service-accounts/:id/tokens/:tokenID", serviceAccountHandler.RevokeToken)
 		}
 	}
 
@@ -256,6 +687,58 @@ func (a *App) healthCheck() gin.HandlerFunc {
 	}
 }
 
+// readinessCheck fails only when the database is unreachable; without Redis
+// the app keeps serving in degraded mode, reported under "redis"
+func (a *App) readinessCheck() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+		defer cancel()
+
+		status := a.redisWatcher.Status()
+		sqlDB, err := a.db.DB()
+		if err == nil {
+			err = sqlDB.PingContext(ctx)
+		}
+		if err != nil {
+			c.JSON(http.StatusServiceUnavailable, utils.Response{
+				Success: false,
+				Error:   "database unreachable",
+				Data:    gin.H{"redis": status},
+			})
+			return
+		}
+
+		utils.SuccessResponse(c, http.StatusOK, "Service is ready", gin.H{
+			"database": "ok",
+			"redis":    status,
+		})
+	}
+}
+
+// metrics exposes the Redis mode in the Prometheus text format
+func (a *App) metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := a.redisWatcher.Status()
+		degraded := 0
+		if status.Mode == services.RedisModeDegraded {
+			degraded = 1
+		}
+
+		var b strings.Builder
+		fmt.Fprintln(&b, "# HELP lynx_redis_degraded Whether the app is running without Redis (1) or normally (0).")
+		fmt.Fprintln(&b, "# TYPE lynx_redis_degraded gauge")
+		fmt.Fprintf(&b, "lynx_redis_degraded %d\n", degraded)
+		fmt.Fprintln(&b, "# HELP lynx_redis_mode_transitions_total Redis mode changes since startup.")
+		fmt.Fprintln(&b, "# TYPE lynx_redis_mode_transitions_total counter")
+		fmt.Fprintf(&b, "lynx_redis_mode_transitions_total %d\n", status.Transitions)
+		fmt.Fprintln(&b, "# HELP lynx_redis_mode_since_seconds Unix time the current Redis mode started.")
+		fmt.Fprintln(&b, "# TYPE lynx_redis_mode_since_seconds gauge")
+		fmt.Fprintf(&b, "lynx_redis_mode_since_seconds %d\n", status.Since.Unix())
+
+		c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", []byte(b.String()))
+	}
+}
+
 func (a *App) notFound() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		utils.ErrorResponse(c, http.StatusNotFound, errors.New("route not found"))
@@ -267,7 +750,6 @@ func (a *App) initDatabase() (*gorm.DB, error) {
 	fmt.Println("DBHost:", a.config.DBHost)
 	fmt.Println("DBPort:", a.config.DBPort)
 	fmt.Println("DBUser:", a.config.DBUser)
-	fmt.Println("DBPassword:", a.config.DBPassword)
 	fmt.Println("DBName:", a.config.DBName)
 
 	sslMode := "disable"
@@ -275,10 +757,7 @@ func (a *App) initDatabase() (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
 		a.config.DBHost, a.config.DBUser, a.config.DBPassword, a.config.DBName, a.config.DBPort, sslMode)
 
-	// Don't print password in production
-	if a.config.AppEnv != "production" {
-		fmt.Println("DSN:", dsn)
-	}
+	fmt.Println("DSN:", a.config.DatabaseSummary())
 	fmt.Println("================================")
 
 	gormConfig := &gorm.Config{
@@ -346,12 +825,13 @@ func (a *App) initMigrations() error {
 	}
 
 	// ✅ Run migrations
-	if err := a.db.AutoMigrate(
-		&models.User{},
-		&models.URL{},
-	); err != nil {
+	if err := a.db.AutoMigrate(models.Migrated()...); err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
+	// Case-insensitive short code lookups (SHORT_CODE_CASE=insensitive)
+	if err := a.db.Exec("CREATE INDEX IF NOT EXISTS idx_urls_short_code_lower ON urls (LOWER(short_code))").Error; err != nil {
+		return fmt.Errorf("failed to create short code index: %w", err)
+	}
 
 	// ✅ Verify tables exist
 	var tableCount int64
@@ -372,6 +852,17 @@ func (a *App) initMigrations() error {
 
 	utils.Logger.Info("Tables verified successfully", "count", tableCount)
 
+	// ✅ Promote configured admin accounts
+	if len(a.config.AdminEmails) > 0 {
+		result := a.db.Model(&models.User{}).
+			Where("email IN ?", a.config.AdminEmails).
+			Update("role", models.RoleAdmin)
+		if result.Error != nil {
+			return fmt.Errorf("failed to promote admin accounts: %w", result.Error)
+		}
+		utils.Logger.Info("Admin accounts promoted", "count", result.RowsAffected)
+	}
+
 	// ✅ Test database connection
 	if sqlDB, err := a.db.DB(); err == nil {
 		if err := sqlDB.Ping(); err != nil {
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/config"
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const checkTimeout = 5 * time.Second
+
+// status of a single check; warnings are reported but don't fail the run
+type status int
+
+const (
+	statusPass status = iota
+	statusWarn
+	statusFail
+)
+
+type result struct {
+	name   string
+	status status
+	detail string
+}
+
+func (s status) icon() string {
+	switch s {
+	case statusPass:
+		return "✅"
+	case statusWarn:
+		return "⚠️ "
+	default:
+		return "❌"
+	}
+}
+
+func pass(name, format string, args ...interface{}) result {
+	return result{name: name, status: statusPass, detail: fmt.Sprintf(format, args...)}
+}
+
+func warn(name, format string, args ...interface{}) result {
+	return result{name: name, status: statusWarn, detail: fmt.Sprintf(format, args...)}
+}
+
+func fail(name, format string, args ...interface{}) result {
+	return result{name: name, status: statusFail, detail: fmt.Sprintf(format, args...)}
+}
+
+// doctor checks every dependency the server needs at startup (config,
+// Postgres, migrations, Redis, SMTP, BASE_URL) and prints a pass/fail report.
+// It exits non-zero if any check fails, so it can gate deploys.
+func main() {
+	fmt.Println("🩺 Lynx doctor")
+	fmt.Println(strings.Repeat("=", 50))
+
+	var results []result
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		results = append(results, fail("config", "%v", err))
+		report(results)
+		os.Exit(1)
+	}
+	results = append(results, pass("config", "env=%s, jwt=%s", cfg.AppEnv, jwtAlgorithm(cfg)))
+
+	db, dbResult := checkPostgres(cfg)
+	results = append(results, dbResult)
+	if db != nil {
+		results = append(results, checkMigrations(db))
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	} else {
+		results = append(results, fail("migrations", "skipped, no database connection"))
+	}
+
+	results = append(results, checkRedis(cfg))
+	results = append(results, checkSMTP(cfg))
+	results = append(results, checkBaseURL(cfg))
+
+	if !report(results) {
+		os.Exit(1)
+	}
+}
+
+func jwtAlgorithm(cfg *config.Config) string {
+	if cfg.JWTSigningKey != nil {
+		return cfg.JWTSigningKey.Method.Alg()
+	}
+	return "HS256"
+}
+
+func checkPostgres(cfg *config.Config) (*gorm.DB, result) {
+	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC connect_timeout=%d",
+		cfg.DBHost, cfg.DBUser, cfg.DBPassword, cfg.DBName, cfg.DBPort, int(checkTimeout.Seconds()))
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fail("postgres", "%s: %v", cfg.DatabaseSummary(), err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fail("postgres", "failed to get database instance: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fail("postgres", "%s: %v", cfg.DatabaseSummary(), err)
+	}
+
+	var version string
+	db.Raw("SHOW server_version").Scan(&version)
+	return db, pass("postgres", "%s (server %s)", cfg.DatabaseSummary(), version)
+}
+
+// checkMigrations compares the live schema with the models the server
+// migrates, without changing anything
+func checkMigrations(db *gorm.DB) result {
+	migrator := db.Migrator()
+
+	var missing []string
+	for _, model := range models.Migrated() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(model); err != nil {
+			return fail("migrations", "failed to parse model: %v", err)
+		}
+
+		table := stmt.Schema.Table
+		if !migrator.HasTable(table) {
+			missing = append(missing, table)
+			continue
+		}
+		for _, field := range stmt.Schema.Fields {
+			if field.DBName == "" {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				missing = append(missing, table+"."+field.DBName)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fail("migrations", "pending, missing %s", strings.Join(missing, ", "))
+	}
+	return pass("migrations", "%d tables up to date", len(models.Migrated()))
+}
+
+func checkRedis(cfg *config.Config) result {
+	client := redis.NewClient(&redis.Options{
+		Addr:        fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+		Password:    cfg.RedisPassword,
+		DialTimeout: checkTimeout,
+		ReadTimeout: checkTimeout,
+	})
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fail("redis", "%s: %v", cfg.RedisSummary(), err)
+	}
+
+	testKey := fmt.Sprintf("doctor:%d", time.Now().UnixNano())
+	if err := client.Set(ctx, testKey, "ok", 10*time.Second).Err(); err != nil {
+		return fail("redis", "write test failed: %v", err)
+	}
+	client.Del(ctx, testKey)
+
+	return pass("redis", "%s", cfg.RedisSummary())
+}
+
+// checkSMTP connects and authenticates without sending anything
+func checkSMTP(cfg *config.Config) result {
+	if cfg.SMTPUsername == "" || cfg.SMTPPassword == "" {
+		return warn("smtp", "not configured, emails will not be sent")
+	}
+	if cfg.SMTPFrom == "" {
+		return fail("smtp", "SMTP_FROM_EMAIL is not set")
+	}
+
+	addr := net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort)
+	conn, err := net.DialTimeout("tcp", addr, checkTimeout)
+	if err != nil {
+		return fail("smtp", "%s: %v", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(checkTimeout))
+
+	client, err := smtp.NewClient(conn, cfg.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fail("smtp", "%s: %v", addr, err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.SMTPHost}); err != nil {
+			return fail("smtp", "STARTTLS failed: %v", err)
+		}
+	}
+
+	auth := smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	if err := client.Auth(auth); err != nil {
+		return fail("smtp", "authentication failed: %v", err)
+	}
+	client.Quit()
+
+	return pass("smtp", "%s as %s", addr, cfg.SMTPUsername)
+}
+
+// checkBaseURL makes sure short links will resolve for visitors; a running
+// server answers on /health, anything else reachable is only a warning
+func checkBaseURL(cfg *config.Config) result {
+	if cfg.BaseURL == "" {
+		return fail("base_url", "BASE_URL is not set")
+	}
+
+	client := &http.Client{Timeout: checkTimeout}
+	resp, err := client.Get(strings.TrimSuffix(cfg.BaseURL, "/") + "/health")
+	if err != nil {
+		return fail("base_url", "%s unreachable: %v", cfg.BaseURL, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return warn("base_url", "%s/health returned %d", cfg.BaseURL, resp.StatusCode)
+	}
+	return pass("base_url", "%s", cfg.BaseURL)
+}
+
+// report prints the results and returns whether every check passed
+func report(results []result) bool {
+	ok := true
+	fmt.Println()
+	for _, r := range results {
+		fmt.Printf("  %s %-12s %s\n", r.status.icon(), r.name, r.detail)
+		if r.status == statusFail {
+			ok = false
+		}
+	}
+
+	fmt.Println("\n" + strings.Repeat("=", 50))
+	if ok {
+		fmt.Println("🎉 All checks passed")
+	} else {
+		fmt.Println("❌ Some checks failed")
+	}
+	fmt.Println(strings.Repeat("=", 50))
+	return ok
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/marcelaritonang/website-urlshortener-lynx-backend/internal/models"
+	"golang.org/x/crypto/argon2"
+)
+
+// hashbench measures Argon2id hashing latency on this machine and recommends
+// the strongest parameters that stay within a latency budget.
+//
+//	go run ./tools/hashbench -budget 250ms -max-memory 262144
+func main() {
+	budget := flag.Duration("budget", 250*time.Millisecond, "maximum acceptable hashing latency per login")
+	maxMemory := flag.Uint("max-memory", 256*1024, "largest memory cost to try, in KiB")
+	maxTime := flag.Uint("max-time", 6, "largest iteration count to try")
+	threads := flag.Uint("threads", uint(min(runtime.NumCPU(), 4)), "parallelism")
+	runs := flag.Int("runs", 3, "runs per candidate (median is reported)")
+	flag.Parse()
+
+	if *threads < 1 || *threads > 255 {
+		fmt.Fprintln(os.Stderr, "Error: -threads must be between 1 and 255")
+		os.Exit(1)
+	}
+
+	fmt.Println("🔐 Argon2id benchmark")
+	fmt.Printf("Budget: %s, threads: %d, CPUs: %d\n", *budget, *threads, runtime.NumCPU())
+	fmt.Println(strings.Repeat("=", 50))
+	fmt.Printf("%10s %6s %12s\n", "memory KiB", "time", "median")
+
+	var best *models.Argon2Params
+	password := []byte("benchmark-password")
+	salt := make([]byte, models.SaltLength)
+
+	for memory := uint32(models.MinArgon2MemoryKB); memory <= uint32(*maxMemory); memory *= 2 {
+		for t := uint32(1); t <= uint32(*maxTime); t++ {
+			params := models.Argon2Params{Time: t, MemoryKB: memory, Threads: uint8(*threads)}
+			if params.Validate() != nil {
+				continue
+			}
+
+			latency := measure(params, password, salt, *runs)
+			marker := ""
+			if latency <= *budget {
+				if best == nil || work(params) > work(*best) {
+					p := params
+					best = &p
+				}
+			} else {
+				marker = "  over budget"
+			}
+			fmt.Printf("%10d %6d %12s%s\n", memory, t, latency.Round(time.Millisecond), marker)
+
+			// More iterations at this memory size only get slower
+			if latency > *budget {
+				break
+			}
+		}
+	}
+
+	fmt.Println(strings.Repeat("=", 50))
+	if best == nil {
+		fmt.Println("❌ No parameters meet the safe minimums within the budget; raise -budget or use faster hardware")
+		os.Exit(1)
+	}
+
+	fmt.Println("✅ Recommended settings (add to your .env file):")
+	fmt.Printf("ARGON2_TIME=%d\n", best.Time)
+	fmt.Printf("ARGON2_MEMORY_KB=%d\n", best.MemoryKB)
+	fmt.Printf("ARGON2_THREADS=%d\n", best.Threads)
+}
+
+// measure returns the median latency of hashing with the given parameters
+func measure(p models.Argon2Params, password, salt []byte, runs int) time.Duration {
+	if runs < 1 {
+		runs = 1
+	}
+	samples := make([]time.Duration, runs)
+	for i := range samples {
+		start := time.Now()
+		argon2.IDKey(password, salt, p.Time, p.MemoryKB, p.Threads, models.Argon2KeyLength)
+		samples[i] = time.Since(start)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return samples[len(samples)/2]
+}
+
+// work approximates the attack cost of a parameter set; memory is preferred
+// over iterations at equal work since it is what hurts GPU attackers
+func work(p models.Argon2Params) uint64 {
+	return uint64(p.Time)*uint64(p.MemoryKB)*2 + uint64(p.MemoryKB)
+}